@@ -0,0 +1,39 @@
+// Package admin serves the embedded admin single-page UI
+package admin
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// UIPath is where the admin UI is served
+const UIPath = "/admin/ui/"
+
+//go:embed ui
+var uiFiles embed.FS
+
+// UIHandler returns an HTTP handler serving the admin UI's static files below UIPath
+func UIHandler() http.Handler {
+	files, err := fs.Sub(uiFiles, "ui")
+	if err != nil {
+		// The directory is embedded at build time, so this cannot fail
+		panic(err)
+	}
+	fileServer := http.StripPrefix(UIPath, http.FileServer(http.FS(files)))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Content-Security-Policy", "default-src 'self'; style-src 'self' 'unsafe-inline'")
+		fileServer.ServeHTTP(w, r)
+	})
+}
+
+// SetupUI configures the admin UI routes on the given router
+func SetupUI(router *mux.Router) {
+	router.Handle("/admin/ui", http.RedirectHandler(UIPath, http.StatusMovedPermanently)).Methods("GET")
+	router.PathPrefix(UIPath).Handler(UIHandler()).Methods("GET", "HEAD")
+}
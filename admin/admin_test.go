@@ -0,0 +1,32 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetupUI(t *testing.T) {
+	router := mux.NewRouter()
+	SetupUI(router)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/admin/ui/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "<title>RSS Feed Backend · Admin</title>")
+	assert.Contains(t, w.Header().Get("Content-Security-Policy"), "default-src 'self'")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/admin/ui/app.js", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "../overview")
+	assert.Contains(t, w.Body.String(), `Authorization: "Bearer " + adminToken()`)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/admin/ui", nil))
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+	assert.Equal(t, UIPath, w.Header().Get("Location"))
+}
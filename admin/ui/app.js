@@ -0,0 +1,158 @@
+// Admin UI: polls /admin/overview and renders queue, cache, alert, job, and feed health panels.
+// The overview requires the admin token, which is asked for once and kept for the browser session.
+(function () {
+  "use strict";
+
+  var REFRESH_MS = 5000;
+  var TOKEN_KEY = "adminToken";
+
+  // adminToken returns the stored admin token, prompting for it when there is none
+  function adminToken() {
+    var token = sessionStorage.getItem(TOKEN_KEY);
+    if (!token) {
+      token = window.prompt("Admin token (ADMIN_TOKEN)") || "";
+      if (token) {
+        sessionStorage.setItem(TOKEN_KEY, token);
+      }
+    }
+    return token;
+  }
+
+  function el(tag, text, className) {
+    var node = document.createElement(tag);
+    if (text !== undefined && text !== null) {
+      node.textContent = String(text);
+    }
+    if (className) {
+      node.className = className;
+    }
+    return node;
+  }
+
+  function badge(value) {
+    return el("span", value || "-", "badge " + (value || ""));
+  }
+
+  function time(value) {
+    if (!value || value.indexOf("0001-") === 0) {
+      return "-";
+    }
+    return new Date(value).toLocaleString();
+  }
+
+  function renderStats(id, stats) {
+    var container = document.getElementById(id);
+    container.replaceChildren();
+    stats.forEach(function (stat) {
+      var node = el("div", null, "stat");
+      node.appendChild(el("strong", stat[1]));
+      node.appendChild(el("span", stat[0]));
+      container.appendChild(node);
+    });
+  }
+
+  function renderRows(id, rows, columns) {
+    var body = document.getElementById(id);
+    body.replaceChildren();
+    if (!rows.length) {
+      var row = el("tr");
+      var cell = el("td", "None", "empty");
+      cell.colSpan = columns;
+      row.appendChild(cell);
+      body.appendChild(row);
+      return;
+    }
+    rows.forEach(function (cells) {
+      var row = el("tr");
+      cells.forEach(function (cell) {
+        row.appendChild(cell instanceof Node ? cell : el("td", cell));
+      });
+      body.appendChild(row);
+    });
+  }
+
+  function cellWith(node, className) {
+    var cell = el("td", null, className);
+    cell.appendChild(node);
+    return cell;
+  }
+
+  function urlCell(url) {
+    var cell = el("td", url, "url");
+    cell.title = url;
+    return cell;
+  }
+
+  function render(overview) {
+    var queue = overview.queue;
+    renderStats("queue-stats", queue ? [
+      ["Queued", queue.depth + " / " + queue.capacity],
+      ["Awaiting store", queue.store_depth + " / " + queue.store_capacity],
+      ["Load", Math.round(queue.load * 100) + "%"],
+      ["Pending", queue.pending],
+      ["Processing", queue.processing],
+      ["Deferred", queue.deferred],
+      ["Completed", queue.completed],
+      ["Failed", queue.failed]
+    ] : [["Queue", "Not reported"]]);
+
+    var cache = overview.cache;
+    renderStats("cache-stats", cache ? [
+      ["Hit rate", Math.round(cache.hit_rate * 100) + "%"],
+      ["Hits", cache.hits],
+      ["Misses", cache.misses],
+      ["Entries", cache.entries < 0 ? "-" : cache.entries]
+    ] : [["Cache", "Not reported"]]);
+
+    renderRows("alerts-body", overview.alerts.map(function (alert) {
+      return [cellWith(badge(alert.severity)), alert.title, alert.description, time(alert.timestamp)];
+    }), 4);
+
+    renderRows("jobs-body", overview.recent_jobs.map(function (job) {
+      return [
+        job.job_id,
+        urlCell(job.url),
+        cellWith(badge(job.status)),
+        job.items_count || 0,
+        job.duration_ms ? job.duration_ms + " ms" : "-",
+        time(job.created_at)
+      ];
+    }), 6);
+
+    renderRows("feeds-body", overview.feed_health.map(function (feed) {
+      return [urlCell(feed.feed_url), cellWith(badge(feed.status)), feed.reason || "-", time(feed.last_fetch_at), time(feed.last_new_item_at)];
+    }), 5);
+
+    var updated = document.getElementById("updated");
+    updated.className = "";
+    updated.textContent = "Updated " + time(overview.generated_at);
+  }
+
+  function refresh() {
+    fetch("../overview", {
+      headers: { Accept: "application/json", Authorization: "Bearer " + adminToken() }
+    })
+      .then(function (response) {
+        // A rejected token is forgotten, so the next refresh asks again
+        if (response.status === 401) {
+          sessionStorage.removeItem(TOKEN_KEY);
+          throw new Error("admin token rejected");
+        }
+        if (!response.ok) {
+          throw new Error("HTTP " + response.status);
+        }
+        return response.json();
+      })
+      .then(render)
+      .catch(function (err) {
+        var updated = document.getElementById("updated");
+        updated.className = "error";
+        updated.textContent = "Refresh failed: " + err.message;
+      })
+      .then(function () {
+        setTimeout(refresh, REFRESH_MS);
+      });
+  }
+
+  refresh();
+})();
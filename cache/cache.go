@@ -7,9 +7,10 @@ performance by reducing redundant RSS feed fetching and Datastore operations.
 package cache
 
 import (
+	"context"
 	"fmt"
-	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/Nexora-Open-Source/rss-feed-backend/utils"
@@ -103,6 +104,14 @@ func (c *InMemoryCache) Clear() error {
 	return nil
 }
 
+// Len returns the number of cached entries, including expired ones not yet cleaned up
+func (c *InMemoryCache) Len() int {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	return len(c.items)
+}
+
 // startCleanup periodically removes expired items
 func (c *InMemoryCache) startCleanup() {
 	ticker := time.NewTicker(5 * time.Minute)
@@ -136,6 +145,20 @@ type CacheManager struct {
 	defaultItemsTTL time.Duration
 	highFreqFeedTTL time.Duration
 	lowFreqFeedTTL  time.Duration
+	// feedTTLOverride, when set, supplies learned per-feed TTLs ahead of the frequency estimate
+	feedTTLOverride func(url string) (time.Duration, bool)
+	// Lookup counters reported by Stats
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// CacheStats reports cache effectiveness
+type CacheStats struct {
+	Hits    int64   `json:"hits"`
+	Misses  int64   `json:"misses"`
+	HitRate float64 `json:"hit_rate"`
+	// Entries is -1 when the underlying cache cannot report its size
+	Entries int `json:"entries"`
 }
 
 // NewCacheManager creates a new cache manager
@@ -152,11 +175,18 @@ func NewCacheManager(cache Cache, logger *logrus.Logger, defaultFeedTTL, default
 	}
 }
 
+// SetFeedTTLOverride sets a function supplying per-feed TTLs, such as learned poll intervals;
+// feeds it reports no TTL for fall back to the frequency estimate
+func (cm *CacheManager) SetFeedTTLOverride(override func(url string) (time.Duration, bool)) {
+	cm.feedTTLOverride = override
+}
+
 // GetFeedItems retrieves cached feed items
 func (cm *CacheManager) GetFeedItems(url string) ([]*utils.FeedItem, bool) {
 	key := fmt.Sprintf("feed:%s", url)
-	items, found := cm.cache.Get(key)
+	items, found := cm.get(key)
 
+	cm.recordLookup(found)
 	if found {
 		cm.logger.WithFields(logrus.Fields{
 			"url":         url,
@@ -169,11 +199,36 @@ func (cm *CacheManager) GetFeedItems(url string) ([]*utils.FeedItem, bool) {
 	return items, found
 }
 
+// recordLookup counts a cache hit or miss
+func (cm *CacheManager) recordLookup(found bool) {
+	if found {
+		cm.hits.Add(1)
+	} else {
+		cm.misses.Add(1)
+	}
+}
+
+// Stats returns hit and miss counts since startup and the number of cached entries
+func (cm *CacheManager) Stats() CacheStats {
+	stats := CacheStats{
+		Hits:    cm.hits.Load(),
+		Misses:  cm.misses.Load(),
+		Entries: -1,
+	}
+	if lookups := stats.Hits + stats.Misses; lookups > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(lookups)
+	}
+	if sized, ok := cm.cache.(interface{ Len() int }); ok {
+		stats.Entries = sized.Len()
+	}
+	return stats
+}
+
 // SetFeedItems caches feed items with adaptive TTL
 func (cm *CacheManager) SetFeedItems(url string, items []*utils.FeedItem) error {
 	ttl := cm.calculateAdaptiveTTL(url, items)
 	key := fmt.Sprintf("feed:%s", url)
-	err := cm.cache.Set(key, items, ttl)
+	err := cm.set(key, items, ttl)
 
 	if err != nil {
 		cm.logger.WithFields(logrus.Fields{
@@ -196,8 +251,9 @@ func (cm *CacheManager) SetFeedItems(url string, items []*utils.FeedItem) error
 
 // GetStoredItems retrieves cached stored items
 func (cm *CacheManager) GetStoredItems(queryKey string) ([]*utils.FeedItem, bool) {
-	items, found := cm.cache.Get(queryKey)
+	items, found := cm.get(queryKey)
 
+	cm.recordLookup(found)
 	if found {
 		cm.logger.WithFields(logrus.Fields{
 			"query_key":   queryKey,
@@ -212,7 +268,7 @@ func (cm *CacheManager) GetStoredItems(queryKey string) ([]*utils.FeedItem, bool
 
 // SetStoredItems caches stored items
 func (cm *CacheManager) SetStoredItems(queryKey string, items []*utils.FeedItem) error {
-	err := cm.cache.Set(queryKey, items, cm.itemsTTL)
+	err := cm.set(queryKey, items, cm.itemsTTL)
 
 	if err != nil {
 		cm.logger.WithFields(logrus.Fields{
@@ -234,7 +290,7 @@ func (cm *CacheManager) SetStoredItems(queryKey string, items []*utils.FeedItem)
 // InvalidateFeed removes cached feed data
 func (cm *CacheManager) InvalidateFeed(url string) error {
 	key := fmt.Sprintf("feed:%s", url)
-	err := cm.cache.Delete(key)
+	err := cm.remove(key)
 
 	if err != nil {
 		cm.logger.WithFields(logrus.Fields{
@@ -250,6 +306,12 @@ func (cm *CacheManager) InvalidateFeed(url string) error {
 
 // calculateAdaptiveTTL determines optimal cache TTL based on feed characteristics
 func (cm *CacheManager) calculateAdaptiveTTL(url string, items []*utils.FeedItem) time.Duration {
+	if cm.feedTTLOverride != nil {
+		if ttl, ok := cm.feedTTLOverride(url); ok {
+			return ttl
+		}
+	}
+
 	if len(items) == 0 {
 		return cm.defaultFeedTTL
 	}
@@ -281,83 +343,34 @@ func (cm *CacheManager) calculateAdaptiveTTL(url string, items []*utils.FeedItem
 
 // analyzeUpdateFrequency analyzes the average time between feed item publications
 func (cm *CacheManager) analyzeUpdateFrequency(items []*utils.FeedItem) time.Duration {
-	if len(items) < 2 {
-		return 24 * time.Hour // Default to low frequency for small feeds
-	}
-
-	// Parse publication dates and sort by date
-	parsedItems := make([]struct {
-		item    *utils.FeedItem
-		pubTime time.Time
-		valid   bool
-	}, 0, len(items))
-
-	for _, item := range items {
-		if pubTime, err := time.Parse(time.RFC3339, item.PubDate); err == nil {
-			parsedItems = append(parsedItems, struct {
-				item    *utils.FeedItem
-				pubTime time.Time
-				valid   bool
-			}{item, pubTime, true})
-		} else {
-			// Try other common date formats
-			formats := []string{
-				time.RFC1123Z,
-				time.RFC1123,
-				"2006-01-02 15:04:05",
-				"2006-01-02T15:04:05Z",
-			}
-			for _, format := range formats {
-				if pubTime, err := time.Parse(format, item.PubDate); err == nil {
-					parsedItems = append(parsedItems, struct {
-						item    *utils.FeedItem
-						pubTime time.Time
-						valid   bool
-					}{item, pubTime, true})
-					break
-				}
-			}
-		}
-	}
-
-	// Filter valid items and sort by publication date (newest first)
-	validItems := make([]struct {
-		item    *utils.FeedItem
-		pubTime time.Time
-		valid   bool
-	}, 0, len(parsedItems))
-	for _, pi := range parsedItems {
-		if pi.valid {
-			validItems = append(validItems, pi)
-		}
+	if interval := utils.EstimateUpdateInterval(items); interval > 0 {
+		return interval
 	}
+	return 24 * time.Hour // Default to low frequency when the interval is unknown
+}
 
-	if len(validItems) < 2 {
-		return 24 * time.Hour
+// get reads a cache entry; a lookup failed by fault injection is a miss
+func (cm *CacheManager) get(key string) ([]*utils.FeedItem, bool) {
+	if err := utils.InjectFault(context.Background(), utils.FaultTargetCache); err != nil {
+		return nil, false
 	}
+	return cm.cache.Get(key)
+}
 
-	// Sort by publication time (newest first)
-	sort.Slice(validItems, func(i, j int) bool {
-		return validItems[i].pubTime.After(validItems[j].pubTime)
-	})
-
-	// Calculate average time difference between consecutive items
-	var totalDuration time.Duration
-	count := 0
-
-	for i := 1; i < len(validItems); i++ {
-		diff := validItems[i-1].pubTime.Sub(validItems[i].pubTime)
-		if diff > 0 && diff < 7*24*time.Hour { // Ignore unrealistic gaps
-			totalDuration += diff
-			count++
-		}
+// set writes a cache entry unless fault injection fails the write
+func (cm *CacheManager) set(key string, items []*utils.FeedItem, ttl time.Duration) error {
+	if err := utils.InjectFault(context.Background(), utils.FaultTargetCache); err != nil {
+		return err
 	}
+	return cm.cache.Set(key, items, ttl)
+}
 
-	if count == 0 {
-		return 24 * time.Hour
+// remove removes a cache entry unless fault injection fails the removal
+func (cm *CacheManager) remove(key string) error {
+	if err := utils.InjectFault(context.Background(), utils.FaultTargetCache); err != nil {
+		return err
 	}
-
-	return totalDuration / time.Duration(count)
+	return cm.cache.Delete(key)
 }
 
 // ClearAll clears all cached data
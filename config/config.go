@@ -10,6 +10,7 @@ package config
 import (
 	"context"
 	"fmt"
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
@@ -18,7 +19,10 @@ import (
 	"cloud.google.com/go/datastore"
 	"github.com/Nexora-Open-Source/rss-feed-backend/cache"
 	"github.com/Nexora-Open-Source/rss-feed-backend/container"
+	"github.com/Nexora-Open-Source/rss-feed-backend/handlers"
 	"github.com/Nexora-Open-Source/rss-feed-backend/middleware"
+	"github.com/Nexora-Open-Source/rss-feed-backend/monitoring"
+	"github.com/Nexora-Open-Source/rss-feed-backend/utils"
 	"github.com/sirupsen/logrus"
 )
 
@@ -27,16 +31,72 @@ type Config struct {
 	ProjectID  string
 	LogLevel   string
 	ServerPort string
+	// Application log backend ("logrus" or "slog"), format ("json" or "text"), and output ("stdout", "stderr", a file path, or "off")
+	LogBackend string
+	LogFormat  string
+	LogOutput  string
+	// Access log format ("combined" or "json") and output, configured independently of the application log
+	AccessLogFormat string
+	AccessLogOutput string
 	// Rate limiting configuration
 	RateLimitRequestsPerMinute float64
 	RateLimitBurst             int
 	RateLimitCleanupInterval   time.Duration
+	// Per-route request budgets as "route=duration" entries, and the budget of unlisted routes (0 is unbounded)
+	RouteTimeouts       []string
+	RouteTimeoutDefault time.Duration
 	// Enhanced CORS configuration
 	CORSConfig CORSConfig
 	// Cleanup intervals
 	ClientCleanupInterval time.Duration
 	// Performance optimization settings
 	PerformanceConfig PerformanceConfig
+	// Outbound request security settings
+	SecurityConfig SecurityConfig
+	// Garbage collector tuning settings
+	RuntimeConfig RuntimeConfig
+	// Fault injection settings for resilience testing
+	FaultInjectionConfig FaultInjectionConfig
+	// ReadOnly makes this instance a read replica that rejects mutations with 503
+	ReadOnly bool
+	// ReplicaRefreshInterval is how often a read replica reloads the mute rules, counters, and feed state the writer changes
+	ReplicaRefreshInterval time.Duration
+	// AdminUIEnabled serves the embedded admin UI at /admin/ui/ and its /admin/overview endpoint
+	AdminUIEnabled bool
+	// SimulatedFeedEnabled serves synthetic items at /dev/simulated-feed; allowed only in development environments
+	SimulatedFeedEnabled bool
+	// IDFormat selects how request, job, and rule IDs are generated: "ulid" or "uuidv7"
+	IDFormat string
+}
+
+// SecurityConfig holds settings that guard outbound feed fetches and admin endpoints
+type SecurityConfig struct {
+	// Additional CIDR ranges feed URLs may not target (private and metadata ranges are always blocked)
+	BlockedCIDRs []string `json:"blocked_cidrs"`
+	// Bearer token admin endpoints require (they are disabled without one)
+	AdminToken string `json:"-"`
+	// CIDR ranges of proxies whose X-Forwarded-For entries identify clients (none trusts only the connection's address)
+	TrustedProxies []string `json:"trusted_proxies"`
+}
+
+// RuntimeConfig holds garbage collector tuning applied at startup
+type RuntimeConfig struct {
+	// GCPercent overrides GOGC when non-zero; negative turns the percentage trigger off and relies on MemoryLimitMB
+	GCPercent int `json:"gc_percent"`
+	// MemoryLimitMB overrides GOMEMLIMIT when non-zero
+	MemoryLimitMB int `json:"memory_limit_mb"`
+	// HeapBallastMB allocates an unused heap buffer so bursty ingest triggers fewer collections (0 disables)
+	HeapBallastMB int `json:"heap_ballast_mb"`
+}
+
+// FaultInjectionConfig holds settings for injecting faults into dependencies, for resilience testing outside production
+type FaultInjectionConfig struct {
+	Enabled bool `json:"enabled"`
+	// ErrorRates and LatencyRates are "target=rate" entries for the cache, datastore, and fetch targets, rates from 0 to 1
+	ErrorRates   []string `json:"error_rates"`
+	LatencyRates []string `json:"latency_rates"`
+	// Latency is how long a call chosen for injected latency is delayed
+	Latency time.Duration `json:"latency"`
 }
 
 // PerformanceConfig holds performance-related configuration
@@ -58,6 +118,96 @@ type PerformanceConfig struct {
 	AsyncBackpressure    bool          `json:"async_backpressure"`
 	AsyncRejectThreshold float64       `json:"async_reject_threshold"`
 	AsyncWaitTimeout     time.Duration `json:"async_wait_timeout"`
+	// Sync fetch settings
+	SyncFetchTimeout   time.Duration `json:"sync_fetch_timeout"`
+	AutoAsyncThreshold time.Duration `json:"auto_async_threshold"`
+	MaxJobStatusWait   time.Duration `json:"max_job_status_wait"`
+	JobResultTTL       time.Duration `json:"job_result_ttl"`
+	// Feed redirect settings
+	RedirectConfirmations int `json:"redirect_confirmations"`
+	// Feed icon settings
+	IconCacheTTL time.Duration `json:"icon_cache_ttl"`
+	IconMaxBytes int           `json:"icon_max_bytes"`
+	// Feed health settings
+	DeadFeedWindow time.Duration `json:"dead_feed_window"`
+	DeadFeedAlerts bool          `json:"dead_feed_alerts"`
+	// Cross-source deduplication settings
+	DedupTitleThreshold float64 `json:"dedup_title_threshold"`
+	DedupWindowSize     int     `json:"dedup_window_size"`
+	// Story clustering settings
+	StoryTitleThreshold float64       `json:"story_title_threshold"`
+	StoryWindow         time.Duration `json:"story_window"`
+	// Top stories ranking settings
+	RankingRecencyWeight float64       `json:"ranking_recency_weight"`
+	RankingSourceWeight  float64       `json:"ranking_source_weight"`
+	RankingClusterWeight float64       `json:"ranking_cluster_weight"`
+	RankingKeywordWeight float64       `json:"ranking_keyword_weight"`
+	RankingHalfLife      time.Duration `json:"ranking_half_life"`
+	RankingSourceWeights []string      `json:"ranking_source_weights"`
+	RankingKeywordBoosts []string      `json:"ranking_keyword_boosts"`
+	// Adaptive polling settings
+	PollMinInterval time.Duration `json:"poll_min_interval"`
+	PollMaxInterval time.Duration `json:"poll_max_interval"`
+	PollTargetYield float64       `json:"poll_target_yield"`
+	PollDecay       float64       `json:"poll_decay"`
+	// Throttled feed host settings
+	RetryAfterDefault time.Duration `json:"retry_after_default"`
+	RetryAfterMax     time.Duration `json:"retry_after_max"`
+	MaxJobDeferrals   int           `json:"max_job_deferrals"`
+	// Store pool settings
+	StoreWorkers   int `json:"store_workers"`
+	StoreQueueSize int `json:"store_queue_size"`
+	// Small feed pipelining settings
+	SmallFeedConcurrency int `json:"small_feed_concurrency"`
+	SmallFeedMaxItems    int `json:"small_feed_max_items"`
+	// Per-tenant ingest throttle settings
+	IngestTenantRate float64 `json:"ingest_tenant_rate"`
+	IngestGlobalRate float64 `json:"ingest_global_rate"`
+	IngestBurst      int     `json:"ingest_burst"`
+	IngestMaxQueued  int     `json:"ingest_max_queued"`
+	// User data deletion settings
+	UserDataDeadline time.Duration `json:"user_data_deadline"`
+	// Usage analytics settings; analytics are opt-in for the operator and for each caller
+	UsageAnalyticsEnabled       bool `json:"usage_analytics_enabled"`
+	UsageAnalyticsRetentionDays int  `json:"usage_analytics_retention_days"`
+	// Date filter settings
+	DefaultTimezone string `json:"default_timezone"`
+	// Item history settings; revisions replaced longer ago are pruned and as_of queries cannot reach past them
+	ItemRevisionRetention time.Duration `json:"item_revision_retention"`
+	// Overflow queue settings; 0 rejects jobs turned away by backpressure instead of holding them
+	OverflowMaxJobs int `json:"overflow_max_jobs"`
+	// Job status settings; finished jobs beyond the in-memory cap are archived to Datastore
+	JobStatusRetention  time.Duration `json:"job_status_retention"`
+	JobStatusMaxEntries int           `json:"job_status_max_entries"`
+	// Job watchdog settings; 0 leaves a budget unbounded or turns off alerts
+	JobMaxDuration      time.Duration `json:"job_max_duration"`
+	JobMaxFeedBytes     int           `json:"job_max_feed_bytes"`
+	JobOverBudgetAlerts int           `json:"job_over_budget_alerts"`
+	// Canary settings; the canary ingests the server's own /canary.rss through the full pipeline
+	CanaryEnabled    bool          `json:"canary_enabled"`
+	CanaryInterval   time.Duration `json:"canary_interval"`
+	CanaryMaxLatency time.Duration `json:"canary_max_latency"`
+	CanaryBaseURL    string        `json:"canary_base_url"`
+	// Item snapshot settings; content hashes are always recorded
+	ItemSnapshotsEnabled bool `json:"item_snapshots_enabled"`
+	// Client ban settings; a threshold of 0 turns its rule off
+	ClientBansEnabled       bool          `json:"client_bans_enabled"`
+	BanInvalidURLThreshold  int           `json:"ban_invalid_url_threshold"`
+	BanAuthFailureThreshold int           `json:"ban_auth_failure_threshold"`
+	BanWindow               time.Duration `json:"ban_window"`
+	BanDuration             time.Duration `json:"ban_duration"`
+	// Page sizes of /items; limits above the maximum are refused
+	ItemsDefaultLimit int `json:"items_default_limit"`
+	ItemsMaxLimit     int `json:"items_max_limit"`
+	// Stale fallback settings; a max age of 0 returns errors instead of stale results
+	StaleFallbackMaxAge     time.Duration `json:"stale_fallback_max_age"`
+	StaleFallbackMaxEntries int           `json:"stale_fallback_max_entries"`
+	// Consecutive ingest failures that call a feed's error webhook; 0 disables error webhooks
+	FeedErrorWebhookThreshold int `json:"feed_error_webhook_threshold"`
+	// Startup warm-up settings; readiness is reported once the warm-up finishes or times out
+	WarmUpEnabled  bool          `json:"warmup_enabled"`
+	WarmUpMaxFeeds int           `json:"warmup_max_feeds"`
+	WarmUpTimeout  time.Duration `json:"warmup_timeout"`
 }
 
 // CORSConfig holds CORS-related configuration
@@ -99,10 +249,19 @@ func NewConfig() *Config {
 		ProjectID:  getEnv("PROJECT_ID", "argon-magnet-442917-k1"),
 		LogLevel:   getEnv("LOG_LEVEL", "info"),
 		ServerPort: getEnv("SERVER_PORT", "8080"),
+		// Application and access logs can be routed separately
+		LogBackend:      getEnv("LOG_BACKEND", "logrus"),
+		LogFormat:       getEnv("LOG_FORMAT", "json"),
+		LogOutput:       getEnv("LOG_OUTPUT", "stderr"),
+		AccessLogFormat: getEnv("ACCESS_LOG_FORMAT", "combined"),
+		AccessLogOutput: getEnv("ACCESS_LOG_OUTPUT", "stdout"),
 		// Rate limiting defaults (10 requests per minute, burst of 5)
 		RateLimitRequestsPerMinute: getEnvFloat("RATE_LIMIT_RPM", 10.0),
 		RateLimitBurst:             getEnvInt("RATE_LIMIT_BURST", 5),
 		RateLimitCleanupInterval:   getEnvDuration("RATE_LIMIT_CLEANUP_INTERVAL", 5*time.Minute),
+		// Request budgets; routes that wait on purpose, such as long-polled job status, are left unbounded
+		RouteTimeouts:       getEnvSlice("ROUTE_TIMEOUTS", []string{"/items=5s", "/fetch-store=30s"}),
+		RouteTimeoutDefault: getEnvDuration("ROUTE_TIMEOUT_DEFAULT", 0),
 		// Enhanced CORS configuration
 		CORSConfig: CORSConfig{
 			Environment: environment,
@@ -127,10 +286,10 @@ func NewConfig() *Config {
 			}),
 			AllowedHeaders: getEnvSlice("CORS_ALLOWED_HEADERS", []string{
 				"Content-Type", "Authorization", "X-Requested-With",
-				"X-Request-ID", "Accept", "Origin", "Cache-Control",
+				"X-Request-ID", "X-User-ID", "X-Tenant-ID", "X-Analytics-Consent", "X-Timezone", "Accept", "Origin", "Cache-Control",
 			}),
 			ExposedHeaders: getEnvSlice("CORS_EXPOSED_HEADERS", []string{
-				"X-Request-ID", "X-Total-Count", "X-Cache",
+				"X-Request-ID", "X-Total-Count", "Link", "X-Cache", "X-Data-Staleness", "Retry-After", "X-Queue-Depth", "X-Queue-Capacity",
 			}),
 			AllowCredentials: getEnvBool("CORS_ALLOW_CREDENTIALS", true),
 			MaxAge:           getEnvInt("CORS_MAX_AGE", 86400), // 24 hours
@@ -158,7 +317,122 @@ func NewConfig() *Config {
 			AsyncBackpressure:    getEnvBool("ASYNC_BACKPRESSURE", true),
 			AsyncRejectThreshold: getEnvFloat("ASYNC_REJECT_THRESHOLD", 0.8), // Reject at 80% capacity
 			AsyncWaitTimeout:     getEnvDuration("ASYNC_WAIT_TIMEOUT", 5*time.Second),
+			// Sync fetch settings
+			SyncFetchTimeout:   getEnvDuration("SYNC_FETCH_TIMEOUT", 30*time.Second),
+			AutoAsyncThreshold: getEnvDuration("AUTO_ASYNC_THRESHOLD", 5*time.Second),
+			MaxJobStatusWait:   getEnvDuration("MAX_JOB_STATUS_WAIT", 30*time.Second),
+			JobResultTTL:       getEnvDuration("JOB_RESULT_TTL", time.Hour),
+			// Feed redirect settings
+			RedirectConfirmations: getEnvInt("FEED_REDIRECT_CONFIRMATIONS", 3),
+			// Feed icon settings
+			IconCacheTTL: getEnvDuration("ICON_CACHE_TTL", 24*time.Hour),
+			IconMaxBytes: getEnvInt("ICON_MAX_BYTES", 100*1024),
+			// Feed health settings
+			DeadFeedWindow: getEnvDuration("DEAD_FEED_WINDOW", 14*24*time.Hour),
+			DeadFeedAlerts: getEnvBool("DEAD_FEED_ALERTS", false),
+			// Cross-source deduplication settings
+			DedupTitleThreshold: getEnvFloat("DEDUP_TITLE_THRESHOLD", 0.8),
+			DedupWindowSize:     getEnvInt("DEDUP_WINDOW_SIZE", 5000),
+			// Story clustering settings
+			StoryTitleThreshold: getEnvFloat("STORY_TITLE_THRESHOLD", 0.5),
+			StoryWindow:         getEnvDuration("STORY_WINDOW", 48*time.Hour),
+			// Top stories ranking settings
+			RankingRecencyWeight: getEnvFloat("RANKING_RECENCY_WEIGHT", 1.0),
+			RankingSourceWeight:  getEnvFloat("RANKING_SOURCE_WEIGHT", 1.0),
+			RankingClusterWeight: getEnvFloat("RANKING_CLUSTER_WEIGHT", 1.0),
+			RankingKeywordWeight: getEnvFloat("RANKING_KEYWORD_WEIGHT", 1.0),
+			RankingHalfLife:      getEnvDuration("RANKING_HALF_LIFE", 6*time.Hour),
+			RankingSourceWeights: getEnvSlice("RANKING_SOURCE_WEIGHTS", []string{}),
+			RankingKeywordBoosts: getEnvSlice("RANKING_KEYWORD_BOOSTS", []string{}),
+			// Adaptive polling settings
+			PollMinInterval: getEnvDuration("POLL_MIN_INTERVAL", 5*time.Minute),
+			PollMaxInterval: getEnvDuration("POLL_MAX_INTERVAL", 24*time.Hour),
+			PollTargetYield: getEnvFloat("POLL_TARGET_YIELD", 0.5),
+			PollDecay:       getEnvFloat("POLL_DECAY", 0.9),
+			// Throttled feed host settings
+			RetryAfterDefault: getEnvDuration("RETRY_AFTER_DEFAULT", time.Minute),
+			RetryAfterMax:     getEnvDuration("RETRY_AFTER_MAX", time.Hour),
+			MaxJobDeferrals:   getEnvInt("MAX_JOB_DEFERRALS", 3),
+			// Store pool settings
+			StoreWorkers:   getEnvInt("STORE_WORKERS", 3),
+			StoreQueueSize: getEnvInt("STORE_QUEUE_SIZE", 50),
+			// Small feed pipelining settings
+			SmallFeedConcurrency: getEnvInt("SMALL_FEED_CONCURRENCY", 4),
+			SmallFeedMaxItems:    getEnvInt("SMALL_FEED_MAX_ITEMS", 10),
+			// Per-tenant ingest throttle settings
+			IngestTenantRate: getEnvFloat("INGEST_TENANT_RATE", 100),
+			IngestGlobalRate: getEnvFloat("INGEST_GLOBAL_RATE", 500),
+			IngestBurst:      getEnvInt("INGEST_BURST", 500),
+			IngestMaxQueued:  getEnvInt("INGEST_MAX_QUEUED", 10000),
+			// User data deletion settings
+			UserDataDeadline: getEnvDuration("USER_DATA_DELETION_DEADLINE", time.Hour),
+			// Usage analytics settings
+			UsageAnalyticsEnabled:       getEnvBool("USAGE_ANALYTICS_ENABLED", false),
+			UsageAnalyticsRetentionDays: getEnvInt("USAGE_ANALYTICS_RETENTION_DAYS", 30),
+			// Date filter settings
+			DefaultTimezone: getEnv("DEFAULT_TIMEZONE", "UTC"),
+			// Item history settings
+			ItemRevisionRetention: getEnvDuration("ITEM_REVISION_RETENTION", 30*24*time.Hour),
+			// Overflow queue settings
+			OverflowMaxJobs: getEnvInt("OVERFLOW_QUEUE_MAX_JOBS", 0),
+			// Job status settings
+			JobStatusRetention:  getEnvDuration("JOB_STATUS_RETENTION", 24*time.Hour),
+			JobStatusMaxEntries: getEnvInt("JOB_STATUS_MAX_ENTRIES", 10000),
+			// Job watchdog settings
+			JobMaxDuration:      getEnvDuration("JOB_MAX_DURATION", 2*time.Minute),
+			JobMaxFeedBytes:     getEnvInt("JOB_MAX_FEED_BYTES", 10*1024*1024),
+			JobOverBudgetAlerts: getEnvInt("JOB_OVER_BUDGET_ALERT_THRESHOLD", 3),
+			// Canary settings
+			CanaryEnabled:    getEnvBool("CANARY_ENABLED", false),
+			CanaryInterval:   getEnvDuration("CANARY_INTERVAL", 5*time.Minute),
+			CanaryMaxLatency: getEnvDuration("CANARY_MAX_LATENCY", 30*time.Second),
+			CanaryBaseURL:    getEnv("CANARY_BASE_URL", "http://localhost:8080"),
+			// Item snapshot settings
+			ItemSnapshotsEnabled: getEnvBool("ITEM_SNAPSHOTS_ENABLED", false),
+			// Client ban settings
+			ClientBansEnabled:       getEnvBool("CLIENT_BANS_ENABLED", false),
+			BanInvalidURLThreshold:  getEnvInt("BAN_INVALID_URL_THRESHOLD", 20),
+			BanAuthFailureThreshold: getEnvInt("BAN_AUTH_FAILURE_THRESHOLD", 10),
+			BanWindow:               getEnvDuration("BAN_WINDOW", 10*time.Minute),
+			BanDuration:             getEnvDuration("BAN_DURATION", 30*time.Minute),
+			// Page size settings
+			ItemsDefaultLimit: getEnvInt("ITEMS_DEFAULT_LIMIT", 100),
+			ItemsMaxLimit:     getEnvInt("ITEMS_MAX_LIMIT", 1000),
+			// Stale fallback settings
+			StaleFallbackMaxAge:     getEnvDuration("STALE_FALLBACK_MAX_AGE", 24*time.Hour),
+			StaleFallbackMaxEntries: getEnvInt("STALE_FALLBACK_MAX_ENTRIES", 500),
+			// Feed error webhook settings
+			FeedErrorWebhookThreshold: getEnvInt("FEED_ERROR_WEBHOOK_THRESHOLD", 3),
+			// Startup warm-up settings
+			WarmUpEnabled:  getEnvBool("WARMUP_ENABLED", true),
+			WarmUpMaxFeeds: getEnvInt("WARMUP_MAX_FEEDS", 20),
+			WarmUpTimeout:  getEnvDuration("WARMUP_TIMEOUT", 30*time.Second),
+		},
+		// Outbound request security settings
+		SecurityConfig: SecurityConfig{
+			BlockedCIDRs:   getEnvSlice("BLOCKED_CIDRS", []string{}),
+			AdminToken:     getEnv("ADMIN_TOKEN", ""),
+			TrustedProxies: getEnvSlice("TRUSTED_PROXIES", []string{}),
+		},
+		RuntimeConfig: RuntimeConfig{
+			GCPercent:     getEnvInt("GC_PERCENT", 0),
+			MemoryLimitMB: getEnvInt("MEMORY_LIMIT_MB", 0),
+			HeapBallastMB: getEnvInt("HEAP_BALLAST_MB", 0),
 		},
+		// Fault injection is for staging; validation refuses it outside development and staging environments
+		FaultInjectionConfig: FaultInjectionConfig{
+			Enabled:      getEnvBool("FAULT_INJECTION_ENABLED", false),
+			ErrorRates:   getEnvSlice("FAULT_ERROR_RATES", []string{}),
+			LatencyRates: getEnvSlice("FAULT_LATENCY_RATES", []string{}),
+			Latency:      getEnvDuration("FAULT_LATENCY", 500*time.Millisecond),
+		},
+		ReadOnly:               getEnvBool("READ_ONLY", false),
+		ReplicaRefreshInterval: getEnvDuration("REPLICA_REFRESH_INTERVAL", time.Minute),
+		IDFormat:               getEnv("ID_FORMAT", "ulid"),
+		// Admin UI is opt-in because it exposes operational details
+		AdminUIEnabled: getEnvBool("ADMIN_UI_ENABLED", false),
+		// The simulated feed is opt-in, so a deployment that leaves ENVIRONMENT unset does not serve it
+		SimulatedFeedEnabled: getEnvBool("SIMULATED_FEED_ENABLED", false),
 	}
 }
 
@@ -167,9 +441,274 @@ func (c *Config) Validate() error {
 	if c.ProjectID == "" {
 		return fmt.Errorf("PROJECT_ID environment variable is required")
 	}
+	if err := c.LogOptions().Validate(); err != nil {
+		return fmt.Errorf("LOG_BACKEND, LOG_LEVEL, or LOG_FORMAT is invalid: %v", err)
+	}
+	if err := middleware.ValidateAccessLogFormat(c.AccessLogFormat); err != nil {
+		return fmt.Errorf("ACCESS_LOG_FORMAT is invalid: %v", err)
+	}
+	if _, err := c.RouteTimeoutBudgets(); err != nil {
+		return fmt.Errorf("ROUTE_TIMEOUTS is invalid: %v", err)
+	}
+	if c.RouteTimeoutDefault < 0 {
+		return fmt.Errorf("ROUTE_TIMEOUT_DEFAULT must not be negative")
+	}
+	if _, err := utils.ParseCIDRs(c.SecurityConfig.BlockedCIDRs); err != nil {
+		return fmt.Errorf("BLOCKED_CIDRS is invalid: %v", err)
+	}
+	if c.SecurityConfig.AdminToken != "" && len(c.SecurityConfig.AdminToken) < 32 {
+		return fmt.Errorf("ADMIN_TOKEN must be at least 32 characters")
+	}
+	if _, err := utils.ParseCIDRs(c.SecurityConfig.TrustedProxies); err != nil {
+		return fmt.Errorf("TRUSTED_PROXIES is invalid: %v", err)
+	}
+	if c.PerformanceConfig.SyncFetchTimeout < 0 {
+		return fmt.Errorf("SYNC_FETCH_TIMEOUT must not be negative")
+	}
+	if c.PerformanceConfig.AutoAsyncThreshold < 0 {
+		return fmt.Errorf("AUTO_ASYNC_THRESHOLD must not be negative")
+	}
+	if c.PerformanceConfig.MaxJobStatusWait < 0 {
+		return fmt.Errorf("MAX_JOB_STATUS_WAIT must not be negative")
+	}
+	if c.PerformanceConfig.JobResultTTL < 0 {
+		return fmt.Errorf("JOB_RESULT_TTL must not be negative")
+	}
+	if c.PerformanceConfig.RedirectConfirmations < 0 {
+		return fmt.Errorf("FEED_REDIRECT_CONFIRMATIONS must not be negative")
+	}
+	if c.PerformanceConfig.IconMaxBytes < 0 {
+		return fmt.Errorf("ICON_MAX_BYTES must not be negative")
+	}
+	if c.PerformanceConfig.DeadFeedWindow < 0 {
+		return fmt.Errorf("DEAD_FEED_WINDOW must not be negative")
+	}
+	if c.PerformanceConfig.DedupTitleThreshold < 0 || c.PerformanceConfig.DedupTitleThreshold > 1 {
+		return fmt.Errorf("DEDUP_TITLE_THRESHOLD must be between 0 and 1")
+	}
+	if c.PerformanceConfig.DedupWindowSize < 0 {
+		return fmt.Errorf("DEDUP_WINDOW_SIZE must not be negative")
+	}
+	if c.PerformanceConfig.StoryTitleThreshold <= 0 || c.PerformanceConfig.StoryTitleThreshold > 1 {
+		return fmt.Errorf("STORY_TITLE_THRESHOLD must be greater than 0 and at most 1")
+	}
+	if c.PerformanceConfig.StoryWindow <= 0 {
+		return fmt.Errorf("STORY_WINDOW must be positive")
+	}
+	if c.PerformanceConfig.RankingHalfLife <= 0 {
+		return fmt.Errorf("RANKING_HALF_LIFE must be positive")
+	}
+	if _, err := utils.ParseWeights(c.PerformanceConfig.RankingSourceWeights); err != nil {
+		return fmt.Errorf("RANKING_SOURCE_WEIGHTS is invalid: %v", err)
+	}
+	if _, err := utils.ParseWeights(c.PerformanceConfig.RankingKeywordBoosts); err != nil {
+		return fmt.Errorf("RANKING_KEYWORD_BOOSTS is invalid: %v", err)
+	}
+	if c.PerformanceConfig.PollMinInterval <= 0 {
+		return fmt.Errorf("POLL_MIN_INTERVAL must be positive")
+	}
+	if c.PerformanceConfig.PollMaxInterval < c.PerformanceConfig.PollMinInterval {
+		return fmt.Errorf("POLL_MAX_INTERVAL must not be less than POLL_MIN_INTERVAL")
+	}
+	if c.PerformanceConfig.PollTargetYield <= 0 || c.PerformanceConfig.PollTargetYield >= 1 {
+		return fmt.Errorf("POLL_TARGET_YIELD must be between 0 and 1, exclusive")
+	}
+	if c.PerformanceConfig.PollDecay <= 0 || c.PerformanceConfig.PollDecay > 1 {
+		return fmt.Errorf("POLL_DECAY must be greater than 0 and at most 1")
+	}
+	if c.PerformanceConfig.RetryAfterDefault <= 0 {
+		return fmt.Errorf("RETRY_AFTER_DEFAULT must be positive")
+	}
+	if c.PerformanceConfig.RetryAfterMax < c.PerformanceConfig.RetryAfterDefault {
+		return fmt.Errorf("RETRY_AFTER_MAX must be at least RETRY_AFTER_DEFAULT")
+	}
+	if c.PerformanceConfig.MaxJobDeferrals < 0 {
+		return fmt.Errorf("MAX_JOB_DEFERRALS must not be negative")
+	}
+	if c.PerformanceConfig.StoreWorkers <= 0 {
+		return fmt.Errorf("STORE_WORKERS must be positive")
+	}
+	if c.PerformanceConfig.StoreQueueSize <= 0 {
+		return fmt.Errorf("STORE_QUEUE_SIZE must be positive")
+	}
+	if c.PerformanceConfig.SmallFeedConcurrency < 0 {
+		return fmt.Errorf("SMALL_FEED_CONCURRENCY must not be negative")
+	}
+	if c.PerformanceConfig.SmallFeedMaxItems <= 0 {
+		return fmt.Errorf("SMALL_FEED_MAX_ITEMS must be positive")
+	}
+	if c.PerformanceConfig.IngestTenantRate < 0 || c.PerformanceConfig.IngestGlobalRate < 0 {
+		return fmt.Errorf("INGEST_TENANT_RATE and INGEST_GLOBAL_RATE must not be negative")
+	}
+	if c.PerformanceConfig.IngestBurst <= 0 {
+		return fmt.Errorf("INGEST_BURST must be positive")
+	}
+	if c.PerformanceConfig.IngestMaxQueued < 0 {
+		return fmt.Errorf("INGEST_MAX_QUEUED must not be negative")
+	}
+	if c.PerformanceConfig.UserDataDeadline <= 0 {
+		return fmt.Errorf("USER_DATA_DELETION_DEADLINE must be positive")
+	}
+	if c.PerformanceConfig.UsageAnalyticsEnabled && c.PerformanceConfig.UsageAnalyticsRetentionDays <= 0 {
+		return fmt.Errorf("USAGE_ANALYTICS_RETENTION_DAYS must be positive when usage analytics are enabled")
+	}
+	if _, err := time.LoadLocation(c.PerformanceConfig.DefaultTimezone); err != nil {
+		return fmt.Errorf("DEFAULT_TIMEZONE is invalid: %v", err)
+	}
+	if c.PerformanceConfig.ItemRevisionRetention <= 0 {
+		return fmt.Errorf("ITEM_REVISION_RETENTION must be positive")
+	}
+	if c.PerformanceConfig.OverflowMaxJobs < 0 {
+		return fmt.Errorf("OVERFLOW_QUEUE_MAX_JOBS must not be negative")
+	}
+	if c.PerformanceConfig.JobStatusRetention <= 0 {
+		return fmt.Errorf("JOB_STATUS_RETENTION must be positive")
+	}
+	if c.PerformanceConfig.JobStatusMaxEntries <= 0 {
+		return fmt.Errorf("JOB_STATUS_MAX_ENTRIES must be positive")
+	}
+	if c.PerformanceConfig.JobMaxDuration < 0 || c.PerformanceConfig.JobMaxFeedBytes < 0 || c.PerformanceConfig.JobOverBudgetAlerts < 0 {
+		return fmt.Errorf("JOB_MAX_DURATION, JOB_MAX_FEED_BYTES, and JOB_OVER_BUDGET_ALERT_THRESHOLD must not be negative")
+	}
+	if c.PerformanceConfig.CanaryEnabled {
+		if c.PerformanceConfig.CanaryInterval <= 0 || c.PerformanceConfig.CanaryMaxLatency <= 0 {
+			return fmt.Errorf("CANARY_INTERVAL and CANARY_MAX_LATENCY must be positive when the canary is enabled")
+		}
+		baseURL, err := url.Parse(c.PerformanceConfig.CanaryBaseURL)
+		if err != nil {
+			return fmt.Errorf("CANARY_BASE_URL is invalid: %v", err)
+		}
+		if (baseURL.Scheme != "http" && baseURL.Scheme != "https") || baseURL.Host == "" {
+			return fmt.Errorf("CANARY_BASE_URL must be an absolute http or https URL")
+		}
+	}
+	if c.PerformanceConfig.ClientBansEnabled {
+		if c.PerformanceConfig.BanInvalidURLThreshold < 0 || c.PerformanceConfig.BanAuthFailureThreshold < 0 {
+			return fmt.Errorf("BAN_INVALID_URL_THRESHOLD and BAN_AUTH_FAILURE_THRESHOLD must not be negative")
+		}
+		if c.PerformanceConfig.BanWindow <= 0 || c.PerformanceConfig.BanDuration <= 0 {
+			return fmt.Errorf("BAN_WINDOW and BAN_DURATION must be positive when client bans are enabled")
+		}
+	}
+	if c.PerformanceConfig.ItemsMaxLimit < 1 || c.PerformanceConfig.ItemsMaxLimit > handlers.MaxItemsPageSize {
+		return fmt.Errorf("ITEMS_MAX_LIMIT must be between 1 and %d", handlers.MaxItemsPageSize)
+	}
+	if c.PerformanceConfig.ItemsDefaultLimit < 1 || c.PerformanceConfig.ItemsDefaultLimit > c.PerformanceConfig.ItemsMaxLimit {
+		return fmt.Errorf("ITEMS_DEFAULT_LIMIT must be between 1 and ITEMS_MAX_LIMIT")
+	}
+	if c.PerformanceConfig.StaleFallbackMaxAge < 0 || c.PerformanceConfig.StaleFallbackMaxEntries < 0 {
+		return fmt.Errorf("STALE_FALLBACK_MAX_AGE and STALE_FALLBACK_MAX_ENTRIES must not be negative")
+	}
+	if c.PerformanceConfig.FeedErrorWebhookThreshold < 0 {
+		return fmt.Errorf("FEED_ERROR_WEBHOOK_THRESHOLD must not be negative")
+	}
+	if c.PerformanceConfig.WarmUpEnabled {
+		if c.PerformanceConfig.WarmUpMaxFeeds < 0 {
+			return fmt.Errorf("WARMUP_MAX_FEEDS must not be negative")
+		}
+		if c.PerformanceConfig.WarmUpTimeout <= 0 {
+			return fmt.Errorf("WARMUP_TIMEOUT must be positive when the warm-up is enabled")
+		}
+	}
+	if _, err := utils.NewIDGenerator(c.IDFormat); err != nil {
+		return fmt.Errorf("ID_FORMAT is invalid: %v", err)
+	}
+	if err := c.RuntimeOptions().Validate(); err != nil {
+		return fmt.Errorf("GC_PERCENT, MEMORY_LIMIT_MB, and HEAP_BALLAST_MB are invalid: %v", err)
+	}
+	if c.ReadOnly && c.ReplicaRefreshInterval <= 0 {
+		return fmt.Errorf("REPLICA_REFRESH_INTERVAL must be positive in read-only mode")
+	}
+	if c.FaultInjectionConfig.Enabled && !c.IsDevelopment() && !c.IsStaging() {
+		return fmt.Errorf("FAULT_INJECTION_ENABLED requires ENVIRONMENT to be development, dev, local, staging, or stage")
+	}
+	if c.SimulatedFeedEnabled && !c.IsDevelopment() {
+		return fmt.Errorf("SIMULATED_FEED_ENABLED requires ENVIRONMENT to be development, dev, or local")
+	}
+	if _, err := c.NewFaultInjector(); err != nil {
+		return err
+	}
 	return nil
 }
 
+// LogOptions returns the application log settings
+func (c *Config) LogOptions() middleware.LogOptions {
+	return middleware.LogOptions{
+		Backend: c.LogBackend,
+		Level:   c.LogLevel,
+		Format:  c.LogFormat,
+		Output:  c.LogOutput,
+	}
+}
+
+// RouteTimeoutBudgets returns the request budget of each listed route
+func (c *Config) RouteTimeoutBudgets() (map[string]time.Duration, error) {
+	return middleware.ParseRouteTimeouts(c.RouteTimeouts)
+}
+
+// RuntimeOptions returns the garbage collector settings to apply at startup
+func (c *Config) RuntimeOptions() monitoring.RuntimeOptions {
+	return monitoring.RuntimeOptions{
+		GCPercent:    c.RuntimeConfig.GCPercent,
+		MemoryLimit:  int64(c.RuntimeConfig.MemoryLimitMB) << 20,
+		BallastBytes: int64(c.RuntimeConfig.HeapBallastMB) << 20,
+	}
+}
+
+// IsDevelopment reports whether ENVIRONMENT names a development environment, where development-only routes are served
+func (c *Config) IsDevelopment() bool {
+	switch strings.ToLower(c.CORSConfig.Environment) {
+	case "development", "dev", "local":
+		return true
+	}
+	return false
+}
+
+// IsStaging reports whether ENVIRONMENT names a staging environment, where test-only faults may be injected
+func (c *Config) IsStaging() bool {
+	switch strings.ToLower(c.CORSConfig.Environment) {
+	case "staging", "stage":
+		return true
+	}
+	return false
+}
+
+// Redacted returns a copy of the configuration that is safe to share, with secrets and URL credentials removed
+func (c *Config) Redacted() Config {
+	redacted := *c
+	redacted.SecurityConfig.AdminToken = ""
+	if parsed, err := url.Parse(redacted.PerformanceConfig.CanaryBaseURL); err == nil && parsed.User != nil {
+		parsed.User = nil
+		redacted.PerformanceConfig.CanaryBaseURL = parsed.String()
+	}
+	return redacted
+}
+
+// NewFaultInjector returns the injector for configured faults, or nil when fault injection is disabled
+func (c *Config) NewFaultInjector() (*utils.FaultInjector, error) {
+	if !c.FaultInjectionConfig.Enabled {
+		return nil, nil
+	}
+	errorRates, err := utils.ParseWeights(c.FaultInjectionConfig.ErrorRates)
+	if err == nil {
+		err = utils.ValidateFaultRates(errorRates)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("FAULT_ERROR_RATES is invalid: %v", err)
+	}
+	latencyRates, err := utils.ParseWeights(c.FaultInjectionConfig.LatencyRates)
+	if err == nil {
+		err = utils.ValidateFaultRates(latencyRates)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("FAULT_LATENCY_RATES is invalid: %v", err)
+	}
+	if c.FaultInjectionConfig.Latency < 0 {
+		return nil, fmt.Errorf("FAULT_LATENCY must not be negative")
+	}
+	return utils.NewFaultInjector(errorRates, latencyRates, c.FaultInjectionConfig.Latency), nil
+}
+
 // NewServices creates and initializes all service dependencies using DI container
 func NewServices(config *Config) (*Services, error) {
 	logger := middleware.Logger
@@ -197,9 +736,111 @@ func NewServices(config *Config) (*Services, error) {
 	)
 	logger.Info("Cache manager initialized successfully")
 
+	// Build handler settings from configuration
+	blockedCIDRs, err := utils.ParseCIDRs(config.SecurityConfig.BlockedCIDRs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse blocked CIDRs: %v", err)
+	}
+	trustedProxies, err := utils.ParseCIDRs(config.SecurityConfig.TrustedProxies)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse trusted proxies: %v", err)
+	}
+	sourceWeights, err := utils.ParseWeights(config.PerformanceConfig.RankingSourceWeights)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ranking source weights: %v", err)
+	}
+	keywordBoosts, err := utils.ParseWeights(config.PerformanceConfig.RankingKeywordBoosts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ranking keyword boosts: %v", err)
+	}
+	defaultTimezone, err := time.LoadLocation(config.PerformanceConfig.DefaultTimezone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load default timezone: %v", err)
+	}
+	usageAnalyticsDays := 0
+	if config.PerformanceConfig.UsageAnalyticsEnabled {
+		usageAnalyticsDays = config.PerformanceConfig.UsageAnalyticsRetentionDays
+	}
+	var banRules []handlers.BanRule
+	if config.PerformanceConfig.ClientBansEnabled {
+		banRules = handlers.DefaultBanRules(config.PerformanceConfig.BanInvalidURLThreshold, config.PerformanceConfig.BanAuthFailureThreshold)
+	}
+	// The canary writes what it ingests, so read replicas leave it to the writer
+	canaryURL := ""
+	if config.PerformanceConfig.CanaryEnabled && !config.ReadOnly {
+		canaryURL = strings.TrimRight(config.PerformanceConfig.CanaryBaseURL, "/") + handlers.CanaryPath
+	}
+	handlerConfig := handlers.HandlerConfig{
+		BlockedCIDRs:            blockedCIDRs,
+		SyncFetchTimeout:        config.PerformanceConfig.SyncFetchTimeout,
+		AutoAsyncThreshold:      config.PerformanceConfig.AutoAsyncThreshold,
+		MaxJobStatusWait:        config.PerformanceConfig.MaxJobStatusWait,
+		JobResultTTL:            config.PerformanceConfig.JobResultTTL,
+		RedirectConfirmations:   config.PerformanceConfig.RedirectConfirmations,
+		IconCacheTTL:            config.PerformanceConfig.IconCacheTTL,
+		IconMaxBytes:            int64(config.PerformanceConfig.IconMaxBytes),
+		StaleFeedWindow:         config.PerformanceConfig.DeadFeedWindow,
+		DuplicateTitleThreshold: config.PerformanceConfig.DedupTitleThreshold,
+		DuplicateWindowSize:     config.PerformanceConfig.DedupWindowSize,
+		StoryTitleThreshold:     config.PerformanceConfig.StoryTitleThreshold,
+		StoryWindow:             config.PerformanceConfig.StoryWindow,
+		RankingWeights: utils.RankingWeights{
+			Recency:         config.PerformanceConfig.RankingRecencyWeight,
+			Source:          config.PerformanceConfig.RankingSourceWeight,
+			ClusterSize:     config.PerformanceConfig.RankingClusterWeight,
+			Keyword:         config.PerformanceConfig.RankingKeywordWeight,
+			RecencyHalfLife: config.PerformanceConfig.RankingHalfLife,
+		},
+		SourceWeights: sourceWeights,
+		KeywordBoosts: keywordBoosts,
+		PollPolicy: utils.PollPolicy{
+			MinInterval:     config.PerformanceConfig.PollMinInterval,
+			MaxInterval:     config.PerformanceConfig.PollMaxInterval,
+			TargetYield:     config.PerformanceConfig.PollTargetYield,
+			Decay:           config.PerformanceConfig.PollDecay,
+			MinObservations: utils.DefaultPollPolicy().MinObservations,
+		},
+		RetryPolicy: utils.RetryPolicy{
+			DefaultWait:  config.PerformanceConfig.RetryAfterDefault,
+			MaxWait:      config.PerformanceConfig.RetryAfterMax,
+			MaxDeferrals: config.PerformanceConfig.MaxJobDeferrals,
+		},
+		StoreWorkers:            config.PerformanceConfig.StoreWorkers,
+		StoreQueueSize:          config.PerformanceConfig.StoreQueueSize,
+		SmallFeedConcurrency:    config.PerformanceConfig.SmallFeedConcurrency,
+		SmallFeedMaxItems:       config.PerformanceConfig.SmallFeedMaxItems,
+		IngestTenantRate:        config.PerformanceConfig.IngestTenantRate,
+		IngestGlobalRate:        config.PerformanceConfig.IngestGlobalRate,
+		IngestBurst:             config.PerformanceConfig.IngestBurst,
+		IngestMaxQueued:         config.PerformanceConfig.IngestMaxQueued,
+		UserDataDeadline:        config.PerformanceConfig.UserDataDeadline,
+		UsageAnalyticsDays:      usageAnalyticsDays,
+		DefaultTimezone:         defaultTimezone,
+		ItemRevisionRetention:   config.PerformanceConfig.ItemRevisionRetention,
+		OverflowMaxJobs:         config.PerformanceConfig.OverflowMaxJobs,
+		JobStatusRetention:      config.PerformanceConfig.JobStatusRetention,
+		JobStatusMaxEntries:     config.PerformanceConfig.JobStatusMaxEntries,
+		JobMaxDuration:          config.PerformanceConfig.JobMaxDuration,
+		JobMaxFeedBytes:         int64(config.PerformanceConfig.JobMaxFeedBytes),
+		JobOverBudgetAlerts:     config.PerformanceConfig.JobOverBudgetAlerts,
+		CanaryURL:               canaryURL,
+		CanaryMaxLatency:        config.PerformanceConfig.CanaryMaxLatency,
+		ItemSnapshots:           config.PerformanceConfig.ItemSnapshotsEnabled,
+		BanRules:                banRules,
+		BanWindow:               config.PerformanceConfig.BanWindow,
+		BanDuration:             config.PerformanceConfig.BanDuration,
+		TrustedProxies:          trustedProxies,
+		DefaultPageSize:         config.PerformanceConfig.ItemsDefaultLimit,
+		MaxPageSize:             config.PerformanceConfig.ItemsMaxLimit,
+		StaleFallbackMaxAge:     config.PerformanceConfig.StaleFallbackMaxAge,
+		StaleFallbackMaxEntries: config.PerformanceConfig.StaleFallbackMaxEntries,
+		ErrorWebhookThreshold:   config.PerformanceConfig.FeedErrorWebhookThreshold,
+		ReadOnly:                config.ReadOnly,
+	}
+
 	// Initialize dependency injection container
 	diContainer := container.NewContainer()
-	if err := diContainer.InitializeServices(datastoreClient, cacheManager, logger); err != nil {
+	if err := diContainer.InitializeServices(datastoreClient, cacheManager, logger, handlerConfig); err != nil {
 		return nil, fmt.Errorf("failed to initialize dependency container: %v", err)
 	}
 
@@ -217,6 +858,22 @@ func NewAppConfig() (*AppConfig, error) {
 		return nil, fmt.Errorf("configuration validation failed: %v", err)
 	}
 
+	// Generate IDs in the configured format from the first request on
+	idGenerator, err := utils.NewIDGenerator(config.IDFormat)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ID generator: %v", err)
+	}
+	utils.SetIDGenerator(idGenerator)
+
+	// Faults must be installed before services wrap their dependencies
+	faultInjector, err := config.NewFaultInjector()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create fault injector: %v", err)
+	}
+	if faultInjector != nil {
+		utils.SetFaultInjector(faultInjector)
+	}
+
 	services, err := NewServices(config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize services: %v", err)
@@ -137,7 +137,7 @@ func (c *Container) GetHandler() (*handlers.Handler, error) {
 }
 
 // InitializeServices initializes all core services with proper dependencies
-func (c *Container) InitializeServices(datastoreClient *datastore.Client, cacheManager *cache.CacheManager, logger *logrus.Logger) error {
+func (c *Container) InitializeServices(datastoreClient *datastore.Client, cacheManager *cache.CacheManager, logger *logrus.Logger, handlerConfig handlers.HandlerConfig) error {
 	// Register core services
 	c.RegisterSingleton("logger", logger)
 	c.RegisterSingleton("datastore", datastoreClient)
@@ -145,7 +145,7 @@ func (c *Container) InitializeServices(datastoreClient *datastore.Client, cacheM
 
 	// Register handler factory that depends on other services
 	c.RegisterFactory("handler", func() (interface{}, error) {
-		return handlers.NewHandler(datastoreClient, cacheManager, logger), nil
+		return handlers.NewHandlerWithConfig(datastoreClient, cacheManager, logger, handlerConfig), nil
 	})
 
 	return nil
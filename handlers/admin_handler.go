@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/Nexora-Open-Source/rss-feed-backend/cache"
+	"github.com/Nexora-Open-Source/rss-feed-backend/middleware"
+	"github.com/Nexora-Open-Source/rss-feed-backend/monitoring"
+	"github.com/Nexora-Open-Source/rss-feed-backend/types"
+	"github.com/Nexora-Open-Source/rss-feed-backend/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// adminRecentJobs is how many of the newest async jobs the admin overview lists
+const adminRecentJobs = 20
+
+// queueStatsSource is implemented by async processors that report queue statistics
+type queueStatsSource interface {
+	QueueStats() QueueStats
+	RecentJobs(limit int) []types.AsyncJobStatus
+}
+
+// cacheStatsSource is implemented by cache managers that report hit statistics
+type cacheStatsSource interface {
+	Stats() cache.CacheStats
+}
+
+// AdminOverview is the response body for GET /admin/overview
+type AdminOverview struct {
+	GeneratedAt time.Time `json:"generated_at"`
+	// Queue and Cache are omitted when the configured processor or cache does not report statistics
+	Queue      *QueueStats            `json:"queue,omitempty"`
+	RecentJobs []types.AsyncJobStatus `json:"recent_jobs"`
+	FeedHealth []FeedHealth           `json:"feed_health"`
+	Cache      *cache.CacheStats      `json:"cache,omitempty"`
+	Alerts     []monitoring.Alert     `json:"alerts"`
+}
+
+// @Summary Get admin overview
+// @Description Returns an operational snapshot for the admin UI: async queue depth and job counts, the most recent jobs, health of every tracked feed, cache hit statistics, and active alerts. Requires the admin token.
+// @Tags Admin
+// @Produce json
+// @Security AdminToken
+// @Success 200 {object} AdminOverview "Operational overview"
+// @Failure 401 {object} middleware.APIError "Missing or invalid admin token"
+// @Router /admin/overview [get]
+func (h *Handler) HandleGetAdminOverview(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Header.Get("X-Request-ID")
+	if requestID == "" {
+		requestID = utils.GenerateRequestID()
+		w.Header().Set("X-Request-ID", requestID)
+	}
+
+	overview := AdminOverview{
+		GeneratedAt: time.Now().UTC(),
+		RecentJobs:  []types.AsyncJobStatus{},
+		FeedHealth:  h.FeedHealth.All(),
+		Alerts:      []monitoring.Alert{},
+	}
+	if source, ok := h.AsyncProcessor.(queueStatsSource); ok {
+		stats := source.QueueStats()
+		overview.Queue = &stats
+		overview.RecentJobs = source.RecentJobs(adminRecentJobs)
+	}
+	if source, ok := h.CacheManager.(cacheStatsSource); ok {
+		stats := source.Stats()
+		overview.Cache = &stats
+	}
+	if h.Alerts != nil {
+		// Copy alerts so encoding does not race with the manager resolving them
+		for _, alert := range h.Alerts.GetActiveAlerts() {
+			overview.Alerts = append(overview.Alerts, *alert)
+		}
+		sort.Slice(overview.Alerts, func(i, j int) bool {
+			return overview.Alerts[i].Timestamp.After(overview.Alerts[j].Timestamp)
+		})
+	}
+	if overview.FeedHealth == nil {
+		overview.FeedHealth = []FeedHealth{}
+	}
+
+	middleware.Log(r.Context()).WithFields(logrus.Fields{
+		"recent_jobs": len(overview.RecentJobs),
+		"feeds":       len(overview.FeedHealth),
+		"alerts":      len(overview.Alerts),
+	}).Debug("Admin overview retrieved")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(overview)
+}
@@ -1,8 +1,15 @@
 package handlers
 
 import (
+	"container/list"
+	"context"
+	"errors"
 	"fmt"
+	"net"
+	"net/http"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"cloud.google.com/go/datastore"
@@ -13,12 +20,32 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// defaultJobResultTTL is how long completed job results are kept for retrieval
+const defaultJobResultTTL = time.Hour
+
+// defaultSmallFeedMaxItems is the item count below which a feed is small enough to pipeline
+const defaultSmallFeedMaxItems = 10
+
+// JobTask is work run by a task job; it reports the outcome for each target it touched
+type JobTask func(ctx context.Context) ([]types.TargetResult, error)
+
+// TaskFactory rebuilds a task job's work from its payload
+type TaskFactory func(payload []byte) (JobTask, error)
+
 // AsyncJob represents a background job for RSS feed processing
 type AsyncJob struct {
 	ID        string
 	URL       string
 	RequestID string
+	TenantID  string
 	CreatedAt time.Time
+	// Operation and Task are set for jobs that run a task instead of fetching URL
+	Operation string
+	Task      JobTask
+	// Payload is set for task jobs submitted with SubmitDurableTask; Task is rebuilt from it after waiting in the overflow queue
+	Payload []byte
+	// Deferrals counts how often the job was rescheduled because the feed host was throttling
+	Deferrals int
 }
 
 // AsyncJobResult represents the result of an async job
@@ -31,19 +58,59 @@ type AsyncJobResult struct {
 	Duration    time.Duration
 }
 
-// AsyncProcessor handles background RSS feed processing
+/*
+AsyncProcessor handles background RSS feed processing.
+
+Feed jobs run in two stages with their own worker pools: fetch workers download
+and parse feeds, then hand the items over a bounded channel to store workers,
+which write them to Datastore and the cache. Slow Datastore writes therefore do
+not hold up fetches until the store queue fills. Task jobs run on fetch workers.
+
+Every job shares one FIFO queue: fetches submitted by clients, bulk feed tasks,
+and the canary's scheduled runs alike. Splitting it into priority queues would
+need aging, so scheduled jobs still run under sustained request load.
+*/
 type AsyncProcessor struct {
 	jobs            chan AsyncJob
+	stores          chan storeJob
 	results         chan AsyncJobResult
 	quit            chan bool
 	wg              sync.WaitGroup
 	jobStatus       map[string]*types.AsyncJobStatus
+	jobDone         map[string]chan struct{} // closed when a job reaches a terminal status
+	jobResults      map[string]*cache.CacheItem
+	resultTTL       time.Duration
+	statusTTL       time.Duration            // how long job statuses are kept after they are created
+	maxJobStatuses  int                      // how many job statuses are kept in memory before finished ones are evicted
+	jobLRU          *list.List               // finished job IDs, most recently used first
+	jobLRUElements  map[string]*list.Element // each finished job's entry in jobLRU
+	statusArchive   *JobStatusArchive
+	jobMisses       map[string]time.Time // job IDs found in neither memory nor archive, by when the miss expires
+	redirects       *FeedRedirectTracker
+	feedHealth      *FeedHealthTracker
+	clusters        *ItemClusterer
+	muteRules       *MuteRuleStore
+	polls           *PollScheduler
+	ingestThrottle  *IngestThrottle
+	ingestCounters  *IngestCounters
+	overflow        *OverflowQueue
+	watchdog        *JobWatchdog
+	snapshots       *SnapshotStore
+	errorWebhooks   *FeedErrorWebhooks
+	feedClient      *http.Client           // fetches feeds, refusing private and blocked addresses
+	blockedCIDRs    []*net.IPNet           // networks submitted feed URLs may not target, beyond the built-in private ranges
+	trustedFeeds    map[string]bool        // operator-configured feed URLs fetched without address checks
+	canaryFeed      string                 // the canary's feed URL, whose item is stored apart from feed items
+	taskFactories   map[string]TaskFactory // by operation, for task jobs that can wait in the overflow queue
+	retryPolicy     utils.RetryPolicy
 	statusMutex     sync.RWMutex
 	shutdownMutex   sync.RWMutex // Add mutex for shutdown flag
 	shuttingDown    bool         // Add shutdown flag
 	logger          *logrus.Logger
 	datastoreClient *datastore.Client
 	cacheManager    *cache.CacheManager
+	workers         int
+	avgJobDuration  time.Duration // moving average of finished jobs, used to estimate when a full queue has room
 	// Backpressure configuration
 	backpressureEnabled bool
 	rejectThreshold     float64
@@ -51,17 +118,40 @@ type AsyncProcessor struct {
 	queueSize           int
 	cleanupQuit         chan bool // Add quit channel for cleanup goroutine
 	resultsQuit         chan bool // Add quit channel for results
+	// Small feeds are mostly network wait, so each worker may run up to smallFeedConcurrency of them alongside its current job
+	smallFeedConcurrency int
+	smallFeedMaxItems    int
 }
 
-// NewAsyncProcessor creates a new async processor with the given parameters
+// NewAsyncProcessor creates a new async processor with the given parameters; its store pool matches the fetch pool
 func NewAsyncProcessor(workers, queueSize int, backpressureEnabled bool, rejectThreshold float64, waitTimeout time.Duration, logger *logrus.Logger, datastoreClient *datastore.Client, cacheManager *cache.CacheManager) *AsyncProcessor {
+	return NewAsyncProcessorWithStorePool(workers, queueSize, workers, queueSize, backpressureEnabled, rejectThreshold, waitTimeout, logger, datastoreClient, cacheManager)
+}
+
+// NewAsyncProcessorWithStorePool creates a new async processor whose fetch and store pools are sized separately
+func NewAsyncProcessorWithStorePool(workers, queueSize, storeWorkers, storeQueueSize int, backpressureEnabled bool, rejectThreshold float64, waitTimeout time.Duration, logger *logrus.Logger, datastoreClient *datastore.Client, cacheManager *cache.CacheManager) *AsyncProcessor {
 	processor := &AsyncProcessor{
 		jobs:                make(chan AsyncJob, queueSize),
+		stores:              make(chan storeJob, storeQueueSize),
 		results:             make(chan AsyncJobResult, queueSize),
 		quit:                make(chan bool),
 		cleanupQuit:         make(chan bool),
 		resultsQuit:         make(chan bool),
 		jobStatus:           make(map[string]*types.AsyncJobStatus),
+		jobDone:             make(map[string]chan struct{}),
+		jobResults:          make(map[string]*cache.CacheItem),
+		resultTTL:           defaultJobResultTTL,
+		statusTTL:           defaultJobStatusRetention,
+		maxJobStatuses:      defaultMaxJobStatuses,
+		jobLRU:              list.New(),
+		jobLRUElements:      make(map[string]*list.Element),
+		jobMisses:           make(map[string]time.Time),
+		taskFactories:       make(map[string]TaskFactory),
+		feedClient:          utils.NewSafeHTTPClient(0, nil),
+		trustedFeeds:        make(map[string]bool),
+		watchdog:            NewJobWatchdog(defaultJobMaxDuration, defaultJobMaxFeedBytes, 0),
+		retryPolicy:         utils.DefaultRetryPolicy(),
+		smallFeedMaxItems:   defaultSmallFeedMaxItems,
 		logger:              logger,
 		datastoreClient:     datastoreClient,
 		cacheManager:        cacheManager,
@@ -69,16 +159,22 @@ func NewAsyncProcessor(workers, queueSize int, backpressureEnabled bool, rejectT
 		rejectThreshold:     rejectThreshold,
 		waitTimeout:         waitTimeout,
 		queueSize:           queueSize,
+		workers:             workers,
 	}
 
-	// Update active workers metric
+	// Update active workers metrics
 	monitoring.UpdateActiveWorkers(workers)
+	monitoring.UpdateActiveStoreWorkers(storeWorkers)
 
-	// Start workers
+	// Start fetch and store workers
 	for i := 0; i < workers; i++ {
 		processor.wg.Add(1)
 		go processor.worker(i)
 	}
+	for i := 0; i < storeWorkers; i++ {
+		processor.wg.Add(1)
+		go processor.storeWorker(i)
+	}
 
 	// Start result processor
 	processor.wg.Add(1)
@@ -88,29 +184,126 @@ func NewAsyncProcessor(workers, queueSize int, backpressureEnabled bool, rejectT
 	processor.wg.Add(1)
 	go processor.cleanupOldJobs()
 
+	// Start moving overflowed jobs back into the queue
+	processor.wg.Add(1)
+	go processor.drainOverflow()
+
 	return processor
 }
 
 // SubmitJob submits a new job for async processing with backpressure
 func (ap *AsyncProcessor) SubmitJob(url, requestID string) (string, error) {
-	jobID := fmt.Sprintf("job_%d_%s", time.Now().UnixNano(), requestID)
+	return ap.SubmitTenantJob(url, requestID, DefaultTenant)
+}
+
+// SubmitTenantJob submits a new job whose writes draw on the tenant's ingest budget
+func (ap *AsyncProcessor) SubmitTenantJob(url, requestID, tenantID string) (string, error) {
+	return ap.SubmitTenantJobWithContext(context.Background(), url, requestID, tenantID)
+}
 
+// SubmitTenantJobWithContext submits a tenant job like SubmitTenantJob, giving up on backpressure waits once ctx is done
+func (ap *AsyncProcessor) SubmitTenantJobWithContext(ctx context.Context, url, requestID, tenantID string) (string, error) {
+	if err := ap.checkFeedURL(url); err != nil {
+		return "", err
+	}
 	job := AsyncJob{
-		ID:        jobID,
+		ID:        "job_" + utils.NewID(),
 		URL:       url,
 		RequestID: requestID,
+		TenantID:  tenantID,
+		CreatedAt: time.Now(),
+	}
+	return ap.submitOrOverflow(ctx, job)
+}
+
+// SubmitTask queues a task job, such as a bulk feed operation, on the same workers and backpressure as fetch jobs
+func (ap *AsyncProcessor) SubmitTask(operation, requestID string, task JobTask) (string, error) {
+	job := AsyncJob{
+		ID:        "job_" + utils.NewID(),
+		RequestID: requestID,
+		TenantID:  DefaultTenant,
+		CreatedAt: time.Now(),
+		Operation: operation,
+		Task:      task,
+	}
+	if err := ap.submit(context.Background(), job); err != nil {
+		return "", err
+	}
+	return job.ID, nil
+}
+
+// SubmitDurableTask queues a task job built from payload by the TaskFactory set for operation.
+// Unlike SubmitTask, a job turned away by backpressure is held in the overflow queue when one is configured.
+func (ap *AsyncProcessor) SubmitDurableTask(operation, requestID string, payload []byte) (string, error) {
+	return ap.SubmitDurableTaskWithContext(context.Background(), operation, requestID, payload)
+}
+
+// SubmitDurableTaskWithContext queues a durable task like SubmitDurableTask, giving up on backpressure waits once ctx is done
+func (ap *AsyncProcessor) SubmitDurableTaskWithContext(ctx context.Context, operation, requestID string, payload []byte) (string, error) {
+	task, err := ap.buildTask(operation, payload)
+	if err != nil {
+		return "", err
+	}
+	job := AsyncJob{
+		ID:        "job_" + utils.NewID(),
+		RequestID: requestID,
+		TenantID:  DefaultTenant,
 		CreatedAt: time.Now(),
+		Operation: operation,
+		Task:      task,
+		Payload:   payload,
+	}
+	return ap.submitOrOverflow(ctx, job)
+}
+
+// submitOrOverflow queues a job, falling back to the overflow queue when backpressure turns it away
+func (ap *AsyncProcessor) submitOrOverflow(ctx context.Context, job AsyncJob) (string, error) {
+	err := ap.submit(ctx, job)
+	var queueFull *QueueFullError
+	if errors.As(err, &queueFull) && ap.overflowJob(job) {
+		return job.ID, nil
 	}
+	if err != nil {
+		return "", err
+	}
+	return job.ID, nil
+}
+
+// buildTask rebuilds a task job's work with the TaskFactory set for operation
+func (ap *AsyncProcessor) buildTask(operation string, payload []byte) (JobTask, error) {
+	ap.statusMutex.RLock()
+	factory, exists := ap.taskFactories[operation]
+	ap.statusMutex.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("no task factory set for operation %q", operation)
+	}
+	return factory(payload)
+}
+
+// submit tracks a job as pending and queues it, applying backpressure when enabled.
+// Waiting for room stops early when ctx is done, since the submitter has gone away.
+func (ap *AsyncProcessor) submit(ctx context.Context, job AsyncJob) error {
+	jobID := job.ID
+	url := job.URL
+	requestID := job.RequestID
 
 	// Initialize job status
 	ap.statusMutex.Lock()
-	ap.jobStatus[jobID] = &types.AsyncJobStatus{
+	evicted := ap.trackJob(&types.AsyncJobStatus{
 		JobID:     jobID,
 		URL:       url,
+		Operation: job.Operation,
 		Status:    "pending",
 		CreatedAt: job.CreatedAt,
-	}
+		Events:    []types.JobEvent{{At: job.CreatedAt, Type: types.JobEventQueued}},
+	})
+	ap.jobDone[jobID] = make(chan struct{})
+	statusArchive := ap.statusArchive
 	ap.statusMutex.Unlock()
+	statusArchive.Save(context.Background(), evicted)
+
+	// The whole submission, shaping delay included, is bound by the wait timeout
+	deadline := time.Now().Add(ap.waitTimeout)
 
 	// Apply backpressure if enabled
 	if ap.backpressureEnabled {
@@ -123,16 +316,38 @@ func (ap *AsyncProcessor) SubmitJob(url, requestID string) (string, error) {
 				"queue_size":       len(ap.jobs),
 				"max_queue_size":   ap.queueSize,
 			}).Warn("Rejecting job due to backpressure - queue near capacity")
-			return "", fmt.Errorf("async processor queue under backpressure (load: %.2f%%)", currentLoad*100)
+			ap.forgetJob(jobID)
+			return ap.queueFullError(false)
 		}
 
-		// Wait with timeout if queue is getting full
-		if currentLoad >= ap.rejectThreshold*0.8 {
+		// Slow submitters down as the queue approaches the reject threshold, so load is shaped before jobs are refused
+		if delay := backpressureDelay(currentLoad, ap.rejectThreshold, ap.waitTimeout/2); delay > 0 {
 			ap.logger.WithFields(logrus.Fields{
 				"url":          url,
 				"current_load": fmt.Sprintf("%.2f", currentLoad),
+				"delay":        delay.String(),
 				"wait_timeout": ap.waitTimeout.String(),
 			}).Info("Queue approaching capacity, applying backpressure delay")
+			monitoring.RecordBackpressureDelay(delay.Seconds())
+
+			timer := time.NewTimer(delay)
+			select {
+			case <-timer.C:
+			case <-ap.quit:
+				timer.Stop()
+				ap.forgetJob(jobID)
+				return fmt.Errorf("async processor is shutting down")
+			case <-ctx.Done():
+				timer.Stop()
+				ap.forgetJob(jobID)
+				return fmt.Errorf("job submission abandoned: %w", ctx.Err())
+			}
+
+			// Other submitters may have filled the queue meanwhile
+			if float64(len(ap.jobs))/float64(ap.queueSize) >= ap.rejectThreshold {
+				ap.forgetJob(jobID)
+				return ap.queueFullError(false)
+			}
 		}
 	}
 
@@ -144,34 +359,440 @@ func (ap *AsyncProcessor) SubmitJob(url, requestID string) (string, error) {
 		ap.logger.WithFields(logrus.Fields{
 			"job_id":     jobID,
 			"url":        url,
+			"operation":  job.Operation,
 			"request_id": requestID,
 			"queue_load": fmt.Sprintf("%.2f", float64(len(ap.jobs))/float64(ap.queueSize)),
 		}).Info("Job submitted for async processing")
-		return jobID, nil
-	case <-time.After(ap.waitTimeout):
+		return nil
+	case <-time.After(time.Until(deadline)):
 		ap.logger.WithFields(logrus.Fields{
 			"url":            url,
 			"wait_timeout":   ap.waitTimeout.String(),
 			"queue_size":     len(ap.jobs),
 			"max_queue_size": ap.queueSize,
 		}).Warn("Job submission timed out due to queue pressure")
-		return "", fmt.Errorf("async processor queue timeout after %v", ap.waitTimeout)
+		ap.forgetJob(jobID)
+		return ap.queueFullError(true)
+	case <-ctx.Done():
+		ap.forgetJob(jobID)
+		return fmt.Errorf("job submission abandoned: %w", ctx.Err())
+	}
+}
+
+// backpressureSoftFraction is the fraction of the reject threshold at which submissions start being delayed
+const backpressureSoftFraction = 0.8
+
+/*
+backpressureDelay returns how long a submission waits before queueing at the given
+queue load. Below the soft threshold (backpressureSoftFraction of the reject
+threshold) there is no delay; from there it grows linearly to maxDelay at the
+reject threshold, where jobs are rejected instead.
+*/
+func backpressureDelay(load, rejectThreshold float64, maxDelay time.Duration) time.Duration {
+	soft := rejectThreshold * backpressureSoftFraction
+	if load < soft || rejectThreshold <= soft || maxDelay <= 0 {
+		return 0
+	}
+	fraction := min((load-soft)/(rejectThreshold-soft), 1)
+	return time.Duration(fraction * float64(maxDelay))
+}
+
+// QueueFullError is returned when a job is rejected because the async queue is under backpressure
+type QueueFullError struct {
+	Depth    int
+	Capacity int
+	Load     float64
+	// RetryAfter estimates how long until the queue has room, from its depth and recent job durations
+	RetryAfter time.Duration
+	// TimedOut is set when the job waited for room and none opened up
+	TimedOut bool
+}
+
+func (e *QueueFullError) Error() string {
+	if e.TimedOut {
+		return fmt.Sprintf("async processor queue timeout (depth: %d/%d)", e.Depth, e.Capacity)
+	}
+	return fmt.Sprintf("async processor queue under backpressure (load: %.2f%%, depth: %d/%d)", e.Load*100, e.Depth, e.Capacity)
+}
+
+// minQueueRetryAfter and maxQueueRetryAfter bound the wait suggested to clients turned away by backpressure
+const (
+	minQueueRetryAfter = time.Second
+	maxQueueRetryAfter = 5 * time.Minute
+)
+
+// queueFullError describes the current queue load for a rejected job
+func (ap *AsyncProcessor) queueFullError(timedOut bool) *QueueFullError {
+	depth := len(ap.jobs)
+	err := &QueueFullError{Depth: depth, Capacity: ap.queueSize, TimedOut: timedOut}
+	if ap.queueSize > 0 {
+		err.Load = float64(depth) / float64(ap.queueSize)
+	}
+
+	ap.statusMutex.RLock()
+	avg := ap.avgJobDuration
+	ap.statusMutex.RUnlock()
+	if avg <= 0 {
+		avg = ap.waitTimeout
 	}
+
+	// Enough of the queue must drain to bring it back under the reject threshold
+	excess := depth - int(ap.rejectThreshold*float64(ap.queueSize)) + 1
+	wait := time.Duration(max(excess, 1)) * avg / time.Duration(max(ap.workers, 1))
+	err.RetryAfter = min(max(wait, minQueueRetryAfter), maxQueueRetryAfter)
+	return err
 }
 
-// GetJobStatus retrieves the status of a job
+// forgetJob drops the status of a job that was never queued
+func (ap *AsyncProcessor) forgetJob(jobID string) {
+	ap.statusMutex.Lock()
+	defer ap.statusMutex.Unlock()
+
+	ap.untrackJob(jobID)
+}
+
+// GetJobStatus retrieves a snapshot of a job's status, safe to read while the job keeps running
 func (ap *AsyncProcessor) GetJobStatus(jobID string) (*types.AsyncJobStatus, bool) {
+	return ap.GetJobStatusWithContext(context.Background(), jobID)
+}
+
+// GetJobStatusWithContext retrieves a job's status like GetJobStatus, reading any archived status under ctx.
+// Jobs found nowhere are remembered for jobMissTTL, so repeated lookups of unknown IDs do not each read Datastore.
+func (ap *AsyncProcessor) GetJobStatusWithContext(ctx context.Context, jobID string) (*types.AsyncJobStatus, bool) {
+	ap.statusMutex.Lock()
+	status, exists := ap.jobStatus[jobID]
+	if !exists {
+		statusArchive, retention := ap.statusArchive, ap.statusTTL
+		expires, missed := ap.jobMisses[jobID]
+		ap.statusMutex.Unlock()
+		if missed && time.Now().Before(expires) {
+			return nil, false
+		}
+
+		// Finished jobs evicted from memory at capacity may still be archived
+		archived, found, err := statusArchive.Lookup(ctx, jobID, retention)
+		if !found && err == nil {
+			ap.rememberJobMiss(jobID)
+		}
+		return archived, found
+	}
+	defer ap.statusMutex.Unlock()
+
+	ap.touchJob(jobID)
+	snapshot := *status
+	snapshot.Events = append([]types.JobEvent(nil), status.Events...)
+	return &snapshot, true
+}
+
+// QueueStats summarizes the async job queue and the jobs still tracked
+type QueueStats struct {
+	Depth      int     `json:"depth"`
+	Capacity   int     `json:"capacity"`
+	Load       float64 `json:"load"`
+	Pending    int     `json:"pending"`
+	Processing int     `json:"processing"`
+	Completed  int     `json:"completed"`
+	Failed     int     `json:"failed"`
+	Deferred   int     `json:"deferred"`
+	// Overflowed counts jobs waiting in the persistent overflow queue for room in the job queue
+	Overflowed int `json:"overflowed"`
+	// StoreDepth is how many fetched feeds are waiting for a store worker
+	StoreDepth    int `json:"store_depth"`
+	StoreCapacity int `json:"store_capacity"`
+}
+
+// QueueStats returns the current queue depth and job counts by status
+func (ap *AsyncProcessor) QueueStats() QueueStats {
+	stats := QueueStats{
+		Depth:         len(ap.jobs),
+		Capacity:      ap.queueSize,
+		StoreDepth:    len(ap.stores),
+		StoreCapacity: cap(ap.stores),
+	}
+	if ap.queueSize > 0 {
+		stats.Load = float64(stats.Depth) / float64(ap.queueSize)
+	}
+
 	ap.statusMutex.RLock()
 	defer ap.statusMutex.RUnlock()
 
-	status, exists := ap.jobStatus[jobID]
-	return status, exists
+	for _, status := range ap.jobStatus {
+		switch status.Status {
+		case "pending":
+			stats.Pending++
+		case "processing":
+			stats.Processing++
+		case "completed":
+			stats.Completed++
+		case "failed":
+			stats.Failed++
+		case "deferred":
+			stats.Deferred++
+		case "queued_deferred":
+			stats.Overflowed++
+		}
+	}
+	return stats
+}
+
+// RecentJobs returns up to limit tracked jobs, newest first
+func (ap *AsyncProcessor) RecentJobs(limit int) []types.AsyncJobStatus {
+	ap.statusMutex.RLock()
+	jobs := make([]types.AsyncJobStatus, 0, len(ap.jobStatus))
+	for _, status := range ap.jobStatus {
+		jobs = append(jobs, *status)
+	}
+	ap.statusMutex.RUnlock()
+
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].CreatedAt.After(jobs[j].CreatedAt)
+	})
+	if limit > 0 && len(jobs) > limit {
+		jobs = jobs[:limit]
+	}
+	return jobs
+}
+
+// SetResultTTL sets how long completed job results are retained; zero disables retention
+func (ap *AsyncProcessor) SetResultTTL(ttl time.Duration) {
+	ap.statusMutex.Lock()
+	defer ap.statusMutex.Unlock()
+
+	ap.resultTTL = ttl
+}
+
+// SetJobStatusLimits sets how long job statuses are kept and how many are kept in memory. Beyond maxEntries,
+// the least recently used finished jobs are evicted to archive, where they can still be looked up until retention ends.
+func (ap *AsyncProcessor) SetJobStatusLimits(retention time.Duration, maxEntries int, archive *JobStatusArchive) {
+	ap.statusMutex.Lock()
+	defer ap.statusMutex.Unlock()
+
+	ap.statusTTL = retention
+	ap.maxJobStatuses = maxEntries
+	ap.statusArchive = archive
+}
+
+// SetRedirectTracker sets the tracker used to canonicalize feed URLs that permanently redirect
+func (ap *AsyncProcessor) SetRedirectTracker(redirects *FeedRedirectTracker) {
+	ap.statusMutex.Lock()
+	defer ap.statusMutex.Unlock()
+
+	ap.redirects = redirects
+}
+
+// SetFeedHealthTracker sets the tracker used to detect dead feeds
+func (ap *AsyncProcessor) SetFeedHealthTracker(feedHealth *FeedHealthTracker) {
+	ap.statusMutex.Lock()
+	defer ap.statusMutex.Unlock()
+
+	ap.feedHealth = feedHealth
+}
+
+// SetItemClusterer sets the clusterer used to group duplicate items across sources
+func (ap *AsyncProcessor) SetItemClusterer(clusters *ItemClusterer) {
+	ap.statusMutex.Lock()
+	defer ap.statusMutex.Unlock()
+
+	ap.clusters = clusters
+}
+
+// SetMuteRules sets the mute rules applied to items at ingest
+func (ap *AsyncProcessor) SetMuteRules(muteRules *MuteRuleStore) {
+	ap.statusMutex.Lock()
+	defer ap.statusMutex.Unlock()
+
+	ap.muteRules = muteRules
+}
+
+// SetPollScheduler sets the scheduler that learns how often each feed should be polled
+func (ap *AsyncProcessor) SetPollScheduler(polls *PollScheduler) {
+	ap.statusMutex.Lock()
+	defer ap.statusMutex.Unlock()
+
+	ap.polls = polls
+}
+
+// SetRetryPolicy sets how jobs for throttled feeds are rescheduled
+func (ap *AsyncProcessor) SetRetryPolicy(policy utils.RetryPolicy) {
+	ap.statusMutex.Lock()
+	defer ap.statusMutex.Unlock()
+
+	ap.retryPolicy = policy
+}
+
+// SetSmallFeedPipelining lets each worker run up to concurrency jobs for feeds whose last poll returned
+// fewer than maxItems items alongside its current job; zero concurrency processes every job in turn
+func (ap *AsyncProcessor) SetSmallFeedPipelining(concurrency, maxItems int) {
+	ap.statusMutex.Lock()
+	defer ap.statusMutex.Unlock()
+
+	ap.smallFeedConcurrency = concurrency
+	ap.smallFeedMaxItems = maxItems
+}
+
+// SetIngestThrottle sets the throttle that limits each tenant's Datastore writes
+func (ap *AsyncProcessor) SetIngestThrottle(throttle *IngestThrottle) {
+	ap.statusMutex.Lock()
+	defer ap.statusMutex.Unlock()
+
+	ap.ingestThrottle = throttle
+}
+
+// SetIngestCounters sets the per-source counters updated once for each batch a job stores
+func (ap *AsyncProcessor) SetIngestCounters(counters *IngestCounters) {
+	ap.statusMutex.Lock()
+	defer ap.statusMutex.Unlock()
+
+	ap.ingestCounters = counters
+}
+
+// SetOverflowQueue sets the persistent queue that accepts fetch and durable task jobs turned away by backpressure; nil rejects them
+func (ap *AsyncProcessor) SetOverflowQueue(overflow *OverflowQueue) {
+	ap.statusMutex.Lock()
+	defer ap.statusMutex.Unlock()
+
+	ap.overflow = overflow
+}
+
+// SetSnapshotStore sets the store that keeps snapshots of the content of stored items; nil keeps none
+func (ap *AsyncProcessor) SetSnapshotStore(snapshots *SnapshotStore) {
+	ap.statusMutex.Lock()
+	defer ap.statusMutex.Unlock()
+
+	ap.snapshots = snapshots
+}
+
+// SetErrorWebhooks sets the webhooks notified when a feed keeps failing to ingest; nil notifies nobody
+func (ap *AsyncProcessor) SetErrorWebhooks(errorWebhooks *FeedErrorWebhooks) {
+	ap.statusMutex.Lock()
+	defer ap.statusMutex.Unlock()
+
+	ap.errorWebhooks = errorWebhooks
+}
+
+// SetJobWatchdog sets the watchdog that stops feed jobs exceeding their wall-clock or feed size budget
+func (ap *AsyncProcessor) SetJobWatchdog(watchdog *JobWatchdog) {
+	ap.statusMutex.Lock()
+	defer ap.statusMutex.Unlock()
+
+	ap.watchdog = watchdog
+}
+
+// SetFeedClient sets the client feeds are fetched with and the networks submitted feed URLs may not target,
+// beyond the built-in private ranges
+func (ap *AsyncProcessor) SetFeedClient(client *http.Client, blocked []*net.IPNet) {
+	ap.statusMutex.Lock()
+	defer ap.statusMutex.Unlock()
+
+	ap.feedClient = client
+	ap.blockedCIDRs = blocked
+}
+
+// TrustFeedURL exempts an operator-configured feed URL, such as the canary's, from address checks
+// so it may be served from a private address
+func (ap *AsyncProcessor) TrustFeedURL(feedURL string) {
+	ap.statusMutex.Lock()
+	defer ap.statusMutex.Unlock()
+
+	ap.trustedFeeds[feedURL] = true
+}
+
+// SetCanaryFeed trusts the canary's feed URL like TrustFeedURL and stores the canary item from jobs for that URL
+// under CanaryItemKind; jobs for any other feed store all their items as feed items
+func (ap *AsyncProcessor) SetCanaryFeed(feedURL string) {
+	ap.statusMutex.Lock()
+	defer ap.statusMutex.Unlock()
+
+	ap.trustedFeeds[feedURL] = true
+	ap.canaryFeed = feedURL
+}
+
+// checkFeedURL refuses feed URLs that may not be fetched, unless they are trusted
+func (ap *AsyncProcessor) checkFeedURL(feedURL string) error {
+	ap.statusMutex.RLock()
+	defer ap.statusMutex.RUnlock()
+
+	if ap.trustedFeeds[feedURL] {
+		return nil
+	}
+	return utils.ValidateFetchURL(feedURL, ap.blockedCIDRs)
+}
+
+// SetTaskFactory sets how task jobs of operation submitted with SubmitDurableTask are built from their payload
+func (ap *AsyncProcessor) SetTaskFactory(operation string, factory TaskFactory) {
+	ap.statusMutex.Lock()
+	defer ap.statusMutex.Unlock()
+
+	ap.taskFactories[operation] = factory
+}
+
+// GetJobResult retrieves the items produced by a completed job while its result is retained
+func (ap *AsyncProcessor) GetJobResult(jobID string) ([]*utils.FeedItem, bool) {
+	ap.statusMutex.RLock()
+	defer ap.statusMutex.RUnlock()
+
+	result, exists := ap.jobResults[jobID]
+	if !exists || result.IsExpired() {
+		return nil, false
+	}
+	return result.Data, true
+}
+
+// storeJobResult retains the items of a completed job for later retrieval
+func (ap *AsyncProcessor) storeJobResult(jobID string, items []*utils.FeedItem) {
+	ap.statusMutex.Lock()
+	defer ap.statusMutex.Unlock()
+
+	if ap.resultTTL <= 0 {
+		return
+	}
+	if _, exists := ap.jobStatus[jobID]; !exists {
+		return
+	}
+	ap.jobResults[jobID] = &cache.CacheItem{
+		Data:      items,
+		ExpiresAt: time.Now().Add(ap.resultTTL),
+	}
+}
+
+// WaitForJob blocks until the job completes or fails, or the context is done,
+// and then returns the job's latest status
+func (ap *AsyncProcessor) WaitForJob(ctx context.Context, jobID string) (*types.AsyncJobStatus, bool) {
+	ap.statusMutex.RLock()
+	_, exists := ap.jobStatus[jobID]
+	done := ap.jobDone[jobID]
+	ap.statusMutex.RUnlock()
+
+	if !exists {
+		return nil, false
+	}
+
+	// A missing channel means the job already finished
+	if done != nil {
+		select {
+		case <-done:
+		case <-ctx.Done():
+		}
+	}
+
+	return ap.GetJobStatus(jobID)
 }
 
-// worker processes jobs in the background
+/*
+worker processes jobs in the background.
+
+Jobs for small feeds are handed to goroutines of their own, up to the configured
+pipelining limit, so the worker can take the next job while they wait on the
+network. The worker waits for them before it exits.
+*/
 func (ap *AsyncProcessor) worker(workerID int) {
 	defer ap.wg.Done()
 
+	var pipelined sync.WaitGroup
+	defer pipelined.Wait()
+	// Only this goroutine starts pipelined jobs, so checking then incrementing is safe
+	var inFlight atomic.Int32
+
 	ap.logger.WithField("worker_id", workerID).Info("Async worker started")
 
 	for {
@@ -179,6 +800,16 @@ func (ap *AsyncProcessor) worker(workerID int) {
 		case job := <-ap.jobs:
 			// Update queue size metric
 			monitoring.UpdateAsyncQueueSize(len(ap.jobs))
+			if limit := ap.pipelineLimit(job); limit > 0 && int(inFlight.Load()) < limit {
+				inFlight.Add(1)
+				pipelined.Add(1)
+				go func() {
+					defer pipelined.Done()
+					defer inFlight.Add(-1)
+					ap.processJob(workerID, job)
+				}()
+				continue
+			}
 			ap.processJob(workerID, job)
 		case <-ap.quit:
 			ap.logger.WithField("worker_id", workerID).Info("Async worker stopping")
@@ -187,6 +818,31 @@ func (ap *AsyncProcessor) worker(workerID int) {
 	}
 }
 
+// pipelineLimit returns how many small-feed jobs a worker may run alongside its current job
+// if job is for a small feed, or 0 if the job should be processed in turn
+func (ap *AsyncProcessor) pipelineLimit(job AsyncJob) int {
+	if job.Task != nil {
+		return 0
+	}
+
+	ap.statusMutex.RLock()
+	concurrency := ap.smallFeedConcurrency
+	maxItems := ap.smallFeedMaxItems
+	redirects := ap.redirects
+	polls := ap.polls
+	ap.statusMutex.RUnlock()
+
+	if concurrency <= 0 {
+		return 0
+	}
+	// Feeds that have not been polled yet are of unknown size
+	count, known := polls.ItemCount(redirects.Resolve(job.URL))
+	if !known || count >= maxItems {
+		return 0
+	}
+	return concurrency
+}
+
 // safeSendResult safely sends a result to the results channel
 func (ap *AsyncProcessor) safeSendResult(result AsyncJobResult) {
 	ap.shutdownMutex.RLock()
@@ -209,6 +865,11 @@ func (ap *AsyncProcessor) safeSendResult(result AsyncJobResult) {
 
 // processJob processes a single job
 func (ap *AsyncProcessor) processJob(workerID int, job AsyncJob) {
+	if job.Task != nil {
+		ap.processTask(workerID, job)
+		return
+	}
+
 	startTime := time.Now()
 
 	// Update job status to processing
@@ -219,12 +880,31 @@ func (ap *AsyncProcessor) processJob(workerID int, job AsyncJob) {
 		"job_id":     job.ID,
 		"url":        job.URL,
 		"request_id": job.RequestID,
+		"tenant":     job.TenantID,
 	}).Info("Processing async job")
 
+	// Key the feed by its canonical URL if it has permanently moved
+	ap.statusMutex.RLock()
+	redirects := ap.redirects
+	feedHealth := ap.feedHealth
+	clusters := ap.clusters
+	muteRules := ap.muteRules
+	polls := ap.polls
+	retryPolicy := ap.retryPolicy
+	watchdog := ap.watchdog
+	errorWebhooks := ap.errorWebhooks
+	feedClient := ap.feedClient
+	if ap.trustedFeeds[job.URL] {
+		feedClient = &http.Client{}
+	}
+	ap.statusMutex.RUnlock()
+	feedURL := redirects.Resolve(job.URL)
+
 	// Check cache first
 	if ap.cacheManager != nil {
-		cachedItems, found := ap.cacheManager.GetFeedItems(job.URL)
+		cachedItems, found := ap.cacheManager.GetFeedItems(feedURL)
 		if found {
+			ap.recordEvent(job.ID, types.JobEvent{Type: types.JobEventCacheHit, Items: len(cachedItems)})
 			result := AsyncJobResult{
 				JobID:       job.ID,
 				URL:         job.URL,
@@ -245,9 +925,55 @@ func (ap *AsyncProcessor) processJob(workerID int, job AsyncJob) {
 		monitoring.RecordCacheMiss("get_feed_items")
 	}
 
-	// Fetch RSS feed
-	items, err := utils.FetchRSSFeed(job.URL)
+	// Hold off while the feed host is still asking clients to back off
+	if retryAt, throttled := polls.RetryAt(feedURL); throttled {
+		reason := fmt.Errorf("%w until %s", utils.ErrFeedThrottled, retryAt.UTC().Format(time.RFC3339))
+		if !ap.deferJob(workerID, job, time.Until(retryAt), retryPolicy, reason) {
+			monitoring.RecordAsyncJob("failed", time.Since(startTime).Seconds())
+			ap.safeSendResult(AsyncJobResult{
+				JobID:       job.ID,
+				URL:         job.URL,
+				Error:       reason,
+				ProcessedAt: time.Now(),
+				Duration:    time.Since(startTime),
+			})
+		}
+		return
+	}
+
+	// Fetch RSS feed within the job's budgets
+	ctx, cancel := watchdog.Context(context.Background())
+	fetchResult, err := runWithinBudget(ctx, func() (*utils.FetchResult, error) {
+		return utils.FetchRSSFeedResultWithClient(ctx, feedClient, feedURL, watchdog.MaxFeedBytes())
+	})
+	cancel()
+	if errors.Is(err, utils.ErrFeedTooLarge) {
+		err = &JobLimitError{Limit: JobLimitFeedSize, Budget: fmt.Sprintf("%d bytes", watchdog.MaxFeedBytes()), Err: err}
+	}
 	if err != nil {
+		var limitErr *JobLimitError
+		if errors.As(err, &limitErr) {
+			watchdog.RecordStop(feedURL, limitErr)
+			ap.logger.WithFields(logrus.Fields{
+				"worker_id": workerID,
+				"job_id":    job.ID,
+				"url":       feedURL,
+				"limit":     limitErr.Limit,
+				"budget":    limitErr.Budget,
+			}).Warn("Async job stopped by watchdog")
+		}
+
+		var throttled *utils.ThrottledError
+		if errors.As(err, &throttled) {
+			wait := retryPolicy.Wait(throttled.RetryAfter)
+			polls.RecordRetryAfter(context.Background(), feedURL, wait)
+			if ap.deferJob(workerID, job, wait, retryPolicy, err) {
+				return
+			}
+		}
+		feedHealth.RecordFailure(context.Background(), feedURL, err)
+		errorWebhooks.RecordFetchFailure(feedURL, err)
+
 		result := AsyncJobResult{
 			JobID:       job.ID,
 			URL:         job.URL,
@@ -258,6 +984,7 @@ func (ap *AsyncProcessor) processJob(workerID int, job AsyncJob) {
 		}
 
 		// Record failure metrics
+		monitoring.RecordAsyncStage("fetch", "failed", time.Since(startTime).Seconds())
 		monitoring.RecordAsyncJob("failed", time.Since(startTime).Seconds())
 		monitoring.RecordFeedFetch(job.URL, "failed", time.Since(startTime).Seconds(), -1)
 
@@ -265,14 +992,126 @@ func (ap *AsyncProcessor) processJob(workerID int, job AsyncJob) {
 		return
 	}
 
-	// Save to datastore
-	if err := SaveToDatastore(ap.datastoreClient, items); err != nil {
+	fetched := types.JobEvent{
+		Type:       types.JobEventFetched,
+		Bytes:      fetchResult.Bytes,
+		DurationMs: fetchResult.FetchDuration.Milliseconds(),
+	}
+	if fetchResult.FinalURL != feedURL {
+		fetched.Message = "redirected to " + fetchResult.FinalURL
+	}
+	ap.recordEvent(job.ID, fetched)
+	ap.recordEvent(job.ID, types.JobEvent{
+		Type:       types.JobEventParsed,
+		Items:      len(fetchResult.Items),
+		DurationMs: fetchResult.ParseDuration.Milliseconds(),
+	})
+
+	items := fetchResult.Items
+	watchdog.RecordSuccess(feedURL)
+	feedHealth.RecordSuccess(context.Background(), feedURL, items)
+	polls.RecordPoll(context.Background(), feedURL, items)
+
+	// Track permanent redirects; a confirmed migration re-keys the cache entry
+	canonicalURL, err := redirects.Observe(context.Background(), feedURL, fetchResult)
+	if err != nil {
 		ap.logger.WithFields(logrus.Fields{
 			"worker_id": workerID,
 			"job_id":    job.ID,
-			"url":       job.URL,
+			"url":       feedURL,
 			"error":     err.Error(),
+		}).Warn("Failed to persist feed URL migration")
+	}
+	feedURL = canonicalURL
+
+	// Group copies of articles already seen from other sources
+	clusters.Assign(items)
+	muteRules.SuppressAtIngest(items)
+	monitoring.RecordAsyncStage("fetch", "success", time.Since(startTime).Seconds())
+
+	// Hand off to the store pool; a full store queue holds this worker back instead of piling up fetched items
+	stored := storeJob{job: job, feedURL: feedURL, items: items, startTime: startTime}
+	select {
+	case ap.stores <- stored:
+		monitoring.UpdateAsyncStoreQueueSize(len(ap.stores))
+	case <-ap.quit:
+		ap.logger.WithField("job_id", job.ID).Debug("Dropping fetched items due to shutdown")
+	}
+}
+
+// storeJob carries a fetched feed from the fetch pool to the store pool
+type storeJob struct {
+	job       AsyncJob
+	feedURL   string
+	items     []*utils.FeedItem
+	startTime time.Time
+}
+
+// storeWorker saves fetched feeds in the background
+func (ap *AsyncProcessor) storeWorker(workerID int) {
+	defer ap.wg.Done()
+
+	ap.logger.WithField("store_worker_id", workerID).Info("Async store worker started")
+
+	for {
+		select {
+		case stored := <-ap.stores:
+			monitoring.UpdateAsyncStoreQueueSize(len(ap.stores))
+			ap.storeItems(workerID, stored)
+		case <-ap.quit:
+			ap.logger.WithField("store_worker_id", workerID).Info("Async store worker stopping")
+			return
+		}
+	}
+}
+
+// saveJobItems saves a job's items as feed items, except for canary feed jobs, which write only the canary item
+func (ap *AsyncProcessor) saveJobItems(ctx context.Context, client DatastoreClientInterface, job AsyncJob, items []*utils.FeedItem) (IngestReport, error) {
+	ap.statusMutex.RLock()
+	canaryFeed := ap.canaryFeed
+	ap.statusMutex.RUnlock()
+
+	if canaryFeed != "" && job.URL == canaryFeed {
+		return IngestReport{}, saveCanaryItems(ctx, client, items)
+	}
+	return SaveToDatastoreWithReport(ctx, client, items)
+}
+
+// storeItems saves and caches a fetched feed's items, then reports the job's result
+func (ap *AsyncProcessor) storeItems(workerID int, stored storeJob) {
+	job, feedURL, items, startTime := stored.job, stored.feedURL, stored.items, stored.startTime
+	storeStart := time.Now()
+
+	ap.statusMutex.RLock()
+	ingestThrottle := ap.ingestThrottle
+	ingestCounters := ap.ingestCounters
+	snapshots := ap.snapshots
+	errorWebhooks := ap.errorWebhooks
+	ap.statusMutex.RUnlock()
+
+	// Save to datastore, recording each batch on the job's timeline
+	writer := timelineWriter{
+		DatastoreClientInterface: ingestClient(withFaultInjection(ap.datastoreClient), ingestThrottle, job.TenantID),
+		processor:                ap,
+		jobID:                    job.ID,
+	}
+	report, err := ap.saveJobItems(context.Background(), writer, job, items)
+	if len(report.Failed) > 0 {
+		// List the items that could not be stored on the job's status
+		ap.statusMutex.Lock()
+		if jobStatus, exists := ap.jobStatus[job.ID]; exists {
+			jobStatus.Results = append(jobStatus.Results, report.Failed...)
+		}
+		ap.statusMutex.Unlock()
+	}
+	if err != nil {
+		ap.logger.WithFields(logrus.Fields{
+			"store_worker_id": workerID,
+			"job_id":          job.ID,
+			"url":             job.URL,
+			"error":           err.Error(),
 		}).Error("Failed to save items to datastore in async job")
+		errorWebhooks.RecordStoreFailure(feedURL, err)
 
 		result := AsyncJobResult{
 			JobID:       job.ID,
@@ -284,28 +1123,41 @@ func (ap *AsyncProcessor) processJob(workerID int, job AsyncJob) {
 		}
 
 		// Record datastore error metrics
-		monitoring.RecordDatastoreOperation("save", "failed", time.Since(startTime).Seconds())
+		monitoring.RecordDatastoreOperation("save", "failed", time.Since(storeStart).Seconds())
+		monitoring.RecordAsyncStage("store", "failed", time.Since(storeStart).Seconds())
 		monitoring.RecordAsyncJob("failed", time.Since(startTime).Seconds())
 
-		ap.results <- result
+		ap.safeSendResult(result)
 		return
 	}
 
 	// Record successful datastore operation
-	monitoring.RecordDatastoreOperation("save", "success", time.Since(startTime).Seconds())
+	monitoring.RecordDatastoreOperation("save", "success", time.Since(storeStart).Seconds())
+	errorWebhooks.RecordSuccess(feedURL)
+	ingestCounters.Apply(context.Background(), feedURL, report.Batches)
+	if _, err := snapshots.Save(context.Background(), report.Items); err != nil {
+		// Items keep their content hashes, so a missing snapshot only costs a refetch to render them again
+		ap.logger.WithFields(logrus.Fields{
+			"store_worker_id": workerID,
+			"job_id":          job.ID,
+			"url":             job.URL,
+			"error":           err.Error(),
+		}).Warn("Failed to store item snapshots in async job")
+	}
 
 	// Cache the results
 	if ap.cacheManager != nil {
-		if err := ap.cacheManager.SetFeedItems(job.URL, items); err != nil {
+		if err := ap.cacheManager.SetFeedItems(feedURL, items); err != nil {
 			ap.logger.WithFields(logrus.Fields{
-				"worker_id": workerID,
-				"job_id":    job.ID,
-				"url":       job.URL,
-				"error":     err.Error(),
+				"store_worker_id": workerID,
+				"job_id":          job.ID,
+				"url":             job.URL,
+				"error":           err.Error(),
 			}).Warn("Failed to cache feed items in async job")
 			monitoring.RecordDatastoreOperation("cache_set", "failed", 0)
 		} else {
 			monitoring.RecordDatastoreOperation("cache_set", "success", 0)
+			ap.recordEvent(job.ID, types.JobEvent{Type: types.JobEventCached, Items: len(items)})
 		}
 	}
 
@@ -319,18 +1171,165 @@ func (ap *AsyncProcessor) processJob(workerID int, job AsyncJob) {
 	}
 
 	// Record success metrics
+	monitoring.RecordAsyncStage("store", "success", time.Since(storeStart).Seconds())
 	monitoring.RecordAsyncJob("completed", time.Since(startTime).Seconds())
 	monitoring.RecordFeedFetch(job.URL, "success", time.Since(startTime).Seconds(), len(items))
 
-	ap.results <- result
+	ap.safeSendResult(result)
+
+	ap.logger.WithFields(logrus.Fields{
+		"store_worker_id": workerID,
+		"job_id":          job.ID,
+		"url":             job.URL,
+		"items_count":     len(items),
+		"items_failed":    len(report.Failed),
+		"duration_ms":     time.Since(startTime).Milliseconds(),
+	}).Info("Async job completed successfully")
+}
+
+/*
+deferJob reschedules a job whose feed host is throttling requests, marking it
+"deferred" until it is queued again after wait. It returns false when the job has
+used up its deferrals and should fail instead.
+*/
+func (ap *AsyncProcessor) deferJob(workerID int, job AsyncJob, wait time.Duration, policy utils.RetryPolicy, reason error) bool {
+	if job.Deferrals >= policy.MaxDeferrals {
+		return false
+	}
+	if policy.MaxWait > 0 && wait > policy.MaxWait {
+		wait = policy.MaxWait
+	}
+	job.Deferrals++
+	retryAt := time.Now().Add(wait)
+
+	ap.statusMutex.Lock()
+	if jobStatus, exists := ap.jobStatus[job.ID]; exists {
+		jobStatus.Status = "deferred"
+		jobStatus.Error = reason.Error()
+		jobStatus.RetryAt = &retryAt
+		jobStatus.Deferrals = job.Deferrals
+		appendJobEvent(jobStatus, types.JobEvent{
+			Type:       types.JobEventDeferred,
+			Message:    reason.Error(),
+			DurationMs: wait.Milliseconds(),
+		})
+	}
+	ap.statusMutex.Unlock()
+
+	monitoring.RecordAsyncJob("deferred", 0)
+	ap.logger.WithFields(logrus.Fields{
+		"worker_id": workerID,
+		"job_id":    job.ID,
+		"url":       job.URL,
+		"deferrals": job.Deferrals,
+		"retry_at":  retryAt.UTC().Format(time.RFC3339),
+		"reason":    reason.Error(),
+	}).Warn("Feed host is throttling, deferring async job")
+
+	time.AfterFunc(wait, func() { ap.requeue(job) })
+	return true
+}
+
+// requeue returns a deferred job to the queue. When the queue is full or the processor is stopping, the job is held
+// in the overflow queue, to run once there is room or after a restart; without an overflow queue it fails.
+func (ap *AsyncProcessor) requeue(job AsyncJob) {
+	reason := ap.queueDeferred(job)
+	if reason == "" {
+		return
+	}
+
+	if ap.overflowJob(job) {
+		ap.statusMutex.Lock()
+		if jobStatus, exists := ap.jobStatus[job.ID]; exists {
+			jobStatus.Status = "queued_deferred"
+			jobStatus.RetryAt = nil
+			appendJobEvent(jobStatus, types.JobEvent{Type: types.JobEventDeferred, Message: reason + ", held in overflow queue"})
+		}
+		ap.statusMutex.Unlock()
+		return
+	}
+	ap.updateJobStatus(job.ID, "failed", reason, 0, 0)
+}
+
+// queueDeferred hands a deferred job to the queue without blocking, returning why it could not when it was not queued
+func (ap *AsyncProcessor) queueDeferred(job AsyncJob) string {
+	ap.shutdownMutex.RLock()
+	defer ap.shutdownMutex.RUnlock()
+
+	if ap.shuttingDown {
+		return "async processor stopped before rescheduling deferred job"
+	}
+
+	// Mark the job pending first so a worker picking it up is not overwritten
+	ap.statusMutex.Lock()
+	if jobStatus, exists := ap.jobStatus[job.ID]; exists {
+		jobStatus.Status = "pending"
+		jobStatus.RetryAt = nil
+		appendJobEvent(jobStatus, types.JobEvent{Type: types.JobEventQueued, Message: "requeued after throttling"})
+	}
+	ap.statusMutex.Unlock()
+
+	select {
+	case ap.jobs <- job:
+		monitoring.UpdateAsyncQueueSize(len(ap.jobs))
+		return ""
+	default:
+		// Blocking here could stall shutdown, which waits for this read lock
+		return "async processor queue full when rescheduling deferred job"
+	}
+}
+
+// processTask runs a task job and records its per-target results
+func (ap *AsyncProcessor) processTask(workerID int, job AsyncJob) {
+	startTime := time.Now()
+	ap.updateJobStatus(job.ID, "processing", "", 0, 0)
+
+	ap.logger.WithFields(logrus.Fields{
+		"worker_id":  workerID,
+		"job_id":     job.ID,
+		"operation":  job.Operation,
+		"request_id": job.RequestID,
+	}).Info("Processing async task")
+
+	results, err := job.Task(context.Background())
+	duration := time.Since(startTime)
+
+	// Store results before the status flips so waiters see them
+	ap.statusMutex.Lock()
+	if jobStatus, exists := ap.jobStatus[job.ID]; exists {
+		jobStatus.Results = results
+	}
+	ap.statusMutex.Unlock()
+
+	if err != nil {
+		monitoring.RecordAsyncJob("failed", duration.Seconds())
+		ap.updateJobStatus(job.ID, "failed", err.Error(), 0, duration.Milliseconds())
+		ap.logger.WithFields(logrus.Fields{
+			"worker_id": workerID,
+			"job_id":    job.ID,
+			"operation": job.Operation,
+			"error":     err.Error(),
+		}).Error("Async task failed")
+		return
+	}
+
+	succeeded := 0
+	for _, result := range results {
+		if result.Success {
+			succeeded++
+		}
+	}
+	monitoring.RecordAsyncJob("completed", duration.Seconds())
+	ap.updateJobStatus(job.ID, "completed", "", succeeded, duration.Milliseconds())
 
 	ap.logger.WithFields(logrus.Fields{
 		"worker_id":   workerID,
 		"job_id":      job.ID,
-		"url":         job.URL,
-		"items_count": len(items),
-		"duration_ms": time.Since(startTime).Milliseconds(),
-	}).Info("Async job completed successfully")
+		"operation":   job.Operation,
+		"targets":     len(results),
+		"succeeded":   succeeded,
+		"duration_ms": duration.Milliseconds(),
+	}).Info("Async task completed")
 }
 
 // resultProcessor processes job results
@@ -348,6 +1347,10 @@ func (ap *AsyncProcessor) resultProcessor() {
 				status = "failed"
 				errorMsg = result.Error.Error()
 				itemsCount = 0
+				ap.setJobErrorType(result.JobID, result.Error)
+			} else {
+				// Store the result before the status flips so waiters can read it immediately
+				ap.storeJobResult(result.JobID, result.Items)
 			}
 
 			ap.updateJobStatus(result.JobID, status, errorMsg, itemsCount, result.Duration.Milliseconds())
@@ -364,8 +1367,10 @@ func (ap *AsyncProcessor) resultProcessor() {
 			for len(ap.results) > 0 {
 				result := <-ap.results
 				if result.Error != nil {
+					ap.setJobErrorType(result.JobID, result.Error)
 					ap.updateJobStatus(result.JobID, "failed", result.Error.Error(), 0, result.Duration.Milliseconds())
 				} else {
+					ap.storeJobResult(result.JobID, result.Items)
 					ap.updateJobStatus(result.JobID, "completed", "", len(result.Items), result.Duration.Milliseconds())
 				}
 			}
@@ -386,35 +1391,78 @@ func (ap *AsyncProcessor) updateJobStatus(jobID, status, errorMsg string, itemsC
 		jobStatus.DurationMs = durationMs
 		now := time.Now()
 		jobStatus.CompletedAt = &now
+
+		switch status {
+		case "processing":
+			appendJobEvent(jobStatus, types.JobEvent{At: now, Type: types.JobEventStarted})
+		case "completed":
+			appendJobEvent(jobStatus, types.JobEvent{At: now, Type: types.JobEventCompleted, Items: itemsCount, DurationMs: durationMs})
+		case "failed":
+			appendJobEvent(jobStatus, types.JobEvent{At: now, Type: types.JobEventFailed, Message: errorMsg, DurationMs: durationMs})
+		}
 	}
+
+	// Wake long-polling waiters once the job is finished, and make it a candidate for eviction
+	if status == "completed" || status == "failed" {
+		ap.touchJob(jobID)
+		if durationMs > 0 {
+			ap.observeJobDuration(time.Duration(durationMs) * time.Millisecond)
+		}
+		if done, exists := ap.jobDone[jobID]; exists {
+			close(done)
+			delete(ap.jobDone, jobID)
+		}
+	}
+}
+
+// observeJobDuration folds a finished job's duration into the moving average; callers must hold statusMutex
+func (ap *AsyncProcessor) observeJobDuration(duration time.Duration) {
+	if ap.avgJobDuration <= 0 {
+		ap.avgJobDuration = duration
+		return
+	}
+	ap.avgJobDuration += (duration - ap.avgJobDuration) / 10
 }
 
+// jobCleanupInterval is how often job statuses past their retention are removed
+const jobCleanupInterval = 5 * time.Minute
+
 // cleanupOldJobs removes old job statuses
 func (ap *AsyncProcessor) cleanupOldJobs() {
 	defer ap.wg.Done()
 
-	ticker := time.NewTicker(1 * time.Hour)
+	ticker := time.NewTicker(jobCleanupInterval)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ticker.C:
 			ap.statusMutex.Lock()
-			cutoff := time.Now().Add(-24 * time.Hour)
+			cutoff := time.Now().Add(-ap.statusTTL)
+			statusArchive := ap.statusArchive
 			removed := 0
 
 			for jobID, jobStatus := range ap.jobStatus {
 				if jobStatus.CreatedAt.Before(cutoff) {
-					delete(ap.jobStatus, jobID)
+					ap.untrackJob(jobID)
 					removed++
 				}
 			}
 
+			for jobID, result := range ap.jobResults {
+				if result.IsExpired() {
+					delete(ap.jobResults, jobID)
+				}
+			}
+
 			ap.statusMutex.Unlock()
 
 			if removed > 0 {
 				ap.logger.WithField("removed_count", removed).Info("Cleaned up old async job statuses")
 			}
+			if err := statusArchive.Prune(context.Background(), cutoff); err != nil {
+				ap.logger.WithError(err).Warn("Failed to prune archived job statuses")
+			}
 		case <-ap.cleanupQuit:
 			ap.logger.Info("Cleanup goroutine stopping")
 			return
@@ -1,11 +1,22 @@
 package handlers
 
 import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"cloud.google.com/go/datastore"
+	"github.com/Nexora-Open-Source/rss-feed-backend/cache"
+	"github.com/Nexora-Open-Source/rss-feed-backend/types"
+	"github.com/Nexora-Open-Source/rss-feed-backend/utils"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
@@ -39,21 +50,18 @@ func TestAsyncProcessorGetJobStatus(t *testing.T) {
 	logger := logrus.New()
 	logger.SetLevel(logrus.ErrorLevel)
 
-	processor := NewAsyncProcessor(1, 5, true, 0.8, 5*time.Second, logger, nil, nil)
+	// No workers, so the job stays pending whether or not its feed could be fetched
+	processor := NewAsyncProcessor(0, 5, true, 0.8, 5*time.Second, logger, nil, nil)
 	defer processor.Stop()
 
 	// Submit a job
 	jobID, err := processor.SubmitJob("https://example.com/rss.xml", "test-request-123")
 	require.NoError(t, err)
 
-	// Small delay to check status before worker processes it
-	time.Sleep(1 * time.Millisecond)
-
 	// Check initial status
 	status, exists := processor.GetJobStatus(jobID)
-	assert.True(t, exists)
-	// Status might be "pending" or "processing" depending on timing
-	assert.Contains(t, []string{"pending", "processing"}, status.Status)
+	require.True(t, exists)
+	assert.Equal(t, "pending", status.Status)
 	assert.Equal(t, jobID, status.JobID)
 	assert.Equal(t, "https://example.com/rss.xml", status.URL)
 }
@@ -104,3 +112,613 @@ func BenchmarkAsyncProcessorSubmitJob(b *testing.B) {
 		processor.SubmitJob("https://example.com/rss.xml", "test-request")
 	}
 }
+
+func TestAsyncProcessorWaitForJob(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	// No workers, so the job stays pending until updated below
+	processor := NewAsyncProcessor(0, 5, true, 0.8, 5*time.Second, logger, nil, nil)
+	defer processor.Stop()
+
+	jobID, err := processor.SubmitJob("https://example.com/rss.xml", "test-request-123")
+	require.NoError(t, err)
+
+	// Wait elapses while the job is still pending
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	status, exists := processor.WaitForJob(ctx, jobID)
+	require.True(t, exists)
+	assert.Equal(t, "pending", status.Status)
+
+	// Completing the job wakes the waiter
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		processor.updateJobStatus(jobID, "completed", "", 3, 10)
+	}()
+	status, exists = processor.WaitForJob(context.Background(), jobID)
+	require.True(t, exists)
+	assert.Equal(t, "completed", status.Status)
+
+	// Finished jobs return immediately
+	status, exists = processor.WaitForJob(context.Background(), jobID)
+	require.True(t, exists)
+	assert.Equal(t, "completed", status.Status)
+
+	_, exists = processor.WaitForJob(context.Background(), "nonexistent-job")
+	assert.False(t, exists)
+}
+
+func TestAsyncProcessorJobResult(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	processor := NewAsyncProcessor(0, 5, true, 0.8, 5*time.Second, logger, nil, nil)
+	defer processor.Stop()
+
+	jobID, err := processor.SubmitJob("https://example.com/rss.xml", "test-request-123")
+	require.NoError(t, err)
+
+	_, found := processor.GetJobResult(jobID)
+	assert.False(t, found)
+
+	items := []*utils.FeedItem{{Title: "Item 1", Link: "https://example.com/1"}}
+	processor.results <- AsyncJobResult{JobID: jobID, URL: "https://example.com/rss.xml", Items: items}
+
+	status, exists := processor.WaitForJob(context.Background(), jobID)
+	require.True(t, exists)
+	assert.Equal(t, "completed", status.Status)
+
+	result, found := processor.GetJobResult(jobID)
+	require.True(t, found)
+	assert.Equal(t, items, result)
+
+	// Results are not retained once the TTL has passed
+	processor.SetResultTTL(time.Nanosecond)
+	processor.storeJobResult(jobID, items)
+	time.Sleep(time.Millisecond)
+	_, found = processor.GetJobResult(jobID)
+	assert.False(t, found)
+}
+
+func TestAsyncProcessorSubmitTask(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	processor := NewAsyncProcessor(1, 5, true, 0.8, 5*time.Second, logger, nil, nil)
+	defer processor.Stop()
+
+	jobID, err := processor.SubmitTask("bulk_disable", "test-request-123", func(ctx context.Context) ([]types.TargetResult, error) {
+		return []types.TargetResult{
+			{Target: "https://a.example.com/feed.xml", Success: true},
+			{Target: "https://b.example.com/feed.xml", Error: "feed is not subscribed"},
+		}, nil
+	})
+	require.NoError(t, err)
+
+	status, exists := processor.WaitForJob(context.Background(), jobID)
+	require.True(t, exists)
+	assert.Equal(t, "completed", status.Status)
+	assert.Equal(t, "bulk_disable", status.Operation)
+	assert.Equal(t, 1, status.ItemsCount)
+	assert.Len(t, status.Results, 2)
+}
+
+func TestAsyncProcessorDefersThrottledJobs(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	processor := NewAsyncProcessor(1, 5, true, 0.8, 5*time.Second, logger, nil, nil)
+	defer processor.Stop()
+	processor.SetPollScheduler(NewPollScheduler(nil, utils.DefaultPollPolicy(), logger))
+	processor.SetRetryPolicy(utils.RetryPolicy{DefaultWait: 20 * time.Millisecond, MaxWait: time.Second, MaxDeferrals: 1})
+	processor.TrustFeedURL(server.URL + "/feed.xml")
+
+	jobID, err := processor.SubmitJob(server.URL+"/feed.xml", "test-request-123")
+	require.NoError(t, err)
+
+	// The first 503 defers the job instead of failing it
+	assert.Eventually(t, func() bool {
+		status, _ := processor.GetJobStatus(jobID)
+		return status.Status == "deferred"
+	}, time.Second, 5*time.Millisecond)
+
+	// Once its deferrals are used up the job fails with the throttling error
+	status, exists := processor.WaitForJob(context.Background(), jobID)
+	require.True(t, exists)
+	assert.Equal(t, "failed", status.Status)
+	assert.Equal(t, 1, status.Deferrals)
+	assert.Contains(t, status.Error, utils.ErrFeedThrottled.Error())
+	assert.Equal(t, int32(2), requests.Load())
+}
+
+func TestAsyncProcessorOverflowsDeferredJobs(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	// No workers, so jobs stay queued; the deferred job comes back to a full queue
+	processor := NewAsyncProcessor(0, 1, false, 0.8, 5*time.Second, logger, nil, nil)
+	defer processor.Stop()
+	processor.SetOverflowQueue(NewOverflowQueue(nil, 5, logger))
+
+	deferredID, err := processor.SubmitJob("https://example.com/rss.xml", "test-request-123")
+	require.NoError(t, err)
+	deferred := <-processor.jobs
+	_, err = processor.SubmitJob("https://example.com/other.xml", "test-request-123")
+	require.NoError(t, err)
+
+	// A full queue holds the job in the overflow queue instead of failing it
+	processor.requeue(deferred)
+	status, exists := processor.GetJobStatus(deferredID)
+	require.True(t, exists)
+	assert.Equal(t, "queued_deferred", status.Status)
+	require.Len(t, processor.overflow.Jobs(), 1)
+	assert.Equal(t, deferredID, processor.overflow.Jobs()[0].JobID)
+
+	// Without an overflow queue the job fails
+	processor.SetOverflowQueue(nil)
+	processor.requeue(deferred)
+	status, _ = processor.GetJobStatus(deferredID)
+	assert.Equal(t, "failed", status.Status)
+	assert.Contains(t, status.Error, "queue full")
+
+	// A job coming back after shutdown does not stay deferred
+	stopped := NewAsyncProcessor(0, 1, false, 0.8, 5*time.Second, logger, nil, nil)
+	jobID, err := stopped.SubmitJob("https://example.com/rss.xml", "test-request-123")
+	require.NoError(t, err)
+	job := <-stopped.jobs
+	stopped.Stop()
+	stopped.requeue(job)
+	status, _ = stopped.GetJobStatus(jobID)
+	assert.Equal(t, "failed", status.Status)
+	assert.Contains(t, status.Error, "stopped")
+}
+
+func TestAsyncProcessorRefusesPrivateFeedURLs(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	processor := NewAsyncProcessor(0, 5, true, 0.8, 5*time.Second, logger, nil, nil)
+	defer processor.Stop()
+	_, blocked, err := net.ParseCIDR("203.0.113.0/24")
+	require.NoError(t, err)
+	processor.SetFeedClient(utils.NewSafeHTTPClient(0, nil), []*net.IPNet{blocked})
+
+	for _, feedURL := range []string{"http://169.254.169.254/latest/meta-data", "http://127.0.0.1:8080/feed.xml", "http://203.0.113.7/feed.xml", "file:///etc/passwd"} {
+		_, err := processor.SubmitJob(feedURL, "test-request-123")
+		assert.Error(t, err, feedURL)
+	}
+
+	// Operator-configured feeds such as the canary's may be served locally
+	processor.TrustFeedURL("http://127.0.0.1:8080/feed.xml")
+	_, err = processor.SubmitJob("http://127.0.0.1:8080/feed.xml", "test-request-123")
+	assert.NoError(t, err)
+}
+
+func TestAsyncProcessorWatchdogStopsJobsOverBudget(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	release := make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/big.xml", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Repeat("x", 4096)))
+	})
+	mux.HandleFunc("/slow.xml", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	defer close(release)
+
+	processor := NewAsyncProcessor(1, 5, true, 0.8, 5*time.Second, logger, nil, nil)
+	defer processor.Stop()
+	watchdog := NewJobWatchdog(100*time.Millisecond, 1024, 2)
+	var alerted []string
+	watchdog.SetNotifier(func(feedURL string, strikes int, err *JobLimitError) {
+		alerted = append(alerted, feedURL)
+	})
+	processor.SetJobWatchdog(watchdog)
+	processor.TrustFeedURL(server.URL + "/big.xml")
+	processor.TrustFeedURL(server.URL + "/slow.xml")
+
+	tests := []struct {
+		path  string
+		limit string
+	}{
+		{"/big.xml", JobLimitFeedSize},
+		{"/slow.xml", JobLimitDuration},
+		{"/big.xml", JobLimitFeedSize},
+	}
+	for _, tt := range tests {
+		jobID, err := processor.SubmitJob(server.URL+tt.path, "test-request-123")
+		require.NoError(t, err)
+
+		status, exists := processor.WaitForJob(context.Background(), jobID)
+		require.True(t, exists)
+		assert.Equal(t, "failed", status.Status, tt.path)
+		assert.Equal(t, JobErrorResourceLimit, status.ErrorType, tt.path)
+		assert.Contains(t, status.Error, tt.limit, tt.path)
+	}
+
+	// Only the feed stopped twice in a row is alerted on
+	assert.Equal(t, []string{server.URL + "/big.xml"}, alerted)
+}
+
+func TestAsyncProcessorJobTimeline(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	const feedURL = "https://example.com/rss.xml"
+	cacheManager := cache.NewCacheManager(cache.NewInMemoryCache(time.Minute), logger, time.Minute, time.Minute, time.Minute, time.Minute)
+	require.NoError(t, cacheManager.SetFeedItems(feedURL, []*utils.FeedItem{{Title: "Cached", Link: "https://example.com/1"}}))
+
+	processor := NewAsyncProcessor(1, 5, true, 0.8, 5*time.Second, logger, nil, cacheManager)
+	defer processor.Stop()
+
+	jobID, err := processor.SubmitJob(feedURL, "test-request-123")
+	require.NoError(t, err)
+
+	status, exists := processor.WaitForJob(context.Background(), jobID)
+	require.True(t, exists)
+	assert.Equal(t, "completed", status.Status)
+
+	var eventTypes []string
+	for _, event := range status.Events {
+		assert.False(t, event.At.IsZero())
+		eventTypes = append(eventTypes, event.Type)
+	}
+	assert.Equal(t, []string{types.JobEventQueued, types.JobEventStarted, types.JobEventCacheHit, types.JobEventCompleted}, eventTypes)
+	assert.Equal(t, 1, status.Events[2].Items)
+
+	// Datastore batches written by the job are recorded with their size
+	mockDatastore := new(MockDatastoreClient)
+	mockDatastore.On("PutMulti", mock.Anything, mock.Anything, mock.Anything).Return([]*datastore.Key{}, nil)
+	writer := timelineWriter{DatastoreClientInterface: mockDatastore, processor: processor, jobID: jobID}
+	_, err = writer.PutMulti(context.Background(), make([]*datastore.Key, 3), nil)
+	require.NoError(t, err)
+
+	status, _ = processor.GetJobStatus(jobID)
+	last := status.Events[len(status.Events)-1]
+	assert.Equal(t, types.JobEventSaved, last.Type)
+	assert.Equal(t, 3, last.Items)
+}
+
+func TestAsyncProcessorPipelinesSmallFeeds(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	var active, peak atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := active.Add(1)
+		defer active.Add(-1)
+		for {
+			previous := peak.Load()
+			if current <= previous || peak.CompareAndSwap(previous, current) {
+				break
+			}
+		}
+		<-release
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	feedURL := server.URL + "/feed.xml"
+	polls := NewPollScheduler(nil, utils.DefaultPollPolicy(), logger)
+	polls.RecordPoll(context.Background(), feedURL, []*utils.FeedItem{{Title: "Only", Link: "https://example.com/1"}})
+
+	processor := NewAsyncProcessor(1, 10, false, 0.8, 5*time.Second, logger, nil, nil)
+	defer processor.Stop()
+	processor.SetPollScheduler(polls)
+	processor.SetSmallFeedPipelining(2, 10)
+	processor.TrustFeedURL(feedURL)
+
+	var jobIDs []string
+	for i := 0; i < 4; i++ {
+		jobID, err := processor.SubmitJob(feedURL, "test-request-123")
+		require.NoError(t, err)
+		jobIDs = append(jobIDs, jobID)
+	}
+
+	// The single worker runs two pipelined jobs plus one of its own
+	assert.Eventually(t, func() bool { return active.Load() == 3 }, time.Second, 5*time.Millisecond)
+	close(release)
+
+	for _, jobID := range jobIDs {
+		status, exists := processor.WaitForJob(context.Background(), jobID)
+		require.True(t, exists)
+		assert.Equal(t, "failed", status.Status)
+	}
+	assert.Equal(t, int32(3), peak.Load())
+}
+
+func TestAsyncProcessorHandsFetchedFeedsToStorePool(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<?xml version="1.0"?><rss version="2.0"><channel><title>Test</title>
+<item><title>Item 1</title><link>https://example.com/1</link></item></channel></rss>`))
+	}))
+	defer server.Close()
+
+	// Without store workers, fetched feeds wait in the bounded store queue
+	processor := NewAsyncProcessorWithStorePool(1, 5, 0, 1, true, 0.8, 5*time.Second, logger, nil, nil)
+	defer processor.Stop()
+	processor.TrustFeedURL(server.URL + "/feed.xml")
+
+	jobID, err := processor.SubmitJob(server.URL+"/feed.xml", "test-request-123")
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		return processor.QueueStats().StoreDepth == 1
+	}, time.Second, 5*time.Millisecond)
+
+	stats := processor.QueueStats()
+	assert.Equal(t, 1, stats.StoreCapacity)
+	assert.Equal(t, 0, stats.Depth)
+
+	status, exists := processor.GetJobStatus(jobID)
+	require.True(t, exists)
+	assert.Equal(t, "processing", status.Status)
+	assert.Equal(t, types.JobEventParsed, status.Events[len(status.Events)-1].Type)
+}
+
+func TestAsyncProcessorOverflowsJobsUnderBackpressure(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	// No workers, so jobs stay queued; the fifth job finds the queue at the 80% reject threshold
+	processor := NewAsyncProcessor(0, 5, true, 0.8, 5*time.Second, logger, nil, nil)
+	defer processor.Stop()
+
+	for i := 0; i < 4; i++ {
+		_, err := processor.SubmitJob("https://example.com/rss.xml", "test-request-123")
+		require.NoError(t, err)
+	}
+	_, err := processor.SubmitJob("https://example.com/rss.xml", "test-request-123")
+	var queueFull *QueueFullError
+	require.ErrorAs(t, err, &queueFull)
+	assert.Equal(t, 4, queueFull.Depth)
+	assert.Equal(t, 5, queueFull.Capacity)
+	assert.GreaterOrEqual(t, queueFull.RetryAfter, minQueueRetryAfter)
+	assert.Equal(t, 4, processor.QueueStats().Pending, "rejected jobs are not tracked")
+
+	// With an overflow queue the job is accepted as queued_deferred, up to the queue's capacity
+	processor.SetOverflowQueue(NewOverflowQueue(nil, 1, logger))
+	jobID, err := processor.SubmitJob("https://example.com/rss.xml", "test-request-123")
+	require.NoError(t, err)
+	status, exists := processor.GetJobStatus(jobID)
+	require.True(t, exists)
+	assert.Equal(t, "queued_deferred", status.Status)
+	assert.Equal(t, 1, processor.QueueStats().Overflowed)
+	_, err = processor.SubmitJob("https://example.com/rss.xml", "test-request-123")
+	assert.ErrorAs(t, err, &queueFull)
+
+	// Once the queue drains below the threshold the job moves back into it
+	<-processor.jobs
+	<-processor.jobs
+	processor.dispatchOverflow()
+	assert.Eventually(t, func() bool {
+		status, _ := processor.GetJobStatus(jobID)
+		return status.Status == "pending"
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.Empty(t, processor.overflow.Jobs())
+}
+
+func TestAsyncProcessorOverflowsDurableTasks(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	// No workers, so jobs stay queued; the second job finds the queue at the reject threshold
+	processor := NewAsyncProcessor(0, 2, true, 0.5, 5*time.Second, logger, nil, nil)
+	defer processor.Stop()
+	processor.SetOverflowQueue(NewOverflowQueue(nil, 5, logger))
+
+	processor.SetTaskFactory("bulk_disable", func(payload []byte) (JobTask, error) {
+		return func(ctx context.Context) ([]types.TargetResult, error) { return nil, nil }, nil
+	})
+
+	_, err := processor.SubmitDurableTask("bulk_unknown", "test-request-123", []byte(`{}`))
+	assert.Error(t, err, "operations without a task factory are refused")
+
+	_, err = processor.SubmitDurableTask("bulk_disable", "test-request-123", []byte(`{"n":1}`))
+	require.NoError(t, err)
+
+	// A plain task cannot be rebuilt, so it is still rejected
+	_, err = processor.SubmitTask("bulk_enable", "test-request-123", func(ctx context.Context) ([]types.TargetResult, error) { return nil, nil })
+	var queueFull *QueueFullError
+	assert.ErrorAs(t, err, &queueFull)
+
+	jobID, err := processor.SubmitDurableTask("bulk_disable", "test-request-123", []byte(`{"n":2}`))
+	require.NoError(t, err)
+	status, exists := processor.GetJobStatus(jobID)
+	require.True(t, exists)
+	assert.Equal(t, "queued_deferred", status.Status)
+	assert.Equal(t, "bulk_disable", status.Operation)
+
+	// Once the queue drains the task is rebuilt from its payload and queued
+	<-processor.jobs
+	processor.dispatchOverflow()
+	job := <-processor.jobs
+	assert.Equal(t, jobID, job.ID)
+	assert.Equal(t, "bulk_disable", job.Operation)
+	assert.Equal(t, []byte(`{"n":2}`), job.Payload)
+	assert.NotNil(t, job.Task)
+	assert.Empty(t, processor.overflow.Jobs())
+}
+
+// claimingDatastore is a transactional store whose transactions fail for jobs another instance claimed
+type claimingDatastore struct {
+	*MockDatastoreClient
+	claimedElsewhere bool
+}
+
+func (c *claimingDatastore) RunInTransaction(ctx context.Context, f func(tx *datastore.Transaction) error, opts ...datastore.TransactionOption) (*datastore.Commit, error) {
+	if c.claimedElsewhere {
+		return nil, datastore.ErrNoSuchEntity
+	}
+	return &datastore.Commit{}, nil
+}
+
+func TestAsyncProcessorClaimsOverflowJobsBeforeQueueing(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	store := &claimingDatastore{MockDatastoreClient: new(MockDatastoreClient)}
+	store.On("PutMulti", mock.Anything, mock.Anything, mock.Anything).Return(nil, nil)
+
+	processor := NewAsyncProcessor(0, 5, false, 0.8, 5*time.Second, logger, nil, nil)
+	defer processor.Stop()
+	processor.SetOverflowQueue(NewOverflowQueue(store, 5, logger))
+	require.NoError(t, processor.overflow.Push(context.Background(), OverflowJob{JobID: "job-1", URL: "https://example.com/rss.xml"}))
+
+	// Another instance ran the job first, so it is dropped here without being queued
+	store.claimedElsewhere = true
+	processor.dispatchOverflow()
+	assert.Empty(t, processor.jobs)
+	assert.Empty(t, processor.overflow.Jobs())
+	_, exists := processor.GetJobStatus("job-1")
+	assert.False(t, exists)
+
+	require.NoError(t, processor.overflow.Push(context.Background(), OverflowJob{JobID: "job-2", URL: "https://example.com/rss.xml"}))
+	store.claimedElsewhere = false
+	processor.dispatchOverflow()
+	job := <-processor.jobs
+	assert.Equal(t, "job-2", job.ID)
+	assert.Empty(t, processor.overflow.Jobs())
+}
+
+func TestAsyncProcessorEvictsFinishedJobStatusesAtCapacity(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	mockDatastore := new(MockDatastoreClient)
+
+	// No workers, so submitted jobs stay pending until marked otherwise
+	processor := NewAsyncProcessor(0, 10, false, 0.8, 5*time.Second, logger, nil, nil)
+	defer processor.Stop()
+	processor.SetJobStatusLimits(time.Hour, 2, NewJobStatusArchive(mockDatastore, logger))
+
+	var archived []*archivedJobStatus
+	mockDatastore.On("PutMulti", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		archived = append(archived, args.Get(2).([]*archivedJobStatus)...)
+	}).Return([]*datastore.Key{}, nil)
+
+	finishedID, err := processor.SubmitJob("https://example.com/finished.xml", "test-request-123")
+	require.NoError(t, err)
+	processor.updateJobStatus(finishedID, "completed", "", 3, 10)
+	pendingID, err := processor.SubmitJob("https://example.com/pending.xml", "test-request-123")
+	require.NoError(t, err)
+
+	// Going over the cap evicts the finished job to the archive
+	_, err = processor.SubmitJob("https://example.com/rss.xml", "test-request-123")
+	require.NoError(t, err)
+	require.Len(t, archived, 1)
+	assert.Equal(t, finishedID, archived[0].JobID)
+
+	// Pending jobs are never evicted, even over the cap
+	_, err = processor.SubmitJob("https://example.com/rss.xml", "test-request-123")
+	require.NoError(t, err)
+	assert.Len(t, archived, 1)
+	status, exists := processor.GetJobStatus(pendingID)
+	require.True(t, exists)
+	assert.Equal(t, "pending", status.Status)
+
+	// The evicted job is still found in the archive
+	mockDatastore.On("Get", mock.Anything, datastore.NameKey(jobStatusKind, finishedID, nil), mock.Anything).Run(func(args mock.Arguments) {
+		*args.Get(2).(*archivedJobStatus) = *archived[0]
+	}).Return(nil)
+	status, exists = processor.GetJobStatus(finishedID)
+	require.True(t, exists)
+	assert.Equal(t, "completed", status.Status)
+	assert.Equal(t, 3, status.ItemsCount)
+
+	// Only finished jobs are candidates for eviction
+	assert.Equal(t, 0, processor.jobLRU.Len())
+
+	// Unknown jobs are looked up in the archive once, then remembered as missing
+	mockDatastore.On("Get", mock.Anything, mock.Anything, mock.Anything).Return(datastore.ErrNoSuchEntity).Once()
+	_, exists = processor.GetJobStatusWithContext(context.Background(), "unknown-job")
+	assert.False(t, exists)
+	_, exists = processor.GetJobStatusWithContext(context.Background(), "unknown-job")
+	assert.False(t, exists)
+	mockDatastore.AssertNumberOfCalls(t, "Get", 2)
+}
+
+func TestBackpressureDelayBands(t *testing.T) {
+	maxDelay := time.Second
+
+	tests := []struct {
+		name string
+		load float64
+		want time.Duration
+	}{
+		{"idle", 0, 0},
+		{"below soft threshold", 0.5, 0},
+		{"at soft threshold", 0.64, 0},
+		{"halfway to reject threshold", 0.72, 500 * time.Millisecond},
+		{"just below reject threshold", 0.79, 937500 * time.Microsecond},
+		{"at reject threshold", 0.8, maxDelay},
+		{"above reject threshold", 0.95, maxDelay},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, float64(tt.want), float64(backpressureDelay(tt.load, 0.8, maxDelay)), float64(time.Millisecond))
+		})
+	}
+
+	assert.Zero(t, backpressureDelay(0.9, 0.8, 0), "no delay without a budget")
+	assert.Zero(t, backpressureDelay(0.9, 0, maxDelay), "no delay without a threshold")
+}
+
+func TestAsyncProcessorDelaysSubmissionsNearCapacity(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	// No workers; with a reject threshold of 1.0 delays start at 80% load and reach 100ms at full load
+	processor := NewAsyncProcessor(0, 10, true, 1.0, 200*time.Millisecond, logger, nil, nil)
+	defer processor.Stop()
+
+	submit := func() time.Duration {
+		start := time.Now()
+		_, err := processor.SubmitJob("https://example.com/rss.xml", "test-request-123")
+		require.NoError(t, err)
+		return time.Since(start)
+	}
+
+	for i := 0; i < 8; i++ {
+		assert.Less(t, submit(), 40*time.Millisecond, "no delay below the soft threshold")
+	}
+	// At 80% load the delay is still zero; at 90% it is half of the 100ms maximum
+	assert.Less(t, submit(), 40*time.Millisecond)
+	elapsed := submit()
+	assert.GreaterOrEqual(t, elapsed, 50*time.Millisecond)
+	assert.Less(t, elapsed, 200*time.Millisecond, "the delay is bound by the wait timeout")
+
+	// A submitter that goes away stops waiting, and its job is not kept
+	<-processor.jobs
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := processor.SubmitTenantJobWithContext(ctx, "https://example.com/rss.xml", "test-request-123", DefaultTenant)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Len(t, processor.jobs, 9)
+	assert.Equal(t, 10, processor.QueueStats().Pending, "only the jobs submitted earlier are tracked")
+	submit()
+
+	// A full queue rejects without delay
+	start := time.Now()
+	_, err = processor.SubmitJob("https://example.com/rss.xml", "test-request-123")
+	var queueFull *QueueFullError
+	assert.ErrorAs(t, err, &queueFull)
+	assert.Less(t, time.Since(start), 40*time.Millisecond)
+}
@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"net/http"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/Nexora-Open-Source/rss-feed-backend/monitoring"
+	"github.com/Nexora-Open-Source/rss-feed-backend/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// CanaryPath is where the server hosts its canary feed
+const CanaryPath = "/canary.rss"
+
+// canaryItemLink is the link of the canary feed's only item; it never changes, so each run overwrites one stored item
+const canaryItemLink = "https://canary.invalid/item"
+
+// CanaryItemKind is the Datastore kind holding the canary item, apart from feed items so it never shows in /items or gains revisions
+const CanaryItemKind = "CanaryItem"
+
+// canaryPubDate is the canary item's fixed publication date; only its title changes between runs
+const canaryPubDate = "Mon, 01 Jan 2001 00:00:00 +0000"
+
+// Canary stages, named in results and metrics by where a run failed
+const (
+	CanaryStageSubmit  = "submit"
+	CanaryStageIngest  = "ingest"
+	CanaryStageLatency = "latency"
+	CanaryStageStore   = "store"
+	CanaryStageCache   = "cache"
+)
+
+// CanaryResult is the outcome of one canary run
+type CanaryResult struct {
+	At        time.Time `json:"at"`
+	Success   bool      `json:"success"`
+	LatencyMs int64     `json:"latency_ms"`
+	// Stage is where a failed run stopped
+	Stage string `json:"stage,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+/*
+Canary checks the ingest pipeline end to end with a feed the server hosts itself.
+
+Each run serves a fresh token as the title of the canary feed's single item,
+drops the feed from the cache so it must be fetched again, and ingests it as an
+async job. The run passes when the job completes within maxLatency and the item
+read back from Datastore and from the cache carries the token. Jobs for the canary
+feed store its item under CanaryItemKind rather than as a feed item, so readers
+never see it, and items of other feeds never take its place.
+*/
+type Canary struct {
+	mu         sync.Mutex
+	token      string
+	last       *CanaryResult
+	notifier   func(CanaryResult)
+	feedURL    string
+	maxLatency time.Duration
+	processor  AsyncProcessorInterface
+	store      DatastoreClientInterface
+	cache      CacheManagerInterface
+	logger     *logrus.Logger
+}
+
+// NewCanary creates a canary that ingests the feed served at feedURL; an empty feedURL disables it and returns nil
+func NewCanary(feedURL string, maxLatency time.Duration, processor AsyncProcessorInterface, store DatastoreClientInterface, cache CacheManagerInterface, logger *logrus.Logger) *Canary {
+	if feedURL == "" {
+		return nil
+	}
+	return &Canary{
+		feedURL:    feedURL,
+		maxLatency: maxLatency,
+		processor:  processor,
+		store:      store,
+		cache:      cache,
+		logger:     logger,
+	}
+}
+
+// SetFailureNotifier sets the function called with each failed run
+func (c *Canary) SetFailureNotifier(notifier func(CanaryResult)) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.notifier = notifier
+}
+
+// LastResult returns the outcome of the latest run, if there has been one
+func (c *Canary) LastResult() (CanaryResult, bool) {
+	if c == nil {
+		return CanaryResult{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.last == nil {
+		return CanaryResult{}, false
+	}
+	return *c.last, true
+}
+
+// currentToken returns the token of the latest run, or "idle" before the first
+func (c *Canary) currentToken() string {
+	if c == nil {
+		return "idle"
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token == "" {
+		return "idle"
+	}
+	return c.token
+}
+
+// canaryTitle is the canary item's title for a run's token
+func canaryTitle(token string) string {
+	return "Canary " + token
+}
+
+// Run ingests the canary feed once and records whether every stage passed
+func (c *Canary) Run(ctx context.Context) CanaryResult {
+	if c == nil {
+		return CanaryResult{}
+	}
+
+	token := utils.NewID()
+	c.mu.Lock()
+	c.token = token
+	c.mu.Unlock()
+
+	start := time.Now()
+	stage, err := c.check(ctx, token, start)
+	result := CanaryResult{At: start, Success: err == nil, LatencyMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		result.Stage = stage
+		result.Error = err.Error()
+	}
+	monitoring.RecordCanaryRun(stage, time.Since(start).Seconds())
+
+	c.mu.Lock()
+	c.last = &result
+	notifier := c.notifier
+	c.mu.Unlock()
+
+	if err != nil {
+		c.logger.WithFields(logrus.Fields{
+			"stage":      stage,
+			"latency_ms": result.LatencyMs,
+			"error":      err.Error(),
+		}).Error("Canary run failed")
+		if notifier != nil {
+			notifier(result)
+		}
+		return result
+	}
+	c.logger.WithField("latency_ms", result.LatencyMs).Debug("Canary run passed")
+	return result
+}
+
+// check runs each stage of the canary, returning the stage that failed, or "success"
+func (c *Canary) check(ctx context.Context, token string, start time.Time) (string, error) {
+	// A cached copy would let the job skip fetching, parsing, and storing
+	if c.cache != nil {
+		if err := c.cache.InvalidateFeed(c.feedURL); err != nil {
+			return CanaryStageCache, fmt.Errorf("failed to clear cached canary feed: %v", err)
+		}
+	}
+
+	jobID, err := c.processor.SubmitJob(c.feedURL, "canary_"+token)
+	if err != nil {
+		return CanaryStageSubmit, fmt.Errorf("failed to submit canary job: %v", err)
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, c.maxLatency)
+	defer cancel()
+	status, exists := c.processor.WaitForJob(waitCtx, jobID)
+	if !exists {
+		return CanaryStageIngest, fmt.Errorf("canary job %s disappeared", jobID)
+	}
+	switch status.Status {
+	case "completed":
+	case "failed":
+		return CanaryStageIngest, fmt.Errorf("canary job failed: %s", status.Error)
+	default:
+		return CanaryStageLatency, fmt.Errorf("canary job still %s after %s", status.Status, c.maxLatency)
+	}
+	if latency := time.Since(start); latency > c.maxLatency {
+		return CanaryStageLatency, fmt.Errorf("canary took %s, over the %s budget", latency.Round(time.Millisecond), c.maxLatency)
+	}
+
+	want := canaryTitle(token)
+	if c.store != nil {
+		var stored utils.FeedItem
+		if err := c.store.Get(ctx, datastore.NameKey(CanaryItemKind, canaryItemLink, nil), &stored); err != nil {
+			return CanaryStageStore, fmt.Errorf("failed to read canary item: %v", err)
+		}
+		if stored.Title != want {
+			return CanaryStageStore, fmt.Errorf("stored canary item is %q, want %q", stored.Title, want)
+		}
+	}
+	if c.cache != nil {
+		cached, found := c.cache.GetFeedItems(c.feedURL)
+		if !found || len(cached) != 1 || cached[0].Title != want {
+			return CanaryStageCache, fmt.Errorf("canary feed was not cached with the current item")
+		}
+	}
+	return "success", nil
+}
+
+// saveCanaryItems stores the canary item among the canary feed's items under CanaryItemKind; the feed has no others
+func saveCanaryItems(ctx context.Context, client DatastoreClientInterface, items []*utils.FeedItem) error {
+	for _, item := range items {
+		if item.Link != canaryItemLink {
+			continue
+		}
+		key := datastore.NameKey(CanaryItemKind, canaryItemLink, nil)
+		if _, err := client.PutMulti(ctx, []*datastore.Key{key}, []*utils.FeedItem{item}); err != nil {
+			return fmt.Errorf("failed to save canary item: %v", err)
+		}
+	}
+	return nil
+}
+
+// @Summary Canary feed
+// @Description Serves the tiny RSS feed the built-in canary ingests to check the pipeline end to end. Its single item's title changes with every canary run.
+// @Tags Health
+// @Produce xml
+// @Success 200 {string} string "RSS feed"
+// @Router /canary.rss [get]
+func (h *Handler) HandleCanaryFeed(w http.ResponseWriter, r *http.Request) {
+	token := h.Canary.currentToken()
+	w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0"><channel><title>Canary</title><link>%s</link><description>Pipeline canary</description>
+<item><title>%s</title><link>%s</link><description>Canary item</description><pubDate>%s</pubDate></item>
+</channel></rss>
+`, canaryItemLink, html.EscapeString(canaryTitle(token)), canaryItemLink, canaryPubDate)
+}
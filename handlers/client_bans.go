@@ -0,0 +1,371 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Nexora-Open-Source/rss-feed-backend/middleware"
+	"github.com/Nexora-Open-Source/rss-feed-backend/monitoring"
+	"github.com/Nexora-Open-Source/rss-feed-backend/utils"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// Built-in ban rules
+const (
+	BanRuleInvalidURL  = "invalid_url"
+	BanRuleAuthFailure = "auth_failure"
+)
+
+// BanRule bans a client whose requests get Threshold matching responses within the ban window
+type BanRule struct {
+	Name string
+	// Method and Path restrict the rule to one route; empty matches any
+	Method string
+	Path   string
+	// Statuses are the response statuses that count against the client
+	Statuses  []int
+	Threshold int
+}
+
+// matches reports whether a response to r with status counts against the client
+func (rule BanRule) matches(r *http.Request, status int) bool {
+	if rule.Method != "" && rule.Method != r.Method {
+		return false
+	}
+	if rule.Path != "" && rule.Path != r.URL.Path {
+		return false
+	}
+	for _, candidate := range rule.Statuses {
+		if candidate == status {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultBanRules returns the built-in rules: invalid feed URLs sent to /fetch-store, and failed
+// authentication (401) on any route; a threshold of 0 leaves a rule out. Forbidden (403) responses do
+// not count, since they also refuse authenticated callers and banned clients.
+func DefaultBanRules(invalidURLThreshold, authFailureThreshold int) []BanRule {
+	var rules []BanRule
+	if invalidURLThreshold > 0 {
+		rules = append(rules, BanRule{
+			Name:      BanRuleInvalidURL,
+			Method:    http.MethodPost,
+			Path:      "/fetch-store",
+			Statuses:  []int{http.StatusBadRequest},
+			Threshold: invalidURLThreshold,
+		})
+	}
+	if authFailureThreshold > 0 {
+		rules = append(rules, BanRule{
+			Name:      BanRuleAuthFailure,
+			Statuses:  []int{http.StatusUnauthorized},
+			Threshold: authFailureThreshold,
+		})
+	}
+	return rules
+}
+
+// ClientBan is a client temporarily refused for breaking a ban rule
+type ClientBan struct {
+	// ID identifies the ban for lifting it; it is derived from the client's address
+	ID string `json:"id"`
+	IP string `json:"ip"`
+	// UserAgent is what the client sent with the request that got it banned, for reference only
+	UserAgent string    `json:"user_agent"`
+	Rule      string    `json:"rule"`
+	Strikes   int       `json:"strikes"`
+	BannedAt  time.Time `json:"banned_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// bannedClient is a client as the ban list identifies it
+type bannedClient struct {
+	id        string
+	ip        string
+	userAgent string
+}
+
+// banClientFromRequest identifies the client behind r by its address, as resolved through trustedProxies
+func banClientFromRequest(r *http.Request, trustedProxies []*net.IPNet) bannedClient {
+	ip := middleware.ClientIP(r, trustedProxies)
+	hash := sha256.Sum256([]byte(ip))
+	return bannedClient{id: fmt.Sprintf("%x", hash)[:16], ip: ip, userAgent: r.UserAgent()}
+}
+
+/*
+ClientBans temporarily refuses clients that keep misusing the API.
+
+Each rule counts a client's responses with matching statuses, such as 400s from
+/fetch-store for invalid feed URLs or 401s from failed authentication. A client
+whose count for a rule reaches the rule's threshold within the window is refused
+with 403 for the ban duration. Clients are told apart by address alone, since
+headers such as the user agent are chosen by the client; X-Forwarded-For is only
+believed from trusted proxies. Requests refused while banned do not count toward
+further strikes.
+*/
+type ClientBans struct {
+	mu       sync.Mutex
+	rules    []BanRule
+	window   time.Duration
+	duration time.Duration
+	// strikes holds the times of each client's matching responses by rule, within the window
+	strikes map[string]map[string][]time.Time
+	bans    map[string]*ClientBan
+	// trustedProxies are the proxies whose X-Forwarded-For entries are believed
+	trustedProxies []*net.IPNet
+	logger         *logrus.Logger
+	now            func() time.Time
+}
+
+// NewClientBans bans clients breaking a rule within window for duration, identifying clients behind trustedProxies
+// by forwarded address; no rules disables bans and returns nil
+func NewClientBans(rules []BanRule, window, duration time.Duration, trustedProxies []*net.IPNet, logger *logrus.Logger) *ClientBans {
+	if len(rules) == 0 {
+		return nil
+	}
+	return &ClientBans{
+		rules:          rules,
+		window:         window,
+		duration:       duration,
+		trustedProxies: trustedProxies,
+		strikes:        make(map[string]map[string][]time.Time),
+		bans:           make(map[string]*ClientBan),
+		logger:         logger,
+		now:            time.Now,
+	}
+}
+
+// Middleware refuses banned clients and counts the responses of others against the ban rules
+func (b *ClientBans) Middleware(next http.Handler) http.Handler {
+	if b == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client := banClientFromRequest(r, b.trustedProxies)
+		if ban, banned := b.active(client.id); banned {
+			requestID := r.Header.Get("X-Request-ID")
+			if requestID == "" {
+				requestID = utils.GenerateRequestID()
+			}
+			retryAfter := int(time.Until(ban.ExpiresAt).Seconds()) + 1
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			middleware.RespondForbidden(w, fmt.Errorf("client is temporarily banned for %s until %s", ban.Rule, ban.ExpiresAt.Format(time.RFC3339)), requestID)
+			return
+		}
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+		b.Record(r, recorder.status)
+	})
+}
+
+// Record counts a response to r with the given status against the rules it matches, banning the client when one is broken
+func (b *ClientBans) Record(r *http.Request, status int) {
+	if b == nil {
+		return
+	}
+
+	var matched []BanRule
+	for _, rule := range b.rules {
+		if rule.matches(r, status) {
+			matched = append(matched, rule)
+		}
+	}
+	if len(matched) == 0 {
+		return
+	}
+
+	client := banClientFromRequest(r, b.trustedProxies)
+	now := b.now()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, banned := b.bans[client.id]; banned {
+		return
+	}
+	rules, exists := b.strikes[client.id]
+	if !exists {
+		rules = make(map[string][]time.Time)
+		b.strikes[client.id] = rules
+	}
+	for _, rule := range matched {
+		times := append(recentStrikes(rules[rule.Name], now.Add(-b.window)), now)
+		rules[rule.Name] = times
+		if len(times) < rule.Threshold {
+			continue
+		}
+
+		ban := &ClientBan{
+			ID:        client.id,
+			IP:        client.ip,
+			UserAgent: client.userAgent,
+			Rule:      rule.Name,
+			Strikes:   len(times),
+			BannedAt:  now,
+			ExpiresAt: now.Add(b.duration),
+		}
+		b.bans[client.id] = ban
+		delete(b.strikes, client.id)
+		monitoring.RecordClientBan(rule.Name)
+		b.logger.WithFields(logrus.Fields{
+			"ban_id":     ban.ID,
+			"ip":         ban.IP,
+			"user_agent": ban.UserAgent,
+			"rule":       ban.Rule,
+			"strikes":    ban.Strikes,
+			"expires_at": ban.ExpiresAt.Format(time.RFC3339),
+		}).Warn("Client banned")
+		return
+	}
+}
+
+// recentStrikes drops strike times before cutoff
+func recentStrikes(times []time.Time, cutoff time.Time) []time.Time {
+	kept := times[:0]
+	for _, at := range times {
+		if at.After(cutoff) {
+			kept = append(kept, at)
+		}
+	}
+	return kept
+}
+
+// active returns the client's ban if it has not expired
+func (b *ClientBans) active(id string) (ClientBan, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ban, exists := b.bans[id]
+	if !exists {
+		return ClientBan{}, false
+	}
+	if !b.now().Before(ban.ExpiresAt) {
+		delete(b.bans, id)
+		return ClientBan{}, false
+	}
+	return *ban, true
+}
+
+// List returns the bans in force, those expiring soonest first
+func (b *ClientBans) List() []ClientBan {
+	bans := []ClientBan{}
+	if b == nil {
+		return bans
+	}
+	now := b.now()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, ban := range b.bans {
+		if now.Before(ban.ExpiresAt) {
+			bans = append(bans, *ban)
+		}
+	}
+	sort.Slice(bans, func(i, j int) bool {
+		if !bans[i].ExpiresAt.Equal(bans[j].ExpiresAt) {
+			return bans[i].ExpiresAt.Before(bans[j].ExpiresAt)
+		}
+		return bans[i].ID < bans[j].ID
+	})
+	return bans
+}
+
+// Lift ends a ban early and clears the client's strikes, reporting whether the ban was in force
+func (b *ClientBans) Lift(id string) bool {
+	if b == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ban, exists := b.bans[id]
+	delete(b.bans, id)
+	delete(b.strikes, id)
+	return exists && b.now().Before(ban.ExpiresAt)
+}
+
+// Cleanup forgets expired bans and strikes that have left the window
+func (b *ClientBans) Cleanup() {
+	if b == nil {
+		return
+	}
+	now := b.now()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for id, ban := range b.bans {
+		if !now.Before(ban.ExpiresAt) {
+			delete(b.bans, id)
+		}
+	}
+	for id, rules := range b.strikes {
+		for name, times := range rules {
+			if times = recentStrikes(times, now.Add(-b.window)); len(times) == 0 {
+				delete(rules, name)
+			} else {
+				rules[name] = times
+			}
+		}
+		if len(rules) == 0 {
+			delete(b.strikes, id)
+		}
+	}
+}
+
+// @Summary List client bans
+// @Description Returns the clients currently refused for breaking a ban rule, such as repeatedly sending invalid feed URLs to /fetch-store or failing authentication. The list is empty when bans are turned off. Requires the admin token.
+// @Tags Admin
+// @Produce json
+// @Security AdminToken
+// @Success 200 {array} ClientBan "Bans in force"
+// @Failure 401 {object} middleware.APIError "Missing or invalid admin token"
+// @Router /admin/bans [get]
+func (h *Handler) HandleListClientBans(w http.ResponseWriter, r *http.Request) {
+	bans := h.ClientBans.List()
+
+	middleware.Log(r.Context()).WithField("bans", len(bans)).Debug("Client bans listed")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(bans)
+}
+
+// @Summary Lift a client ban
+// @Description Ends a client's ban before it expires and clears the strikes counted against it. Requires the admin token.
+// @Tags Admin
+// @Security AdminToken
+// @Param id path string true "Ban ID"
+// @Success 204 "Ban lifted"
+// @Failure 401 {object} middleware.APIError "Missing or invalid admin token"
+// @Failure 404 {object} middleware.APIError "No ban in force with this ID"
+// @Router /admin/bans/{id} [delete]
+func (h *Handler) HandleLiftClientBan(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Header.Get("X-Request-ID")
+	if requestID == "" {
+		requestID = utils.GenerateRequestID()
+		w.Header().Set("X-Request-ID", requestID)
+	}
+
+	id := mux.Vars(r)["id"]
+	if !h.ClientBans.Lift(id) {
+		middleware.RespondNotFound(w, fmt.Errorf("no ban in force with ID %s", id), requestID)
+		return
+	}
+
+	middleware.Log(r.Context()).WithField("ban_id", id).Info("Client ban lifted")
+	w.WriteHeader(http.StatusNoContent)
+}
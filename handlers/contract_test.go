@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/Nexora-Open-Source/rss-feed-backend/cache"
+	"github.com/Nexora-Open-Source/rss-feed-backend/types"
+	"github.com/Nexora-Open-Source/rss-feed-backend/utils"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// Both the real implementations and the generated mocks must satisfy the service interfaces
+var (
+	_ DatastoreClientInterface = (*datastore.Client)(nil)
+	_ DatastoreClientInterface = (*MockDatastoreClient)(nil)
+	_ CacheManagerInterface    = (*cache.CacheManager)(nil)
+	_ CacheManagerInterface    = (*MockCacheManager)(nil)
+	_ AsyncProcessorInterface  = (*AsyncProcessor)(nil)
+	_ AsyncProcessorInterface  = (*MockAsyncProcessor)(nil)
+)
+
+// contractEntity is the entity the Datastore contract stores
+type contractEntity struct {
+	Name string `datastore:"name"`
+}
+
+// datastoreContract checks the Datastore behavior handlers rely on: missing entities report ErrNoSuchEntity,
+// and written entities can be read back until deleted
+func datastoreContract(t *testing.T, client DatastoreClientInterface) {
+	ctx := context.Background()
+	key := datastore.NameKey("ContractEntity", "contract_"+utils.NewID(), nil)
+
+	var missing contractEntity
+	assert.ErrorIs(t, client.Get(ctx, key, &missing), datastore.ErrNoSuchEntity)
+
+	keys, err := client.PutMulti(ctx, []*datastore.Key{key}, []*contractEntity{{Name: "stored"}})
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+
+	var stored contractEntity
+	require.NoError(t, client.Get(ctx, key, &stored))
+	assert.Equal(t, "stored", stored.Name)
+
+	require.NoError(t, client.DeleteMulti(ctx, []*datastore.Key{key}))
+	assert.ErrorIs(t, client.Get(ctx, key, &missing), datastore.ErrNoSuchEntity)
+}
+
+// cacheContract checks the cache behavior handlers rely on: unknown keys miss,
+// and cached items are returned until their feed is invalidated
+func cacheContract(t *testing.T, cacheManager CacheManagerInterface) {
+	feedURL := "https://example.com/contract.xml"
+	items := []*utils.FeedItem{{Title: "Contract item", Link: "https://example.com/contract"}}
+
+	_, found := cacheManager.GetFeedItems(feedURL)
+	assert.False(t, found)
+
+	require.NoError(t, cacheManager.SetFeedItems(feedURL, items))
+	cached, found := cacheManager.GetFeedItems(feedURL)
+	assert.True(t, found)
+	assert.Equal(t, items, cached)
+
+	require.NoError(t, cacheManager.InvalidateFeed(feedURL))
+	_, found = cacheManager.GetFeedItems(feedURL)
+	assert.False(t, found)
+
+	require.NoError(t, cacheManager.SetStoredItems("items:contract", items))
+	cached, found = cacheManager.GetStoredItems("items:contract")
+	assert.True(t, found)
+	assert.Equal(t, items, cached)
+}
+
+// asyncContract checks the job behavior handlers rely on: unknown jobs are not found,
+// and a submitted task can be waited on until it completes
+func asyncContract(t *testing.T, processor AsyncProcessorInterface) {
+	_, exists := processor.GetJobStatus("job_missing")
+	assert.False(t, exists)
+
+	jobID, err := processor.SubmitTask("contract", "contract-request", func(ctx context.Context) ([]types.TargetResult, error) {
+		return nil, nil
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, jobID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	status, exists := processor.WaitForJob(ctx, jobID)
+	require.True(t, exists)
+	assert.Equal(t, jobID, status.JobID)
+	assert.Equal(t, "completed", status.Status)
+
+	status, exists = processor.GetJobStatus(jobID)
+	require.True(t, exists)
+	assert.Equal(t, "completed", status.Status)
+}
+
+func TestDatastoreContract(t *testing.T) {
+	t.Run("mock", func(t *testing.T) {
+		client := NewMockDatastoreClient(t)
+		client.On("Get", mock.Anything, mock.Anything, mock.Anything).Return(datastore.ErrNoSuchEntity).Once()
+		client.On("PutMulti", mock.Anything, mock.Anything, mock.Anything).
+			Return(func(ctx context.Context, keys []*datastore.Key, src interface{}) ([]*datastore.Key, error) {
+				return keys, nil
+			}).Once()
+		client.On("Get", mock.Anything, mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) {
+				args.Get(2).(*contractEntity).Name = "stored"
+			}).
+			Return(nil).Once()
+		client.On("DeleteMulti", mock.Anything, mock.Anything).Return(nil).Once()
+		client.On("Get", mock.Anything, mock.Anything, mock.Anything).Return(datastore.ErrNoSuchEntity).Once()
+
+		datastoreContract(t, client)
+	})
+
+	t.Run("datastore", func(t *testing.T) {
+		if os.Getenv("DATASTORE_EMULATOR_HOST") == "" {
+			t.Skip("DATASTORE_EMULATOR_HOST is not set")
+		}
+		client, err := datastore.NewClient(context.Background(), "contract-test")
+		require.NoError(t, err)
+		defer client.Close()
+
+		datastoreContract(t, client)
+	})
+}
+
+func TestCacheManagerContract(t *testing.T) {
+	t.Run("mock", func(t *testing.T) {
+		cacheManager := NewMockCacheManager(t)
+		feedURL := "https://example.com/contract.xml"
+		cacheManager.On("GetFeedItems", feedURL).Return(nil, false).Once()
+		cacheManager.On("SetFeedItems", feedURL, mock.Anything).Return(nil).Once()
+		cacheManager.On("GetFeedItems", feedURL).
+			Return([]*utils.FeedItem{{Title: "Contract item", Link: "https://example.com/contract"}}, true).Once()
+		cacheManager.On("InvalidateFeed", feedURL).Return(nil).Once()
+		cacheManager.On("GetFeedItems", feedURL).Return(nil, false).Once()
+		cacheManager.On("SetStoredItems", "items:contract", mock.Anything).Return(nil).Once()
+		cacheManager.On("GetStoredItems", "items:contract").
+			Return([]*utils.FeedItem{{Title: "Contract item", Link: "https://example.com/contract"}}, true).Once()
+
+		cacheContract(t, cacheManager)
+	})
+
+	t.Run("cache manager", func(t *testing.T) {
+		logger := logrus.New()
+		logger.SetLevel(logrus.ErrorLevel)
+		cacheContract(t, cache.NewCacheManager(cache.NewInMemoryCache(time.Minute), logger, time.Minute, time.Minute, time.Minute, time.Minute))
+	})
+}
+
+func TestAsyncProcessorContract(t *testing.T) {
+	t.Run("mock", func(t *testing.T) {
+		processor := NewMockAsyncProcessor(t)
+		processor.On("GetJobStatus", "job_missing").Return(nil, false).Once()
+		processor.On("SubmitTask", "contract", "contract-request", mock.Anything).Return("job_contract", nil).Once()
+		completed := &types.AsyncJobStatus{JobID: "job_contract", Status: "completed"}
+		processor.On("WaitForJob", mock.Anything, "job_contract").Return(completed, true).Once()
+		processor.On("GetJobStatus", "job_contract").Return(completed, true).Once()
+
+		asyncContract(t, processor)
+	})
+
+	t.Run("async processor", func(t *testing.T) {
+		logger := logrus.New()
+		logger.SetLevel(logrus.ErrorLevel)
+		processor := NewAsyncProcessor(1, 5, true, 0.8, 5*time.Second, logger, nil, nil)
+		defer processor.Stop()
+
+		asyncContract(t, processor)
+	})
+}
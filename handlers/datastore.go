@@ -3,6 +3,8 @@ Package handlers provides functions to interact with Google Cloud Datastore.
 
 Key Functions:
   - SaveToDatastore: Stores RSS feed items in Datastore with batch operations.
+  - SaveToDatastoreWithContext: Same as SaveToDatastore, but stops when the context is cancelled.
+  - SaveToDatastoreWithReport: Same as SaveToDatastoreWithContext, and reports which items failed and why.
   - FetchFeedItems: Retrieves stored feed items from Datastore with pagination.
   - BatchSaveToDatastore: Performs batch save operations for better performance.
 */
@@ -10,11 +12,16 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 
+	"github.com/Nexora-Open-Source/rss-feed-backend/middleware"
+	"github.com/Nexora-Open-Source/rss-feed-backend/monitoring"
+	"github.com/Nexora-Open-Source/rss-feed-backend/types"
 	"github.com/Nexora-Open-Source/rss-feed-backend/utils"
+	"github.com/sirupsen/logrus"
 
 	"cloud.google.com/go/datastore"
 )
@@ -41,8 +48,12 @@ Usage:
 	}
 */
 func SaveToDatastore(client DatastoreClientInterface, items []*utils.FeedItem, batchSize ...int) error {
-	adaptiveBatchSize := calculateAdaptiveBatchSize(len(items), getBatchSizeFromConfig(batchSize...))
-	_, err := BatchSaveToDatastoreWithDeduplication(client, items, adaptiveBatchSize)
+	return SaveToDatastoreWithContext(context.Background(), client, items, batchSize...)
+}
+
+// SaveToDatastoreWithContext saves feed items like SaveToDatastore, stopping between batches once ctx is done
+func SaveToDatastoreWithContext(ctx context.Context, client DatastoreClientInterface, items []*utils.FeedItem, batchSize ...int) error {
+	_, err := SaveToDatastoreWithReport(ctx, client, items, batchSize...)
 	return err
 }
 
@@ -137,7 +148,11 @@ Returns:
   - An error if Datastore operation fails.
 */
 func CheckForDuplicates(client DatastoreReaderInterface, items []*utils.FeedItem) (map[string]*utils.FeedItem, error) {
-	ctx := context.Background()
+	return checkForDuplicates(context.Background(), client, items)
+}
+
+// checkForDuplicates implements CheckForDuplicates using the caller's context
+func checkForDuplicates(ctx context.Context, client DatastoreReaderInterface, items []*utils.FeedItem) (map[string]*utils.FeedItem, error) {
 	existingItems := make(map[string]*utils.FeedItem)
 
 	// Collect all content hashes from new items
@@ -148,6 +163,9 @@ func CheckForDuplicates(client DatastoreReaderInterface, items []*utils.FeedItem
 
 	// Query existing items by their links (primary duplicate detection)
 	for _, item := range items {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		key := datastore.NameKey("FeedItem", item.Link, nil)
 		var existing utils.FeedItem
 		err := client.Get(ctx, key, &existing)
@@ -184,27 +202,79 @@ Usage:
 	}
 */
 func BatchSaveToDatastoreWithDeduplication(client DatastoreClientInterface, items []*utils.FeedItem, batchSize int) (int, error) {
-	ctx := context.Background()
-	newItemsCount := 0
+	return batchSaveWithDeduplication(context.Background(), client, items, batchSize)
+}
+
+// batchSaveWithDeduplication implements BatchSaveToDatastoreWithDeduplication using the caller's context
+func batchSaveWithDeduplication(ctx context.Context, client DatastoreClientInterface, items []*utils.FeedItem, batchSize int) (int, error) {
+	report, err := saveItems(ctx, client, items, batchSize)
+	return report.Saved, err
+}
+
+// IngestReport summarizes a save of feed items, listing each item that could not be stored and why
+type IngestReport struct {
+	Saved int `json:"saved"`
+	// Changed counts the saved items that replaced a stored copy whose content differed
+	Changed    int                  `json:"changed"`
+	Duplicates int                  `json:"duplicates"`
+	Failed     []types.TargetResult `json:"failed,omitempty"`
+	// Items lists the items written, new or changed
+	Items []*utils.FeedItem `json:"-"`
+	// Batches lists each batch written, identified by its idempotency token, for exactly-once counting
+	Batches []IngestBatch `json:"-"`
+}
+
+/*
+SaveToDatastoreWithReport saves feed items like SaveToDatastoreWithContext and reports the outcome.
+
+Items Datastore rejects individually are retried once and then skipped, so one bad
+entity does not fail the rest of its batch; they are listed in the report's Failed
+results by link. An error is returned only when the save could not proceed, or when
+no item could be stored at all.
+*/
+func SaveToDatastoreWithReport(ctx context.Context, client DatastoreClientInterface, items []*utils.FeedItem, batchSize ...int) (IngestReport, error) {
+	adaptiveBatchSize := calculateAdaptiveBatchSize(len(items), getBatchSizeFromConfig(batchSize...))
+	return saveItems(ctx, client, items, adaptiveBatchSize)
+}
+
+// saveItems drops duplicates of stored items and saves the rest in batches
+func saveItems(ctx context.Context, client DatastoreClientInterface, items []*utils.FeedItem, batchSize int) (IngestReport, error) {
+	var report IngestReport
+	items, report.Failed = storableItems(items)
 
 	// Check for duplicates first
-	existingItems, err := CheckForDuplicates(client, items)
+	existingItems, err := checkForDuplicates(ctx, client, items)
 	if err != nil {
-		return 0, err
+		return report, err
 	}
 
 	var uniqueItems []*utils.FeedItem
+	var revisions []*ItemRevision
+	changed := make(map[*utils.FeedItem]bool)
+	now := time.Now().UTC()
 	for _, item := range items {
 		itemHash := item.GenerateContentHash()
+		ingestedAt := now
 		if existing, exists := existingItems[itemHash]; exists {
-			// Check if this is really a duplicate using multiple criteria
-			if item.IsDuplicate(existing) {
+			// A stored item whose content hash differs was edited at its origin and is written again
+			if item.ContentChanged(existing) {
+				changed[item] = true
+				revisions = append(revisions, newItemRevision(existing, now))
+			} else if item.IsDuplicate(existing) {
+				// Check if this is really a duplicate using multiple criteria
+				report.Duplicates++
 				continue // Skip duplicate
 			}
+			// Rewrites keep the time the item was first stored, even when that was not recorded
+			ingestedAt = existing.IngestedAt
 		}
+		item.IngestedAt, item.RevisedAt = ingestedAt, now
 		uniqueItems = append(uniqueItems, item)
 	}
 
+	// Keep the versions being replaced, so /items?as_of can show them
+	saveItemRevisions(ctx, client, revisions)
+
 	// Save unique items in batches
 	for i := 0; i < len(uniqueItems); i += batchSize {
 		end := i + batchSize
@@ -212,29 +282,131 @@ func BatchSaveToDatastoreWithDeduplication(client DatastoreClientInterface, item
 			end = len(uniqueItems)
 		}
 
-		batch := uniqueItems[i:end]
-		keys := make([]*datastore.Key, len(batch))
-
-		// Prepare keys for the batch
-		for j, item := range batch {
-			// Use the Link as the unique key to prevent duplicates
-			keys[j] = datastore.NameKey("FeedItem", item.Link, nil)
+		// Stop before the next batch if the caller has gone away or timed out
+		if err := ctx.Err(); err != nil {
+			return report, err
 		}
 
-		// Perform batch put operation
-		_, err := client.PutMulti(ctx, keys, batch)
+		saved, failed, err := putItemBatch(ctx, client, uniqueItems[i:end])
 		if err != nil {
-			return newItemsCount, fmt.Errorf("batch save failed at batch starting index %d: %v", i, err)
+			return report, fmt.Errorf("batch save failed at batch starting index %d: %v", i, err)
 		}
+		if len(saved) > 0 {
+			report.Saved += len(saved)
+			report.Items = append(report.Items, saved...)
+			report.Batches = append(report.Batches, IngestBatch{Token: IngestToken(saved), Items: len(saved)})
+			for _, item := range saved {
+				if changed[item] {
+					report.Changed++
+				}
+			}
+		}
+		report.Failed = append(report.Failed, failed...)
+	}
 
-		newItemsCount += len(batch)
+	monitoring.RecordIngestItems("saved", report.Saved-report.Changed)
+	monitoring.RecordIngestItems("changed", report.Changed)
+	monitoring.RecordIngestItems("duplicate", report.Duplicates)
+	monitoring.RecordIngestItems("failed", len(report.Failed))
+
+	if report.Saved == 0 && len(report.Failed) > 0 {
+		return report, fmt.Errorf("none of %d items could be saved; first failure: %s: %s", len(report.Failed), report.Failed[0].Target, report.Failed[0].Error)
 	}
+	return report, nil
+}
 
-	return newItemsCount, nil
+// itemKeys returns the Datastore keys of feed items, which are keyed by link to prevent duplicates
+func itemKeys(items []*utils.FeedItem) []*datastore.Key {
+	keys := make([]*datastore.Key, len(items))
+	for i, item := range items {
+		keys[i] = datastore.NameKey("FeedItem", item.Link, nil)
+	}
+	return keys
 }
 
 /*
-CleanupOldFeedItems removes feed items older than the specified date.
+putItemBatch writes one batch of items and returns the items saved and those that failed.
+
+When Datastore rejects only some entities, those are retried once on their own in
+case the failures were transient, and any still failing are returned as failed
+results. Errors that are not per-entity fail the whole batch.
+*/
+func putItemBatch(ctx context.Context, client DatastoreClientInterface, batch []*utils.FeedItem) ([]*utils.FeedItem, []types.TargetResult, error) {
+	_, err := client.PutMulti(ctx, itemKeys(batch), batch)
+	if err == nil {
+		return batch, nil, nil
+	}
+	var multiErr datastore.MultiError
+	if !errors.As(err, &multiErr) || len(multiErr) != len(batch) {
+		return nil, nil, err
+	}
+
+	var saved, retry []*utils.FeedItem
+	for i, entityErr := range multiErr {
+		if entityErr != nil {
+			retry = append(retry, batch[i])
+		} else {
+			saved = append(saved, batch[i])
+		}
+	}
+	monitoring.RecordDatastoreOperation("save", "partial", 0)
+
+	errs := make([]error, len(retry))
+	if ctx.Err() != nil {
+		for i := range errs {
+			errs[i] = ctx.Err()
+		}
+	} else if _, err := client.PutMulti(ctx, itemKeys(retry), retry); err != nil {
+		if errors.As(err, &multiErr) && len(multiErr) == len(retry) {
+			copy(errs, multiErr)
+		} else {
+			for i := range errs {
+				errs[i] = err
+			}
+		}
+	}
+
+	var failed []types.TargetResult
+	for i, entityErr := range errs {
+		if entityErr == nil {
+			saved = append(saved, retry[i])
+			continue
+		}
+		failed = append(failed, types.TargetResult{Target: retry[i].Link, Error: entityErr.Error()})
+	}
+
+	if len(failed) > 0 {
+		middleware.Logger.WithFields(logrus.Fields{
+			"batch_size":  len(batch),
+			"failed":      len(failed),
+			"first_link":  failed[0].Target,
+			"first_error": failed[0].Error,
+		}).Warn("Some feed items could not be saved")
+	}
+	return saved, failed, nil
+}
+
+// storableItems truncates oversized item fields and sets aside items whose link cannot be a Datastore key,
+// so that a single oversized item cannot fail a whole batch
+func storableItems(items []*utils.FeedItem) ([]*utils.FeedItem, []types.TargetResult) {
+	storable := make([]*utils.FeedItem, 0, len(items))
+	var skipped []types.TargetResult
+	for _, item := range items {
+		if item.Link == "" || len(item.Link) > utils.MaxLinkBytes {
+			skipped = append(skipped, types.TargetResult{
+				Target: item.Link,
+				Error:  fmt.Sprintf("link must be between 1 and %d bytes to be stored as a key", utils.MaxLinkBytes),
+			})
+			continue
+		}
+		item.EnforceSizeLimits()
+		storable = append(storable, item)
+	}
+	return storable, skipped
+}
+
+/*
+CleanupOldFeedItems removes feed items older than the specified date, along with their revisions.
 
 Parameters:
   - client: Datastore client instance
@@ -281,6 +453,10 @@ func CleanupOldFeedItems(client DatastoreClientInterface, olderThan time.Time, b
 		}
 
 		batch := keys[i:end]
+		// Revisions go first, so a failed batch never leaves history without its item
+		if err := deleteItemRevisions(ctx, client, batch); err != nil {
+			return deletedCount, fmt.Errorf("batch delete failed at batch starting index %d: %v", i, err)
+		}
 		err := client.DeleteMulti(ctx, batch)
 		if err != nil {
 			return deletedCount, fmt.Errorf("batch delete failed at batch starting index %d: %v", i, err)
@@ -380,6 +556,8 @@ type PaginatedResult struct {
 	TotalCount int               `json:"total_count"`
 	HasMore    bool              `json:"has_more"`
 	NextCursor string            `json:"next_cursor,omitempty"`
+	// DuplicateCounts holds the size of each collapsed duplicate cluster, keyed by cluster ID
+	DuplicateCounts map[string]int `json:"duplicate_counts,omitempty"`
 }
 
 /*
@@ -408,10 +586,10 @@ func FetchFeedItems(client DatastoreReaderInterface, params PaginationParams) (*
 
 	// Set default limit if not specified
 	if params.Limit <= 0 {
-		params.Limit = 100
+		params.Limit = defaultItemsPageSize
 	}
-	if params.Limit > 1000 {
-		params.Limit = 1000 // Maximum limit to prevent excessive resource usage
+	if params.Limit > MaxItemsPageSize {
+		params.Limit = MaxItemsPageSize // Maximum limit to prevent excessive resource usage
 	}
 
 	// Apply pagination
@@ -462,8 +640,10 @@ func FetchFeedItems(client DatastoreReaderInterface, params PaginationParams) (*
 FetchFeedItemsWithFilter retrieves RSS feed items from Google Cloud Datastore with pagination and filtering support.
 
 Parameters:
+  - ctx: Context ending the queries, such as the request's
   - client: Datastore client instance
-  - params: ItemsQueryParams containing pagination and filter parameters.
+  - params: ItemsQueryParams containing pagination and filter parameters; when AsOf is set,
+    the items are read as they were stored at that time.
 
 Returns:
   - A PaginatedResult containing filtered feed items and pagination metadata.
@@ -476,13 +656,15 @@ Usage:
 		PaginationParams: PaginationParams{Limit: 50, Offset: 0},
 		FilterParams: FilterParams{Source: "example.com", DateFrom: "2023-01-01T00:00:00Z"},
 	}
-	result, err := FetchFeedItemsWithFilter(client, params)
+	result, err := FetchFeedItemsWithFilter(ctx, client, params)
 	if err != nil {
 	    log.Fatalf("Failed to fetch filtered feed items: %v", err)
 	}
 */
-func FetchFeedItemsWithFilter(client DatastoreReaderInterface, params ItemsQueryParams) (*PaginatedResult, error) {
-	ctx := context.Background()
+func FetchFeedItemsWithFilter(ctx context.Context, client DatastoreReaderInterface, params ItemsQueryParams) (*PaginatedResult, error) {
+	if !params.AsOf.IsZero() {
+		return fetchFeedItemsAsOf(ctx, client, params)
+	}
 	query := datastore.NewQuery("FeedItem")
 
 	// Apply filters
@@ -496,24 +678,14 @@ func FetchFeedItemsWithFilter(client DatastoreReaderInterface, params ItemsQuery
 	}
 
 	// Apply date filters if provided
-	if params.DateFrom != "" {
-		if dateFrom, err := time.Parse(time.RFC3339, params.DateFrom); err == nil {
-			query = query.Filter("pub_date >=", dateFrom.Format(time.RFC3339))
-		}
-	}
-
-	if params.DateTo != "" {
-		if dateTo, err := time.Parse(time.RFC3339, params.DateTo); err == nil {
-			query = query.Filter("pub_date <=", dateTo.Format(time.RFC3339))
-		}
-	}
+	query = filterPubDate(query, params.FilterParams)
 
 	// Set default limit if not specified
 	if params.Limit <= 0 {
-		params.Limit = 100
+		params.Limit = defaultItemsPageSize
 	}
-	if params.Limit > 1000 {
-		params.Limit = 1000 // Maximum limit to prevent excessive resource usage
+	if params.Limit > MaxItemsPageSize {
+		params.Limit = MaxItemsPageSize // Maximum limit to prevent excessive resource usage
 	}
 
 	// Apply pagination
@@ -555,16 +727,7 @@ func FetchFeedItemsWithFilter(client DatastoreReaderInterface, params ItemsQuery
 	if params.Author != "" {
 		countQuery = countQuery.Filter("author =", params.Author)
 	}
-	if params.DateFrom != "" {
-		if dateFrom, err := time.Parse(time.RFC3339, params.DateFrom); err == nil {
-			countQuery = countQuery.Filter("pub_date >=", dateFrom.Format(time.RFC3339))
-		}
-	}
-	if params.DateTo != "" {
-		if dateTo, err := time.Parse(time.RFC3339, params.DateTo); err == nil {
-			countQuery = countQuery.Filter("pub_date <=", dateTo.Format(time.RFC3339))
-		}
-	}
+	countQuery = filterPubDate(countQuery, params.FilterParams)
 
 	totalKeys, err := client.GetAll(ctx, countQuery, nil)
 	if err != nil {
@@ -590,6 +753,22 @@ func FetchFeedItemsWithFilter(client DatastoreReaderInterface, params ItemsQuery
 	}, nil
 }
 
+// filterPubDate restricts a FeedItem query to the filter's date range. Bounds are compared in UTC;
+// ones without an offset are read as UTC, since handlers resolve the caller's timezone before querying.
+func filterPubDate(query *datastore.Query, params FilterParams) *datastore.Query {
+	if params.DateFrom != "" {
+		if dateFrom, err := utils.ParseDateBound(params.DateFrom, time.UTC, false); err == nil {
+			query = query.Filter("pub_date >=", dateFrom.Format(time.RFC3339))
+		}
+	}
+	if params.DateTo != "" {
+		if dateTo, err := utils.ParseDateBound(params.DateTo, time.UTC, true); err == nil {
+			query = query.Filter("pub_date <=", dateTo.Format(time.RFC3339))
+		}
+	}
+	return query
+}
+
 /*
 FetchFeedItemsLegacy retrieves all RSS feed items stored in Google Cloud Datastore (legacy function).
 
@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/Nexora-Open-Source/rss-feed-backend/cache"
+	"github.com/Nexora-Open-Source/rss-feed-backend/middleware"
+	"github.com/Nexora-Open-Source/rss-feed-backend/monitoring"
+	"github.com/Nexora-Open-Source/rss-feed-backend/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// diagnosticsProbeTimeout bounds each dependency probe made for a diagnostics snapshot
+const diagnosticsProbeTimeout = 5 * time.Second
+
+// Dependency probe statuses
+const (
+	DependencyStatusOK    = "ok"
+	DependencyStatusError = "error"
+)
+
+// DependencyLatency is the outcome of probing one dependency
+type DependencyLatency struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+	// CheckedAt is when the dependency was probed; results of scheduled checks, such as the ingest canary, may be older than the snapshot
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// CircuitBreakerState is a breaker currently holding back work
+type CircuitBreakerState struct {
+	// Name identifies what is held back, such as a feed whose host asked clients to back off
+	Name   string `json:"name"`
+	State  string `json:"state"`
+	Reason string `json:"reason"`
+	// Until is when the breaker lets work through again
+	Until time.Time `json:"until"`
+}
+
+// Diagnostics is the response body for GET /admin/diagnostics
+type Diagnostics struct {
+	GeneratedAt time.Time `json:"generated_at"`
+	Uptime      string    `json:"uptime"`
+	ReadOnly    bool      `json:"read_only"`
+	// Config is the effective configuration with secrets removed; omitted when the server did not supply it
+	Config interface{} `json:"config,omitempty"`
+	// Queue and Cache are omitted when the configured processor or cache does not report statistics
+	Queue *QueueStats       `json:"queue,omitempty"`
+	Cache *cache.CacheStats `json:"cache,omitempty"`
+	// CircuitBreakers lists the open breakers; feeds whose hosts responded 429 or 503 are not fetched until their retry time
+	CircuitBreakers []CircuitBreakerState    `json:"circuit_breakers"`
+	RecentErrors    []middleware.RecentError `json:"recent_errors"`
+	Dependencies    []DependencyLatency      `json:"dependencies"`
+	Alerts          []monitoring.Alert       `json:"alerts"`
+	WarmUp          *WarmUpReport            `json:"warmup,omitempty"`
+}
+
+// @Summary Get system diagnostics
+// @Description Returns one JSON snapshot of system state for attaching to incident tickets: the effective configuration with secrets removed, async queue state, cache statistics, open circuit breakers, recent errors, dependency latencies measured for this request, and active alerts. Requires the admin token.
+// @Tags Admin
+// @Produce json
+// @Security AdminToken
+// @Success 200 {object} Diagnostics "System diagnostics"
+// @Failure 401 {object} middleware.APIError "Missing or invalid admin token"
+// @Router /admin/diagnostics [get]
+func (h *Handler) HandleGetDiagnostics(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Header.Get("X-Request-ID")
+	if requestID == "" {
+		requestID = utils.GenerateRequestID()
+		w.Header().Set("X-Request-ID", requestID)
+	}
+
+	diagnostics := Diagnostics{
+		GeneratedAt:     time.Now().UTC(),
+		Uptime:          time.Since(startTime).Round(time.Second).String(),
+		ReadOnly:        h.Config.ReadOnly,
+		Config:          h.DiagnosticsConfig,
+		CircuitBreakers: h.openCircuitBreakers(),
+		RecentErrors:    h.RecentErrors.Entries(),
+		Dependencies:    h.probeDependencies(r.Context()),
+		Alerts:          []monitoring.Alert{},
+	}
+	if source, ok := h.AsyncProcessor.(queueStatsSource); ok {
+		stats := source.QueueStats()
+		diagnostics.Queue = &stats
+	}
+	if source, ok := h.CacheManager.(cacheStatsSource); ok {
+		stats := source.Stats()
+		diagnostics.Cache = &stats
+	}
+	if h.Alerts != nil {
+		// Copy alerts so encoding does not race with the manager resolving them
+		for _, alert := range h.Alerts.GetActiveAlerts() {
+			diagnostics.Alerts = append(diagnostics.Alerts, *alert)
+		}
+		sort.Slice(diagnostics.Alerts, func(i, j int) bool {
+			return diagnostics.Alerts[i].Timestamp.After(diagnostics.Alerts[j].Timestamp)
+		})
+	}
+	if h.WarmUp != nil {
+		report := h.WarmUp.Report()
+		diagnostics.WarmUp = &report
+	}
+
+	middleware.Log(r.Context()).WithFields(logrus.Fields{
+		"circuit_breakers": len(diagnostics.CircuitBreakers),
+		"recent_errors":    len(diagnostics.RecentErrors),
+		"alerts":           len(diagnostics.Alerts),
+	}).Info("Diagnostics snapshot retrieved")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(diagnostics)
+}
+
+// openCircuitBreakers lists the feeds held back because their hosts asked clients to back off, ordered by feed URL
+func (h *Handler) openCircuitBreakers() []CircuitBreakerState {
+	breakers := []CircuitBreakerState{}
+	for feedURL, retryAt := range h.Polls.Backoffs() {
+		breakers = append(breakers, CircuitBreakerState{
+			Name:   "feed:" + feedURL,
+			State:  "open",
+			Reason: "feed host asked clients to back off",
+			Until:  retryAt.UTC(),
+		})
+	}
+	sort.Slice(breakers, func(i, j int) bool {
+		return breakers[i].Name < breakers[j].Name
+	})
+	return breakers
+}
+
+// probeDependencies times a round trip to Datastore and reports the latest ingest canary run; the cache is in
+// process, so it is not probed
+func (h *Handler) probeDependencies(ctx context.Context) []DependencyLatency {
+	ctx, cancel := context.WithTimeout(ctx, diagnosticsProbeTimeout)
+	defer cancel()
+
+	dependencies := []DependencyLatency{
+		probeDependency("datastore", func() error {
+			return h.pingDatastore(ctx)
+		}),
+	}
+	if result, ok := h.Canary.LastResult(); ok {
+		canary := DependencyLatency{
+			Name:      "ingest_canary",
+			Status:    DependencyStatusOK,
+			LatencyMs: float64(result.LatencyMs),
+			CheckedAt: result.At.UTC(),
+		}
+		if !result.Success {
+			canary.Status, canary.Error = DependencyStatusError, result.Error
+		}
+		dependencies = append(dependencies, canary)
+	}
+	return dependencies
+}
+
+// probeDependency times one call to a dependency
+func probeDependency(name string, probe func() error) DependencyLatency {
+	start := time.Now()
+	err := probe()
+	result := DependencyLatency{
+		Name:      name,
+		Status:    DependencyStatusOK,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+		CheckedAt: start.UTC(),
+	}
+	if err != nil {
+		result.Status, result.Error = DependencyStatusError, err.Error()
+	}
+	return result
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+
+	"cloud.google.com/go/datastore"
+	"github.com/Nexora-Open-Source/rss-feed-backend/utils"
+)
+
+// faultInjectingClient delays and fails Datastore calls at the rates of the installed fault injector
+type faultInjectingClient struct {
+	DatastoreClientInterface
+}
+
+// Get reads an entity unless a fault is injected
+func (c faultInjectingClient) Get(ctx context.Context, key *datastore.Key, dst interface{}) error {
+	if err := utils.InjectFault(ctx, utils.FaultTargetDatastore); err != nil {
+		return err
+	}
+	return c.DatastoreClientInterface.Get(ctx, key, dst)
+}
+
+// GetAll runs a query unless a fault is injected
+func (c faultInjectingClient) GetAll(ctx context.Context, q *datastore.Query, dst interface{}) ([]*datastore.Key, error) {
+	if err := utils.InjectFault(ctx, utils.FaultTargetDatastore); err != nil {
+		return nil, err
+	}
+	return c.DatastoreClientInterface.GetAll(ctx, q, dst)
+}
+
+// PutMulti writes entities unless a fault is injected
+func (c faultInjectingClient) PutMulti(ctx context.Context, keys []*datastore.Key, src interface{}) ([]*datastore.Key, error) {
+	if err := utils.InjectFault(ctx, utils.FaultTargetDatastore); err != nil {
+		return nil, err
+	}
+	return c.DatastoreClientInterface.PutMulti(ctx, keys, src)
+}
+
+// DeleteMulti deletes entities unless a fault is injected
+func (c faultInjectingClient) DeleteMulti(ctx context.Context, keys []*datastore.Key) error {
+	if err := utils.InjectFault(ctx, utils.FaultTargetDatastore); err != nil {
+		return err
+	}
+	return c.DatastoreClientInterface.DeleteMulti(ctx, keys)
+}
+
+// RunInTransaction runs f in a transaction unless a fault is injected
+func (c faultInjectingClient) RunInTransaction(ctx context.Context, f func(tx *datastore.Transaction) error, opts ...datastore.TransactionOption) (*datastore.Commit, error) {
+	transactor, ok := c.DatastoreClientInterface.(DatastoreTransactorInterface)
+	if !ok {
+		return nil, errors.New("datastore client does not support transactions")
+	}
+	if err := utils.InjectFault(ctx, utils.FaultTargetDatastore); err != nil {
+		return nil, err
+	}
+	return transactor.RunInTransaction(ctx, f, opts...)
+}
+
+// withFaultInjection returns a Datastore client whose calls are subject to fault injection, or client itself while injection is off
+func withFaultInjection(client DatastoreClientInterface) DatastoreClientInterface {
+	if client == nil || !utils.FaultInjectionEnabled() {
+		return client
+	}
+	return faultInjectingClient{DatastoreClientInterface: client}
+}
@@ -0,0 +1,348 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"cloud.google.com/go/datastore"
+	"github.com/Nexora-Open-Source/rss-feed-backend/middleware"
+	"github.com/Nexora-Open-Source/rss-feed-backend/types"
+	"github.com/Nexora-Open-Source/rss-feed-backend/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// Bulk feed actions
+const (
+	BulkActionEnable  = "enable"
+	BulkActionDisable = "disable"
+	BulkActionDelete  = "delete"
+	BulkActionRetag   = "retag"
+)
+
+// Limits on bulk feed requests
+const (
+	maxBulkFeeds     = 1000
+	maxFeedTags      = 20
+	maxFeedTagLength = 50
+	// bulkWriteBatch is the most entities written to Datastore in one call
+	bulkWriteBatch = 500
+)
+
+// BulkFeedFilter selects subscribed feeds by their current state; every set field must match
+type BulkFeedFilter struct {
+	Folder   string `json:"folder,omitempty"` // Folder or any of its subfolders
+	Tag      string `json:"tag,omitempty"`
+	Status   string `json:"status,omitempty"` // active or stale
+	Disabled *bool  `json:"disabled,omitempty"`
+}
+
+// BulkFeedRequest represents the request body for POST /feeds/bulk
+type BulkFeedRequest struct {
+	// Action is enable, disable, delete, or retag
+	Action string `json:"action"`
+	// URLs and Filter select the feeds to change; exactly one must be given
+	URLs   []string        `json:"urls,omitempty"`
+	Filter *BulkFeedFilter `json:"filter,omitempty"`
+	// Tags replaces the tags of each feed when Action is retag; an empty list clears them
+	Tags []string `json:"tags,omitempty"`
+}
+
+// Validate checks the request and normalizes its folder and tags
+func (req *BulkFeedRequest) Validate() error {
+	switch req.Action {
+	case BulkActionEnable, BulkActionDisable, BulkActionDelete, BulkActionRetag:
+	default:
+		return fmt.Errorf("action must be one of %q, %q, %q, or %q", BulkActionEnable, BulkActionDisable, BulkActionDelete, BulkActionRetag)
+	}
+
+	if (len(req.URLs) == 0) == (req.Filter == nil) {
+		return fmt.Errorf("exactly one of urls or filter is required")
+	}
+	if len(req.URLs) > maxBulkFeeds {
+		return fmt.Errorf("urls cannot list more than %d feeds", maxBulkFeeds)
+	}
+	if filter := req.Filter; filter != nil {
+		filter.Folder = NormalizeFolderPath(filter.Folder)
+		filter.Tag = strings.ToLower(strings.TrimSpace(filter.Tag))
+		// An empty filter would select every feed, which is too easy to send by mistake
+		if filter.Folder == "" && filter.Tag == "" && filter.Status == "" && filter.Disabled == nil {
+			return fmt.Errorf("filter must set at least one of folder, tag, status, or disabled")
+		}
+		if filter.Status != "" && filter.Status != FeedStatusActive && filter.Status != FeedStatusStale {
+			return fmt.Errorf("filter status must be %q or %q", FeedStatusActive, FeedStatusStale)
+		}
+	}
+
+	if req.Action != BulkActionRetag {
+		if len(req.Tags) > 0 {
+			return fmt.Errorf("tags are only accepted with the %q action", BulkActionRetag)
+		}
+		return nil
+	}
+	tags, err := normalizeTags(req.Tags)
+	if err != nil {
+		return err
+	}
+	req.Tags = tags
+	return nil
+}
+
+// normalizeTags lowercases and trims tags, dropping empty and repeated ones
+func normalizeTags(tags []string) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if len(tag) > maxFeedTagLength {
+			return nil, fmt.Errorf("tags cannot exceed %d characters", maxFeedTagLength)
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	if len(normalized) > maxFeedTags {
+		return nil, fmt.Errorf("a feed cannot have more than %d tags", maxFeedTags)
+	}
+	return normalized, nil
+}
+
+// matches reports whether a feed satisfies every field set on the filter
+func (filter *BulkFeedFilter) matches(feed FeedSource, status string) bool {
+	if filter.Folder != "" && !inFolder(feed.Folder, filter.Folder) {
+		return false
+	}
+	if filter.Tag != "" && !containsTag(feed.Tags, filter.Tag) {
+		return false
+	}
+	if filter.Status != "" && status != filter.Status {
+		return false
+	}
+	return filter.Disabled == nil || feed.Disabled == *filter.Disabled
+}
+
+// containsTag reports whether tags includes tag
+func containsTag(tags []string, tag string) bool {
+	for _, candidate := range tags {
+		if candidate == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// @Summary Change many feed subscriptions at once
+// @Description Enables, disables, deletes, or retags the subscribed feeds selected by URL or by filter. The change runs as an async job; poll /job-status for per-feed results. Predefined feeds cannot be changed. Requires the admin token, since one call can change every subscription.
+// @Tags RSS Feed Operations
+// @Accept json
+// @Produce json
+// @Security AdminToken
+// @Param request body BulkFeedRequest true "Bulk feed operation"
+// @Success 202 {object} FetchResponse "Job submitted"
+// @Failure 400 {object} middleware.APIError "Bad request"
+// @Failure 401 {object} middleware.APIError "Missing or invalid admin token"
+// @Failure 500 {object} middleware.APIError "Internal server error"
+// @Failure 503 {object} middleware.APIError "Async job queue is full; retry after the Retry-After header"
+// @Router /feeds/bulk [post]
+func (h *Handler) HandleBulkFeeds(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Header.Get("X-Request-ID")
+	if requestID == "" {
+		requestID = utils.GenerateRequestID()
+		w.Header().Set("X-Request-ID", requestID)
+	}
+
+	var req BulkFeedRequest
+	if r.Body == nil {
+		middleware.RespondBadRequest(w, fmt.Errorf("request body is required"), requestID)
+		return
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		middleware.RespondBadRequest(w, fmt.Errorf("invalid request body: %v", err), requestID)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		middleware.RespondValidationError(w, err, requestID)
+		return
+	}
+
+	operation := "bulk_" + req.Action
+	payload, err := json.Marshal(req)
+	if err != nil {
+		middleware.RespondInternalError(w, err, requestID)
+		return
+	}
+	jobID, err := h.AsyncProcessor.SubmitDurableTaskWithContext(r.Context(), operation, requestID, payload)
+	if err != nil {
+		if respondQueueFull(w, err, requestID) {
+			return
+		}
+		middleware.Log(r.Context()).WithFields(logrus.Fields{
+			"operation": operation,
+			"error":     err.Error(),
+		}).Error("Failed to submit bulk feed operation")
+		middleware.RespondInternalError(w, err, requestID)
+		return
+	}
+
+	middleware.Log(r.Context()).WithFields(logrus.Fields{
+		"operation": operation,
+		"job_id":    jobID,
+		"urls":      len(req.URLs),
+		"filtered":  req.Filter != nil,
+	}).Info("Bulk feed operation submitted")
+
+	response := FetchResponse{
+		Success:   true,
+		Message:   "Bulk feed operation submitted; poll /job-status for per-feed results",
+		JobID:     jobID,
+		RequestID: requestID,
+		Status:    "submitted",
+	}
+	// A full job queue may have parked the job in the overflow queue instead
+	if status, exists := h.AsyncProcessor.GetJobStatus(jobID); exists && status.Status == "queued_deferred" {
+		response.Message = "Job queue is full; bulk feed operation accepted into the overflow queue and will run when there is room"
+		response.Status = "queued_deferred"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(response)
+}
+
+// bulkFeedTaskFromPayload rebuilds a bulk feed task from its JSON request, so bulk jobs can wait in the overflow queue
+func (h *Handler) bulkFeedTaskFromPayload(payload []byte) (JobTask, error) {
+	var req BulkFeedRequest
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return nil, fmt.Errorf("invalid bulk feed payload: %v", err)
+	}
+	return h.bulkFeedTask(req), nil
+}
+
+// bulkFeedTask returns the job task applying a validated bulk request.
+// Feeds are selected when the job runs, so a filter sees the subscriptions as they are then.
+func (h *Handler) bulkFeedTask(req BulkFeedRequest) JobTask {
+	return func(ctx context.Context) ([]types.TargetResult, error) {
+		var stored []FeedSource
+		if _, err := h.DatastoreClient.GetAll(ctx, datastore.NewQuery(feedSourceKind), &stored); err != nil {
+			return nil, fmt.Errorf("failed to load feed sources: %v", err)
+		}
+
+		var targets []FeedSource
+		var results []types.TargetResult
+		if req.Filter != nil {
+			for _, feed := range stored {
+				status, _ := h.FeedHealth.Status(feed.URL)
+				if req.Filter.matches(feed, status) {
+					targets = append(targets, feed)
+				}
+			}
+		} else {
+			targets, results = h.resolveBulkTargets(ctx, req.URLs, stored)
+		}
+
+		for i := range targets {
+			switch req.Action {
+			case BulkActionEnable:
+				targets[i].Disabled = false
+			case BulkActionDisable:
+				targets[i].Disabled = true
+			case BulkActionRetag:
+				targets[i].Tags = req.Tags
+			}
+		}
+
+		for start := 0; start < len(targets); start += bulkWriteBatch {
+			end := min(start+bulkWriteBatch, len(targets))
+			results = append(results, h.writeBulkBatch(ctx, req.Action, targets[start:end])...)
+		}
+		return results, nil
+	}
+}
+
+// resolveBulkTargets looks up the subscribed feeds named by urls, returning failed results for the rest
+func (h *Handler) resolveBulkTargets(ctx context.Context, urls []string, stored []FeedSource) ([]FeedSource, []types.TargetResult) {
+	subscribed := make(map[string]FeedSource, len(stored))
+	for _, feed := range stored {
+		subscribed[feed.URL] = feed
+	}
+	predefined := make(map[string]bool)
+	if feeds, err := loadPredefinedFeeds(ctx); err == nil {
+		for _, feed := range feeds {
+			predefined[feed.URL] = true
+		}
+	}
+
+	var targets []FeedSource
+	var failed []types.TargetResult
+	seen := make(map[string]bool, len(urls))
+	for _, feedURL := range urls {
+		feedURL = h.Redirects.Resolve(strings.TrimSpace(feedURL))
+		if seen[feedURL] {
+			continue
+		}
+		seen[feedURL] = true
+
+		if feed, exists := subscribed[feedURL]; exists {
+			targets = append(targets, feed)
+			continue
+		}
+		reason := "feed is not subscribed"
+		if predefined[feedURL] {
+			reason = "predefined feeds cannot be changed"
+		}
+		failed = append(failed, types.TargetResult{Target: feedURL, Error: reason})
+	}
+	return targets, failed
+}
+
+// writeBulkBatch saves or deletes one batch of feeds and reports the outcome for each
+func (h *Handler) writeBulkBatch(ctx context.Context, action string, feeds []FeedSource) []types.TargetResult {
+	keys := make([]*datastore.Key, len(feeds))
+	for i, feed := range feeds {
+		keys[i] = datastore.NameKey(feedSourceKind, feed.URL, nil)
+	}
+
+	var err error
+	if action == BulkActionDelete {
+		err = h.DatastoreClient.DeleteMulti(ctx, keys)
+	} else {
+		sources := make([]*FeedSource, len(feeds))
+		for i := range feeds {
+			sources[i] = &feeds[i]
+		}
+		_, err = h.DatastoreClient.PutMulti(ctx, keys, sources)
+	}
+
+	// A MultiError reports each entity separately; any other error failed the whole batch
+	var multiErr datastore.MultiError
+	isMulti := errors.As(err, &multiErr) && len(multiErr) == len(feeds)
+
+	results := make([]types.TargetResult, len(feeds))
+	for i, feed := range feeds {
+		result := types.TargetResult{Target: feed.URL, Success: true}
+		switch {
+		case isMulti && multiErr[i] != nil:
+			result = types.TargetResult{Target: feed.URL, Error: multiErr[i].Error()}
+		case err != nil && !isMulti:
+			result = types.TargetResult{Target: feed.URL, Error: err.Error()}
+		}
+		results[i] = result
+		if result.Success && action == BulkActionDelete {
+			h.ErrorWebhooks.Register(feed.URL, "", 0)
+		}
+	}
+
+	if err != nil {
+		middleware.Logger.WithFields(logrus.Fields{
+			"action": action,
+			"feeds":  len(feeds),
+			"error":  err.Error(),
+		}).Warn("Bulk feed write failed")
+	}
+	return results
+}
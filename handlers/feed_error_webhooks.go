@@ -0,0 +1,330 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/Nexora-Open-Source/rss-feed-backend/monitoring"
+	"github.com/Nexora-Open-Source/rss-feed-backend/utils"
+	"github.com/mmcdole/gofeed"
+	"github.com/sirupsen/logrus"
+)
+
+// FeedErrorEventType is the event reported to feed error webhooks
+const FeedErrorEventType = "feed.ingest_failed"
+
+// Ingest stages a feed can fail at
+const (
+	FeedStageFetch = "fetch"
+	FeedStageParse = "parse"
+	FeedStageStore = "store"
+)
+
+// Categories of feed ingest errors reported to error webhooks
+const (
+	FeedErrorGone      = "gone"
+	FeedErrorParked    = "parked"
+	FeedErrorTooLarge  = "too_large"
+	FeedErrorParse     = "parse_error"
+	FeedErrorThrottled = "throttled"
+	FeedErrorTimeout   = "timeout"
+	FeedErrorBlocked   = "blocked"
+	FeedErrorHTTP      = "http_error"
+	FeedErrorNetwork   = "network"
+	FeedErrorStore     = "store_error"
+	FeedErrorUnknown   = "unknown"
+)
+
+// FeedErrorEvent is the JSON body POSTed to a feed's error webhook
+type FeedErrorEvent struct {
+	Event   string `json:"event"`
+	FeedURL string `json:"feed_url"`
+	// Stage is where the last failure happened: fetch, parse, or store
+	Stage    string `json:"stage"`
+	Category string `json:"category"`
+	Error    string `json:"error"`
+	// ConsecutiveFailures is how many ingests of the feed failed in a row
+	ConsecutiveFailures int `json:"consecutive_failures"`
+	// LastSuccessAt is when the feed was last ingested successfully; omitted when not seen since the server started
+	LastSuccessAt *time.Time `json:"last_success_at,omitempty"`
+	FailedAt      time.Time  `json:"failed_at"`
+}
+
+// feedErrorWebhook is the webhook of one feed and its current failure streak
+type feedErrorWebhook struct {
+	url         string
+	after       int
+	failures    int
+	lastSuccess time.Time
+}
+
+/*
+FeedErrorWebhooks notifies feed owners when their feed keeps failing to ingest.
+
+A feed source may name an error webhook, and optionally how many consecutive
+failures trigger it. Each failed fetch, parse, or store of the feed extends its
+streak; when the streak reaches the threshold, the webhook is sent a
+FeedErrorEvent with the error's category and the feed's last success time. The
+webhook is called once per streak, and a successful ingest ends the streak.
+Streaks are kept in memory, so they restart with the server.
+*/
+type FeedErrorWebhooks struct {
+	mu        sync.Mutex
+	client    *http.Client
+	threshold int
+	feeds     map[string]*feedErrorWebhook
+	logger    *logrus.Logger
+	now       func() time.Time
+}
+
+// NewFeedErrorWebhooks calls webhooks through client after threshold consecutive failures, unless a feed sets
+// its own; a threshold of 0 disables error webhooks and returns nil
+func NewFeedErrorWebhooks(client *http.Client, threshold int, logger *logrus.Logger) *FeedErrorWebhooks {
+	if threshold <= 0 {
+		return nil
+	}
+	return &FeedErrorWebhooks{
+		client:    client,
+		threshold: threshold,
+		feeds:     make(map[string]*feedErrorWebhook),
+		logger:    logger,
+		now:       time.Now,
+	}
+}
+
+// Register sets the error webhook of a feed, called after after consecutive failures (0 uses the default);
+// an empty webhook URL removes it
+func (f *FeedErrorWebhooks) Register(feedURL, webhookURL string, after int) {
+	if f == nil {
+		return
+	}
+	feedURL = feedErrorWebhookKey(feedURL)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if webhookURL == "" {
+		delete(f.feeds, feedURL)
+		return
+	}
+	// Re-registering keeps the feed's streak
+	webhook, exists := f.feeds[feedURL]
+	if !exists {
+		webhook = &feedErrorWebhook{}
+		f.feeds[feedURL] = webhook
+	}
+	webhook.url, webhook.after = webhookURL, after
+}
+
+// RecordSuccess ends the feed's failure streak
+func (f *FeedErrorWebhooks) RecordSuccess(feedURL string) {
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if webhook, exists := f.feeds[feedErrorWebhookKey(feedURL)]; exists {
+		webhook.failures = 0
+		webhook.lastSuccess = f.now()
+	}
+}
+
+// RecordFetchFailure extends the feed's failure streak with an error from fetching and parsing it
+func (f *FeedErrorWebhooks) RecordFetchFailure(feedURL string, err error) {
+	stage := FeedStageFetch
+	if errors.Is(err, utils.ErrFeedParse) {
+		stage = FeedStageParse
+	}
+	f.recordFailure(feedURL, stage, err)
+}
+
+// RecordStoreFailure extends the feed's failure streak with an error from storing its items
+func (f *FeedErrorWebhooks) RecordStoreFailure(feedURL string, err error) {
+	f.recordFailure(feedURL, FeedStageStore, err)
+}
+
+// recordFailure counts a failure and calls the feed's webhook in the background once the streak reaches its threshold
+func (f *FeedErrorWebhooks) recordFailure(feedURL, stage string, err error) {
+	if f == nil || err == nil {
+		return
+	}
+	feedURL = feedErrorWebhookKey(feedURL)
+	f.mu.Lock()
+	webhook, exists := f.feeds[feedURL]
+	if !exists {
+		f.mu.Unlock()
+		return
+	}
+	webhook.failures++
+	threshold := webhook.after
+	if threshold <= 0 {
+		threshold = f.threshold
+	}
+	if webhook.failures != threshold {
+		f.mu.Unlock()
+		return
+	}
+	event := FeedErrorEvent{
+		Event:               FeedErrorEventType,
+		FeedURL:             feedURL,
+		Stage:               stage,
+		Category:            feedErrorCategory(stage, err),
+		Error:               err.Error(),
+		ConsecutiveFailures: webhook.failures,
+		FailedAt:            f.now().UTC(),
+	}
+	if !webhook.lastSuccess.IsZero() {
+		lastSuccess := webhook.lastSuccess.UTC()
+		event.LastSuccessAt = &lastSuccess
+	}
+	webhookURL := webhook.url
+	f.mu.Unlock()
+
+	go f.send(webhookURL, event)
+}
+
+// send POSTs an event to a webhook, logging rather than retrying on failure
+func (f *FeedErrorWebhooks) send(webhookURL string, event FeedErrorEvent) {
+	result := "sent"
+	err := f.post(webhookURL, event)
+	if err != nil {
+		result = "failed"
+	}
+	monitoring.RecordFeedErrorWebhook(result)
+
+	fields := logrus.Fields{
+		"url":      event.FeedURL,
+		"webhook":  redactWebhookURL(webhookURL),
+		"stage":    event.Stage,
+		"category": event.Category,
+		"failures": event.ConsecutiveFailures,
+	}
+	if err != nil {
+		fields["error"] = err.Error()
+		f.logger.WithFields(fields).Warn("Failed to call feed error webhook")
+		return
+	}
+	f.logger.WithFields(fields).Info("Called feed error webhook")
+}
+
+// post delivers an event, treating any non-2xx response as a failure
+func (f *FeedErrorWebhooks) post(webhookURL string, event FeedErrorEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded %s", resp.Status)
+	}
+	return nil
+}
+
+// feedErrorCategory classifies an ingest error for feed owners
+func feedErrorCategory(stage string, err error) string {
+	var throttled *utils.ThrottledError
+	var limitErr *JobLimitError
+	var httpErr gofeed.HTTPError
+	var netErr net.Error
+	switch {
+	case errors.Is(err, utils.ErrFeedGone):
+		return FeedErrorGone
+	case errors.Is(err, utils.ErrFeedParked):
+		return FeedErrorParked
+	case errors.Is(err, utils.ErrFeedTooLarge):
+		return FeedErrorTooLarge
+	case errors.Is(err, utils.ErrFeedParse):
+		return FeedErrorParse
+	case errors.As(err, &throttled), errors.Is(err, utils.ErrFeedThrottled), errors.Is(err, ErrIngestThrottled):
+		return FeedErrorThrottled
+	case errors.As(err, &limitErr), errors.Is(err, context.DeadlineExceeded):
+		return FeedErrorTimeout
+	case errors.Is(err, utils.ErrBlockedAddress):
+		return FeedErrorBlocked
+	case errors.As(err, &httpErr):
+		return FeedErrorHTTP
+	case errors.As(err, &netErr):
+		if netErr.Timeout() {
+			return FeedErrorTimeout
+		}
+		return FeedErrorNetwork
+	case stage == FeedStageStore:
+		return FeedErrorStore
+	}
+	return FeedErrorUnknown
+}
+
+// feedErrorWebhookKey keys feeds by their normalized URL, as ingest reports them
+func feedErrorWebhookKey(feedURL string) string {
+	if normalized, err := utils.NormalizeURL(feedURL); err == nil {
+		return normalized
+	}
+	return feedURL
+}
+
+// redactWebhookURL reduces a webhook URL to its scheme and host, since its path and query often carry a secret
+func redactWebhookURL(webhookURL string) string {
+	parsed, err := url.Parse(webhookURL)
+	if err != nil || parsed.Host == "" {
+		return ""
+	}
+	return parsed.Scheme + "://" + parsed.Host
+}
+
+// LoadFeedErrorWebhooks registers the error webhooks of predefined and subscribed feeds
+func (h *Handler) LoadFeedErrorWebhooks(ctx context.Context) error {
+	if h.ErrorWebhooks == nil {
+		return nil
+	}
+	feeds, err := loadPredefinedFeeds(ctx)
+	if err != nil {
+		return err
+	}
+	h.registerFeedErrorWebhooks(feeds)
+
+	if h.DatastoreClient == nil {
+		return nil
+	}
+	var stored []FeedSource
+	if _, err := h.DatastoreClient.GetAll(ctx, datastore.NewQuery(feedSourceKind), &stored); err != nil {
+		return fmt.Errorf("failed to load feed sources: %v", err)
+	}
+	h.registerFeedErrorWebhooks(stored)
+	return nil
+}
+
+// registerFeedErrorWebhooks registers the error webhooks of feeds under their canonical URL, skipping invalid ones
+func (h *Handler) registerFeedErrorWebhooks(feeds []FeedSource) {
+	for _, feed := range feeds {
+		if feed.ErrorWebhook == "" {
+			continue
+		}
+		webhookURL, err := h.validateAndSanitizeURL(feed.ErrorWebhook)
+		if err != nil {
+			h.Logger.WithFields(logrus.Fields{
+				"url":   feed.URL,
+				"error": err.Error(),
+			}).Warn("Skipping invalid feed error webhook")
+			continue
+		}
+		h.ErrorWebhooks.Register(h.Redirects.Resolve(feedErrorWebhookKey(feed.URL)), webhookURL, feed.ErrorWebhookAfter)
+	}
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/Nexora-Open-Source/rss-feed-backend/middleware"
+	"github.com/Nexora-Open-Source/rss-feed-backend/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// Limits on folder paths accepted from clients
+const (
+	maxFolderPathLength = 200
+	maxFolderDepth      = 5
+)
+
+// FeedFolder is a node in the folder tree of feed sources
+type FeedFolder struct {
+	// Name is the last segment of Path; both are empty for the root folder
+	Name    string        `json:"name"`
+	Path    string        `json:"path"`
+	Feeds   []FeedSource  `json:"feeds"`
+	Folders []*FeedFolder `json:"folders"`
+}
+
+// NormalizeFolderPath trims whitespace around each segment of a folder path and drops empty segments,
+// so " Tech / Go/" becomes "Tech/Go"
+func NormalizeFolderPath(path string) string {
+	var segments []string
+	for _, segment := range strings.Split(path, "/") {
+		if segment = strings.TrimSpace(segment); segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// ValidateFolderPath checks a normalized folder path against the length and depth limits
+func ValidateFolderPath(path string) error {
+	if len(path) > maxFolderPathLength {
+		return fmt.Errorf("folder cannot exceed %d characters", maxFolderPathLength)
+	}
+	if path != "" && strings.Count(path, "/")+1 > maxFolderDepth {
+		return fmt.Errorf("folder cannot be nested more than %d levels deep", maxFolderDepth)
+	}
+	return nil
+}
+
+// inFolder reports whether a feed's folder is folder or one of its subfolders
+func inFolder(feedFolder, folder string) bool {
+	return feedFolder == folder || strings.HasPrefix(feedFolder, folder+"/")
+}
+
+/*
+BuildFolderTree arranges feeds into a tree by their folder paths.
+
+Feeds without a folder belong to the root. Within a folder, feeds keep their
+listing order and subfolders are sorted by name.
+*/
+func BuildFolderTree(feeds []FeedSource) *FeedFolder {
+	root := &FeedFolder{Feeds: []FeedSource{}, Folders: []*FeedFolder{}}
+	index := map[string]*FeedFolder{"": root}
+
+	for _, feed := range feeds {
+		folder := root
+		path := ""
+		for _, segment := range strings.Split(NormalizeFolderPath(feed.Folder), "/") {
+			if segment == "" {
+				continue
+			}
+			if path != "" {
+				path += "/"
+			}
+			path += segment
+			child, exists := index[path]
+			if !exists {
+				child = &FeedFolder{Name: segment, Path: path, Feeds: []FeedSource{}, Folders: []*FeedFolder{}}
+				index[path] = child
+				folder.Folders = append(folder.Folders, child)
+			}
+			folder = child
+		}
+		folder.Feeds = append(folder.Feeds, feed)
+	}
+
+	for _, folder := range index {
+		sort.Slice(folder.Folders, func(i, j int) bool {
+			return folder.Folders[i].Name < folder.Folders[j].Name
+		})
+	}
+	return root
+}
+
+// feedSourceHost returns the site host of a feed URL, without a leading "www.", "feeds.", or "rss."
+func feedSourceHost(feedURL string) string {
+	item := utils.FeedItem{Link: feedURL}
+	host := utils.ItemSource(&item)
+	for _, prefix := range []string{"feeds.", "rss."} {
+		host = strings.TrimPrefix(host, prefix)
+	}
+	return host
+}
+
+/*
+folderFilter keeps items published by a feed in folder or one of its subfolders.
+
+Items do not record the feed they came from, so an item belongs to a feed when
+it was published on the feed's site host or one of its subdomains.
+*/
+func folderFilter(feeds []FeedSource, folder string, items []*utils.FeedItem) []*utils.FeedItem {
+	hosts := make(map[string]bool)
+	for _, feed := range feeds {
+		if inFolder(feed.Folder, folder) {
+			if host := feedSourceHost(feed.URL); host != "" {
+				hosts[host] = true
+			}
+		}
+	}
+
+	filtered := make([]*utils.FeedItem, 0, len(items))
+	for _, item := range items {
+		source := utils.ItemSource(item)
+		for host := range hosts {
+			if source == host || strings.HasSuffix(source, "."+host) {
+				filtered = append(filtered, item)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+// @Summary Get feed folders
+// @Description Returns the feed sources arranged into a folder tree, as in OPML outlines. Feeds without a folder are listed at the root.
+// @Tags RSS Feed Operations
+// @Produce json
+// @Success 200 {object} FeedFolder "Root folder"
+// @Failure 500 {object} middleware.APIError "Internal server error"
+// @Router /folders [get]
+func (h *Handler) HandleGetFolders(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Header.Get("X-Request-ID")
+	if requestID == "" {
+		requestID = utils.GenerateRequestID()
+		w.Header().Set("X-Request-ID", requestID)
+	}
+
+	feeds, staleAge, err := h.loadFeedSources(r.Context())
+	if err != nil {
+		middleware.RespondInternalError(w, err, requestID)
+		return
+	}
+	if staleAge > 0 {
+		setDataStaleness(w, "folders", staleAge)
+	}
+	tree := BuildFolderTree(feeds)
+
+	middleware.Log(r.Context()).WithFields(logrus.Fields{
+		"feeds_count":   len(feeds),
+		"folders_count": len(tree.Folders),
+	}).Info("Feed folders retrieved successfully")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(tree)
+}
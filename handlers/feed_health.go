@@ -0,0 +1,281 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/Nexora-Open-Source/rss-feed-backend/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// feedHealthKind is the Datastore kind holding per-feed liveness state
+const feedHealthKind = "FeedHealth"
+
+// Feed statuses reported in feed metadata
+const (
+	FeedStatusActive = "active"
+	FeedStatusStale  = "stale"
+)
+
+// Reasons a feed is considered possibly dead
+const (
+	StaleReasonNoNewItems = "no_new_items"
+	StaleReasonGone       = "gone"
+	StaleReasonParked     = "parked"
+)
+
+// FeedHealth records when a feed last produced new items and whether it looks dead
+type FeedHealth struct {
+	FeedURL       string    `datastore:"feed_url" json:"feed_url"`
+	Status        string    `datastore:"status" json:"status"`
+	Reason        string    `datastore:"reason,noindex" json:"reason,omitempty"`
+	LastFetchAt   time.Time `datastore:"last_fetch_at,noindex" json:"last_fetch_at"`
+	LastNewItemAt time.Time `datastore:"last_new_item_at,noindex" json:"last_new_item_at"`
+	LatestItem    string    `datastore:"latest_item,noindex" json:"-"`
+}
+
+/*
+FeedHealthTracker detects feeds that have possibly died.
+
+A feed is stale when it has not produced a new item within the configured window,
+or when its last fetch returned 410 Gone or landed on a domain parking page. A
+notifier, if set, is called once each time a feed becomes stale.
+*/
+type FeedHealthTracker struct {
+	mu       sync.RWMutex
+	feeds    map[string]*FeedHealth
+	window   time.Duration
+	store    DatastoreClientInterface
+	logger   *logrus.Logger
+	notifier func(FeedHealth)
+	now      func() time.Time
+}
+
+// NewFeedHealthTracker creates a tracker that marks feeds stale after window without new items
+func NewFeedHealthTracker(store DatastoreClientInterface, window time.Duration, logger *logrus.Logger) *FeedHealthTracker {
+	return &FeedHealthTracker{
+		feeds:  make(map[string]*FeedHealth),
+		window: window,
+		store:  store,
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+// SetStaleNotifier sets a callback invoked when a feed becomes stale
+func (t *FeedHealthTracker) SetStaleNotifier(notifier func(FeedHealth)) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.notifier = notifier
+}
+
+// LoadHealth loads stored feed health records from Datastore, replacing the records loaded before
+func (t *FeedHealthTracker) LoadHealth(ctx context.Context) error {
+	if t == nil || t.store == nil {
+		return nil
+	}
+
+	var records []FeedHealth
+	if _, err := t.store.GetAll(ctx, datastore.NewQuery(feedHealthKind), &records); err != nil {
+		return fmt.Errorf("failed to load feed health: %v", err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.feeds = make(map[string]*FeedHealth, len(records))
+	for i := range records {
+		t.feeds[records[i].FeedURL] = &records[i]
+	}
+	return nil
+}
+
+// Status returns the feed's current status and stale reason; unknown feeds return empty strings
+func (t *FeedHealthTracker) Status(feedURL string) (string, string) {
+	if t == nil {
+		return "", ""
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	record, exists := t.feeds[feedURL]
+	if !exists {
+		return "", ""
+	}
+	status, reason := t.evaluate(record)
+	return status, reason
+}
+
+// RecordSuccess records a successful fetch, advancing the last new item time when the feed has new content
+func (t *FeedHealthTracker) RecordSuccess(ctx context.Context, feedURL string, items []*utils.FeedItem) {
+	if t == nil {
+		return
+	}
+
+	now := t.now()
+	latestAt, latestItem := newestItem(items)
+
+	t.mu.Lock()
+	record, exists := t.feeds[feedURL]
+	if !exists {
+		record = &FeedHealth{FeedURL: feedURL, LastNewItemAt: now}
+		t.feeds[feedURL] = record
+	}
+	wasStale := exists && record.Status == FeedStatusStale
+
+	if latestItem != "" && latestItem != record.LatestItem {
+		record.LatestItem = latestItem
+		// Prefer the item's own date, but never move backwards or into the future
+		newItemAt := now
+		if !latestAt.IsZero() && latestAt.Before(now) {
+			newItemAt = latestAt
+		}
+		if !exists || newItemAt.After(record.LastNewItemAt) {
+			record.LastNewItemAt = newItemAt
+		}
+	}
+	record.LastFetchAt = now
+	record.Reason = ""
+	record.Status, record.Reason = t.evaluate(record)
+	snapshot := *record
+	notifier := t.notifier
+	t.mu.Unlock()
+
+	t.persist(ctx, snapshot)
+	if snapshot.Status == FeedStatusStale && !wasStale && notifier != nil {
+		notifier(snapshot)
+	}
+}
+
+// RecordFailure records a failed fetch; only failures that indicate a dead feed change its status
+func (t *FeedHealthTracker) RecordFailure(ctx context.Context, feedURL string, fetchErr error) {
+	if t == nil {
+		return
+	}
+
+	var reason string
+	switch {
+	case errors.Is(fetchErr, utils.ErrFeedGone):
+		reason = StaleReasonGone
+	case errors.Is(fetchErr, utils.ErrFeedParked):
+		reason = StaleReasonParked
+	default:
+		return
+	}
+
+	t.mu.Lock()
+	record, exists := t.feeds[feedURL]
+	if !exists {
+		record = &FeedHealth{FeedURL: feedURL}
+		t.feeds[feedURL] = record
+	}
+	wasStale := record.Status == FeedStatusStale
+	record.LastFetchAt = t.now()
+	record.Status = FeedStatusStale
+	record.Reason = reason
+	snapshot := *record
+	notifier := t.notifier
+	t.mu.Unlock()
+
+	t.persist(ctx, snapshot)
+	if !wasStale && notifier != nil {
+		notifier(snapshot)
+	}
+}
+
+// StaleFeeds returns the health records of all feeds that are currently stale
+func (t *FeedHealthTracker) StaleFeeds() []FeedHealth {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	var stale []FeedHealth
+	for _, record := range t.feeds {
+		if status, reason := t.evaluate(record); status == FeedStatusStale {
+			snapshot := *record
+			snapshot.Status, snapshot.Reason = status, reason
+			stale = append(stale, snapshot)
+		}
+	}
+	return stale
+}
+
+// All returns the health records of every tracked feed, ordered by URL
+func (t *FeedHealthTracker) All() []FeedHealth {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	all := make([]FeedHealth, 0, len(t.feeds))
+	for _, record := range t.feeds {
+		snapshot := *record
+		snapshot.Status, snapshot.Reason = t.evaluate(record)
+		all = append(all, snapshot)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].FeedURL < all[j].FeedURL
+	})
+	return all
+}
+
+// evaluate computes the status of a record; callers must hold the lock
+func (t *FeedHealthTracker) evaluate(record *FeedHealth) (string, string) {
+	if record.Reason == StaleReasonGone || record.Reason == StaleReasonParked {
+		return FeedStatusStale, record.Reason
+	}
+	if t.window > 0 && !record.LastNewItemAt.IsZero() && t.now().Sub(record.LastNewItemAt) > t.window {
+		return FeedStatusStale, StaleReasonNoNewItems
+	}
+	return FeedStatusActive, ""
+}
+
+// persist stores a feed health record, logging rather than failing the fetch on error
+func (t *FeedHealthTracker) persist(ctx context.Context, record FeedHealth) {
+	if t.store == nil {
+		return
+	}
+
+	key := datastore.NameKey(feedHealthKind, record.FeedURL, nil)
+	if _, err := t.store.PutMulti(ctx, []*datastore.Key{key}, []*FeedHealth{&record}); err != nil {
+		t.logger.WithFields(logrus.Fields{
+			"url":   record.FeedURL,
+			"error": err.Error(),
+		}).Warn("Failed to save feed health")
+	}
+}
+
+// newestItem returns the publication time and link of the most recent item
+func newestItem(items []*utils.FeedItem) (time.Time, string) {
+	var latestAt time.Time
+	latestItem := ""
+	for _, item := range items {
+		pubTime, err := utils.ParsePubDate(item.PubDate)
+		if err != nil {
+			continue
+		}
+		if latestItem == "" || pubTime.After(latestAt) {
+			latestAt, latestItem = pubTime, item.Link
+		}
+	}
+
+	// Undated feeds are tracked by their first item
+	if latestItem == "" && len(items) > 0 {
+		latestItem = items[0].Link
+	}
+	return latestAt, latestItem
+}
@@ -1,27 +1,73 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"math"
 	"net/http"
+	"net/url"
 	"os"
+	"strings"
+	"time"
 
+	"cloud.google.com/go/datastore"
 	"github.com/Nexora-Open-Source/rss-feed-backend/middleware"
 	"github.com/Nexora-Open-Source/rss-feed-backend/utils"
 	"github.com/sirupsen/logrus"
 )
 
-// FeedSource represents a predefined RSS feed source
+// feedSourceKind is the Datastore kind holding subscribed feed sources
+const feedSourceKind = "FeedSource"
+
+// FeedSource represents a predefined or subscribed RSS feed source
 type FeedSource struct {
-	Name string `json:"name"`
-	URL  string `json:"url"`
+	Name                  string `json:"name" datastore:"name"`
+	URL                   string `json:"url" datastore:"url"`
+	IconURL               string `json:"icon_url,omitempty" datastore:"icon_url,noindex"`
+	Description           string `json:"description,omitempty" datastore:"description,noindex"`
+	Language              string `json:"language,omitempty" datastore:"language,noindex"`
+	UpdateIntervalMinutes int    `json:"update_interval_minutes,omitempty" datastore:"update_interval_minutes,noindex"`
+	Status                string `json:"status,omitempty" datastore:"-"`
+	StaleReason           string `json:"stale_reason,omitempty" datastore:"-"`
+	// PollIntervalMinutes is the learned interval between polls, and PollYieldRate the chance a poll finds new items
+	PollIntervalMinutes int     `json:"poll_interval_minutes,omitempty" datastore:"-"`
+	PollYieldRate       float64 `json:"poll_yield_rate,omitempty" datastore:"-"`
+	// ItemsIngested counts the feed's items stored so far, and ItemsToday those stored today (UTC)
+	ItemsIngested int64 `json:"items_ingested,omitempty" datastore:"-"`
+	ItemsToday    int64 `json:"items_today,omitempty" datastore:"-"`
+	// Folder is a slash-separated folder path such as "Tech/Go"; empty for feeds at the top level
+	Folder string   `json:"folder,omitempty" datastore:"folder"`
+	Tags   []string `json:"tags,omitempty" datastore:"tags"`
+	// Disabled feeds stay subscribed but are paused
+	Disabled bool `json:"disabled,omitempty" datastore:"disabled"`
+	// ErrorWebhook is called after ErrorWebhookAfter consecutive ingest failures (0 uses the configured default);
+	// feed lists show only its scheme and host
+	ErrorWebhook      string `json:"error_webhook,omitempty" datastore:"error_webhook,noindex"`
+	ErrorWebhookAfter int    `json:"error_webhook_after,omitempty" datastore:"error_webhook_after,noindex"`
+	// SchemaVersion is the layout the source was stored with; see FeedSourceSchemaVersion
+	SchemaVersion int `json:"-" datastore:"schema_version"`
+}
+
+// AddFeedRequest represents the request body for POST /feeds
+type AddFeedRequest struct {
+	URL         string `json:"url" validate:"required"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	Folder      string `json:"folder,omitempty"`
+	// ErrorWebhook is called when the feed fails to ingest ErrorWebhookAfter times in a row
+	ErrorWebhook      string `json:"error_webhook,omitempty"`
+	ErrorWebhookAfter int    `json:"error_webhook_after,omitempty"`
 }
 
-// @Summary Get predefined RSS feed sources
-// @Description Returns a list of predefined RSS feed sources from a JSON file.
+// @Summary Get RSS feed sources
+// @Description Returns the predefined RSS feed sources from a JSON file followed by subscribed sources. When Datastore fails, the subscribed sources last read are listed instead, with an X-Data-Staleness header giving their age in seconds.
 // @Tags RSS Feed Operations
 // @Accept json
 // @Produce json
+// @Param status query string false "Filter by feed status (active, stale)"
 // @Success 200 {array} FeedSource "List of predefined feed sources"
+// @Failure 400 {object} middleware.APIError "Invalid status filter"
 // @Failure 500 {object} middleware.APIError "Internal server error"
 // @Router /feeds [get]
 func (h *Handler) HandleGetFeeds(w http.ResponseWriter, r *http.Request) {
@@ -31,12 +77,55 @@ func (h *Handler) HandleGetFeeds(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("X-Request-ID", requestID)
 	}
 
+	status := r.URL.Query().Get("status")
+	if status != "" && status != FeedStatusActive && status != FeedStatusStale {
+		middleware.RespondBadRequest(w, fmt.Errorf("invalid status filter: must be %q or %q", FeedStatusActive, FeedStatusStale), requestID)
+		return
+	}
+
 	// Log the request
-	middleware.Logger.WithFields(logrus.Fields{
-		"request_id": requestID,
-		"action":     "get_feeds",
+	middleware.Log(r.Context()).WithFields(logrus.Fields{
+		"action": "get_feeds",
+		"status": status,
 	}).Info("Processing feed list request")
 
+	feeds, staleAge, err := h.loadFeedSources(r.Context())
+	if err != nil {
+		middleware.RespondInternalError(w, err, requestID)
+		return
+	}
+	if staleAge > 0 {
+		setDataStaleness(w, "feeds", staleAge)
+	}
+	feeds = filterFeedsByStatus(feeds, status)
+
+	// Log successful completion
+	middleware.Log(r.Context()).WithFields(logrus.Fields{
+		"feeds_count": len(feeds),
+	}).Info("Feed list retrieved successfully")
+
+	// Respond with the list of feeds
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(feeds)
+}
+
+// loadFeedSources returns the predefined feed sources followed by subscribed ones, enriched for display;
+// staleAge is how old the subscribed sources are when they come from the stale fallback, and 0 when current
+func (h *Handler) loadFeedSources(ctx context.Context) ([]FeedSource, time.Duration, error) {
+	feeds, err := loadPredefinedFeeds(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	subscribed, staleAge := h.loadSubscribedFeeds(ctx, feeds)
+	feeds = append(feeds, subscribed...)
+	h.enrichFeedSources(feeds)
+	return feeds, staleAge, nil
+}
+
+// loadPredefinedFeeds reads the predefined feed sources from data/feeds.json, falling back to
+// a built-in list when the file cannot be opened
+func loadPredefinedFeeds(ctx context.Context) ([]FeedSource, error) {
 	// Define the path to the JSON file
 	filePath := "data/feeds.json"
 
@@ -59,48 +148,241 @@ func (h *Handler) HandleGetFeeds(w http.ResponseWriter, r *http.Request) {
 	// Open the JSON file
 	file, err := os.Open(filePath)
 	if err != nil {
-		middleware.Logger.WithFields(logrus.Fields{
-			"request_id": requestID,
-			"file_path":  filePath,
-			"error":      err.Error(),
+		middleware.Log(ctx).WithFields(logrus.Fields{
+			"file_path": filePath,
+			"error":     err.Error(),
 		}).Error("Error opening feeds.json file, using fallback feeds")
 
 		// Fallback to hardcoded feeds if file is not found
-		feeds := []FeedSource{
+		return []FeedSource{
 			{Name: "TechCrunch", URL: "https://techcrunch.com/feed/"},
 			{Name: "BBC News", URL: "http://feeds.bbci.co.uk/news/rss.xml"},
 			{Name: "The Verge", URL: "https://www.theverge.com/rss/index.xml"},
 			{Name: "CNN Top Stories", URL: "http://rss.cnn.com/rss/edition.rss"},
 			{Name: "Hacker News", URL: "https://hnrss.org/frontpage"},
-		}
-
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(feeds)
-		return
+		}, nil
 	}
 	defer file.Close()
 
 	// Decode the JSON data
 	var feeds []FeedSource
 	if err := json.NewDecoder(file).Decode(&feeds); err != nil {
-		middleware.Logger.WithFields(logrus.Fields{
-			"request_id": requestID,
-			"file_path":  filePath,
-			"error":      err.Error(),
+		middleware.Log(ctx).WithFields(logrus.Fields{
+			"file_path": filePath,
+			"error":     err.Error(),
 		}).Error("Error decoding feeds.json file")
+		return nil, err
+	}
+	for i := range feeds {
+		feeds[i].Folder = NormalizeFolderPath(feeds[i].Folder)
+	}
+	return feeds, nil
+}
+
+// enrichFeedSources reports moved feeds under their canonical URL and attaches cached site icons, health, learned poll intervals, and ingest counts;
+// error webhooks are redacted
+func (h *Handler) enrichFeedSources(feeds []FeedSource) {
+	for i := range feeds {
+		feeds[i].URL = h.Redirects.Resolve(feeds[i].URL)
+		feeds[i].ErrorWebhook = redactWebhookURL(feeds[i].ErrorWebhook)
+		if feeds[i].IconURL == "" {
+			feeds[i].IconURL = h.Icons.IconURL(feeds[i].URL)
+		}
+		feeds[i].Status, feeds[i].StaleReason = h.FeedHealth.Status(feeds[i].URL)
+		if interval, yieldRate := h.Polls.LearnedInterval(feeds[i].URL); interval > 0 {
+			feeds[i].PollIntervalMinutes = int(interval.Round(time.Minute) / time.Minute)
+			feeds[i].PollYieldRate = math.Round(yieldRate*100) / 100
+		}
+		if counts, ok := h.IngestCounters.Counts(feeds[i].URL); ok {
+			feeds[i].ItemsIngested, feeds[i].ItemsToday = counts.ItemsIngested, counts.ItemsToday
+		}
+	}
+}
+
+// filterFeedsByStatus keeps feeds with the given status; an empty status keeps all feeds
+func filterFeedsByStatus(feeds []FeedSource, status string) []FeedSource {
+	if status == "" {
+		return feeds
+	}
+
+	filtered := make([]FeedSource, 0, len(feeds))
+	for _, feed := range feeds {
+		if feed.Status == status {
+			filtered = append(filtered, feed)
+		}
+	}
+	return filtered
+}
+
+// loadSubscribedFeeds returns stored feed sources not already in the predefined list; when Datastore
+// fails they come from the stale fallback, with their age
+func (h *Handler) loadSubscribedFeeds(ctx context.Context, predefined []FeedSource) ([]FeedSource, time.Duration) {
+	var stored []FeedSource
+	var staleAge time.Duration
+	if _, err := h.DatastoreClient.GetAll(ctx, datastore.NewQuery(feedSourceKind), &stored); err != nil {
+		remembered, age, found := h.StaleData.RecallFeeds()
+		middleware.Log(ctx).WithFields(logrus.Fields{
+			"error":        err.Error(),
+			"stale_served": found,
+		}).Warn("Failed to load subscribed feed sources")
+		if !found {
+			return nil, 0
+		}
+		stored, staleAge = remembered, age
+	} else {
+		h.StaleData.RememberFeeds(stored)
+	}
+
+	known := make(map[string]bool, len(predefined))
+	for _, feed := range predefined {
+		known[feed.URL] = true
+	}
+
+	var feeds []FeedSource
+	for _, feed := range stored {
+		if !known[feed.URL] {
+			feeds = append(feeds, feed)
+		}
+	}
+	return feeds, staleAge
+}
+
+// @Summary Subscribe to an RSS feed
+// @Description Adds a feed source by URL. The feed is fetched once to fill in its name, description, language, and update frequency when not supplied. An optional error_webhook is POSTed a feed.ingest_failed event once the feed fails to ingest error_webhook_after times in a row.
+// @Tags RSS Feed Operations
+// @Accept json
+// @Produce json
+// @Param request body AddFeedRequest true "Feed subscription request"
+// @Success 201 {object} FeedSource "Subscribed feed source"
+// @Failure 400 {object} middleware.APIError "Bad request"
+// @Failure 502 {object} middleware.APIError "Feed could not be fetched"
+// @Failure 500 {object} middleware.APIError "Internal server error"
+// @Router /feeds [post]
+func (h *Handler) HandleAddFeed(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Header.Get("X-Request-ID")
+	if requestID == "" {
+		requestID = utils.GenerateRequestID()
+		w.Header().Set("X-Request-ID", requestID)
+	}
+
+	var req AddFeedRequest
+	if r.Body == nil {
+		middleware.RespondBadRequest(w, fmt.Errorf("request body is required"), requestID)
+		return
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		middleware.RespondBadRequest(w, fmt.Errorf("invalid request body: %v", err), requestID)
+		return
+	}
+	if req.URL == "" {
+		middleware.RespondBadRequest(w, fmt.Errorf("URL field is required"), requestID)
+		return
+	}
+
+	folder := NormalizeFolderPath(req.Folder)
+	if err := ValidateFolderPath(folder); err != nil {
+		middleware.RespondValidationError(w, err, requestID)
+		return
+	}
+
+	sanitizedURL, err := h.validateAndSanitizeURL(req.URL)
+	if err != nil {
+		middleware.RespondValidationError(w, err, requestID)
+		return
+	}
+	sanitizedURL = h.Redirects.Resolve(sanitizedURL)
+
+	var webhookURL string
+	if req.ErrorWebhook != "" {
+		if webhookURL, err = h.validateAndSanitizeURL(req.ErrorWebhook); err != nil {
+			middleware.RespondValidationError(w, fmt.Errorf("invalid error_webhook: %v", err), requestID)
+			return
+		}
+	}
+	if req.ErrorWebhookAfter < 0 {
+		middleware.RespondValidationError(w, fmt.Errorf("error_webhook_after must not be negative"), requestID)
+		return
+	}
+
+	middleware.Log(r.Context()).WithFields(logrus.Fields{
+		"url":    sanitizedURL,
+		"action": "add_feed",
+	}).Info("Processing feed subscription request")
+
+	ctx := r.Context()
+	if h.Config.SyncFetchTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.Config.SyncFetchTimeout)
+		defer cancel()
+	}
+
+	// Fetch the feed once to discover its metadata
+	result, err := h.fetchFeed(ctx, sanitizedURL)
+	if err != nil {
+		if h.respondSyncContextError(w, ctx, sanitizedURL, requestID) {
+			return
+		}
+		middleware.Log(r.Context()).WithFields(logrus.Fields{
+			"url":   sanitizedURL,
+			"error": err.Error(),
+		}).Error("Failed to fetch feed for subscription")
+		middleware.RespondExternalAPIError(w, err, requestID)
+		return
+	}
+
+	feed := discoverFeedSource(sanitizedURL, result)
+	if name := strings.TrimSpace(req.Name); name != "" {
+		feed.Name = name
+	}
+	if description := strings.TrimSpace(req.Description); description != "" {
+		feed.Description = description
+	}
+	feed.Folder = folder
+	feed.ErrorWebhook, feed.ErrorWebhookAfter = webhookURL, req.ErrorWebhookAfter
+
+	key := datastore.NameKey(feedSourceKind, feed.URL, nil)
+	if _, err := h.DatastoreClient.PutMulti(ctx, []*datastore.Key{key}, []*FeedSource{feed}); err != nil {
+		middleware.Log(r.Context()).WithFields(logrus.Fields{
+			"url":   feed.URL,
+			"error": err.Error(),
+		}).Error("Failed to save feed source")
 		middleware.RespondInternalError(w, err, requestID)
 		return
 	}
 
-	// Log successful completion
-	middleware.Logger.WithFields(logrus.Fields{
-		"request_id":  requestID,
-		"feeds_count": len(feeds),
-	}).Info("Feed list retrieved successfully")
+	middleware.Log(r.Context()).WithFields(logrus.Fields{
+		"url":  feed.URL,
+		"name": feed.Name,
+	}).Info("Feed source subscribed successfully")
+
+	h.ErrorWebhooks.Register(feed.URL, feed.ErrorWebhook, feed.ErrorWebhookAfter)
+	feed.IconURL = h.Icons.IconURL(feed.URL)
+	feed.ErrorWebhook = redactWebhookURL(feed.ErrorWebhook)
 
-	// Respond with the list of feeds
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(feeds)
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(feed)
+}
+
+// discoverFeedSource builds a feed source from the metadata of a fetched feed
+func discoverFeedSource(feedURL string, result *utils.FetchResult) *FeedSource {
+	feed := &FeedSource{
+		Name:        result.Title,
+		URL:         feedURL,
+		Description: result.Description,
+		Language:    result.Language,
+	}
+
+	// Fall back to the host name for feeds without a title
+	if feed.Name == "" {
+		if u, err := url.Parse(feedURL); err == nil {
+			feed.Name = u.Hostname()
+		}
+	}
+
+	if interval := utils.EstimateUpdateInterval(result.Items); interval > 0 {
+		feed.UpdateIntervalMinutes = int(interval.Round(time.Minute) / time.Minute)
+	}
+
+	return feed
 }
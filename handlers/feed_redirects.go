@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/Nexora-Open-Source/rss-feed-backend/utils"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// feedAliasKind is the Datastore kind holding confirmed feed URL aliases
+	feedAliasKind = "FeedAlias"
+	// feedHistoryKind is the Datastore kind holding feed lifecycle events
+	feedHistoryKind = "FeedHistory"
+	// maxAliasDepth bounds alias chain resolution to guard against cycles
+	maxAliasDepth = 5
+
+	// FeedEventURLMigrated is recorded when a feed's URL moves after a confirmed permanent redirect
+	FeedEventURLMigrated = "url_migrated"
+)
+
+// FeedAlias maps a feed URL that permanently redirects to its canonical URL
+type FeedAlias struct {
+	AliasURL      string    `datastore:"alias_url" json:"alias_url"`
+	CanonicalURL  string    `datastore:"canonical_url" json:"canonical_url"`
+	Confirmations int       `datastore:"confirmations,noindex" json:"confirmations"`
+	MigratedAt    time.Time `datastore:"migrated_at,noindex" json:"migrated_at"`
+}
+
+// FeedHistoryEntry records a change in a feed's lifecycle
+type FeedHistoryEntry struct {
+	FeedURL   string    `datastore:"feed_url" json:"feed_url"`
+	Event     string    `datastore:"event" json:"event"`
+	OldURL    string    `datastore:"old_url,noindex" json:"old_url,omitempty"`
+	NewURL    string    `datastore:"new_url,noindex" json:"new_url,omitempty"`
+	Details   string    `datastore:"details,noindex" json:"details,omitempty"`
+	Timestamp time.Time `datastore:"timestamp" json:"timestamp"`
+}
+
+// pendingRedirect counts consecutive permanent redirects to the same target
+type pendingRedirect struct {
+	target string
+	count  int
+}
+
+/*
+FeedRedirectTracker canonicalizes feed URLs that permanently redirect.
+
+A redirect is only trusted after it has been observed on the configured number of
+consecutive fetches; the old URL is then kept as an alias so cache and storage
+lookups made with it resolve to the canonical URL, and the migration is written
+to the feed history. Redirect targets that may not be fetched, such as private
+addresses, are never confirmed, and aliases stored before they were checked are
+not followed to them.
+*/
+type FeedRedirectTracker struct {
+	mu        sync.RWMutex
+	aliases   map[string]string
+	pending   map[string]*pendingRedirect
+	threshold int
+	blocked   []*net.IPNet
+	store     DatastoreClientInterface
+	logger    *logrus.Logger
+}
+
+// NewFeedRedirectTracker creates a tracker that confirms redirects after threshold consecutive observations
+func NewFeedRedirectTracker(store DatastoreClientInterface, threshold int, logger *logrus.Logger) *FeedRedirectTracker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &FeedRedirectTracker{
+		aliases:   make(map[string]string),
+		pending:   make(map[string]*pendingRedirect),
+		threshold: threshold,
+		store:     store,
+		logger:    logger,
+	}
+}
+
+// SetBlockedCIDRs sets the networks redirect targets may not point into, beyond the built-in private ranges
+func (t *FeedRedirectTracker) SetBlockedCIDRs(blocked []*net.IPNet) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.blocked = blocked
+}
+
+// LoadAliases loads confirmed aliases from Datastore, replacing the aliases loaded before
+func (t *FeedRedirectTracker) LoadAliases(ctx context.Context) error {
+	if t == nil || t.store == nil {
+		return nil
+	}
+
+	var aliases []FeedAlias
+	if _, err := t.store.GetAll(ctx, datastore.NewQuery(feedAliasKind), &aliases); err != nil {
+		return fmt.Errorf("failed to load feed aliases: %v", err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.aliases = make(map[string]string, len(aliases))
+	for _, alias := range aliases {
+		t.aliases[alias.AliasURL] = alias.CanonicalURL
+	}
+
+	t.logger.WithField("aliases_count", len(aliases)).Info("Feed URL aliases loaded")
+	return nil
+}
+
+// Resolve returns the canonical URL for a feed URL, following confirmed aliases
+func (t *FeedRedirectTracker) Resolve(feedURL string) string {
+	if t == nil {
+		return feedURL
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	resolved := feedURL
+	for i := 0; i < maxAliasDepth; i++ {
+		next, exists := t.aliases[resolved]
+		if !exists || next == resolved {
+			break
+		}
+		if err := utils.ValidateFetchURL(next, t.blocked); err != nil {
+			t.logger.WithFields(logrus.Fields{
+				"url":         resolved,
+				"redirect_to": next,
+				"error":       err.Error(),
+			}).Warn("Ignoring feed alias to a URL that may not be fetched")
+			break
+		}
+		resolved = next
+	}
+	return resolved
+}
+
+/*
+Observe records the outcome of a fetch and returns the URL the feed should be keyed by.
+
+When the fetch followed only permanent redirects to the same target for the configured
+number of consecutive fetches, the redirect is confirmed: the requested URL becomes an
+alias of the target, the alias and a history entry are persisted, and the target is
+returned. Otherwise the requested URL is returned unchanged. A persistence error is
+returned alongside the canonical URL; the in-memory alias remains in effect.
+*/
+func (t *FeedRedirectTracker) Observe(ctx context.Context, requestedURL string, result *utils.FetchResult) (string, error) {
+	if t == nil || result == nil {
+		return requestedURL, nil
+	}
+
+	t.mu.Lock()
+	if !result.PermanentRedirect || result.FinalURL == requestedURL || utils.ValidateFetchURL(result.FinalURL, t.blocked) != nil {
+		delete(t.pending, requestedURL)
+		t.mu.Unlock()
+		return requestedURL, nil
+	}
+
+	pending, exists := t.pending[requestedURL]
+	if !exists || pending.target != result.FinalURL {
+		pending = &pendingRedirect{target: result.FinalURL}
+		t.pending[requestedURL] = pending
+	}
+	pending.count++
+
+	if pending.count < t.threshold {
+		t.mu.Unlock()
+		t.logger.WithFields(logrus.Fields{
+			"url":           requestedURL,
+			"redirect_to":   result.FinalURL,
+			"confirmations": pending.count,
+			"threshold":     t.threshold,
+		}).Info("Permanent feed redirect observed, awaiting confirmation")
+		return requestedURL, nil
+	}
+
+	// Confirmed; older aliases pointing at the requested URL resolve through the chain
+	delete(t.pending, requestedURL)
+	t.aliases[requestedURL] = result.FinalURL
+	confirmations := pending.count
+	t.mu.Unlock()
+
+	t.logger.WithFields(logrus.Fields{
+		"old_url":       requestedURL,
+		"new_url":       result.FinalURL,
+		"confirmations": confirmations,
+	}).Info("Feed URL migrated after confirmed permanent redirect")
+
+	return result.FinalURL, t.persistMigration(ctx, requestedURL, result.FinalURL, confirmations)
+}
+
+// persistMigration stores the alias and the migration history entry
+func (t *FeedRedirectTracker) persistMigration(ctx context.Context, oldURL, newURL string, confirmations int) error {
+	if t.store == nil {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	alias := []*FeedAlias{{
+		AliasURL:      oldURL,
+		CanonicalURL:  newURL,
+		Confirmations: confirmations,
+		MigratedAt:    now,
+	}}
+	if _, err := t.store.PutMulti(ctx, []*datastore.Key{datastore.NameKey(feedAliasKind, oldURL, nil)}, alias); err != nil {
+		return fmt.Errorf("failed to save feed alias: %v", err)
+	}
+
+	history := []*FeedHistoryEntry{{
+		FeedURL:   newURL,
+		Event:     FeedEventURLMigrated,
+		OldURL:    oldURL,
+		NewURL:    newURL,
+		Details:   fmt.Sprintf("permanent redirect confirmed after %d consecutive fetches", confirmations),
+		Timestamp: now,
+	}}
+	if _, err := t.store.PutMulti(ctx, []*datastore.Key{datastore.IncompleteKey(feedHistoryKind, nil)}, history); err != nil {
+		return fmt.Errorf("failed to save feed history: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"cloud.google.com/go/datastore"
+	"github.com/Nexora-Open-Source/rss-feed-backend/monitoring"
+)
+
+// feedSourceMigrations upgrade a FeedSource loaded at version i to version i+1; see utils.FeedItemSchemaVersion
+var feedSourceMigrations = []func(*FeedSource){
+	// 1: folder paths and tags are stored normalized, so filters can compare them directly
+	func(feed *FeedSource) {
+		feed.Folder = NormalizeFolderPath(feed.Folder)
+		if tags, err := normalizeTags(feed.Tags); err == nil {
+			feed.Tags = tags
+		}
+	},
+}
+
+// FeedSourceSchemaVersion is the schema version at which feed sources are stored
+var FeedSourceSchemaVersion = len(feedSourceMigrations)
+
+// Upgrade migrates the feed source to FeedSourceSchemaVersion, returning the version it started at
+func (feed *FeedSource) Upgrade() int {
+	from := feed.SchemaVersion
+	for feed.SchemaVersion < len(feedSourceMigrations) {
+		feedSourceMigrations[feed.SchemaVersion](feed)
+		feed.SchemaVersion++
+	}
+	return from
+}
+
+// Load implements datastore.PropertyLoadSaver, upgrading sources stored at an older schema version
+func (feed *FeedSource) Load(properties []datastore.Property) error {
+	if err := datastore.LoadStruct(feed, properties); err != nil {
+		return err
+	}
+	if from := feed.Upgrade(); from != feed.SchemaVersion {
+		monitoring.RecordSchemaMigration(feedSourceKind, from)
+	}
+	return nil
+}
+
+// Save implements datastore.PropertyLoadSaver, storing the source at the current schema version
+func (feed *FeedSource) Save() ([]datastore.Property, error) {
+	upgraded := *feed
+	upgraded.Upgrade()
+	return datastore.SaveStruct(&upgraded)
+}
@@ -8,27 +8,43 @@ package handlers
 
 import (
 	"context"
+	"net"
+	"net/http"
 	"time"
 
 	"cloud.google.com/go/datastore"
 	"github.com/Nexora-Open-Source/rss-feed-backend/cache"
+	"github.com/Nexora-Open-Source/rss-feed-backend/middleware"
+	"github.com/Nexora-Open-Source/rss-feed-backend/monitoring"
 	"github.com/Nexora-Open-Source/rss-feed-backend/types"
 	"github.com/Nexora-Open-Source/rss-feed-backend/utils"
 	"github.com/sirupsen/logrus"
 )
 
+// Test mocks of the service interfaces are generated by mockery v2 from .mockery.yaml
+//go:generate mockery
+
 // CacheManagerInterface defines the interface for cache operations
 type CacheManagerInterface interface {
 	GetStoredItems(key string) ([]*utils.FeedItem, bool)
 	SetStoredItems(key string, items []*utils.FeedItem) error
 	GetFeedItems(key string) ([]*utils.FeedItem, bool)
 	SetFeedItems(key string, items []*utils.FeedItem) error
+	InvalidateFeed(url string) error
 }
 
 // AsyncProcessorInterface defines the interface for async processing
 type AsyncProcessorInterface interface {
 	SubmitJob(url, requestID string) (string, error)
+	SubmitTenantJob(url, requestID, tenantID string) (string, error)
+	SubmitTenantJobWithContext(ctx context.Context, url, requestID, tenantID string) (string, error)
+	SubmitTask(operation, requestID string, task JobTask) (string, error)
+	SubmitDurableTask(operation, requestID string, payload []byte) (string, error)
+	SubmitDurableTaskWithContext(ctx context.Context, operation, requestID string, payload []byte) (string, error)
 	GetJobStatus(jobID string) (*types.AsyncJobStatus, bool)
+	GetJobStatusWithContext(ctx context.Context, jobID string) (*types.AsyncJobStatus, bool)
+	WaitForJob(ctx context.Context, jobID string) (*types.AsyncJobStatus, bool)
+	GetJobResult(jobID string) ([]*utils.FeedItem, bool)
 }
 
 // DatastoreReaderInterface defines read operations for datastore
@@ -49,20 +65,220 @@ type DatastoreClientInterface interface {
 	DatastoreWriterInterface
 }
 
+// DatastoreTransactorInterface runs functions in a Datastore transaction; stores without it make no atomic claims
+type DatastoreTransactorInterface interface {
+	RunInTransaction(ctx context.Context, f func(tx *datastore.Transaction) error, opts ...datastore.TransactionOption) (*datastore.Commit, error)
+}
+
+// HandlerConfig holds tunable settings for HTTP handlers
+type HandlerConfig struct {
+	// BlockedCIDRs lists networks feed URLs may not target, in addition to the built-in private ranges
+	BlockedCIDRs []*net.IPNet
+	// SyncFetchTimeout caps how long a synchronous fetch-store may run (0 disables the cap)
+	SyncFetchTimeout time.Duration
+	// AutoAsyncThreshold is how long an auto_async request runs synchronously before becoming a job
+	AutoAsyncThreshold time.Duration
+	// MaxJobStatusWait caps the wait parameter accepted by long-polling job status requests
+	MaxJobStatusWait time.Duration
+	// JobResultTTL is how long completed async job results remain retrievable (0 disables retention)
+	JobResultTTL time.Duration
+	// RedirectConfirmations is how many consecutive permanent redirects confirm a feed URL migration
+	RedirectConfirmations int
+	// IconCacheTTL is how long resolved feed site icons are cached
+	IconCacheTTL time.Duration
+	// IconMaxBytes is the largest site icon that will be accepted
+	IconMaxBytes int64
+	// StaleFeedWindow is how long a feed may go without new items before it is marked stale (0 disables)
+	StaleFeedWindow time.Duration
+	// DuplicateTitleThreshold is the title similarity at which items from different sources are duplicates (0 matches by URL and identical content only)
+	DuplicateTitleThreshold float64
+	// DuplicateWindowSize is how many recent items new items are compared against for duplicates
+	DuplicateWindowSize int
+	// StoryTitleThreshold is the title similarity at which items are grouped into the same story
+	StoryTitleThreshold float64
+	// StoryWindow is the default look-back and maximum spacing of related items in a story
+	StoryWindow time.Duration
+	// RankingWeights sets how much each signal contributes to top stories scores
+	RankingWeights utils.RankingWeights
+	// SourceWeights scales the source signal of items by publishing domain (unlisted sources count as 1)
+	SourceWeights map[string]float64
+	// KeywordBoosts adds to the score of items whose title or description mentions a keyword
+	KeywordBoosts map[string]float64
+	// PollPolicy bounds and targets the poll intervals learned from how often polls find new items
+	PollPolicy utils.PollPolicy
+	// RetryPolicy bounds how long fetches wait after a feed host responds 429 or 503, and how often jobs are rescheduled
+	RetryPolicy utils.RetryPolicy
+	// StoreWorkers is how many async workers save fetched feeds (0 matches the fetch workers)
+	StoreWorkers int
+	// StoreQueueSize is how many fetched feeds may wait for a store worker before fetch workers block (0 matches the job queue)
+	StoreQueueSize int
+	// SmallFeedConcurrency is how many small-feed jobs each async worker may run alongside its current job (0 disables)
+	SmallFeedConcurrency int
+	// SmallFeedMaxItems is the item count below which a feed's last poll marks it small
+	SmallFeedMaxItems int
+	// IngestTenantRate is how many entities per second each tenant may write to Datastore (0 disables the throttle)
+	IngestTenantRate float64
+	// IngestGlobalRate is how many entities per second all tenants together may write, shared evenly (0 is unbounded)
+	IngestGlobalRate float64
+	// IngestBurst is how many entities a tenant may write at once before being throttled
+	IngestBurst int
+	// IngestMaxQueued is how many entities a tenant may have waiting for budget before writes are rejected (0 is unbounded)
+	IngestMaxQueued int
+	// UserDataDeadline is how long after a request a user's data must be deleted before the deletion job fails
+	UserDataDeadline time.Duration
+	// UsageAnalyticsDays is how many days of aggregated usage analytics are kept (0 disables analytics)
+	UsageAnalyticsDays int
+	// DefaultTimezone is the timezone of date filters without a UTC offset when the caller names none
+	DefaultTimezone *time.Location
+	// ItemRevisionRetention is how long replaced item versions are kept for as_of queries
+	ItemRevisionRetention time.Duration
+	// OverflowMaxJobs is how many fetch and bulk feed jobs turned away by backpressure are held in a persistent queue (0 rejects them)
+	OverflowMaxJobs int
+	// JobStatusRetention is how long job statuses, in memory or archived, can be looked up
+	JobStatusRetention time.Duration
+	// JobStatusMaxEntries is how many job statuses are kept in memory before finished ones are archived
+	JobStatusMaxEntries int
+	// JobMaxDuration is how long a feed job may spend fetching and parsing before the watchdog stops it (0 is unbounded)
+	JobMaxDuration time.Duration
+	// JobMaxFeedBytes is the largest feed document a job may fetch (0 is unbounded)
+	JobMaxFeedBytes int64
+	// JobOverBudgetAlerts is how many jobs for one feed the watchdog stops in a row before alerting (0 never alerts)
+	JobOverBudgetAlerts int
+	// CanaryURL is where the canary fetches the server's own canary feed (empty disables the canary)
+	CanaryURL string
+	// CanaryMaxLatency is how long a canary run may take to ingest the canary feed before it fails
+	CanaryMaxLatency time.Duration
+	// ItemSnapshots stores a compressed copy of each stored item's content, addressed by content hash
+	ItemSnapshots bool
+	// BanRules are the rules that temporarily ban misbehaving clients (none disables bans)
+	BanRules []BanRule
+	// BanWindow is how far back a client's responses count toward a ban rule's threshold
+	BanWindow time.Duration
+	// BanDuration is how long a banned client is refused
+	BanDuration time.Duration
+	// TrustedProxies are the proxies whose X-Forwarded-For entries identify clients for bans
+	TrustedProxies []*net.IPNet
+	// DefaultPageSize is how many items /items returns when no limit is given
+	DefaultPageSize int
+	// MaxPageSize is the largest limit /items accepts; larger limits are refused (at most MaxItemsPageSize)
+	MaxPageSize int
+	// StaleFallbackMaxAge is how old remembered results may be and still be served when Datastore fails (0 disables the fallback)
+	StaleFallbackMaxAge time.Duration
+	// StaleFallbackMaxEntries is how many item queries are remembered for the stale fallback
+	StaleFallbackMaxEntries int
+	// ErrorWebhookThreshold is how many consecutive ingest failures call a feed's error webhook, unless the feed sets its own (0 disables error webhooks)
+	ErrorWebhookThreshold int
+	// ReadOnly marks this instance as a read replica; mutations are rejected before reaching handlers
+	ReadOnly bool
+}
+
+// DefaultHandlerConfig returns the handler settings used when none are configured
+func DefaultHandlerConfig() HandlerConfig {
+	return HandlerConfig{
+		SyncFetchTimeout:        30 * time.Second,
+		AutoAsyncThreshold:      5 * time.Second,
+		MaxJobStatusWait:        30 * time.Second,
+		JobResultTTL:            time.Hour,
+		RedirectConfirmations:   3,
+		IconCacheTTL:            24 * time.Hour,
+		IconMaxBytes:            100 * 1024,
+		StaleFeedWindow:         14 * 24 * time.Hour,
+		DuplicateTitleThreshold: 0.8,
+		DuplicateWindowSize:     5000,
+		StoryTitleThreshold:     0.5,
+		StoryWindow:             48 * time.Hour,
+		RankingWeights:          utils.DefaultRankingWeights(),
+		PollPolicy:              utils.DefaultPollPolicy(),
+		RetryPolicy:             utils.DefaultRetryPolicy(),
+		StoreWorkers:            3,
+		StoreQueueSize:          50,
+		SmallFeedConcurrency:    4,
+		SmallFeedMaxItems:       defaultSmallFeedMaxItems,
+		IngestTenantRate:        100,
+		IngestGlobalRate:        500,
+		IngestBurst:             500,
+		IngestMaxQueued:         10000,
+		UserDataDeadline:        time.Hour,
+		DefaultTimezone:         time.UTC,
+		ItemRevisionRetention:   defaultItemRevisionRetention,
+		JobStatusRetention:      defaultJobStatusRetention,
+		JobStatusMaxEntries:     defaultMaxJobStatuses,
+		JobMaxDuration:          defaultJobMaxDuration,
+		JobMaxFeedBytes:         defaultJobMaxFeedBytes,
+		JobOverBudgetAlerts:     3,
+		DefaultPageSize:         defaultItemsPageSize,
+		MaxPageSize:             MaxItemsPageSize,
+		StaleFallbackMaxAge:     24 * time.Hour,
+		StaleFallbackMaxEntries: 500,
+		ErrorWebhookThreshold:   3,
+	}
+}
+
 // Handler contains all service dependencies for HTTP handlers
 type Handler struct {
 	DatastoreClient DatastoreClientInterface
 	CacheManager    CacheManagerInterface
 	Logger          *logrus.Logger
 	AsyncProcessor  AsyncProcessorInterface
+	Redirects       *FeedRedirectTracker
+	Icons           *utils.FaviconResolver
+	FeedHealth      *FeedHealthTracker
+	Clusters        *ItemClusterer
+	Scorer          utils.ItemScorer
+	MuteRules       *MuteRuleStore
+	Polls           *PollScheduler
+	IngestThrottle  *IngestThrottle
+	IngestCounters  *IngestCounters
+	// FeedClient fetches feeds, refusing private and blocked addresses on every redirect hop; nil uses the default safe client
+	FeedClient *http.Client
+	// Overflow holds fetch and bulk feed jobs turned away by a full job queue; nil when disabled
+	Overflow *OverflowQueue
+	// Watchdog stops feed jobs that exceed their budgets
+	Watchdog *JobWatchdog
+	// Canary checks the ingest pipeline end to end; nil when disabled
+	Canary *Canary
+	// UsageAnalytics aggregates usage from consenting callers; nil when analytics are disabled
+	UsageAnalytics *UsageAnalytics
+	// Snapshots keeps the content of stored items for rendering without refetching; nil when disabled
+	Snapshots *SnapshotStore
+	// ClientBans refuses clients that keep breaking ban rules; nil when bans are disabled
+	ClientBans *ClientBans
+	// WarmUp preloads hot data at startup; readiness waits for it, and nil skips it
+	WarmUp *WarmUp
+	// StaleData serves the last good results of read endpoints while Datastore fails; nil when disabled
+	StaleData *StaleFallback
+	// ErrorWebhooks notifies feed owners when their feed keeps failing to ingest; nil when disabled
+	ErrorWebhooks *FeedErrorWebhooks
+	// Alerts supplies active alerts to the admin overview; nil reports none
+	Alerts *monitoring.AlertManager
+	// RecentErrors keeps the latest error log entries for diagnostics; nil reports none
+	RecentErrors *middleware.RecentErrors
+	// DiagnosticsConfig is the effective configuration, with secrets removed, reported by diagnostics
+	DiagnosticsConfig interface{}
+	Config            HandlerConfig
 }
 
 // NewHandler creates a new handler instance with injected dependencies
 func NewHandler(datastoreClient *datastore.Client, cacheManager *cache.CacheManager, logger *logrus.Logger) *Handler {
+	return NewHandlerWithConfig(datastoreClient, cacheManager, logger, DefaultHandlerConfig())
+}
+
+// NewHandlerWithConfig creates a new handler instance with injected dependencies and settings
+func NewHandlerWithConfig(datastoreClient *datastore.Client, cacheManager *cache.CacheManager, logger *logrus.Logger, config HandlerConfig) *Handler {
 	// Default performance settings for backward compatibility
-	asyncProcessor := NewAsyncProcessor(
-		3,             // workers
-		50,            // queueSize
+	workers, queueSize := 3, 50
+	storeWorkers, storeQueueSize := config.StoreWorkers, config.StoreQueueSize
+	if storeWorkers <= 0 {
+		storeWorkers = workers
+	}
+	if storeQueueSize <= 0 {
+		storeQueueSize = queueSize
+	}
+	asyncProcessor := NewAsyncProcessorWithStorePool(
+		workers,
+		queueSize,
+		storeWorkers,
+		storeQueueSize,
 		true,          // backpressureEnabled
 		0.8,           // rejectThreshold (80%)
 		5*time.Second, // waitTimeout
@@ -70,12 +286,121 @@ func NewHandler(datastoreClient *datastore.Client, cacheManager *cache.CacheMana
 		datastoreClient,
 		cacheManager,
 	)
-	return &Handler{
-		DatastoreClient: datastoreClient,
+	asyncProcessor.SetResultTTL(config.JobResultTTL)
+	feedClient := utils.NewSafeHTTPClient(0, config.BlockedCIDRs)
+	asyncProcessor.SetFeedClient(feedClient, config.BlockedCIDRs)
+	if config.CanaryURL != "" {
+		// The canary feed is served by this server, usually on a private address, and only it writes the canary item
+		asyncProcessor.SetCanaryFeed(config.CanaryURL)
+	}
+
+	// Trackers treat a nil store as in-memory only
+	var store DatastoreClientInterface
+	if datastoreClient != nil {
+		store = withFaultInjection(datastoreClient)
+	}
+	redirects := NewFeedRedirectTracker(store, config.RedirectConfirmations, logger)
+	redirects.SetBlockedCIDRs(config.BlockedCIDRs)
+	asyncProcessor.SetRedirectTracker(redirects)
+	feedHealth := NewFeedHealthTracker(store, config.StaleFeedWindow, logger)
+	asyncProcessor.SetFeedHealthTracker(feedHealth)
+	clusters := NewItemClusterer(store, config.DuplicateWindowSize, config.DuplicateTitleThreshold)
+	asyncProcessor.SetItemClusterer(clusters)
+	muteRules := NewMuteRuleStore(store, logger)
+	asyncProcessor.SetMuteRules(muteRules)
+	polls := NewPollScheduler(store, config.PollPolicy, logger)
+	asyncProcessor.SetPollScheduler(polls)
+	asyncProcessor.SetRetryPolicy(config.RetryPolicy)
+	asyncProcessor.SetSmallFeedPipelining(config.SmallFeedConcurrency, config.SmallFeedMaxItems)
+	ingestThrottle := NewIngestThrottle(config.IngestTenantRate, config.IngestGlobalRate, config.IngestBurst, config.IngestMaxQueued)
+	asyncProcessor.SetIngestThrottle(ingestThrottle)
+	ingestCounters := NewIngestCounters(store, logger)
+	asyncProcessor.SetIngestCounters(ingestCounters)
+	// Replicas take no jobs, so only the writer loads and drains the overflow queue
+	var overflow *OverflowQueue
+	if !config.ReadOnly {
+		overflow = NewOverflowQueue(store, config.OverflowMaxJobs, logger)
+	}
+	asyncProcessor.SetOverflowQueue(overflow)
+	watchdog := NewJobWatchdog(config.JobMaxDuration, config.JobMaxFeedBytes, config.JobOverBudgetAlerts)
+	asyncProcessor.SetJobWatchdog(watchdog)
+	var snapshots *SnapshotStore
+	if config.ItemSnapshots {
+		snapshots = NewSnapshotStore(store, logger)
+	}
+	asyncProcessor.SetSnapshotStore(snapshots)
+	errorWebhooks := NewFeedErrorWebhooks(utils.NewSafeHTTPClient(10*time.Second, config.BlockedCIDRs), config.ErrorWebhookThreshold, logger)
+	asyncProcessor.SetErrorWebhooks(errorWebhooks)
+	asyncProcessor.SetJobStatusLimits(config.JobStatusRetention, config.JobStatusMaxEntries, NewJobStatusArchive(store, logger))
+	if cacheManager != nil {
+		// Cache fetched feeds until they are due to be polled again
+		cacheManager.SetFeedTTLOverride(polls.NextInterval)
+	}
+
+	handler := &Handler{
+		DatastoreClient: store,
 		CacheManager:    cacheManager,
 		Logger:          logger,
 		AsyncProcessor:  asyncProcessor,
+		Redirects:       redirects,
+		FeedClient:      feedClient,
+		FeedHealth:      feedHealth,
+		Clusters:        clusters,
+		MuteRules:       muteRules,
+		Polls:           polls,
+		IngestThrottle:  ingestThrottle,
+		IngestCounters:  ingestCounters,
+		Overflow:        overflow,
+		Watchdog:        watchdog,
+		Snapshots:       snapshots,
+		UsageAnalytics:  NewUsageAnalytics(config.UsageAnalyticsDays),
+		ClientBans:      NewClientBans(config.BanRules, config.BanWindow, config.BanDuration, config.TrustedProxies, logger),
+		StaleData:       NewStaleFallback(config.StaleFallbackMaxAge, config.StaleFallbackMaxEntries),
+		ErrorWebhooks:   errorWebhooks,
+		Scorer:          utils.NewWeightedScorer(config.RankingWeights, config.SourceWeights, config.KeywordBoosts),
+		Icons:           utils.NewFaviconResolver(utils.NewSafeHTTPClient(10*time.Second, config.BlockedCIDRs), config.IconCacheTTL, config.IconMaxBytes),
+		Config:          config,
+	}
+
+	// The cache manager is left out rather than passed as a typed nil
+	var canaryCache CacheManagerInterface
+	if cacheManager != nil {
+		canaryCache = cacheManager
+	}
+	handler.Canary = NewCanary(config.CanaryURL, config.CanaryMaxLatency, asyncProcessor, store, canaryCache, logger)
+
+	// Bulk feed jobs are rebuilt from their request when they wait in the overflow queue
+	for _, action := range []string{BulkActionEnable, BulkActionDisable, BulkActionDelete, BulkActionRetag} {
+		asyncProcessor.SetTaskFactory("bulk_"+action, handler.bulkFeedTaskFromPayload)
+	}
+	return handler
+}
+
+// RefreshReplicaState reloads the state read replicas serve but only the writer changes: mute rules, ingest counters,
+// feed URL aliases, and feed health. Each part that fails to load is logged and keeps its previous state.
+func (h *Handler) RefreshReplicaState(ctx context.Context) {
+	loaders := []struct {
+		name string
+		load func(context.Context) error
+	}{
+		{"mute rules", h.MuteRules.LoadRules},
+		{"ingest counters", h.IngestCounters.LoadCounts},
+		{"feed URL aliases", h.Redirects.LoadAliases},
+		{"feed health", h.FeedHealth.LoadHealth},
+	}
+	for _, loader := range loaders {
+		if err := loader.load(ctx); err != nil {
+			h.Logger.WithError(err).Warn("Failed to refresh " + loader.name)
+		}
+	}
+}
+
+// fetchFeed fetches and parses a feed through the handler's feed client
+func (h *Handler) fetchFeed(ctx context.Context, feedURL string) (*utils.FetchResult, error) {
+	if h.FeedClient == nil {
+		return utils.FetchRSSFeedResult(ctx, feedURL)
 	}
+	return utils.FetchRSSFeedResultWithClient(ctx, h.FeedClient, feedURL, 0)
 }
 
 // DatastoreService provides datastore operations
@@ -3,96 +3,29 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"cloud.google.com/go/datastore"
+	"github.com/Nexora-Open-Source/rss-feed-backend/cache"
 	"github.com/Nexora-Open-Source/rss-feed-backend/middleware"
+	"github.com/Nexora-Open-Source/rss-feed-backend/monitoring"
 	"github.com/Nexora-Open-Source/rss-feed-backend/types"
 	"github.com/Nexora-Open-Source/rss-feed-backend/utils"
+	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
-// MockDatastoreClient is a mock for datastore.Client
-type MockDatastoreClient struct {
-	mock.Mock
-}
-
-// GetAll mocks the GetAll method
-func (m *MockDatastoreClient) GetAll(ctx context.Context, q *datastore.Query, dst interface{}) ([]*datastore.Key, error) {
-	args := m.Called(ctx, q, dst)
-	return args.Get(0).([]*datastore.Key), args.Error(1)
-}
-
-// PutMulti mocks the PutMulti method
-func (m *MockDatastoreClient) PutMulti(ctx context.Context, keys []*datastore.Key, src interface{}) ([]*datastore.Key, error) {
-	args := m.Called(ctx, keys, src)
-	return args.Get(0).([]*datastore.Key), args.Error(1)
-}
-
-// Get mocks the Get method
-func (m *MockDatastoreClient) Get(ctx context.Context, key *datastore.Key, dst interface{}) error {
-	args := m.Called(ctx, key, dst)
-	return args.Error(0)
-}
-
-// DeleteMulti mocks the DeleteMulti method
-func (m *MockDatastoreClient) DeleteMulti(ctx context.Context, keys []*datastore.Key) error {
-	args := m.Called(ctx, keys)
-	return args.Error(0)
-}
-
-// MockCacheManager is a mock for cache.CacheManager
-type MockCacheManager struct {
-	mock.Mock
-}
-
-// GetStoredItems mocks the GetStoredItems method
-func (m *MockCacheManager) GetStoredItems(key string) ([]*utils.FeedItem, bool) {
-	args := m.Called(key)
-	return args.Get(0).([]*utils.FeedItem), args.Bool(1)
-}
-
-// SetStoredItems mocks the SetStoredItems method
-func (m *MockCacheManager) SetStoredItems(key string, items []*utils.FeedItem) error {
-	args := m.Called(key, items)
-	return args.Error(0)
-}
-
-// GetFeedItems mocks the GetFeedItems method
-func (m *MockCacheManager) GetFeedItems(key string) ([]*utils.FeedItem, bool) {
-	args := m.Called(key)
-	return args.Get(0).([]*utils.FeedItem), args.Bool(1)
-}
-
-// SetFeedItems mocks the SetFeedItems method
-func (m *MockCacheManager) SetFeedItems(key string, items []*utils.FeedItem) error {
-	args := m.Called(key, items)
-	return args.Error(0)
-}
-
-// MockAsyncProcessor is a mock for AsyncProcessor
-type MockAsyncProcessor struct {
-	mock.Mock
-}
-
-// SubmitJob mocks the SubmitJob method
-func (m *MockAsyncProcessor) SubmitJob(url, requestID string) (string, error) {
-	args := m.Called(url, requestID)
-	return args.String(0), args.Error(1)
-}
-
-// GetJobStatus mocks the GetJobStatus method
-func (m *MockAsyncProcessor) GetJobStatus(jobID string) (*types.AsyncJobStatus, bool) {
-	args := m.Called(jobID)
-	return args.Get(0).(*types.AsyncJobStatus), args.Bool(1)
-}
-
 func setupTestHandler(t *testing.T) (*Handler, *MockDatastoreClient, *MockCacheManager, *MockAsyncProcessor) {
 	mockDatastore := &MockDatastoreClient{}
 	mockCache := &MockCacheManager{}
@@ -172,8 +105,55 @@ func TestHandleReadinessCheck(t *testing.T) {
 	assert.Equal(t, "ready", response["status"])
 }
 
+func TestStartupWarmUpGatesReadiness(t *testing.T) {
+	handler, mockDatastore, mockCache, _ := setupTestHandler(t)
+	handler.WarmUp = NewWarmUp(2)
+	handler.IngestCounters = NewIngestCounters(nil, middleware.Logger)
+	handler.IngestCounters.Apply(context.Background(), "https://busy.example.com/feed.xml", []IngestBatch{{Token: "b1", Items: 5}})
+
+	mockDatastore.On("GetAll", mock.Anything, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			if dst, ok := args.Get(2).(*[]FeedSource); ok {
+				*dst = []FeedSource{{Name: "Busy", URL: "https://busy.example.com/feed.xml"}}
+			}
+		}).
+		Return([]*datastore.Key{}, nil)
+	mockCache.On("SetStoredItems", mock.Anything, mock.Anything).Return(nil)
+
+	// Not ready while warming up
+	w := httptest.NewRecorder()
+	handler.HandleReadinessCheck(w, httptest.NewRequest("GET", "/health/ready", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	report := handler.RunWarmUp(context.Background())
+	assert.Equal(t, 3, report.Pages)
+	assert.Equal(t, 2, report.WarmedFeeds)
+	assert.Zero(t, report.FailedPages)
+	assert.False(t, report.TimedOut)
+
+	// The default page and the busiest feeds, predefined feeds breaking ties, are cached
+	page := ItemsQueryParams{PaginationParams: PaginationParams{Limit: defaultItemsPageSize}}
+	mockCache.AssertCalled(t, "SetStoredItems", itemsCacheKey(page), mock.Anything)
+	for _, source := range []string{"https://busy.example.com", "https://techcrunch.com"} {
+		page.Source = source
+		mockCache.AssertCalled(t, "SetStoredItems", itemsCacheKey(page), mock.Anything)
+	}
+
+	w = httptest.NewRecorder()
+	handler.HandleReadinessCheck(w, httptest.NewRequest("GET", "/health/ready", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
 func TestHandleGetFeeds(t *testing.T) {
-	handler, _, _, _ := setupTestHandler(t)
+	handler, mockDatastore, _, _ := setupTestHandler(t)
+
+	// Subscribed feeds are listed after the predefined ones
+	mockDatastore.On("GetAll", mock.Anything, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			dst := args.Get(2).(*[]FeedSource)
+			*dst = []FeedSource{{Name: "Subscribed", URL: "https://subscribed.example.com/feed.xml"}}
+		}).
+		Return([]*datastore.Key{}, nil)
 
 	req := httptest.NewRequest("GET", "/feeds", nil)
 	w := httptest.NewRecorder()
@@ -186,6 +166,192 @@ func TestHandleGetFeeds(t *testing.T) {
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	require.NoError(t, err)
 	assert.NotEmpty(t, response)
+	assert.Equal(t, "Subscribed", response[len(response)-1].Name)
+}
+
+func TestHandleGetFeedsStatusFilter(t *testing.T) {
+	handler, mockDatastore, _, _ := setupTestHandler(t)
+	handler.FeedHealth = NewFeedHealthTracker(nil, 24*time.Hour, middleware.Logger)
+	handler.FeedHealth.RecordFailure(context.Background(), "https://subscribed.example.com/feed.xml",
+		fmt.Errorf("%w: http error: 410 Gone", utils.ErrFeedGone))
+
+	mockDatastore.On("GetAll", mock.Anything, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			dst := args.Get(2).(*[]FeedSource)
+			*dst = []FeedSource{{Name: "Subscribed", URL: "https://subscribed.example.com/feed.xml"}}
+		}).
+		Return([]*datastore.Key{}, nil)
+
+	req := httptest.NewRequest("GET", "/feeds?status=stale", nil)
+	w := httptest.NewRecorder()
+
+	handler.HandleGetFeeds(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response []FeedSource
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response, 1)
+	assert.Equal(t, FeedStatusStale, response[0].Status)
+	assert.Equal(t, StaleReasonGone, response[0].StaleReason)
+
+	req = httptest.NewRequest("GET", "/feeds?status=dead", nil)
+	w = httptest.NewRecorder()
+	handler.HandleGetFeeds(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestFeedHealthTrackerStaleness(t *testing.T) {
+	tracker := NewFeedHealthTracker(nil, 24*time.Hour, logrus.New())
+	now := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+	tracker.now = func() time.Time { return now }
+
+	var notified []FeedHealth
+	tracker.SetStaleNotifier(func(health FeedHealth) { notified = append(notified, health) })
+
+	feedURL := "https://example.com/feed.xml"
+	status, _ := tracker.Status(feedURL)
+	assert.Empty(t, status)
+
+	// A recent item keeps the feed active
+	tracker.RecordSuccess(context.Background(), feedURL, []*utils.FeedItem{
+		{Link: "https://example.com/1", PubDate: "2024-01-10T06:00:00Z"},
+	})
+	status, _ = tracker.Status(feedURL)
+	assert.Equal(t, FeedStatusActive, status)
+
+	// Two days later with the same items the feed is stale and the notifier fires once
+	now = now.Add(48 * time.Hour)
+	for i := 0; i < 2; i++ {
+		tracker.RecordSuccess(context.Background(), feedURL, []*utils.FeedItem{
+			{Link: "https://example.com/1", PubDate: "2024-01-10T06:00:00Z"},
+		})
+	}
+	status, reason := tracker.Status(feedURL)
+	assert.Equal(t, FeedStatusStale, status)
+	assert.Equal(t, StaleReasonNoNewItems, reason)
+	require.Len(t, notified, 1)
+	assert.Len(t, tracker.StaleFeeds(), 1)
+
+	// A new item revives it
+	tracker.RecordSuccess(context.Background(), feedURL, []*utils.FeedItem{
+		{Link: "https://example.com/2", PubDate: "2024-01-12T11:00:00Z"},
+		{Link: "https://example.com/1", PubDate: "2024-01-10T06:00:00Z"},
+	})
+	status, _ = tracker.Status(feedURL)
+	assert.Equal(t, FeedStatusActive, status)
+
+	// Ordinary fetch errors do not mark a feed dead
+	tracker.RecordFailure(context.Background(), feedURL, fmt.Errorf("connection reset"))
+	status, _ = tracker.Status(feedURL)
+	assert.Equal(t, FeedStatusActive, status)
+
+	tracker.RecordFailure(context.Background(), feedURL, fmt.Errorf("%w: redirected", utils.ErrFeedParked))
+	status, reason = tracker.Status(feedURL)
+	assert.Equal(t, FeedStatusStale, status)
+	assert.Equal(t, StaleReasonParked, reason)
+	assert.Len(t, notified, 2)
+}
+
+func TestHandleAddFeedValidation(t *testing.T) {
+	handler, _, _, _ := setupTestHandler(t)
+
+	bodies := []string{"invalid json", `{}`, `{"url":"http://10.0.0.1/feed.xml"}`}
+	for _, body := range bodies {
+		req := httptest.NewRequest("POST", "/feeds", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		handler.HandleAddFeed(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+}
+
+func TestHandleBulkFeeds(t *testing.T) {
+	handler, mockDatastore, _, mockAsync := setupTestHandler(t)
+
+	bodies := []string{
+		`{"action":"archive","urls":["https://a.example.com/feed.xml"]}`,
+		`{"action":"disable"}`,
+		`{"action":"disable","urls":["https://a.example.com/feed.xml"],"filter":{"folder":"Tech"}}`,
+		`{"action":"delete","filter":{}}`,
+		`{"action":"enable","urls":["https://a.example.com/feed.xml"],"tags":["tech"]}`,
+	}
+	for _, body := range bodies {
+		req := httptest.NewRequest("POST", "/feeds/bulk", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		handler.HandleBulkFeeds(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+
+	var payload []byte
+	mockAsync.On("SubmitDurableTaskWithContext", mock.Anything, "bulk_disable", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { payload = args.Get(3).([]byte) }).
+		Return("job_bulk", nil)
+	mockAsync.On("GetJobStatus", "job_bulk").Return(&types.AsyncJobStatus{JobID: "job_bulk", Status: "pending"}, true)
+
+	req := httptest.NewRequest("POST", "/feeds/bulk", strings.NewReader(`{"action":"disable","filter":{"folder":"Tech"}}`))
+	w := httptest.NewRecorder()
+	handler.HandleBulkFeeds(w, req)
+	require.Equal(t, http.StatusAccepted, w.Code)
+	var response FetchResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "job_bulk", response.JobID)
+
+	// The job disables only the feeds the filter selects when it runs
+	mockDatastore.On("GetAll", mock.Anything, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			dst := args.Get(2).(*[]FeedSource)
+			*dst = []FeedSource{
+				{Name: "Go Blog", URL: "https://go.dev/blog/feed.atom", Folder: "Tech/Go"},
+				{Name: "World", URL: "https://news.example.com/feed.xml", Folder: "News"},
+			}
+		}).
+		Return([]*datastore.Key{}, nil)
+	mockDatastore.On("PutMulti", mock.Anything, mock.Anything, mock.MatchedBy(func(src []*FeedSource) bool {
+		return len(src) == 1 && src[0].URL == "https://go.dev/blog/feed.atom" && src[0].Disabled
+	})).Return([]*datastore.Key{}, nil)
+
+	// The task is rebuilt from the submitted payload, as it would be after waiting in the overflow queue
+	task, err := handler.bulkFeedTaskFromPayload(payload)
+	require.NoError(t, err)
+	results, err := task(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []types.TargetResult{{Target: "https://go.dev/blog/feed.atom", Success: true}}, results)
+
+	// Feeds named by URL that are not subscribed fail individually
+	deleteReq := BulkFeedRequest{Action: BulkActionDelete, URLs: []string{"https://techcrunch.com/feed/", "https://missing.example.com/rss"}}
+	require.NoError(t, deleteReq.Validate())
+	results, err = handler.bulkFeedTask(deleteReq)(context.Background())
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, "predefined feeds cannot be changed", results[0].Error)
+	assert.Equal(t, "feed is not subscribed", results[1].Error)
+	mockDatastore.AssertNotCalled(t, "DeleteMulti", mock.Anything, mock.Anything)
+}
+
+func TestDiscoverFeedSource(t *testing.T) {
+	result := &utils.FetchResult{
+		Title:       "Example News",
+		Description: "All the news",
+		Language:    "en-us",
+		Items: []*utils.FeedItem{
+			{PubDate: "2024-01-01T12:00:00Z"},
+			{PubDate: "2024-01-01T11:00:00Z"},
+			{PubDate: "2024-01-01T10:00:00Z"},
+		},
+	}
+
+	feed := discoverFeedSource("https://example.com/feed.xml", result)
+	assert.Equal(t, "Example News", feed.Name)
+	assert.Equal(t, "All the news", feed.Description)
+	assert.Equal(t, "en-us", feed.Language)
+	assert.Equal(t, 60, feed.UpdateIntervalMinutes)
+
+	// Untitled feeds are named after their host
+	feed = discoverFeedSource("https://example.com/feed.xml", &utils.FetchResult{})
+	assert.Equal(t, "example.com", feed.Name)
+	assert.Zero(t, feed.UpdateIntervalMinutes)
 }
 
 func TestHandleGetJobStatus(t *testing.T) {
@@ -198,7 +364,7 @@ func TestHandleGetJobStatus(t *testing.T) {
 		URL:    "https://example.com/rss.xml",
 	}
 
-	mockAsync.On("GetJobStatus", "test-job-123").
+	mockAsync.On("GetJobStatusWithContext", mock.Anything, "test-job-123").
 		Return(jobStatus, true)
 
 	req := httptest.NewRequest("GET", "/job-status?job_id=test-job-123", nil)
@@ -219,7 +385,7 @@ func TestHandleGetJobStatusNotFound(t *testing.T) {
 	handler, _, _, mockAsync := setupTestHandler(t)
 
 	// Mock job not found
-	mockAsync.On("GetJobStatus", "nonexistent-job").
+	mockAsync.On("GetJobStatusWithContext", mock.Anything, "nonexistent-job").
 		Return((*types.AsyncJobStatus)(nil), false)
 
 	req := httptest.NewRequest("GET", "/job-status?job_id=nonexistent-job", nil)
@@ -230,6 +396,87 @@ func TestHandleGetJobStatusNotFound(t *testing.T) {
 	assert.Equal(t, http.StatusNotFound, w.Code)
 }
 
+func TestHandleGetJobStatusLongPoll(t *testing.T) {
+	handler, _, _, mockAsync := setupTestHandler(t)
+	handler.Config.MaxJobStatusWait = 30 * time.Second
+
+	jobStatus := &types.AsyncJobStatus{
+		JobID:  "test-job-123",
+		Status: "completed",
+	}
+
+	// The requested wait is capped at MaxJobStatusWait
+	mockAsync.On("WaitForJob", mock.MatchedBy(func(ctx context.Context) bool {
+		deadline, ok := ctx.Deadline()
+		return ok && time.Until(deadline) <= 30*time.Second
+	}), "test-job-123").Return(jobStatus, true)
+
+	req := httptest.NewRequest("GET", "/job-status?job_id=test-job-123&wait=5m", nil)
+	w := httptest.NewRecorder()
+
+	handler.HandleGetJobStatus(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockAsync.AssertExpectations(t)
+	mockAsync.AssertNotCalled(t, "GetJobStatusWithContext", mock.Anything, mock.Anything)
+}
+
+func TestHandleGetJobStatusInvalidWait(t *testing.T) {
+	handler, _, _, _ := setupTestHandler(t)
+
+	for _, wait := range []string{"soon", "-5s"} {
+		req := httptest.NewRequest("GET", "/job-status?job_id=test-job-123&wait="+wait, nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleGetJobStatus(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, wait)
+	}
+}
+
+func TestHandleGetJobResult(t *testing.T) {
+	handler, _, _, mockAsync := setupTestHandler(t)
+
+	items := []*utils.FeedItem{
+		{Title: "Item 1", Link: "https://example.com/1"},
+		{Title: "Item 2", Link: "https://example.com/2"},
+	}
+	mockAsync.On("GetJobStatusWithContext", mock.Anything, "done-job").Return(&types.AsyncJobStatus{JobID: "done-job", Status: "completed"}, true)
+	mockAsync.On("GetJobResult", "done-job").Return(items, true)
+	mockAsync.On("GetJobStatusWithContext", mock.Anything, "running-job").Return(&types.AsyncJobStatus{JobID: "running-job", Status: "processing"}, true)
+	mockAsync.On("GetJobStatusWithContext", mock.Anything, "expired-job").Return(&types.AsyncJobStatus{JobID: "expired-job", Status: "completed"}, true)
+	mockAsync.On("GetJobResult", "expired-job").Return([]*utils.FeedItem(nil), false)
+	mockAsync.On("GetJobStatusWithContext", mock.Anything, "missing-job").Return((*types.AsyncJobStatus)(nil), false)
+
+	tests := []struct {
+		jobID    string
+		expected int
+	}{
+		{"done-job", http.StatusOK},
+		{"running-job", http.StatusConflict},
+		{"expired-job", http.StatusNotFound},
+		{"missing-job", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.jobID, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/jobs/"+tt.jobID+"/result", nil)
+			req = mux.SetURLVars(req, map[string]string{"id": tt.jobID})
+			w := httptest.NewRecorder()
+
+			handler.HandleGetJobResult(w, req)
+
+			assert.Equal(t, tt.expected, w.Code)
+			if tt.expected == http.StatusOK {
+				var response FetchResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, 2, response.ItemsCount)
+				assert.Equal(t, tt.jobID, response.JobID)
+			}
+		})
+	}
+}
+
 func TestHandleFetchAndStoreMissingURL(t *testing.T) {
 	handler, _, _, _ := setupTestHandler(t)
 
@@ -255,6 +502,314 @@ func TestHandleFetchAndStoreInvalidURL(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 }
 
+func TestHandleFetchAndStoreBlocksPrivateTargets(t *testing.T) {
+	handler, _, _, _ := setupTestHandler(t)
+	blocked, err := utils.ParseCIDRs([]string{"203.0.113.0/24"})
+	require.NoError(t, err)
+	handler.Config.BlockedCIDRs = blocked
+
+	urls := []string{
+		"http://169.254.169.254/latest/meta-data/",
+		"http://metadata.google.internal/computeMetadata/v1/",
+		"http://[::1]/feed.xml",
+		"http://[fd00:ec2::254]/feed.xml",
+		"http://[fe80::1]/feed.xml",
+		"http://[::ffff:127.0.0.1]/feed.xml",
+		"http://10.0.0.1/feed.xml",
+		"http://203.0.113.7/feed.xml",
+		"http://LOCALHOST./feed.xml",
+		"http://①②⑦.0.0.1/feed.xml",
+	}
+
+	for _, target := range urls {
+		t.Run(target, func(t *testing.T) {
+			body := strings.NewReader(`{"url":"` + target + `"}`)
+			req := httptest.NewRequest("POST", "/fetch-store", body)
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			handler.HandleFetchAndStore(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Contains(t, w.Body.String(), "private networks")
+		})
+	}
+}
+
+func TestHandleFetchAndStoreSyncTimeout(t *testing.T) {
+	handler, mockDatastore, mockCache, _ := setupTestHandler(t)
+	handler.Config.SyncFetchTimeout = time.Nanosecond
+
+	mockCache.On("GetFeedItems", mock.Anything).Return([]*utils.FeedItem(nil), false)
+
+	body := strings.NewReader(`{"url":"https://example.com/feed.xml"}`)
+	req := httptest.NewRequest("POST", "/fetch-store", body)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	handler.HandleFetchAndStore(w, req)
+
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+	assert.Contains(t, w.Body.String(), "TIMEOUT")
+	mockDatastore.AssertNotCalled(t, "PutMulti", mock.Anything, mock.Anything, mock.Anything)
+	mockCache.AssertNotCalled(t, "SetFeedItems", mock.Anything, mock.Anything)
+}
+
+func TestHandleFetchAndStoreClientDisconnect(t *testing.T) {
+	handler, mockDatastore, mockCache, _ := setupTestHandler(t)
+	handler.Config.SyncFetchTimeout = 30 * time.Second
+
+	mockCache.On("GetFeedItems", mock.Anything).Return([]*utils.FeedItem(nil), false)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	body := strings.NewReader(`{"url":"https://example.com/feed.xml"}`)
+	req := httptest.NewRequest("POST", "/fetch-store", body).WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	handler.HandleFetchAndStore(w, req)
+
+	// Nothing is written back to a client that has gone away
+	assert.Empty(t, w.Body.String())
+	mockDatastore.AssertNotCalled(t, "PutMulti", mock.Anything, mock.Anything, mock.Anything)
+	mockCache.AssertNotCalled(t, "SetFeedItems", mock.Anything, mock.Anything)
+}
+
+func TestHandleFetchAndStoreAutoAsyncFallback(t *testing.T) {
+	handler, mockDatastore, mockCache, mockAsync := setupTestHandler(t)
+	handler.Config.SyncFetchTimeout = 30 * time.Second
+	handler.Config.AutoAsyncThreshold = time.Nanosecond
+
+	mockCache.On("GetFeedItems", mock.Anything).Return([]*utils.FeedItem(nil), false)
+	mockAsync.On("SubmitTenantJobWithContext", mock.Anything, "https://example.com/feed.xml", mock.Anything, DefaultTenant).Return("job-123", nil)
+	mockAsync.On("GetJobStatus", "job-123").Return(&types.AsyncJobStatus{JobID: "job-123", Status: "pending"}, true)
+
+	body := strings.NewReader(`{"url":"https://example.com/feed.xml","auto_async":true}`)
+	req := httptest.NewRequest("POST", "/fetch-store", body)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	handler.HandleFetchAndStore(w, req)
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+
+	var response FetchResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "job-123", response.JobID)
+	assert.Equal(t, "submitted", response.Status)
+	mockAsync.AssertExpectations(t)
+	mockDatastore.AssertNotCalled(t, "PutMulti", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandleFetchAndStoreQueueFull(t *testing.T) {
+	handler, _, _, mockAsync := setupTestHandler(t)
+
+	mockAsync.On("SubmitTenantJobWithContext", mock.Anything, "https://example.com/feed.xml", mock.Anything, DefaultTenant).
+		Return("", &QueueFullError{Depth: 80, Capacity: 100, Load: 0.8, RetryAfter: 2500 * time.Millisecond}).Once()
+
+	post := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/fetch-store", strings.NewReader(`{"url":"https://example.com/feed.xml","async":true}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		handler.HandleFetchAndStore(w, req)
+		return w
+	}
+
+	w := post()
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "3", w.Header().Get("Retry-After"))
+	assert.Equal(t, "80", w.Header().Get("X-Queue-Depth"))
+	assert.Equal(t, "100", w.Header().Get("X-Queue-Capacity"))
+
+	// A job held in the overflow queue is accepted with its deferred status
+	mockAsync.On("SubmitTenantJobWithContext", mock.Anything, "https://example.com/feed.xml", mock.Anything, DefaultTenant).Return("job-123", nil)
+	mockAsync.On("GetJobStatus", "job-123").Return(&types.AsyncJobStatus{JobID: "job-123", Status: "queued_deferred"}, true)
+
+	w = post()
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	var response FetchResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "queued_deferred", response.Status)
+}
+
+func TestIngestCountersApplyEachBatchOnce(t *testing.T) {
+	_, mockDatastore, _, _ := setupTestHandler(t)
+	counters := NewIngestCounters(mockDatastore, middleware.Logger)
+	now := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+	counters.now = func() time.Time { return now }
+
+	feedURL := "https://example.com/feed.xml"
+	first := []*utils.FeedItem{{Title: "One", Link: "https://example.com/1"}, {Title: "Two", Link: "https://example.com/2"}}
+	second := []*utils.FeedItem{{Title: "Three", Link: "https://example.com/3"}}
+	// Tokens depend on the items, not their order
+	assert.Equal(t, IngestToken(first), IngestToken([]*utils.FeedItem{first[1], first[0]}))
+	batches := []IngestBatch{{Token: IngestToken(first), Items: 2}, {Token: IngestToken(second), Items: 1}}
+
+	var stored SourceCounts
+	mockDatastore.On("PutMulti", mock.Anything, mock.MatchedBy(func(keys []*datastore.Key) bool {
+		return keys[0].Kind == "SourceCounts" && keys[0].Name == feedURL
+	}), mock.Anything).Run(func(args mock.Arguments) {
+		stored = *args.Get(2).([]*SourceCounts)[0]
+	}).Return([]*datastore.Key{}, nil).Once()
+
+	assert.Equal(t, 3, counters.Apply(context.Background(), feedURL, batches))
+	// A retry writing the same batches is not counted again
+	assert.Equal(t, 0, counters.Apply(context.Background(), feedURL, batches))
+	counts, ok := counters.Counts(feedURL)
+	require.True(t, ok)
+	assert.Equal(t, int64(3), counts.ItemsIngested)
+	assert.Equal(t, int64(2), counts.Batches)
+	assert.Equal(t, int64(3), counts.ItemsToday)
+	assert.Empty(t, counts.AppliedTokens)
+	mockDatastore.AssertExpectations(t)
+
+	// Applied tokens survive a restart, so a replay is still ignored
+	mockDatastore.On("GetAll", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		*args.Get(2).(*[]SourceCounts) = []SourceCounts{stored}
+	}).Return([]*datastore.Key{}, nil)
+	restarted := NewIngestCounters(mockDatastore, middleware.Logger)
+	restarted.now = func() time.Time { return now.Add(24 * time.Hour) }
+	require.NoError(t, restarted.LoadCounts(context.Background()))
+	assert.Equal(t, 0, restarted.Apply(context.Background(), feedURL, batches[1:]))
+	counts, _ = restarted.Counts(feedURL)
+	assert.Equal(t, int64(3), counts.ItemsIngested)
+	assert.Zero(t, counts.ItemsToday)
+
+	var nilCounters *IngestCounters
+	assert.Zero(t, nilCounters.Apply(context.Background(), feedURL, batches))
+}
+
+func TestRefreshReplicaState(t *testing.T) {
+	handler, mockDatastore, _, _ := setupTestHandler(t)
+	handler.MuteRules = NewMuteRuleStore(mockDatastore, handler.Logger)
+	handler.IngestCounters = NewIngestCounters(mockDatastore, handler.Logger)
+	handler.Redirects = NewFeedRedirectTracker(mockDatastore, 3, handler.Logger)
+	handler.FeedHealth = NewFeedHealthTracker(mockDatastore, time.Hour, handler.Logger)
+
+	feedURL := "https://new.example.com/feed.xml"
+	oldURL := "https://old.example.com/feed.xml"
+	rules := []MuteRule{{ID: "mute_1", UserID: "alice", Type: "keyword", Value: "crypto"}}
+	counts := []SourceCounts{{FeedURL: feedURL, ItemsIngested: 5}}
+	aliases := []FeedAlias{{AliasURL: oldURL, CanonicalURL: feedURL}}
+	mockDatastore.On("GetAll", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		switch dst := args.Get(2).(type) {
+		case *[]MuteRule:
+			*dst = rules
+		case *[]SourceCounts:
+			*dst = counts
+		case *[]FeedAlias:
+			*dst = aliases
+		}
+	}).Return([]*datastore.Key{}, nil)
+
+	handler.RefreshReplicaState(context.Background())
+	assert.Len(t, handler.MuteRules.List("alice"), 1)
+	loaded, _ := handler.IngestCounters.Counts(feedURL)
+	assert.Equal(t, int64(5), loaded.ItemsIngested)
+	assert.Equal(t, feedURL, handler.Redirects.Resolve(oldURL))
+
+	// Each refresh replaces the state with the writer's, so changes made there show up and nothing is doubled
+	rules = []MuteRule{{ID: "mute_2", UserID: "alice", Type: "author", Value: "spam"}}
+	counts = []SourceCounts{{FeedURL: feedURL, ItemsIngested: 7}}
+	aliases = nil
+	handler.RefreshReplicaState(context.Background())
+	require.Len(t, handler.MuteRules.List("alice"), 1)
+	assert.Equal(t, "mute_2", handler.MuteRules.List("alice")[0].ID)
+	loaded, _ = handler.IngestCounters.Counts(feedURL)
+	assert.Equal(t, int64(7), loaded.ItemsIngested)
+	assert.Equal(t, oldURL, handler.Redirects.Resolve(oldURL))
+}
+
+func TestFeedRedirectTrackerConfirmsMigration(t *testing.T) {
+	_, mockDatastore, _, _ := setupTestHandler(t)
+	tracker := NewFeedRedirectTracker(mockDatastore, 3, middleware.Logger)
+
+	oldURL := "https://old.example.com/feed.xml"
+	newURL := "https://new.example.com/feed.xml"
+	moved := &utils.FetchResult{FinalURL: newURL, PermanentRedirect: true}
+
+	mockDatastore.On("PutMulti", mock.Anything, mock.MatchedBy(func(keys []*datastore.Key) bool {
+		return keys[0].Kind == "FeedAlias" && keys[0].Name == oldURL
+	}), mock.Anything).Return([]*datastore.Key{}, nil).Once()
+	mockDatastore.On("PutMulti", mock.Anything, mock.MatchedBy(func(keys []*datastore.Key) bool {
+		return keys[0].Kind == "FeedHistory"
+	}), mock.MatchedBy(func(entries []*FeedHistoryEntry) bool {
+		return entries[0].Event == FeedEventURLMigrated && entries[0].OldURL == oldURL && entries[0].NewURL == newURL
+	})).Return([]*datastore.Key{}, nil).Once()
+
+	// Below the threshold the old URL stays canonical
+	for i := 0; i < 2; i++ {
+		canonical, err := tracker.Observe(context.Background(), oldURL, moved)
+		require.NoError(t, err)
+		assert.Equal(t, oldURL, canonical)
+		assert.Equal(t, oldURL, tracker.Resolve(oldURL))
+	}
+
+	// A fetch without the redirect resets the count
+	canonical, err := tracker.Observe(context.Background(), oldURL, &utils.FetchResult{FinalURL: oldURL})
+	require.NoError(t, err)
+	assert.Equal(t, oldURL, canonical)
+
+	for i := 0; i < 3; i++ {
+		canonical, err = tracker.Observe(context.Background(), oldURL, moved)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, newURL, canonical)
+	assert.Equal(t, newURL, tracker.Resolve(oldURL))
+	mockDatastore.AssertExpectations(t)
+}
+
+func TestFeedRedirectTrackerNil(t *testing.T) {
+	var tracker *FeedRedirectTracker
+
+	assert.Equal(t, "https://example.com/feed.xml", tracker.Resolve("https://example.com/feed.xml"))
+	canonical, err := tracker.Observe(context.Background(), "https://example.com/feed.xml",
+		&utils.FetchResult{FinalURL: "https://moved.example.com/feed.xml", PermanentRedirect: true})
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com/feed.xml", canonical)
+}
+
+func TestFeedRedirectTrackerRefusesPrivateTargets(t *testing.T) {
+	tracker := NewFeedRedirectTracker(nil, 1, middleware.Logger)
+	oldURL := "https://old.example.com/feed.xml"
+
+	// A redirect into a private network is never confirmed
+	canonical, err := tracker.Observe(context.Background(), oldURL,
+		&utils.FetchResult{FinalURL: "http://169.254.169.254/latest/meta-data", PermanentRedirect: true})
+	require.NoError(t, err)
+	assert.Equal(t, oldURL, canonical)
+
+	// Aliases stored before targets were checked are not followed
+	tracker.aliases[oldURL] = "http://127.0.0.1:8080/feed.xml"
+	assert.Equal(t, oldURL, tracker.Resolve(oldURL))
+}
+
+func TestHandleFetchAndStoreResolvesAlias(t *testing.T) {
+	handler, _, mockCache, _ := setupTestHandler(t)
+	handler.Redirects = NewFeedRedirectTracker(nil, 1, middleware.Logger)
+	_, err := handler.Redirects.Observe(context.Background(), "https://old.example.com/feed.xml",
+		&utils.FetchResult{FinalURL: "https://new.example.com/feed.xml", PermanentRedirect: true})
+	require.NoError(t, err)
+
+	cachedItems := []*utils.FeedItem{{Title: "Item 1", Link: "https://new.example.com/1"}}
+	mockCache.On("GetFeedItems", "https://new.example.com/feed.xml").Return(cachedItems, true)
+
+	body := strings.NewReader(`{"url":"https://old.example.com/feed.xml"}`)
+	req := httptest.NewRequest("POST", "/fetch-store", body)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	handler.HandleFetchAndStore(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response FetchResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "https://new.example.com/feed.xml", response.CanonicalURL)
+	mockCache.AssertExpectations(t)
+}
+
 func TestHandleGetFeedItems(t *testing.T) {
 	handler, mockDatastore, mockCache, _ := setupTestHandler(t)
 
@@ -278,6 +833,888 @@ func TestHandleGetFeedItems(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 }
 
+func TestHandleGetFeedItemsCancelsSlowQueries(t *testing.T) {
+	handler, mockDatastore, mockCache, _ := setupTestHandler(t)
+	mockCache.On("GetStoredItems", mock.Anything).Return([]*utils.FeedItem{}, false)
+
+	// The query blocks until its context ends, as Datastore calls do
+	cancelled := make(chan error, 1)
+	mockDatastore.On("GetAll", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		ctx := args.Get(0).(context.Context)
+		select {
+		case <-ctx.Done():
+			cancelled <- ctx.Err()
+		case <-time.After(5 * time.Second):
+		}
+	}).Return([]*datastore.Key(nil), context.DeadlineExceeded)
+
+	router := mux.NewRouter()
+	timeout := middleware.RouteTimeoutMiddleware(map[string]time.Duration{"/items": 20 * time.Millisecond}, 0)
+	router.Handle("/items", timeout(http.HandlerFunc(handler.HandleGetFeedItems))).Methods("GET")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/items?limit=10", nil))
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+
+	// The abandoned query sees the deadline instead of running on
+	select {
+	case err := <-cancelled:
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	case <-time.After(time.Second):
+		t.Fatal("Datastore query was not cancelled when the request timed out")
+	}
+}
+
+func TestHandleGetFeedItemsCollapseDuplicates(t *testing.T) {
+	handler, _, mockCache, _ := setupTestHandler(t)
+	handler.Config.DuplicateTitleThreshold = 0.8
+
+	cachedItems := []*utils.FeedItem{
+		{Title: "Storm forces thousands to evacuate coastal towns", Link: "https://a.example.com/storm", ClusterID: "c1"},
+		{Title: "Storm forces thousands to evacuate coastal towns", Link: "https://b.example.com/storm", ClusterID: "c1"},
+		{Title: "New library opens downtown this weekend", Link: "https://a.example.com/library"},
+	}
+	mockCache.On("GetStoredItems", mock.Anything).Return(cachedItems, true)
+
+	req := httptest.NewRequest("GET", "/items?collapse_duplicates=true", nil)
+	w := httptest.NewRecorder()
+	handler.HandleGetFeedItems(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var result PaginatedResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Len(t, result.Items, 2)
+	assert.Equal(t, map[string]int{"c1": 2}, result.DuplicateCounts)
+
+	req = httptest.NewRequest("GET", "/items?collapse_duplicates=maybe", nil)
+	w = httptest.NewRecorder()
+	handler.HandleGetFeedItems(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestHandleGetFeedItemsPageLimits(t *testing.T) {
+	handler, _, mockCache, _ := setupTestHandler(t)
+	handler.Config.DefaultPageSize = 2
+	handler.Config.MaxPageSize = 50
+
+	cachedItems := []*utils.FeedItem{
+		{Title: "First", Link: "https://a.example.com/1"},
+		{Title: "Second", Link: "https://a.example.com/2"},
+	}
+	mockCache.On("GetStoredItems", mock.Anything).Return(cachedItems, true)
+
+	// Limits outside the configured range are refused, not truncated
+	for _, query := range []string{"limit=0", "limit=51", "limit=100000", "offset=-1"} {
+		w := httptest.NewRecorder()
+		handler.HandleGetFeedItems(w, httptest.NewRequest("GET", "/items?"+query, nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+
+	// A full page links to the next page, and a later page back to the previous one
+	w := httptest.NewRecorder()
+	handler.HandleGetFeedItems(w, httptest.NewRequest("GET", "/items?source=https://a.example.com&cursor=offset:2", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `</items?limit=2&offset=4&source=https%3A%2F%2Fa.example.com>; rel="next", `+
+		`</items?limit=2&offset=0&source=https%3A%2F%2Fa.example.com>; rel="prev", `+
+		`</items?limit=2&offset=0&source=https%3A%2F%2Fa.example.com>; rel="first"`, w.Header().Get("Link"))
+	assert.Empty(t, w.Header().Get("X-Total-Count"))
+
+	mockCache.ExpectedCalls = nil
+	mockCache.On("GetStoredItems", mock.Anything).Return(cachedItems[:1], true)
+	w = httptest.NewRecorder()
+	handler.HandleGetFeedItems(w, httptest.NewRequest("GET", "/items", nil))
+	assert.Empty(t, w.Header().Get("Link"))
+}
+
+func TestSetPaginationHeaders(t *testing.T) {
+	w := httptest.NewRecorder()
+	setPaginationHeaders(w, httptest.NewRequest("GET", "/items?limit=10&offset=15", nil), 15, 10, true, 42)
+	assert.Equal(t, "42", w.Header().Get("X-Total-Count"))
+	assert.Equal(t, `</items?limit=10&offset=25>; rel="next", </items?limit=10&offset=5>; rel="prev", `+
+		`</items?limit=10&offset=0>; rel="first", </items?limit=10&offset=35>; rel="last"`, w.Header().Get("Link"))
+
+	// An empty result still reports its total, with nothing to link to
+	w = httptest.NewRecorder()
+	setPaginationHeaders(w, httptest.NewRequest("GET", "/items", nil), 0, 10, false, 0)
+	assert.Equal(t, "0", w.Header().Get("X-Total-Count"))
+	assert.Empty(t, w.Header().Get("Link"))
+}
+
+func TestStaleFallbackServesLastGoodResults(t *testing.T) {
+	handler, mockDatastore, mockCache, _ := setupTestHandler(t)
+	handler.StaleData = NewStaleFallback(time.Hour, 10)
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	handler.StaleData.now = func() time.Time { return now }
+
+	mockCache.On("GetStoredItems", mock.Anything).Return([]*utils.FeedItem{}, false)
+	mockCache.On("SetStoredItems", mock.Anything, mock.Anything).Return(nil)
+	mockDatastore.On("GetAll", mock.Anything, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			switch dst := args.Get(2).(type) {
+			case *[]*utils.FeedItem:
+				*dst = []*utils.FeedItem{{Title: "Remembered", Link: "https://a.example.com/1"}}
+			case *[]FeedSource:
+				*dst = []FeedSource{{Name: "Subscribed", URL: "https://subscribed.example.com/feed.xml"}}
+			}
+		}).
+		Return([]*datastore.Key{{}}, nil).Times(3)
+	mockDatastore.On("GetAll", mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, fmt.Errorf("datastore unavailable"))
+
+	// Successful reads are remembered
+	w := httptest.NewRecorder()
+	handler.HandleGetFeedItems(w, httptest.NewRequest("GET", "/items?limit=10", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get(DataStalenessHeader))
+	w = httptest.NewRecorder()
+	handler.HandleGetFeeds(w, httptest.NewRequest("GET", "/feeds", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Once Datastore fails, they are served with their age
+	now = now.Add(90 * time.Second)
+	w = httptest.NewRecorder()
+	handler.HandleGetFeedItems(w, httptest.NewRequest("GET", "/items?limit=10", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "STALE", w.Header().Get("X-Cache"))
+	assert.Equal(t, "90", w.Header().Get(DataStalenessHeader))
+	var result PaginatedResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	require.Len(t, result.Items, 1)
+	assert.Equal(t, "Remembered", result.Items[0].Title)
+
+	w = httptest.NewRecorder()
+	handler.HandleGetFeeds(w, httptest.NewRequest("GET", "/feeds", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "90", w.Header().Get(DataStalenessHeader))
+	var feeds []FeedSource
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &feeds))
+	assert.Equal(t, "Subscribed", feeds[len(feeds)-1].Name)
+
+	// A query never read before, or remembered too long ago, still fails
+	w = httptest.NewRecorder()
+	handler.HandleGetFeedItems(w, httptest.NewRequest("GET", "/items?limit=20", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	now = now.Add(time.Hour)
+	w = httptest.NewRecorder()
+	handler.HandleGetFeedItems(w, httptest.NewRequest("GET", "/items?limit=10", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestFeedErrorWebhooksNotifyAfterConsecutiveFailures(t *testing.T) {
+	events := make(chan FeedErrorEvent, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event FeedErrorEvent
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+		events <- event
+	}))
+	defer server.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	webhooks := NewFeedErrorWebhooks(server.Client(), 3, logger)
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	webhooks.now = func() time.Time { return now }
+	feedURL := "https://example.com/feed.xml"
+	webhooks.Register(feedURL, server.URL+"/hooks/secret", 2)
+
+	receive := func() FeedErrorEvent {
+		select {
+		case event := <-events:
+			return event
+		case <-time.After(time.Second):
+			t.Fatal("webhook was not called")
+			return FeedErrorEvent{}
+		}
+	}
+
+	// The feed's own threshold triggers the webhook once per streak, with the last success time
+	webhooks.RecordSuccess(feedURL)
+	lastSuccess := now
+	now = now.Add(time.Hour)
+	parseErr := fmt.Errorf("%w: unexpected EOF", utils.ErrFeedParse)
+	webhooks.RecordFetchFailure(feedURL, parseErr)
+	webhooks.RecordFetchFailure(feedURL, parseErr)
+	event := receive()
+	assert.Equal(t, FeedErrorEventType, event.Event)
+	assert.Equal(t, feedURL, event.FeedURL)
+	assert.Equal(t, FeedStageParse, event.Stage)
+	assert.Equal(t, FeedErrorParse, event.Category)
+	assert.Equal(t, 2, event.ConsecutiveFailures)
+	require.NotNil(t, event.LastSuccessAt)
+	assert.True(t, lastSuccess.Equal(*event.LastSuccessAt))
+	webhooks.RecordFetchFailure(feedURL, parseErr)
+
+	// A success ends the streak, so the next run of failures notifies again
+	webhooks.RecordSuccess(feedURL)
+	webhooks.RecordStoreFailure(feedURL, errors.New("datastore unavailable"))
+	webhooks.RecordStoreFailure(feedURL, errors.New("datastore unavailable"))
+	event = receive()
+	assert.Equal(t, FeedStageStore, event.Stage)
+	assert.Equal(t, FeedErrorStore, event.Category)
+
+	// Feeds without a webhook are not tracked
+	for i := 0; i < 3; i++ {
+		webhooks.RecordFetchFailure("https://other.example.com/feed.xml", utils.ErrFeedGone)
+	}
+	select {
+	case event := <-events:
+		t.Fatalf("unexpected webhook call: %+v", event)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	assert.Equal(t, FeedErrorGone, feedErrorCategory(FeedStageFetch, fmt.Errorf("%w: 410 Gone", utils.ErrFeedGone)))
+	assert.Equal(t, FeedErrorThrottled, feedErrorCategory(FeedStageFetch, &utils.ThrottledError{StatusCode: http.StatusTooManyRequests}))
+	assert.Equal(t, FeedErrorTimeout, feedErrorCategory(FeedStageFetch, &JobLimitError{Limit: JobLimitDuration, Budget: "30s"}))
+	assert.Equal(t, "https://hooks.example.com", redactWebhookURL("https://hooks.example.com/T123/secret?token=abc"))
+}
+
+func TestHandleGetItemCount(t *testing.T) {
+	handler, _, _, _ := setupTestHandler(t)
+	handler.IngestCounters = NewIngestCounters(nil, middleware.Logger)
+	handler.IngestCounters.Apply(context.Background(), "https://a.example.com/feed.xml", []IngestBatch{{Token: "a1", Items: 3}})
+
+	get := func(query string) (int, ItemCountResponse) {
+		req := httptest.NewRequest("GET", "/items/count?"+query, nil)
+		w := httptest.NewRecorder()
+		handler.HandleGetItemCount(w, req)
+		var response ItemCountResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w.Code, response
+	}
+
+	// The first poll only establishes a cursor
+	code, first := get("")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, int64(0), first.Count)
+	assert.Equal(t, "count:3", first.Cursor)
+
+	handler.IngestCounters.Apply(context.Background(), "https://a.example.com/feed.xml", []IngestBatch{{Token: "a2", Items: 2}})
+	handler.IngestCounters.Apply(context.Background(), "https://b.example.com/feed.xml", []IngestBatch{{Token: "b1", Items: 4}})
+
+	_, next := get("since=" + first.Cursor)
+	assert.Equal(t, int64(6), next.Count)
+	assert.Equal(t, "count:9", next.Cursor)
+	_, next = get("since=count:3&source=https://a.example.com/feed.xml")
+	assert.Equal(t, int64(2), next.Count)
+	_, next = get("since=count:100")
+	assert.Equal(t, int64(0), next.Count)
+
+	code, _ = get("since=offset:3")
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestHandleGetFeedItemsNormalizesDateFilters(t *testing.T) {
+	handler, _, mockCache, _ := setupTestHandler(t)
+	handler.Config.DefaultTimezone = time.FixedZone("CET", 3600)
+
+	// Local dates cover the whole day in the default timezone, and equivalent filters share a cache entry
+	mockCache.On("GetStoredItems", "items:limit:100:offset:0:cursor::source::author::date_from:2024-02-29T23:00:00Z:date_to:2024-03-01T22:59:59Z:keyword:").
+		Return([]*utils.FeedItem{}, true).Twice()
+
+	req := httptest.NewRequest("GET", "/items?date_from=2024-03-01&date_to=2024-03-01", nil)
+	w := httptest.NewRecorder()
+	handler.HandleGetFeedItems(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	req = httptest.NewRequest("GET", "/items?date_from=2024-03-01T00:00:00%2B01:00&date_to=2024-03-01T22:59:59&tz=UTC", nil)
+	w = httptest.NewRecorder()
+	handler.HandleGetFeedItems(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockCache.AssertExpectations(t)
+
+	for _, query := range []string{
+		"date_from=2024-03-01&tz=Mars/Olympus",
+		"date_from=03/01/2024",
+		"date_from=2024-03-02&date_to=2024-03-01",
+		"since=2h&date_from=2024-03-01",
+		"since=yesterday",
+	} {
+		req = httptest.NewRequest("GET", "/items?"+query, nil)
+		w = httptest.NewRecorder()
+		handler.HandleGetFeedItems(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
+func TestBuildFolderTree(t *testing.T) {
+	tree := BuildFolderTree([]FeedSource{
+		{Name: "Go Blog", URL: "https://go.dev/blog/feed.atom", Folder: "Tech/Go"},
+		{Name: "BBC News", URL: "http://feeds.bbci.co.uk/news/rss.xml", Folder: "News"},
+		{Name: "Hacker News", URL: "https://hnrss.org/frontpage", Folder: "Tech"},
+		{Name: "Unfiled", URL: "https://example.com/feed.xml"},
+	})
+
+	require.Len(t, tree.Feeds, 1)
+	assert.Equal(t, "Unfiled", tree.Feeds[0].Name)
+	require.Len(t, tree.Folders, 2)
+	assert.Equal(t, "News", tree.Folders[0].Name)
+	tech := tree.Folders[1]
+	assert.Equal(t, "Tech", tech.Path)
+	require.Len(t, tech.Folders, 1)
+	assert.Equal(t, "Tech/Go", tech.Folders[0].Path)
+	assert.Equal(t, "Go Blog", tech.Folders[0].Feeds[0].Name)
+
+	assert.Equal(t, "Tech/Go", NormalizeFolderPath(" Tech / Go/"))
+	assert.Error(t, ValidateFolderPath("a/b/c/d/e/f"))
+}
+
+func TestHandleGetFeedItemsFolderFilter(t *testing.T) {
+	handler, mockDatastore, mockCache, _ := setupTestHandler(t)
+
+	mockDatastore.On("GetAll", mock.Anything, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			dst := args.Get(2).(*[]FeedSource)
+			*dst = []FeedSource{
+				{Name: "Go Blog", URL: "https://go.dev/blog/feed.atom", Folder: "Tech/Go"},
+				{Name: "Example", URL: "https://rss.example.com/feed.xml", Folder: "Tech"},
+			}
+		}).
+		Return([]*datastore.Key{}, nil)
+	mockCache.On("GetStoredItems", mock.Anything).Return([]*utils.FeedItem{
+		{Title: "Go 1.24 released", Link: "https://go.dev/blog/go1.24"},
+		{Title: "Example story", Link: "https://www.example.com/story"},
+		{Title: "Unrelated", Link: "https://other.example.org/post"},
+	}, true)
+
+	req := httptest.NewRequest("GET", "/items?folder=Tech", nil)
+	w := httptest.NewRecorder()
+	handler.HandleGetFeedItems(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var result PaginatedResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Len(t, result.Items, 2)
+
+	req = httptest.NewRequest("GET", "/items?folder=Tech/Go/", nil)
+	w = httptest.NewRecorder()
+	handler.HandleGetFeedItems(w, req)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	require.Len(t, result.Items, 1)
+	assert.Equal(t, "Go 1.24 released", result.Items[0].Title)
+
+	req = httptest.NewRequest("GET", "/folders", nil)
+	w = httptest.NewRecorder()
+	handler.HandleGetFolders(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var tree FeedFolder
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &tree))
+	assert.NotEmpty(t, tree.Folders)
+}
+
+func TestHandleGetStoryClusters(t *testing.T) {
+	handler, _, mockCache, _ := setupTestHandler(t)
+	handler.Config.StoryTitleThreshold = 0.5
+
+	cachedItems := []*utils.FeedItem{
+		{Title: "Storm forces thousands to evacuate coastal towns", Link: "https://a.example.com/storm", PubDate: "2024-03-01T08:00:00Z"},
+		{Title: "Thousands evacuate coastal towns as storm approaches", Link: "https://b.example.org/news/1", PubDate: "2024-03-01T10:00:00Z"},
+		{Title: "New library opens downtown this weekend", Link: "https://c.example.net/library", PubDate: "2024-03-01T09:00:00Z"},
+	}
+	mockCache.On("GetStoredItems", "recent:window:24h0m0s").Return(cachedItems, true)
+
+	req := httptest.NewRequest("GET", "/clusters?window=24h&min_sources=2", nil)
+	w := httptest.NewRecorder()
+	handler.HandleGetStoryClusters(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response StoryClustersResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Clusters, 1)
+	assert.Equal(t, 2, response.Clusters[0].SourceCount)
+	assert.Equal(t, 3, response.ItemsScanned)
+	assert.Equal(t, "24h0m0s", response.Window)
+
+	for _, query := range []string{"window=soon", "limit=0", "min_sources=x"} {
+		req = httptest.NewRequest("GET", "/clusters?"+query, nil)
+		w = httptest.NewRecorder()
+		handler.HandleGetStoryClusters(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
+func TestHandleGetTopItems(t *testing.T) {
+	handler, _, mockCache, _ := setupTestHandler(t)
+	handler.Config.StoryTitleThreshold = 0.5
+
+	recent := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	older := time.Now().Add(-20 * time.Hour).UTC().Format(time.RFC3339)
+	cachedItems := []*utils.FeedItem{
+		{Title: "Library opens downtown this weekend", Link: "https://c.example.net/library", PubDate: older},
+		{Title: "Storm forces thousands to evacuate coastal towns", Link: "https://a.example.com/storm", PubDate: recent},
+		{Title: "Thousands evacuate coastal towns as storm approaches", Link: "https://b.example.org/storm", PubDate: recent},
+	}
+	mockCache.On("GetStoredItems", "recent:window:24h0m0s").Return(cachedItems, true)
+
+	req := httptest.NewRequest("GET", "/items/top?window=24h", nil)
+	w := httptest.NewRecorder()
+	handler.HandleGetTopItems(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response TopItemsResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Items, 2)
+	assert.Equal(t, 2, response.Items[0].StorySize)
+	assert.Contains(t, response.Items[0].Item.Title, "torm")
+	assert.Greater(t, response.Items[0].Score, response.Items[1].Score)
+
+	req = httptest.NewRequest("GET", "/items/top?window=-1h", nil)
+	w = httptest.NewRecorder()
+	handler.HandleGetTopItems(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestPollSchedulerLearnsInterval(t *testing.T) {
+	policy := utils.PollPolicy{MinInterval: 5 * time.Minute, MaxInterval: 24 * time.Hour, TargetYield: 0.5, Decay: 0.9, MinObservations: 3}
+	polls := NewPollScheduler(nil, policy, logrus.New())
+	now := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+	polls.now = func() time.Time { return now }
+
+	feedURL := "https://example.com/feed.xml"
+	_, known := polls.NextInterval(feedURL)
+	assert.False(t, known)
+
+	// Hourly polls of a feed that never changes
+	unchanged := []*utils.FeedItem{{Link: "https://example.com/1", PubDate: "2024-01-10T06:00:00Z"}}
+	for i := 0; i < 10; i++ {
+		polls.RecordPoll(context.Background(), feedURL, unchanged)
+		now = now.Add(time.Hour)
+	}
+	interval, yieldRate := polls.LearnedInterval(feedURL)
+	assert.Greater(t, interval, time.Hour)
+	assert.Less(t, yieldRate, 0.5)
+	next, known := polls.NextInterval(feedURL)
+	assert.True(t, known)
+	assert.GreaterOrEqual(t, next, policy.MinInterval)
+
+	// The learned interval is reported in feed metadata
+	handler, _, _, _ := setupTestHandler(t)
+	handler.Polls = polls
+	feeds := []FeedSource{{Name: "Example", URL: feedURL}, {Name: "Other", URL: "https://other.example.com/feed.xml"}}
+	handler.enrichFeedSources(feeds)
+	assert.Equal(t, int(interval.Round(time.Minute)/time.Minute), feeds[0].PollIntervalMinutes)
+	assert.Zero(t, feeds[1].PollIntervalMinutes)
+}
+
+func TestPollSchedulerRetryAfter(t *testing.T) {
+	policy := utils.PollPolicy{MinInterval: 5 * time.Minute, MaxInterval: 24 * time.Hour, TargetYield: 0.5, Decay: 0.9, MinObservations: 1}
+	polls := NewPollScheduler(nil, policy, logrus.New())
+	now := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+	polls.now = func() time.Time { return now }
+
+	feedURL := "https://example.com/feed.xml"
+	_, throttled := polls.RetryAt(feedURL)
+	assert.False(t, throttled)
+
+	items := []*utils.FeedItem{{Link: "https://example.com/1", PubDate: "2024-01-10T06:00:00Z"}}
+	polls.RecordPoll(context.Background(), feedURL, items)
+	now = now.Add(time.Hour)
+	polls.RecordPoll(context.Background(), feedURL, items)
+
+	// The host's Retry-After holds off polls for longer than the learned interval
+	polls.RecordRetryAfter(context.Background(), feedURL, 48*time.Hour)
+	retryAt, throttled := polls.RetryAt(feedURL)
+	assert.True(t, throttled)
+	assert.Equal(t, now.Add(48*time.Hour), retryAt)
+	next, known := polls.NextInterval(feedURL)
+	assert.True(t, known)
+	assert.Equal(t, 48*time.Hour, next)
+
+	now = now.Add(49 * time.Hour)
+	_, throttled = polls.RetryAt(feedURL)
+	assert.False(t, throttled)
+}
+
+func TestIngestThrottleSharesBudget(t *testing.T) {
+	throttle := NewIngestThrottle(100, 100, 10, 50)
+	ctx := context.Background()
+
+	require.NoError(t, throttle.Acquire(ctx, "alice", 10))
+	usage := throttle.Usage("alice")
+	assert.Equal(t, int64(10), usage.EntitiesWritten)
+	assert.Zero(t, usage.EntitiesQueued)
+	assert.Equal(t, 100.0, usage.WriteRate)
+
+	// A tenant may not queue more than its maximum
+	err := throttle.Acquire(ctx, "alice", 60)
+	assert.ErrorIs(t, err, ErrIngestThrottled)
+	assert.Equal(t, int64(1), throttle.Usage("alice").RejectedWrites)
+
+	// While two tenants write at once, each gets half of the global rate
+	release := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- throttle.Acquire(ctx, "bob", 40)
+	}()
+	go func() {
+		<-release
+		done <- throttle.Acquire(ctx, "carol", 20)
+	}()
+	require.Eventually(t, func() bool { return throttle.Usage("bob").EntitiesQueued > 0 }, time.Second, time.Millisecond)
+	close(release)
+	require.Eventually(t, func() bool { return throttle.Usage("carol").EntitiesQueued > 0 }, time.Second, time.Millisecond)
+	assert.Equal(t, 50.0, throttle.Usage("carol").WriteRate)
+	require.NoError(t, <-done)
+	require.NoError(t, <-done)
+	assert.Equal(t, int64(40), throttle.Usage("bob").EntitiesWritten)
+
+	// A cancelled context stops the wait
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.Error(t, throttle.Acquire(cancelled, "dave", 25))
+
+	// A nil throttle never limits writes
+	var disabled *IngestThrottle
+	assert.NoError(t, disabled.Acquire(ctx, "alice", 1000))
+	assert.Nil(t, NewIngestThrottle(0, 100, 10, 10))
+}
+
+func TestHandleGetUsage(t *testing.T) {
+	handler, _, _, _ := setupTestHandler(t)
+	handler.IngestThrottle = NewIngestThrottle(100, 500, 500, 1000)
+	require.NoError(t, handler.IngestThrottle.Acquire(context.Background(), "acme", 3))
+
+	req := httptest.NewRequest("GET", "/usage", nil)
+	req.Header.Set(TenantIDHeader, "acme")
+	w := httptest.NewRecorder()
+	handler.HandleGetUsage(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response UsageResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "acme", response.Tenant)
+	assert.True(t, response.IngestThrottled)
+	assert.Equal(t, int64(3), response.Ingest.EntitiesWritten)
+
+	// Without a tenant header the user ID names the tenant
+	req = httptest.NewRequest("GET", "/usage", nil)
+	req.Header.Set(UserIDHeader, "alice")
+	w = httptest.NewRecorder()
+	handler.HandleGetUsage(w, req)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "alice", response.Tenant)
+	assert.Zero(t, response.Ingest.EntitiesWritten)
+}
+
+func TestMuteRuleStore(t *testing.T) {
+	rules := NewMuteRuleStore(nil, logrus.New())
+	ctx := context.Background()
+
+	_, err := rules.Add(ctx, "alice", MuteRule{Type: "color", Value: "red"})
+	assert.ErrorIs(t, err, ErrInvalidMuteRule)
+	_, err = rules.Add(ctx, "alice", MuteRule{Type: MuteTypeKeyword, Value: "  "})
+	assert.ErrorIs(t, err, ErrInvalidMuteRule)
+
+	keyword, err := rules.Add(ctx, "alice", MuteRule{Type: MuteTypeKeyword, Value: "Crypto"})
+	require.NoError(t, err)
+	source, err := rules.Add(ctx, "alice", MuteRule{Type: MuteTypeSource, Value: "https://www.tabloid.example.com/news", ApplyAtIngest: true})
+	require.NoError(t, err)
+	assert.Equal(t, "tabloid.example.com", source.Value)
+	_, err = rules.Add(ctx, "bob", MuteRule{Type: MuteTypeAuthor, Value: "Jane Doe"})
+	require.NoError(t, err)
+
+	items := []*utils.FeedItem{
+		{Title: "Crypto prices swing", Link: "https://a.example.com/1", Author: "Someone"},
+		{Title: "Celebrity gossip", Link: "https://m.tabloid.example.com/2", Author: "Someone"},
+		{Title: "Weather update", Link: "https://a.example.com/3", Author: "jane doe"},
+	}
+	assert.Equal(t, []*utils.FeedItem{items[2]}, rules.Filter("alice", items))
+	assert.Equal(t, items[:2], rules.Filter("bob", items))
+	assert.Equal(t, items, rules.Filter("", items))
+
+	// Only ingest-time rules mark items as suppressed
+	rules.SuppressAtIngest(items)
+	assert.Empty(t, items[0].SuppressedFor)
+	assert.Equal(t, []string{"alice"}, items[1].SuppressedFor)
+	assert.Empty(t, items[2].SuppressedFor)
+
+	updated, found, err := rules.Update(ctx, "alice", keyword.ID, MuteRule{Type: MuteTypeKeyword, Value: "weather"})
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "weather", updated.Value)
+	assert.Equal(t, []*utils.FeedItem{items[0]}, rules.Filter("alice", items))
+
+	// Rules are private to their owner
+	_, found, _ = rules.Update(ctx, "bob", keyword.ID, MuteRule{Type: MuteTypeKeyword, Value: "x"})
+	assert.False(t, found)
+	found, _ = rules.Delete(ctx, "bob", keyword.ID)
+	assert.False(t, found)
+
+	found, err = rules.Delete(ctx, "alice", keyword.ID)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Len(t, rules.List("alice"), 1)
+}
+
+func TestHandleMuteRules(t *testing.T) {
+	handler, _, mockCache, _ := setupTestHandler(t)
+	handler.MuteRules = NewMuteRuleStore(nil, middleware.Logger)
+
+	// A user ID is required
+	req := httptest.NewRequest("GET", "/mute-rules", nil)
+	w := httptest.NewRecorder()
+	handler.HandleListMuteRules(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	req = httptest.NewRequest("POST", "/mute-rules", strings.NewReader(`{"type":"keyword","value":"crypto"}`))
+	req.Header.Set(UserIDHeader, "alice")
+	w = httptest.NewRecorder()
+	handler.HandleCreateMuteRule(w, req)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var created MuteRule
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.NotEmpty(t, created.ID)
+
+	req = httptest.NewRequest("POST", "/mute-rules", strings.NewReader(`{"type":"mood","value":"grumpy"}`))
+	req.Header.Set(UserIDHeader, "alice")
+	w = httptest.NewRecorder()
+	handler.HandleCreateMuteRule(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Read endpoints hide muted items from the rule's owner only
+	cachedItems := []*utils.FeedItem{
+		{Title: "Crypto prices swing", Link: "https://a.example.com/1"},
+		{Title: "Weather update", Link: "https://a.example.com/2"},
+	}
+	mockCache.On("GetStoredItems", mock.Anything).Return(cachedItems, true)
+	for userID, expected := range map[string]int{"alice": 1, "bob": 2} {
+		req = httptest.NewRequest("GET", "/items", nil)
+		req.Header.Set(UserIDHeader, userID)
+		w = httptest.NewRecorder()
+		handler.HandleGetFeedItems(w, req)
+		var result PaginatedResult
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		assert.Len(t, result.Items, expected, userID)
+	}
+
+	req = httptest.NewRequest("DELETE", "/mute-rules/"+created.ID, nil)
+	req.Header.Set(UserIDHeader, "alice")
+	req = mux.SetURLVars(req, map[string]string{"id": created.ID})
+	w = httptest.NewRecorder()
+	handler.HandleDeleteMuteRule(w, req)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = httptest.NewRecorder()
+	handler.HandleDeleteMuteRule(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestHandleDeleteUserData(t *testing.T) {
+	handler, mockDatastore, _, mockAsync := setupTestHandler(t)
+	handler.Config.UserDataDeadline = time.Hour
+	handler.MuteRules = NewMuteRuleStore(mockDatastore, middleware.Logger)
+	handler.IngestThrottle = NewIngestThrottle(100, 0, 10, 0)
+	require.NoError(t, handler.IngestThrottle.Acquire(context.Background(), "alice", 1))
+
+	// The admin-only route names the user in its path
+	req := httptest.NewRequest("DELETE", "/users//data", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": ""})
+	w := httptest.NewRecorder()
+	handler.HandleDeleteUserData(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var task JobTask
+	mockAsync.On("SubmitTask", "delete_user_data", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { task = args.Get(2).(JobTask) }).
+		Return("job_forget", nil)
+	req = httptest.NewRequest("DELETE", "/users/alice/data", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "alice"})
+	w = httptest.NewRecorder()
+	handler.HandleDeleteUserData(w, req)
+	require.Equal(t, http.StatusAccepted, w.Code)
+	var response UserDataDeletionResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "job_forget", response.JobID)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), response.Deadline, time.Minute)
+
+	// The job deletes stored mute rules and removes the user from item suppression lists
+	ruleKey := datastore.NameKey("MuteRule", "mute_1", nil)
+	itemKey := datastore.NameKey("FeedItem", "https://example.com/1", nil)
+	mockDatastore.On("GetAll", mock.Anything, mock.Anything, mock.MatchedBy(func(dst *[]*utils.FeedItem) bool {
+		return dst != nil
+	})).Run(func(args mock.Arguments) {
+		*args.Get(2).(*[]*utils.FeedItem) = []*utils.FeedItem{{Link: "https://example.com/1", SuppressedFor: []string{"alice", "bob"}}}
+	}).Return([]*datastore.Key{itemKey}, nil)
+	mockDatastore.On("GetAll", mock.Anything, mock.Anything, mock.Anything).Return([]*datastore.Key{ruleKey}, nil)
+	mockDatastore.On("DeleteMulti", mock.Anything, []*datastore.Key{ruleKey}).Return(nil).Once()
+	mockDatastore.On("PutMulti", mock.Anything, []*datastore.Key{itemKey}, mock.MatchedBy(func(items []*utils.FeedItem) bool {
+		return len(items) == 1 && assert.ObjectsAreEqual([]string{"bob"}, items[0].SuppressedFor)
+	})).Return([]*datastore.Key{}, nil).Once()
+
+	require.NotNil(t, task)
+	results, err := task(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []types.TargetResult{
+		{Target: UserDataMuteRules, Success: true, Count: 1},
+		{Target: UserDataSuppressedItems, Success: true, Count: 1},
+		{Target: UserDataIngestUsage, Success: true, Count: 1},
+	}, results)
+	assert.Zero(t, handler.IngestThrottle.Usage("alice").EntitiesWritten)
+	mockDatastore.AssertExpectations(t)
+
+	// A deletion that cannot finish by its deadline fails with a partial report
+	results, err = handler.deleteUserDataTask("alice", time.Now().Add(-time.Second))(context.Background())
+	assert.Error(t, err)
+	require.Len(t, results, 3)
+	assert.False(t, results[0].Success)
+	assert.Contains(t, results[0].Error, "deadline")
+}
+
+func TestUsageAnalyticsCountsConsentingRequests(t *testing.T) {
+	handler, _, _, _ := setupTestHandler(t)
+
+	// Disabled analytics report nothing
+	req := httptest.NewRequest("GET", "/admin/usage-analytics", nil)
+	w := httptest.NewRecorder()
+	handler.HandleGetUsageAnalytics(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	var report UsageAnalyticsReport
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.False(t, report.Enabled)
+
+	handler.UsageAnalytics = NewUsageAnalytics(7)
+	router := mux.NewRouter()
+	router.Handle("/feeds/{id}", handler.UsageAnalytics.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if mux.Vars(r)["id"] == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))).Methods("GET")
+
+	requests := []struct {
+		path    string
+		headers map[string]string
+	}{
+		{"/feeds/1", map[string]string{AnalyticsConsentHeader: "granted", TenantIDHeader: "acme"}},
+		{"/feeds/missing", map[string]string{AnalyticsConsentHeader: "granted", TenantIDHeader: "acme"}},
+		// Attributed to a user rather than a tenant, so counted for the endpoint only
+		{"/feeds/2", map[string]string{AnalyticsConsentHeader: "granted", UserIDHeader: "alice"}},
+		// No consent, or consent overridden by Global Privacy Control
+		{"/feeds/3", map[string]string{TenantIDHeader: "acme"}},
+		{"/feeds/4", map[string]string{AnalyticsConsentHeader: "granted", TenantIDHeader: "acme", "Sec-GPC": "1"}},
+	}
+	for _, request := range requests {
+		req := httptest.NewRequest("GET", request.path, nil)
+		for name, value := range request.headers {
+			req.Header.Set(name, value)
+		}
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	req = httptest.NewRequest("GET", "/admin/usage-analytics?days=1", nil)
+	w = httptest.NewRecorder()
+	handler.HandleGetUsageAnalytics(w, req)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.True(t, report.Enabled)
+	assert.Equal(t, report.From, report.To)
+	assert.Equal(t, []EndpointUsage{{Endpoint: "GET /feeds/{id}", Requests: 3, Errors: 1}}, report.Endpoints)
+	assert.Equal(t, []TenantUsage{{Tenant: "acme", Requests: 2, Features: map[string]int64{"GET /feeds/{id}": 2}}}, report.Tenants)
+
+	req = httptest.NewRequest("GET", "/admin/usage-analytics?days=zero", nil)
+	w = httptest.NewRecorder()
+	handler.HandleGetUsageAnalytics(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestClientBansRefuseAbusiveClients(t *testing.T) {
+	handler, _, _, _ := setupTestHandler(t)
+
+	// Disabled bans list nothing
+	req := httptest.NewRequest("GET", "/admin/bans", nil)
+	w := httptest.NewRecorder()
+	handler.HandleListClientBans(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, "[]", w.Body.String())
+
+	_, proxies, err := net.ParseCIDR("10.0.0.0/8")
+	require.NoError(t, err)
+	handler.ClientBans = NewClientBans(DefaultBanRules(3, 2), time.Minute, time.Hour, []*net.IPNet{proxies}, handler.Logger)
+	router := mux.NewRouter()
+	router.Handle("/fetch-store", handler.ClientBans.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("url") == "" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))).Methods("POST")
+	router.HandleFunc("/admin/bans", handler.HandleListClientBans).Methods("GET")
+	router.HandleFunc("/admin/bans/{id}", handler.HandleLiftClientBan).Methods("DELETE")
+
+	fetchFrom := func(remoteAddr, forwardedFor, userAgent, query string) int {
+		req := httptest.NewRequest("POST", "/fetch-store"+query, nil)
+		req.RemoteAddr = remoteAddr
+		if forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		req.Header.Set("User-Agent", userAgent)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+	fetch := func(userAgent, query string) int {
+		return fetchFrom("198.51.100.7:4000", "", userAgent, query)
+	}
+
+	// Valid requests never count, and the third invalid URL bans the client
+	assert.Equal(t, http.StatusOK, fetch("scraper/1.0", "?url=https://example.com/feed"))
+	assert.Equal(t, http.StatusBadRequest, fetch("scraper/1.0", ""))
+	assert.Equal(t, http.StatusBadRequest, fetch("scraper/1.0", ""))
+	assert.Equal(t, http.StatusBadRequest, fetch("scraper/1.0", ""))
+	assert.Equal(t, http.StatusForbidden, fetch("scraper/1.0", "?url=https://example.com/feed"))
+
+	// Changing the user agent or forging X-Forwarded-For does not evade the ban
+	assert.Equal(t, http.StatusForbidden, fetch("reader/2.0", "?url=https://example.com/feed"))
+	assert.Equal(t, http.StatusForbidden, fetchFrom("198.51.100.7:4000", "192.0.2.1", "scraper/1.0", "?url=https://example.com/feed"))
+
+	// Clients behind a trusted proxy are told apart by the address it forwarded
+	assert.Equal(t, http.StatusOK, fetchFrom("10.0.0.2:4000", "192.0.2.1", "scraper/1.0", "?url=https://example.com/feed"))
+	assert.Equal(t, http.StatusForbidden, fetchFrom("10.0.0.2:4000", "198.51.100.7", "scraper/1.0", "?url=https://example.com/feed"))
+
+	req = httptest.NewRequest("GET", "/admin/bans", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var bans []ClientBan
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &bans))
+	require.Len(t, bans, 1)
+	assert.Equal(t, "198.51.100.7", bans[0].IP)
+	assert.Equal(t, "scraper/1.0", bans[0].UserAgent)
+	assert.Equal(t, BanRuleInvalidURL, bans[0].Rule)
+	assert.Equal(t, 3, bans[0].Strikes)
+
+	// Lifting the ban serves the client again, with its strikes cleared
+	req = httptest.NewRequest("DELETE", "/admin/bans/"+bans[0].ID, nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, http.StatusBadRequest, fetch("scraper/1.0", ""))
+	assert.Equal(t, http.StatusOK, fetch("scraper/1.0", "?url=https://example.com/feed"))
+
+	req = httptest.NewRequest("DELETE", "/admin/bans/"+bans[0].ID, nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// Only failed authentication counts toward the auth failure rule; 403s also refuse authenticated callers
+	assert.Equal(t, []int{http.StatusUnauthorized}, DefaultBanRules(0, 2)[0].Statuses)
+}
+
+func TestItemClustererGroupsSyndicatedCopies(t *testing.T) {
+	clusters := NewItemClusterer(nil, 100, 0.8)
+
+	original := &utils.FeedItem{Title: "Storm forces thousands to evacuate coastal towns", Link: "https://a.example.com/storm"}
+	clusters.Assign([]*utils.FeedItem{original})
+	require.NotEmpty(t, original.ClusterID)
+
+	syndicated := &utils.FeedItem{Title: "Storm forces thousands to evacuate coastal towns - Wire", Link: "https://b.example.com/2024/storm"}
+	refetched := &utils.FeedItem{Title: "Storm forces thousands to evacuate", Link: "https://a.example.com/storm/?utm_source=rss"}
+	unrelated := &utils.FeedItem{Title: "New library opens downtown this weekend", Link: "https://a.example.com/library"}
+	clusters.Assign([]*utils.FeedItem{syndicated, refetched, unrelated})
+
+	assert.Equal(t, original.ClusterID, syndicated.ClusterID)
+	assert.Equal(t, original.ClusterID, refetched.ClusterID)
+	assert.NotEqual(t, original.ClusterID, unrelated.ClusterID)
+
+	// A nil clusterer leaves items alone
+	var disabled *ItemClusterer
+	item := &utils.FeedItem{Title: "Anything", Link: "https://c.example.com/x"}
+	disabled.Assign([]*utils.FeedItem{item})
+	assert.Empty(t, item.ClusterID)
+}
+
 func TestNewHandler(t *testing.T) {
 	mockDatastore := &MockDatastoreClient{}
 	mockCache := &MockCacheManager{}
@@ -298,3 +1735,408 @@ func TestNewHandler(t *testing.T) {
 	assert.Equal(t, logger, handler.Logger)
 	assert.NotNil(t, handler.AsyncProcessor)
 }
+
+func TestHandleGetAdminOverview(t *testing.T) {
+	handler, _, _, _ := setupTestHandler(t)
+	cacheManager := cache.NewCacheManager(cache.NewInMemoryCache(time.Minute), handler.Logger, time.Minute, time.Minute, time.Minute, time.Minute)
+	handler.CacheManager = cacheManager
+	handler.FeedHealth = NewFeedHealthTracker(nil, time.Hour, handler.Logger)
+	handler.Alerts = monitoring.NewAlertManager(handler.Logger)
+	defer handler.Alerts.Stop()
+
+	feedURL := "https://example.com/feed"
+	handler.FeedHealth.RecordSuccess(context.Background(), feedURL, []*utils.FeedItem{
+		{Title: "Post", Link: "https://example.com/post", PubDate: time.Now().UTC().Format(time.RFC3339)},
+	})
+	require.NoError(t, cacheManager.SetFeedItems(feedURL, []*utils.FeedItem{{Title: "Post"}}))
+	cacheManager.GetFeedItems(feedURL)
+	cacheManager.GetFeedItems("https://example.com/other")
+	handler.Alerts.TriggerManualAlert(monitoring.AlertTypeFeedStale, monitoring.SeverityLow, "Feed possibly dead", "stale", nil)
+
+	req := httptest.NewRequest("GET", "/admin/overview", nil)
+	w := httptest.NewRecorder()
+	handler.HandleGetAdminOverview(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var overview AdminOverview
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &overview))
+
+	// The mock processor reports no queue statistics, so the queue is omitted
+	assert.Nil(t, overview.Queue)
+	assert.Empty(t, overview.RecentJobs)
+	require.Len(t, overview.FeedHealth, 1)
+	assert.Equal(t, FeedStatusActive, overview.FeedHealth[0].Status)
+	require.NotNil(t, overview.Cache)
+	assert.Equal(t, int64(1), overview.Cache.Hits)
+	assert.Equal(t, int64(1), overview.Cache.Misses)
+	assert.Equal(t, 1, overview.Cache.Entries)
+	require.Len(t, overview.Alerts, 1)
+	assert.Equal(t, "Feed possibly dead", overview.Alerts[0].Title)
+}
+
+func TestHandleSimulatedFeed(t *testing.T) {
+	handler, _, _, _ := setupTestHandler(t)
+
+	get := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.HandleSimulatedFeed(w, httptest.NewRequest("GET", "/dev/simulated-feed"+query, nil))
+		return w
+	}
+
+	w := get("?items=50")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var result PaginatedResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	require.Len(t, result.Items, 50)
+	assert.Equal(t, 50, result.TotalCount)
+	assert.NoError(t, result.Items[0].Validate())
+	assert.True(t, result.Items[0].PubDate > result.Items[49].PubDate, "items should be newest first")
+
+	// The same parameters give the same feed, and another seed a different one
+	assert.Equal(t, w.Body.String(), get("?items=50").Body.String())
+	assert.NotEqual(t, w.Body.String(), get("?items=50&seed=2").Body.String())
+
+	start := time.Now()
+	assert.Equal(t, http.StatusOK, get("?items=1&lag=50ms").Code)
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+	assert.Equal(t, http.StatusBadRequest, get("?items=5000").Code)
+	assert.Equal(t, http.StatusBadRequest, get("?lag=1h").Code)
+	assert.Equal(t, http.StatusBadRequest, get("?seed=abc").Code)
+}
+
+func TestHandleGetDiagnostics(t *testing.T) {
+	handler, mockDatastore, _, _ := setupTestHandler(t)
+	handler.Polls = NewPollScheduler(nil, utils.DefaultPollPolicy(), handler.Logger)
+	handler.RecentErrors = middleware.NewRecentErrors(10)
+	handler.Logger.AddHook(handler.RecentErrors)
+	handler.DiagnosticsConfig = map[string]string{"log_level": "info"}
+
+	mockDatastore.On("GetAll", mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("datastore unavailable"))
+	handler.Polls.RecordRetryAfter(context.Background(), "https://example.com/feed.xml", time.Hour)
+	handler.Logger.WithField("url", "https://example.com/feed.xml").Error("Failed to save to Datastore")
+
+	w := httptest.NewRecorder()
+	handler.HandleGetDiagnostics(w, httptest.NewRequest("GET", "/admin/diagnostics", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+
+	var diagnostics Diagnostics
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &diagnostics))
+	assert.Equal(t, map[string]interface{}{"log_level": "info"}, diagnostics.Config)
+	require.Len(t, diagnostics.CircuitBreakers, 1)
+	assert.Equal(t, "feed:https://example.com/feed.xml", diagnostics.CircuitBreakers[0].Name)
+	assert.Equal(t, "open", diagnostics.CircuitBreakers[0].State)
+	require.Len(t, diagnostics.RecentErrors, 1)
+	assert.Equal(t, "Failed to save to Datastore", diagnostics.RecentErrors[0].Message)
+
+	// A failing dependency is reported rather than failing the snapshot
+	require.Len(t, diagnostics.Dependencies, 1)
+	assert.Equal(t, "datastore", diagnostics.Dependencies[0].Name)
+	assert.Equal(t, DependencyStatusError, diagnostics.Dependencies[0].Status)
+	assert.Equal(t, "datastore unavailable", diagnostics.Dependencies[0].Error)
+	assert.Empty(t, diagnostics.Alerts)
+}
+
+func TestSaveToDatastoreGuardsItemSize(t *testing.T) {
+	_, mockDatastore, _, _ := setupTestHandler(t)
+
+	items := []*utils.FeedItem{
+		{Title: "Huge", Link: "https://example.com/huge", Description: strings.Repeat("x", 2<<20)},
+		{Title: "Unkeyable", Link: "https://example.com/" + strings.Repeat("a", utils.MaxLinkBytes)},
+	}
+	mockDatastore.On("Get", mock.Anything, mock.Anything, mock.Anything).Return(datastore.ErrNoSuchEntity)
+	mockDatastore.On("PutMulti", mock.Anything, mock.MatchedBy(func(keys []*datastore.Key) bool {
+		return len(keys) == 1 && keys[0].Name == "https://example.com/huge"
+	}), mock.Anything).Return([]*datastore.Key{}, nil)
+
+	err := SaveToDatastore(mockDatastore, items)
+	assert.NoError(t, err)
+	assert.True(t, items[0].Truncated)
+	assert.Len(t, items[0].Description, utils.MaxDescriptionBytes)
+	mockDatastore.AssertExpectations(t)
+}
+
+func TestSaveToDatastoreReportsPartialBatchFailures(t *testing.T) {
+	_, mockDatastore, _, _ := setupTestHandler(t)
+
+	items := []*utils.FeedItem{
+		{Title: "Good", Link: "https://example.com/good"},
+		{Title: "Flaky", Link: "https://example.com/flaky"},
+		{Title: "Bad", Link: "https://example.com/bad"},
+	}
+	mockDatastore.On("Get", mock.Anything, mock.Anything, mock.Anything).Return(datastore.ErrNoSuchEntity)
+	// The first write rejects two entities; the retry stores one of them
+	mockDatastore.On("PutMulti", mock.Anything, mock.MatchedBy(func(keys []*datastore.Key) bool {
+		return len(keys) == 3
+	}), mock.Anything).Return([]*datastore.Key{}, datastore.MultiError{nil, errors.New("transient"), errors.New("entity too large")}).Once()
+	mockDatastore.On("PutMulti", mock.Anything, mock.MatchedBy(func(keys []*datastore.Key) bool {
+		return len(keys) == 2 && keys[0].Name == "https://example.com/flaky" && keys[1].Name == "https://example.com/bad"
+	}), mock.Anything).Return([]*datastore.Key{}, datastore.MultiError{nil, errors.New("entity too large")}).Once()
+
+	report, err := SaveToDatastoreWithReport(context.Background(), mockDatastore, items)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, report.Saved)
+	assert.Equal(t, []types.TargetResult{{Target: "https://example.com/bad", Error: "entity too large"}}, report.Failed)
+	mockDatastore.AssertExpectations(t)
+
+	// A batch in which nothing could be stored is an error
+	mockDatastore.On("PutMulti", mock.Anything, mock.Anything, mock.Anything).Return([]*datastore.Key{}, datastore.MultiError{errors.New("entity too large")}).Twice()
+	report, err = SaveToDatastoreWithReport(context.Background(), mockDatastore, items[2:])
+	assert.Error(t, err)
+	assert.Equal(t, 0, report.Saved)
+	assert.Len(t, report.Failed, 1)
+}
+
+func TestSaveRewritesItemsWithChangedContent(t *testing.T) {
+	_, mockDatastore, _, _ := setupTestHandler(t)
+
+	edited := "<p>Updated with a correction</p>"
+	items := []*utils.FeedItem{
+		{Title: "Edited", Link: "https://example.com/edited", Description: "Updated with a correction", RawContent: edited, ContentHash: utils.ContentDigest(edited)},
+		{Title: "Unchanged", Link: "https://example.com/unchanged", ContentHash: utils.ContentDigest("<p>Same</p>")},
+	}
+	mockDatastore.On("Get", mock.Anything, mock.MatchedBy(func(key *datastore.Key) bool {
+		return key.Name == "https://example.com/edited"
+	}), mock.Anything).Run(func(args mock.Arguments) {
+		*args.Get(2).(*utils.FeedItem) = utils.FeedItem{Title: "Edited", Link: "https://example.com/edited", ContentHash: utils.ContentDigest("<p>Original</p>"), SuppressedFor: []string{"alice"}}
+	}).Return(nil)
+	mockDatastore.On("Get", mock.Anything, mock.MatchedBy(func(key *datastore.Key) bool {
+		return key.Name == "https://example.com/unchanged"
+	}), mock.Anything).Run(func(args mock.Arguments) {
+		*args.Get(2).(*utils.FeedItem) = utils.FeedItem{Title: "Unchanged", Link: "https://example.com/unchanged", ContentHash: utils.ContentDigest("<p>Same</p>")}
+	}).Return(nil)
+	mockDatastore.On("PutMulti", mock.Anything, mock.MatchedBy(func(keys []*datastore.Key) bool {
+		return len(keys) == 1 && keys[0].Name == "https://example.com/edited"
+	}), mock.Anything).Return([]*datastore.Key{}, nil).Once()
+	// The replaced version is kept as a revision under the item, without the users it was suppressed for
+	mockDatastore.On("PutMulti", mock.Anything, mock.MatchedBy(func(keys []*datastore.Key) bool {
+		return len(keys) == 1 && keys[0].Kind == ItemRevisionKind && keys[0].Parent.Name == "https://example.com/edited"
+	}), mock.MatchedBy(func(revisions []*ItemRevision) bool {
+		return len(revisions) == 1 && revisions[0].ContentHash == utils.ContentDigest("<p>Original</p>") && !revisions[0].SupersededAt.IsZero() &&
+			len(revisions[0].SuppressedFor) == 0
+	})).Return([]*datastore.Key{}, nil).Once()
+
+	report, err := SaveToDatastoreWithReport(context.Background(), mockDatastore, items)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Saved)
+	assert.Equal(t, 1, report.Changed)
+	assert.Equal(t, 1, report.Duplicates)
+	assert.Equal(t, []*utils.FeedItem{items[0]}, report.Items)
+	assert.False(t, items[0].RevisedAt.IsZero())
+	// The stored copy predates ingest times, so the rewrite does not invent one
+	assert.True(t, items[0].IngestedAt.IsZero())
+
+	// Snapshots of the written items are stored once per distinct content
+	copied := &utils.FeedItem{Title: "Syndicated", Link: "https://other.example.com/edited", RawContent: edited, ContentHash: utils.ContentDigest(edited)}
+	mockDatastore.On("PutMulti", mock.Anything, mock.MatchedBy(func(keys []*datastore.Key) bool {
+		return len(keys) == 1 && keys[0].Kind == utils.ItemSnapshotKind && keys[0].Name == utils.ContentDigest(edited)
+	}), mock.Anything).Return([]*datastore.Key{}, nil).Once()
+	stored, err := NewSnapshotStore(mockDatastore, logrus.New()).Save(context.Background(), append(report.Items, copied))
+	require.NoError(t, err)
+	assert.Equal(t, 1, stored)
+}
+
+func TestFetchFeedItemsAsOf(t *testing.T) {
+	_, mockDatastore, _, _ := setupTestHandler(t)
+
+	day := func(month time.Month, d int) time.Time {
+		return time.Date(2024, month, d, 0, 0, 0, 0, time.UTC)
+	}
+	stored := []*utils.FeedItem{
+		{Title: "Added later", Link: "https://example.com/later", IngestedAt: day(4, 1), RevisedAt: day(4, 1)},
+		{Title: "Corrected", Link: "https://example.com/edited", IngestedAt: day(1, 1), RevisedAt: day(3, 1)},
+		{Title: "Legacy", Link: "https://example.com/legacy", PublishedAt: day(1, 1)},
+		{Title: "Undated", Link: "https://example.com/undated"},
+	}
+	revisions := []*ItemRevision{
+		{FeedItem: utils.FeedItem{Title: "First draft", Link: "https://example.com/edited", IngestedAt: day(1, 1)}, SupersededAt: day(1, 15)},
+		{FeedItem: utils.FeedItem{Title: "Original", Link: "https://example.com/edited", IngestedAt: day(1, 1)}, SupersededAt: day(3, 1)},
+	}
+	mockDatastore.On("GetAll", mock.Anything, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			switch dst := args.Get(2).(type) {
+			case *[]*utils.FeedItem:
+				*dst = stored
+			case *[]*ItemRevision:
+				*dst = revisions
+			}
+		}).
+		Return(itemKeys(stored), nil)
+
+	result, err := FetchFeedItemsWithFilter(context.Background(), mockDatastore, ItemsQueryParams{
+		PaginationParams: PaginationParams{Limit: 10},
+		AsOf:             day(2, 1),
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Items, 2)
+	assert.Equal(t, "Original", result.Items[0].Title)
+	assert.Equal(t, "Legacy", result.Items[1].Title)
+	assert.Equal(t, 2, result.TotalCount)
+	assert.False(t, result.HasMore)
+
+	// Keywords match the version current at as_of
+	result, err = FetchFeedItemsWithFilter(context.Background(), mockDatastore, ItemsQueryParams{
+		PaginationParams: PaginationParams{Limit: 10},
+		FilterParams:     FilterParams{Keyword: "draft"},
+		AsOf:             day(1, 10),
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Items, 1)
+	assert.Equal(t, "First draft", result.Items[0].Title)
+
+	// Past states get their own cache entries
+	assert.NotEqual(t, itemsCacheKey(ItemsQueryParams{}), itemsCacheKey(ItemsQueryParams{AsOf: day(2, 1)}))
+
+	// Revisions keep their replacement time through Datastore
+	properties, err := revisions[0].Save()
+	require.NoError(t, err)
+	var loaded ItemRevision
+	require.NoError(t, loaded.Load(properties))
+	assert.Equal(t, "First draft", loaded.Title)
+	assert.True(t, revisions[0].SupersededAt.Equal(loaded.SupersededAt))
+}
+
+func TestItemRevisionRetention(t *testing.T) {
+	handler, mockDatastore, _, _ := setupTestHandler(t)
+
+	// Revisions replaced before the cutoff are pruned
+	expired := []*datastore.Key{datastore.NameKey(ItemRevisionKind, "2024-01-01T00:00:00Z", datastore.NameKey("FeedItem", "https://example.com/a", nil))}
+	mockDatastore.On("GetAll", mock.Anything, mock.Anything, nil).Return(expired, nil).Once()
+	mockDatastore.On("DeleteMulti", mock.Anything, expired).Return(nil).Once()
+	pruned, err := PruneItemRevisions(context.Background(), mockDatastore, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 1, pruned)
+
+	// Retention cleanup deletes an item's revisions before the item
+	itemKey := expired[0].Parent
+	mockDatastore.On("GetAll", mock.Anything, mock.Anything, nil).Return([]*datastore.Key{itemKey}, nil).Once()
+	mockDatastore.On("GetAll", mock.Anything, mock.Anything, nil).Return(expired, nil).Once()
+	mockDatastore.On("DeleteMulti", mock.Anything, expired).Return(nil).Once()
+	mockDatastore.On("DeleteMulti", mock.Anything, []*datastore.Key{itemKey}).Return(nil).Once()
+	deleted, err := CleanupOldFeedItems(mockDatastore, time.Now(), 100)
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+	mockDatastore.AssertExpectations(t)
+
+	// as_of cannot reach past the revision retention window
+	handler.Config.ItemRevisionRetention = 7 * 24 * time.Hour
+	router := mux.NewRouter()
+	router.HandleFunc("/items", handler.HandleGetFeedItems).Methods("GET")
+	req := httptest.NewRequest("GET", "/items?as_of="+time.Now().Add(-handler.Config.ItemRevisionRetention-time.Hour).UTC().Format(time.RFC3339), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "item history is kept for")
+}
+
+func TestHandleGetSnapshot(t *testing.T) {
+	handler, mockDatastore, _, _ := setupTestHandler(t)
+	router := mux.NewRouter()
+	router.HandleFunc("/snapshots/{hash}", handler.HandleGetSnapshot).Methods("GET")
+
+	content := "<p>Served exactly like this</p>"
+	snapshot, err := utils.NewItemSnapshot(content, time.Now())
+	require.NoError(t, err)
+
+	// Snapshots are disabled by default
+	req := httptest.NewRequest("GET", "/snapshots/"+snapshot.Hash, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	handler.Snapshots = NewSnapshotStore(mockDatastore, handler.Logger)
+	mockDatastore.On("Get", mock.Anything, datastore.NameKey(utils.ItemSnapshotKind, snapshot.Hash, nil), mock.Anything).
+		Run(func(args mock.Arguments) {
+			*args.Get(2).(*utils.ItemSnapshot) = *snapshot
+		}).Return(nil).Once()
+
+	req = httptest.NewRequest("GET", "/snapshots/"+snapshot.Hash, nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	var response SnapshotResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, snapshot.Hash, response.Hash)
+	assert.Equal(t, content, response.Content)
+	assert.Equal(t, len(content), response.Size)
+
+	req = httptest.NewRequest("GET", "/snapshots/not-a-hash", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestCanaryRun(t *testing.T) {
+	handler, mockDatastore, mockCache, mockAsync := setupTestHandler(t)
+	canary := NewCanary("http://localhost:8080"+CanaryPath, time.Second, mockAsync, mockDatastore, mockCache, handler.Logger)
+	handler.Canary = canary
+	var failures []CanaryResult
+	canary.SetFailureNotifier(func(result CanaryResult) { failures = append(failures, result) })
+
+	// The feed carries the current run's token as its item title
+	req := httptest.NewRequest("GET", CanaryPath, nil)
+	w := httptest.NewRecorder()
+	handler.HandleCanaryFeed(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "<title>Canary idle</title>")
+
+	feedURL := "http://localhost:8080" + CanaryPath
+	mockCache.On("InvalidateFeed", feedURL).Return(nil)
+	mockAsync.On("SubmitJob", feedURL, mock.Anything).Return("job_canary", nil)
+	mockAsync.On("WaitForJob", mock.Anything, "job_canary").Return(&types.AsyncJobStatus{JobID: "job_canary", Status: "completed"}, true)
+	mockDatastore.On("Get", mock.Anything, datastore.NameKey(CanaryItemKind, canaryItemLink, nil), mock.Anything).
+		Run(func(args mock.Arguments) {
+			args.Get(2).(*utils.FeedItem).Title = canaryTitle(canary.currentToken())
+		}).
+		Return(nil).Once()
+	cachedCall := mockCache.On("GetFeedItems", feedURL)
+	cachedCall.Run(func(args mock.Arguments) {
+		cachedCall.ReturnArguments = mock.Arguments{[]*utils.FeedItem{{Title: canaryTitle(canary.currentToken()), Link: canaryItemLink}}, true}
+	})
+
+	result := canary.Run(context.Background())
+	assert.True(t, result.Success, result.Error)
+	assert.Empty(t, failures)
+
+	w = httptest.NewRecorder()
+	handler.HandleCanaryFeed(w, req)
+	assert.Contains(t, w.Body.String(), "<title>"+canaryTitle(canary.currentToken())+"</title>")
+
+	// An item left over from an earlier run means the store stage did not write
+	mockDatastore.On("Get", mock.Anything, datastore.NameKey(CanaryItemKind, canaryItemLink, nil), mock.Anything).
+		Run(func(args mock.Arguments) {
+			args.Get(2).(*utils.FeedItem).Title = canaryTitle("stale")
+		}).
+		Return(nil)
+	result = canary.Run(context.Background())
+	assert.False(t, result.Success)
+	assert.Equal(t, CanaryStageStore, result.Stage)
+	require.Len(t, failures, 1)
+	last, ok := canary.LastResult()
+	require.True(t, ok)
+	assert.Equal(t, CanaryStageStore, last.Stage)
+
+	// Canary feed jobs keep the canary item apart from feed items, without duplicate checks or revisions
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	processor := NewAsyncProcessor(0, 1, false, 0.8, time.Second, logger, nil, nil)
+	defer processor.Stop()
+	processor.SetCanaryFeed(feedURL)
+	canaryItem := []*utils.FeedItem{{Title: canaryTitle("next"), Link: canaryItemLink}}
+	mockDatastore.On("PutMulti", mock.Anything, []*datastore.Key{datastore.NameKey(CanaryItemKind, canaryItemLink, nil)}, mock.Anything).
+		Return([]*datastore.Key{}, nil).Once()
+	report, err := processor.saveJobItems(context.Background(), mockDatastore, AsyncJob{URL: feedURL}, canaryItem)
+	require.NoError(t, err)
+	assert.Equal(t, 0, report.Saved)
+	mockDatastore.AssertExpectations(t)
+
+	// Any other feed carrying the canary's link only stores an ordinary feed item
+	mockDatastore.On("Get", mock.Anything, mock.Anything, mock.Anything).Return(datastore.ErrNoSuchEntity).Once()
+	mockDatastore.On("PutMulti", mock.Anything, []*datastore.Key{datastore.NameKey("FeedItem", canaryItemLink, nil)}, mock.Anything).
+		Return([]*datastore.Key{}, nil).Once()
+	report, err = processor.saveJobItems(context.Background(), mockDatastore, AsyncJob{URL: "https://example.com/feed.xml"}, canaryItem)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Saved)
+	mockDatastore.AssertExpectations(t)
+}
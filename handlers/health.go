@@ -6,6 +6,7 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"time"
 
@@ -22,6 +23,7 @@ type HealthStatus struct {
 	Version   string            `json:"version"`
 	Services  map[string]string `json:"services"`
 	Uptime    string            `json:"uptime"`
+	ReadOnly  bool              `json:"read_only"`
 }
 
 var startTime = time.Now()
@@ -40,6 +42,7 @@ func (h *Handler) HandleHealthCheck(w http.ResponseWriter, r *http.Request) {
 		Version:   "1.0.0",
 		Services:  make(map[string]string),
 		Uptime:    time.Since(startTime).String(),
+		ReadOnly:  h.Config.ReadOnly,
 	}
 
 	// Check Datastore connectivity
@@ -91,12 +94,18 @@ func (h *Handler) HandleReadinessCheck(w http.ResponseWriter, r *http.Request) {
 		middleware.RespondServiceUnavailable(w, err, requestID)
 		return
 	}
+	// Traffic waits until hot data is cached, so the first requests after a deploy are not all cold
+	if !h.WarmUp.Done() {
+		middleware.RespondServiceUnavailable(w, fmt.Errorf("startup warm-up in progress"), requestID)
+		return
+	}
 
 	response := map[string]interface{}{
 		"status":    "ready",
 		"timestamp": time.Now().Format(time.RFC3339),
 		"services": map[string]string{
 			"datastore": "ready",
+			"warmup":    "done",
 		},
 	}
 
@@ -110,7 +119,11 @@ func (h *Handler) checkDatastoreHealth() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	// Try to perform a simple query to test connectivity
+	return h.pingDatastore(ctx)
+}
+
+// pingDatastore performs the simplest possible query to test connectivity
+func (h *Handler) pingDatastore(ctx context.Context) error {
 	query := datastore.NewQuery("__Namespace").KeysOnly().Limit(1)
 	_, err := h.DatastoreClient.GetAll(ctx, query, nil)
 	return err
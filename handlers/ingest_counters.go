@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/Nexora-Open-Source/rss-feed-backend/monitoring"
+	"github.com/Nexora-Open-Source/rss-feed-backend/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// sourceCountsKind is the Datastore kind holding per-source ingest counters
+const sourceCountsKind = "SourceCounts"
+
+// maxAppliedTokens is how many recent batch tokens each source remembers; a replay older than that is counted again
+const maxAppliedTokens = 256
+
+// SourceCounts holds the ingest counters of one feed source
+type SourceCounts struct {
+	FeedURL       string    `datastore:"feed_url" json:"feed_url"`
+	ItemsIngested int64     `datastore:"items_ingested,noindex" json:"items_ingested"`
+	Batches       int64     `datastore:"batches,noindex" json:"batches"`
+	LastIngestAt  time.Time `datastore:"last_ingest_at,noindex" json:"last_ingest_at"`
+	// ItemsToday counts items ingested on Day, a UTC date such as 2006-01-02
+	Day        string `datastore:"day,noindex" json:"day"`
+	ItemsToday int64  `datastore:"items_today,noindex" json:"items_today"`
+	// AppliedTokens lists the idempotency tokens of the most recently counted batches, oldest first.
+	// They are stored with the counters so a count and its token are always written together.
+	AppliedTokens []string `datastore:"applied_tokens,noindex" json:"-"`
+}
+
+// IngestBatch describes one batch of items written to Datastore
+type IngestBatch struct {
+	// Token identifies the batch by its contents, so a retry or replay writing the same items carries the same token
+	Token string `json:"token"`
+	Items int    `json:"items"`
+}
+
+// IngestToken returns the idempotency token of a batch of items: a hash of their links and content, independent of order
+func IngestToken(items []*utils.FeedItem) string {
+	entries := make([]string, len(items))
+	for i, item := range items {
+		entries[i] = item.Link + "\x00" + item.GenerateContentHash()
+	}
+	sort.Strings(entries)
+
+	hash := sha256.New()
+	for _, entry := range entries {
+		fmt.Fprintf(hash, "%s\n", entry)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+/*
+IngestCounters keeps per-source counts of ingested items.
+
+Each batch is applied at most once per source: its idempotency token is recorded
+alongside the counters, and a batch whose token was already applied, such as one
+written again when a job is retried or replayed, is ignored.
+*/
+type IngestCounters struct {
+	mu      sync.Mutex
+	sources map[string]*SourceCounts
+	total   int64 // sum of ItemsIngested over all sources, so it can be read without a scan
+	store   DatastoreClientInterface
+	logger  *logrus.Logger
+	now     func() time.Time
+}
+
+// NewIngestCounters creates per-source ingest counters persisted to store, or kept in memory when store is nil
+func NewIngestCounters(store DatastoreClientInterface, logger *logrus.Logger) *IngestCounters {
+	return &IngestCounters{
+		sources: make(map[string]*SourceCounts),
+		store:   store,
+		logger:  logger,
+		now:     time.Now,
+	}
+}
+
+// LoadCounts loads stored counters, and the tokens already applied, from Datastore, replacing the counters loaded before
+func (c *IngestCounters) LoadCounts(ctx context.Context) error {
+	if c == nil || c.store == nil {
+		return nil
+	}
+
+	var records []SourceCounts
+	if _, err := c.store.GetAll(ctx, datastore.NewQuery(sourceCountsKind), &records); err != nil {
+		return fmt.Errorf("failed to load ingest counters: %v", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sources = make(map[string]*SourceCounts, len(records))
+	for i := range records {
+		c.sources[records[i].FeedURL] = &records[i]
+	}
+	c.total = 0
+	for _, record := range c.sources {
+		c.total += record.ItemsIngested
+	}
+	return nil
+}
+
+// Apply adds the batches written for a source to its counters, skipping batches already applied.
+// It returns the number of items counted.
+func (c *IngestCounters) Apply(ctx context.Context, feedURL string, batches []IngestBatch) int {
+	if c == nil || len(batches) == 0 {
+		return 0
+	}
+
+	now := c.now().UTC()
+	day := now.Format(time.DateOnly)
+
+	c.mu.Lock()
+	record, exists := c.sources[feedURL]
+	if !exists {
+		record = &SourceCounts{FeedURL: feedURL}
+		c.sources[feedURL] = record
+	}
+
+	counted, applied, replayed := 0, 0, 0
+	for _, batch := range batches {
+		if batch.Items <= 0 {
+			continue
+		}
+		if containsToken(record.AppliedTokens, batch.Token) {
+			replayed++
+			continue
+		}
+		if record.Day != day {
+			record.Day, record.ItemsToday = day, 0
+		}
+		record.ItemsIngested += int64(batch.Items)
+		record.ItemsToday += int64(batch.Items)
+		record.Batches++
+		record.AppliedTokens = append(record.AppliedTokens, batch.Token)
+		counted += batch.Items
+		applied++
+	}
+	if excess := len(record.AppliedTokens) - maxAppliedTokens; excess > 0 {
+		record.AppliedTokens = append([]string(nil), record.AppliedTokens[excess:]...)
+	}
+	if counted > 0 {
+		record.LastIngestAt = now
+		c.total += int64(counted)
+	}
+	snapshot := *record
+	snapshot.AppliedTokens = append([]string(nil), record.AppliedTokens...)
+	c.mu.Unlock()
+
+	monitoring.RecordIngestCounterBatches("replayed", replayed)
+	if counted == 0 {
+		return 0
+	}
+	monitoring.RecordIngestCounterBatches("applied", applied)
+	c.persist(ctx, snapshot)
+	return counted
+}
+
+// Counts returns the counters of a source, with ItemsToday reset if no items were ingested today
+func (c *IngestCounters) Counts(feedURL string) (SourceCounts, bool) {
+	if c == nil {
+		return SourceCounts{}, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	record, exists := c.sources[feedURL]
+	if !exists {
+		return SourceCounts{}, false
+	}
+	return c.snapshot(record), true
+}
+
+// Ingested returns how many items have been counted for a source, or for all sources when feedURL is empty
+func (c *IngestCounters) Ingested(feedURL string) int64 {
+	if c == nil {
+		return 0
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if feedURL == "" {
+		return c.total
+	}
+	if record, exists := c.sources[feedURL]; exists {
+		return record.ItemsIngested
+	}
+	return 0
+}
+
+// All returns the counters of every source, ordered by URL
+func (c *IngestCounters) All() []SourceCounts {
+	if c == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	all := make([]SourceCounts, 0, len(c.sources))
+	for _, record := range c.sources {
+		all = append(all, c.snapshot(record))
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].FeedURL < all[j].FeedURL
+	})
+	return all
+}
+
+// snapshot copies a record without its tokens; callers must hold the lock
+func (c *IngestCounters) snapshot(record *SourceCounts) SourceCounts {
+	snapshot := *record
+	snapshot.AppliedTokens = nil
+	if today := c.now().UTC().Format(time.DateOnly); snapshot.Day != today {
+		snapshot.Day, snapshot.ItemsToday = today, 0
+	}
+	return snapshot
+}
+
+// persist stores a source's counters, logging rather than failing the ingest on error
+func (c *IngestCounters) persist(ctx context.Context, record SourceCounts) {
+	if c.store == nil {
+		return
+	}
+
+	key := datastore.NameKey(sourceCountsKind, record.FeedURL, nil)
+	if _, err := c.store.PutMulti(ctx, []*datastore.Key{key}, []*SourceCounts{&record}); err != nil {
+		c.logger.WithFields(logrus.Fields{
+			"url":   record.FeedURL,
+			"error": err.Error(),
+		}).Warn("Failed to save ingest counters")
+	}
+}
+
+// containsToken reports whether tokens includes token
+func containsToken(tokens []string, token string) bool {
+	for _, candidate := range tokens {
+		if candidate == token {
+			return true
+		}
+	}
+	return false
+}
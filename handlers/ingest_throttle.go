@@ -0,0 +1,265 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"golang.org/x/time/rate"
+)
+
+// TenantIDHeader names the tenant a request belongs to; requests without it are attributed to the caller's user ID
+const TenantIDHeader = "X-Tenant-ID"
+
+// DefaultTenant is the tenant of requests that name neither a tenant nor a user
+const DefaultTenant = "default"
+
+// ErrIngestThrottled is returned when a tenant has more writes queued than it is allowed
+var ErrIngestThrottled = errors.New("ingest throttled")
+
+// TenantFromRequest returns the tenant whose ingest budget a request draws on
+func TenantFromRequest(r *http.Request) string {
+	if tenant := r.Header.Get(TenantIDHeader); userIDPattern.MatchString(tenant) {
+		return tenant
+	}
+	if userID := userIDFromRequest(r); userID != "" {
+		return userID
+	}
+	return DefaultTenant
+}
+
+// IngestUsage reports a tenant's Datastore write budget and how much of it has been used
+type IngestUsage struct {
+	Tenant string `json:"tenant"`
+	// WriteRate is the tenant's current budget in entities per second, shared fairly when tenants compete
+	WriteRate       float64 `json:"write_rate"`
+	WriteBurst      int     `json:"write_burst"`
+	EntitiesWritten int64   `json:"entities_written"`
+	EntitiesQueued  int     `json:"entities_queued"`
+	MaxQueued       int     `json:"max_queued"`
+	// ThrottledWrites counts writes that had to wait for budget, and RejectedWrites those refused outright
+	ThrottledWrites  int64     `json:"throttled_writes"`
+	RejectedWrites   int64     `json:"rejected_writes"`
+	TotalWaitSeconds float64   `json:"total_wait_seconds"`
+	LastWriteAt      time.Time `json:"last_write_at"`
+}
+
+// tenantBudget tracks one tenant's write limiter and usage
+type tenantBudget struct {
+	limiter *rate.Limiter
+	usage   IngestUsage
+}
+
+/*
+IngestThrottle limits how fast each tenant may write items to Datastore.
+
+Each tenant has its own token bucket. While several tenants are writing at once,
+the global rate is split evenly between them, so a large import by one tenant
+queues behind its own budget instead of starving everyone else. Writes wait for
+budget in order; once a tenant has more than its maximum queued, further writes
+are rejected with ErrIngestThrottled.
+*/
+type IngestThrottle struct {
+	mu         sync.Mutex
+	tenants    map[string]*tenantBudget
+	tenantRate float64
+	globalRate float64
+	burst      int
+	maxQueued  int
+	active     int
+}
+
+// NewIngestThrottle creates a throttle allowing each tenant tenantRate entities per second,
+// with all tenants together limited to globalRate (0 leaves the total unbounded).
+// A tenantRate of 0 disables throttling and returns nil.
+func NewIngestThrottle(tenantRate, globalRate float64, burst, maxQueued int) *IngestThrottle {
+	if tenantRate <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &IngestThrottle{
+		tenants:    make(map[string]*tenantBudget),
+		tenantRate: tenantRate,
+		globalRate: globalRate,
+		burst:      burst,
+		maxQueued:  maxQueued,
+	}
+}
+
+// Acquire waits until the tenant may write n entities, or returns an error when its queue is full or ctx is done
+func (t *IngestThrottle) Acquire(ctx context.Context, tenant string, n int) error {
+	if t == nil || n <= 0 {
+		return nil
+	}
+
+	t.mu.Lock()
+	budget := t.budget(tenant)
+	if t.maxQueued > 0 && budget.usage.EntitiesQueued+n > t.maxQueued {
+		budget.usage.RejectedWrites++
+		t.mu.Unlock()
+		return fmt.Errorf("%w: tenant %s has more than %d entities queued", ErrIngestThrottled, tenant, t.maxQueued)
+	}
+	if budget.usage.EntitiesQueued == 0 {
+		t.active++
+		t.rebalance()
+	}
+	budget.usage.EntitiesQueued += n
+	limiter := budget.limiter
+	t.mu.Unlock()
+
+	start := time.Now()
+	written, err := 0, error(nil)
+	for written < n && err == nil {
+		chunk := n - written
+		if chunk > t.burst {
+			chunk = t.burst
+		}
+		if err = limiter.WaitN(ctx, chunk); err == nil {
+			written += chunk
+		}
+	}
+	waited := time.Since(start)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	budget.usage.EntitiesQueued -= n
+	if budget.usage.EntitiesQueued == 0 {
+		t.active--
+		t.rebalance()
+	}
+	budget.usage.EntitiesWritten += int64(written)
+	// Waits shorter than a millisecond are ordinary scheduling, not throttling
+	if waited >= time.Millisecond {
+		budget.usage.ThrottledWrites++
+		budget.usage.TotalWaitSeconds += waited.Seconds()
+	}
+	if written > 0 {
+		budget.usage.LastWriteAt = time.Now()
+	}
+	if err != nil {
+		return fmt.Errorf("waiting for ingest budget: %w", err)
+	}
+	return nil
+}
+
+// Usage returns a tenant's ingest budget and usage
+func (t *IngestThrottle) Usage(tenant string) IngestUsage {
+	if t == nil {
+		return IngestUsage{Tenant: tenant}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.snapshot(tenant, t.budget(tenant))
+}
+
+// AllUsage returns the ingest usage of every tenant that has written, ordered by tenant
+func (t *IngestThrottle) AllUsage() []IngestUsage {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	usage := make([]IngestUsage, 0, len(t.tenants))
+	for tenant, budget := range t.tenants {
+		usage = append(usage, t.snapshot(tenant, budget))
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		return usage[i].Tenant < usage[j].Tenant
+	})
+	return usage
+}
+
+// Forget drops a tenant's usage history, reporting whether there was any.
+// A tenant with writes in flight keeps its budget, but its counters are cleared.
+func (t *IngestThrottle) Forget(tenant string) bool {
+	if t == nil {
+		return false
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	budget, exists := t.tenants[tenant]
+	if !exists {
+		return false
+	}
+	if budget.usage.EntitiesQueued > 0 {
+		budget.usage = IngestUsage{EntitiesQueued: budget.usage.EntitiesQueued}
+	} else {
+		delete(t.tenants, tenant)
+	}
+	return true
+}
+
+// budget returns the tenant's budget, creating it on first use; callers must hold the lock
+func (t *IngestThrottle) budget(tenant string) *tenantBudget {
+	budget, exists := t.tenants[tenant]
+	if !exists {
+		budget = &tenantBudget{limiter: rate.NewLimiter(rate.Limit(t.share()), t.burst)}
+		t.tenants[tenant] = budget
+	}
+	return budget
+}
+
+// share returns the rate each writing tenant currently gets; callers must hold the lock
+func (t *IngestThrottle) share() float64 {
+	share := t.tenantRate
+	if t.globalRate > 0 && t.active > 0 && t.globalRate/float64(t.active) < share {
+		share = t.globalRate / float64(t.active)
+	}
+	return share
+}
+
+// rebalance splits the global rate between writing tenants; callers must hold the lock
+func (t *IngestThrottle) rebalance() {
+	limit := rate.Limit(t.share())
+	for _, budget := range t.tenants {
+		if budget.limiter.Limit() != limit {
+			budget.limiter.SetLimit(limit)
+		}
+	}
+}
+
+// snapshot copies a tenant's usage with its current budget; callers must hold the lock
+func (t *IngestThrottle) snapshot(tenant string, budget *tenantBudget) IngestUsage {
+	usage := budget.usage
+	usage.Tenant = tenant
+	usage.WriteRate = float64(budget.limiter.Limit())
+	usage.WriteBurst = t.burst
+	usage.MaxQueued = t.maxQueued
+	return usage
+}
+
+// throttledWriter charges a tenant's ingest budget for each entity it writes
+type throttledWriter struct {
+	DatastoreClientInterface
+	throttle *IngestThrottle
+	tenant   string
+}
+
+// PutMulti waits for the tenant's budget before writing
+func (w throttledWriter) PutMulti(ctx context.Context, keys []*datastore.Key, src interface{}) ([]*datastore.Key, error) {
+	if err := w.throttle.Acquire(ctx, w.tenant, len(keys)); err != nil {
+		return nil, err
+	}
+	return w.DatastoreClientInterface.PutMulti(ctx, keys, src)
+}
+
+// ingestClient returns a Datastore client whose item writes draw on the tenant's ingest budget
+func ingestClient(client DatastoreClientInterface, throttle *IngestThrottle, tenant string) DatastoreClientInterface {
+	if throttle == nil || client == nil {
+		return client
+	}
+	return throttledWriter{DatastoreClientInterface: client, throttle: throttle, tenant: tenant}
+}
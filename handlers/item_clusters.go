@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"cloud.google.com/go/datastore"
+	"github.com/Nexora-Open-Source/rss-feed-backend/utils"
+)
+
+// clusterEntry is a recently seen item as remembered by the clusterer
+type clusterEntry struct {
+	canonicalURL string
+	contentHash  string
+	titleTokens  []string
+	clusterID    string
+}
+
+/*
+ItemClusterer assigns cluster IDs so that copies of the same article published
+by different sources share one ID.
+
+Incoming items are compared against a bounded window of recently seen items by
+canonical URL, identical content, and title similarity; an item that matches
+joins the existing cluster, otherwise it starts a new one.
+*/
+type ItemClusterer struct {
+	mu        sync.Mutex
+	recent    []clusterEntry
+	next      int
+	capacity  int
+	threshold float64
+	store     DatastoreReaderInterface
+}
+
+// NewItemClusterer creates a clusterer remembering up to capacity items and
+// matching titles at or above threshold similarity (0 matches by URL and identical content only)
+func NewItemClusterer(store DatastoreReaderInterface, capacity int, threshold float64) *ItemClusterer {
+	return &ItemClusterer{
+		capacity:  capacity,
+		threshold: threshold,
+		store:     store,
+	}
+}
+
+// Assign sets the cluster ID of each item that does not have one yet
+func (c *ItemClusterer) Assign(items []*utils.FeedItem) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, item := range items {
+		entry := clusterEntry{
+			canonicalURL: utils.CanonicalItemURL(item.Link),
+			contentHash:  item.ContentHash,
+			titleTokens:  utils.TitleTokens(item.Title),
+			clusterID:    item.ClusterID,
+		}
+		seen := c.find(entry)
+		if entry.clusterID == "" && seen != nil {
+			entry.clusterID = seen.clusterID
+		}
+		if entry.clusterID == "" {
+			entry.clusterID = utils.ClusterIDFor(item)
+		}
+		item.ClusterID = entry.clusterID
+
+		// Refetched items are already in the window
+		if seen == nil || seen.canonicalURL != entry.canonicalURL {
+			c.remember(entry)
+		}
+	}
+}
+
+// LoadRecent seeds the clusterer with the most recently published stored items
+func (c *ItemClusterer) LoadRecent(ctx context.Context) error {
+	if c == nil || c.store == nil || c.capacity <= 0 {
+		return nil
+	}
+
+	query := datastore.NewQuery("FeedItem").Order("-pub_date").Limit(c.capacity)
+	var items []*utils.FeedItem
+	if _, err := c.store.GetAll(ctx, query, &items); err != nil {
+		return fmt.Errorf("failed to load recent items: %v", err)
+	}
+
+	// Remember the oldest first so the newest survive in the window
+	for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+		items[i], items[j] = items[j], items[i]
+	}
+	c.Assign(items)
+	return nil
+}
+
+// find returns the remembered item matching entry, preferring a canonical URL match, then identical content; callers must hold the lock
+func (c *ItemClusterer) find(entry clusterEntry) *clusterEntry {
+	var identical, similar *clusterEntry
+	for i := range c.recent {
+		seen := &c.recent[i]
+		if seen.canonicalURL == entry.canonicalURL {
+			return seen
+		}
+		if identical == nil && entry.contentHash != "" && seen.contentHash == entry.contentHash {
+			identical = seen
+		}
+		if similar == nil && c.threshold > 0 && utils.TokenSimilarity(seen.titleTokens, entry.titleTokens) >= c.threshold {
+			similar = seen
+		}
+	}
+	if identical != nil {
+		return identical
+	}
+	return similar
+}
+
+// remember adds an entry to the window, replacing the oldest once full; callers must hold the lock
+func (c *ItemClusterer) remember(entry clusterEntry) {
+	if c.capacity <= 0 {
+		return
+	}
+	if len(c.recent) < c.capacity {
+		c.recent = append(c.recent, entry)
+		return
+	}
+	c.recent[c.next] = entry
+	c.next = (c.next + 1) % c.capacity
+}
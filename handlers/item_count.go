@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Nexora-Open-Source/rss-feed-backend/middleware"
+	"github.com/Nexora-Open-Source/rss-feed-backend/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// itemCountCursorPrefix marks an item count cursor, which holds the ingest counter value when it was issued
+const itemCountCursorPrefix = "count:"
+
+// ItemCountResponse is the response body for GET /items/count
+type ItemCountResponse struct {
+	// Count is how many items were ingested after the since cursor; 0 when no cursor was given
+	Count int64 `json:"count"`
+	// Cursor marks the current position; pass it as since on the next poll
+	Cursor string `json:"cursor"`
+	Source string `json:"source,omitempty"`
+}
+
+// @Summary Count new items
+// @Description Returns how many items were ingested since a cursor from an earlier call, for "new items" badges. Answered from in-memory ingest counters without querying Datastore, so it is cheap to poll. Call without since to get a starting cursor.
+// @Tags RSS Feed Operations
+// @Produce json
+// @Param since query string false "Cursor returned by a previous call"
+// @Param source query string false "Only count items from this feed URL, as listed by /feeds"
+// @Success 200 {object} ItemCountResponse "New item count"
+// @Failure 400 {object} middleware.APIError "Invalid cursor"
+// @Router /items/count [get]
+func (h *Handler) HandleGetItemCount(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Header.Get("X-Request-ID")
+	if requestID == "" {
+		requestID = utils.GenerateRequestID()
+		w.Header().Set("X-Request-ID", requestID)
+	}
+
+	source := r.URL.Query().Get("source")
+	current := h.IngestCounters.Ingested(source)
+
+	response := ItemCountResponse{
+		Cursor: itemCountCursorPrefix + strconv.FormatInt(current, 10),
+		Source: source,
+	}
+	if since := r.URL.Query().Get("since"); since != "" {
+		value, found := strings.CutPrefix(since, itemCountCursorPrefix)
+		seen, err := strconv.ParseInt(value, 10, 64)
+		if !found || err != nil || seen < 0 {
+			middleware.RespondBadRequest(w, fmt.Errorf("invalid since parameter: expected a cursor from a previous call"), requestID)
+			return
+		}
+		// A cursor ahead of the counters was issued before they were reset, so nothing is known to be new
+		response.Count = max(current-seen, 0)
+	}
+
+	middleware.Log(r.Context()).WithFields(logrus.Fields{
+		"source": source,
+		"count":  response.Count,
+	}).Debug("Item count retrieved")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/Nexora-Open-Source/rss-feed-backend/middleware"
+	"github.com/Nexora-Open-Source/rss-feed-backend/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// ItemRevisionKind is the Datastore kind holding replaced versions of feed items, as children of the item's key
+const ItemRevisionKind = "FeedItemRevision"
+
+// Limits of as_of queries, which reconstruct a past state from stored items and their revisions
+const (
+	// MaxAsOfScanItems caps how many stored items an as_of query reads
+	MaxAsOfScanItems = 2000
+	// MaxAsOfRevisedItems caps how many items edited since as_of a keyword query reads revisions of;
+	// without a keyword, revisions are read for the returned page only
+	MaxAsOfRevisedItems = 100
+)
+
+// defaultItemRevisionRetention is how long replaced item versions are kept when no retention is configured
+const defaultItemRevisionRetention = 30 * 24 * time.Hour
+
+// ErrAsOfScanTooLarge is returned for as_of queries reading more stored items or revisions than their limits allow
+var ErrAsOfScanTooLarge = fmt.Errorf("as_of query matches more than %d items, or more than %d edited items with a keyword; narrow it with source, author, or date filters",
+	MaxAsOfScanItems, MaxAsOfRevisedItems)
+
+// ItemRevision is a version of a feed item that was replaced when the item's content changed at its origin
+type ItemRevision struct {
+	utils.FeedItem
+	// SupersededAt is when the next version replaced this one
+	SupersededAt time.Time
+}
+
+// newItemRevision keeps item as the version replaced at supersededAt. The suppression list is left out: as_of reads
+// apply mute rules as they read, and user IDs kept in history would outlive the deletion of a user's data
+func newItemRevision(item *utils.FeedItem, supersededAt time.Time) *ItemRevision {
+	revision := &ItemRevision{FeedItem: *item, SupersededAt: supersededAt}
+	revision.SuppressedFor = nil
+	return revision
+}
+
+// Load implements datastore.PropertyLoadSaver; the embedded FeedItem would otherwise load the revision as a plain item
+func (r *ItemRevision) Load(properties []datastore.Property) error {
+	itemProperties := make([]datastore.Property, 0, len(properties))
+	for _, property := range properties {
+		if property.Name == "superseded_at" {
+			supersededAt, ok := property.Value.(time.Time)
+			if !ok {
+				return fmt.Errorf("superseded_at has type %T, want time.Time", property.Value)
+			}
+			r.SupersededAt = supersededAt
+			continue
+		}
+		itemProperties = append(itemProperties, property)
+	}
+	return r.FeedItem.Load(itemProperties)
+}
+
+// Save implements datastore.PropertyLoadSaver, storing the item at the current schema version with its replacement time
+func (r *ItemRevision) Save() ([]datastore.Property, error) {
+	properties, err := r.FeedItem.Save()
+	if err != nil {
+		return nil, err
+	}
+	// Indexed so revisions past retention can be found and pruned
+	return append(properties, datastore.Property{Name: "superseded_at", Value: r.SupersededAt}), nil
+}
+
+// itemRevisionKey keys a revision under its item by the time it was replaced
+func itemRevisionKey(revision *ItemRevision) *datastore.Key {
+	return datastore.NameKey(ItemRevisionKind, revision.SupersededAt.UTC().Format(time.RFC3339Nano), datastore.NameKey("FeedItem", revision.Link, nil))
+}
+
+/*
+saveItemRevisions stores the versions of items about to be replaced.
+
+Revisions are written before the items that replace them, so a failed item write
+leaves an extra revision of the version still stored rather than a gap in history.
+History is best effort: a failed revision write is logged and the save goes on,
+since failing the ingest would not bring the replaced version back.
+*/
+func saveItemRevisions(ctx context.Context, client DatastoreClientInterface, revisions []*ItemRevision) {
+	if len(revisions) == 0 {
+		return
+	}
+	keys := make([]*datastore.Key, len(revisions))
+	for i, revision := range revisions {
+		keys[i] = itemRevisionKey(revision)
+	}
+	if _, err := client.PutMulti(ctx, keys, revisions); err != nil {
+		middleware.Logger.WithFields(logrus.Fields{
+			"revisions":  len(revisions),
+			"first_link": revisions[0].Link,
+			"error":      err.Error(),
+		}).Warn("Failed to save feed item revisions")
+	}
+}
+
+// PruneItemRevisions deletes revisions replaced before cutoff and returns how many were deleted
+func PruneItemRevisions(ctx context.Context, client DatastoreClientInterface, cutoff time.Time) (int, error) {
+	query := datastore.NewQuery(ItemRevisionKind).Filter("superseded_at <", cutoff).KeysOnly()
+	keys, err := client.GetAll(ctx, query, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find expired item revisions: %v", err)
+	}
+	for start := 0; start < len(keys); start += bulkWriteBatch {
+		end := min(start+bulkWriteBatch, len(keys))
+		if err := client.DeleteMulti(ctx, keys[start:end]); err != nil {
+			return start, fmt.Errorf("failed to delete expired item revisions: %v", err)
+		}
+	}
+	return len(keys), nil
+}
+
+// deleteItemRevisions deletes every revision of the items with the given keys, before the items themselves are deleted
+func deleteItemRevisions(ctx context.Context, client DatastoreClientInterface, itemKeys []*datastore.Key) error {
+	var revisionKeys []*datastore.Key
+	for _, key := range itemKeys {
+		keys, err := client.GetAll(ctx, datastore.NewQuery(ItemRevisionKind).Ancestor(key).KeysOnly(), nil)
+		if err != nil {
+			return fmt.Errorf("failed to find revisions of %s: %v", key.Name, err)
+		}
+		revisionKeys = append(revisionKeys, keys...)
+	}
+	for start := 0; start < len(revisionKeys); start += bulkWriteBatch {
+		end := min(start+bulkWriteBatch, len(revisionKeys))
+		if err := client.DeleteMulti(ctx, revisionKeys[start:end]); err != nil {
+			return fmt.Errorf("failed to delete item revisions: %v", err)
+		}
+	}
+	return nil
+}
+
+// ingestedBy reports whether the item had been stored at asOf. Items stored before ingest times were recorded
+// count from their publication time, and are left out when that is unknown too.
+func ingestedBy(item *utils.FeedItem, asOf time.Time) bool {
+	if !item.IngestedAt.IsZero() {
+		return !item.IngestedAt.After(asOf)
+	}
+	return !item.PublishedAt.IsZero() && !item.PublishedAt.After(asOf)
+}
+
+// itemVersionAt returns the version of a stored item that was current at asOf: the earliest revision replaced
+// after asOf, or the item itself when none was
+func itemVersionAt(ctx context.Context, client DatastoreReaderInterface, key *datastore.Key, item *utils.FeedItem, asOf time.Time) (*utils.FeedItem, error) {
+	// Only items revised after asOf can have a revision current at asOf
+	if !item.RevisedAt.After(asOf) {
+		return item, nil
+	}
+	var revisions []*ItemRevision
+	if _, err := client.GetAll(ctx, datastore.NewQuery(ItemRevisionKind).Ancestor(key), &revisions); err != nil {
+		return nil, fmt.Errorf("failed to load revisions of %s: %v", item.Link, err)
+	}
+	var current *ItemRevision
+	for _, revision := range revisions {
+		if revision.SupersededAt.After(asOf) && (current == nil || revision.SupersededAt.Before(current.SupersededAt)) {
+			current = revision
+		}
+	}
+	if current == nil {
+		return item, nil
+	}
+	return &current.FeedItem, nil
+}
+
+/*
+fetchFeedItemsAsOf returns the page of items a query would have returned at params.AsOf.
+
+Items ingested after AsOf are left out, and items revised since are replaced by
+the version current at AsOf. Since ingest times are not indexed alongside the
+other filters, every stored item matching them is read, up to MaxAsOfScanItems.
+Without a keyword, revisions are read only for the items on the returned page.
+A keyword must be matched against past versions, so revisions are read for every
+item edited since AsOf, up to MaxAsOfRevisedItems. Items removed by retention
+cleanup cannot be brought back.
+*/
+func fetchFeedItemsAsOf(ctx context.Context, client DatastoreReaderInterface, params ItemsQueryParams) (*PaginatedResult, error) {
+	query := datastore.NewQuery("FeedItem")
+	if params.Source != "" {
+		query = query.Filter("link >", params.Source).Filter("link <", params.Source+"\ufffd")
+	}
+	if params.Author != "" {
+		query = query.Filter("author =", params.Author)
+	}
+	query = filterPubDate(query, params.FilterParams).
+		Order("-pub_date").
+		Limit(MaxAsOfScanItems + 1)
+
+	var stored []*utils.FeedItem
+	storedKeys, err := client.GetAll(ctx, query, &stored)
+	if err != nil {
+		return nil, err
+	}
+	if len(stored) > MaxAsOfScanItems {
+		return nil, ErrAsOfScanTooLarge
+	}
+
+	var items []*utils.FeedItem
+	var keys []*datastore.Key
+	revised := 0
+	for i, item := range stored {
+		if !ingestedBy(item, params.AsOf) {
+			continue
+		}
+		if item.RevisedAt.After(params.AsOf) {
+			revised++
+		}
+		items = append(items, item)
+		keys = append(keys, storedKeys[i])
+	}
+
+	// A keyword can only be matched once past versions are known
+	if keyword := strings.ToLower(params.Keyword); keyword != "" {
+		if revised > MaxAsOfRevisedItems {
+			return nil, ErrAsOfScanTooLarge
+		}
+		var matched []*utils.FeedItem
+		for i, item := range items {
+			version, err := itemVersionAt(ctx, client, keys[i], item, params.AsOf)
+			if err != nil {
+				return nil, err
+			}
+			if strings.Contains(strings.ToLower(version.Title), keyword) ||
+				strings.Contains(strings.ToLower(version.Description), keyword) {
+				matched = append(matched, version)
+			}
+		}
+		items, keys = matched, nil
+	}
+
+	if params.Limit <= 0 {
+		params.Limit = defaultItemsPageSize
+	}
+	if params.Limit > MaxItemsPageSize {
+		params.Limit = MaxItemsPageSize
+	}
+	totalCount := len(items)
+	start := min(params.Offset, totalCount)
+	end := min(start+params.Limit, totalCount)
+
+	page := items[start:end]
+	if keys != nil {
+		page = make([]*utils.FeedItem, 0, end-start)
+		for i := start; i < end; i++ {
+			version, err := itemVersionAt(ctx, client, keys[i], items[i], params.AsOf)
+			if err != nil {
+				return nil, err
+			}
+			page = append(page, version)
+		}
+	}
+
+	result := &PaginatedResult{
+		Items:      page,
+		TotalCount: totalCount,
+		HasMore:    end < totalCount,
+	}
+	if result.HasMore {
+		result.NextCursor = fmt.Sprintf("offset:%d", end)
+	}
+	return result, nil
+}
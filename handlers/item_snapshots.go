@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/Nexora-Open-Source/rss-feed-backend/middleware"
+	"github.com/Nexora-Open-Source/rss-feed-backend/monitoring"
+	"github.com/Nexora-Open-Source/rss-feed-backend/utils"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// snapshotHashPattern matches the content hashes snapshots are addressed by
+var snapshotHashPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// SnapshotResponse is the response body for GET /snapshots/{hash}
+type SnapshotResponse struct {
+	utils.ItemSnapshot
+	// Content is the item content exactly as its feed served it
+	Content string `json:"content"`
+}
+
+/*
+SnapshotStore keeps compressed copies of the content of stored items.
+
+Snapshots are written after items are saved, addressed by content hash: content
+shared by several items, such as an article syndicated across sources, is stored
+once, and writing a snapshot that already exists is harmless. Content too large
+to snapshot is skipped; the item keeps its hash either way.
+*/
+type SnapshotStore struct {
+	store  DatastoreClientInterface
+	logger *logrus.Logger
+	now    func() time.Time
+}
+
+// NewSnapshotStore creates a snapshot store writing to store; a nil store disables snapshots and returns nil
+func NewSnapshotStore(store DatastoreClientInterface, logger *logrus.Logger) *SnapshotStore {
+	if store == nil {
+		return nil
+	}
+	return &SnapshotStore{
+		store:  store,
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+// Save snapshots the content of stored items, returning the number of snapshots written
+func (s *SnapshotStore) Save(ctx context.Context, items []*utils.FeedItem) (int, error) {
+	if s == nil {
+		return 0, nil
+	}
+
+	seen := make(map[string]bool)
+	var keys []*datastore.Key
+	var snapshots []*utils.ItemSnapshot
+	for _, item := range items {
+		if item.ContentHash == "" || seen[item.ContentHash] {
+			continue
+		}
+		seen[item.ContentHash] = true
+
+		snapshot, err := utils.NewItemSnapshot(item.RawContent, s.now().UTC())
+		if err != nil {
+			monitoring.RecordItemSnapshots("skipped", 1)
+			s.logger.WithFields(logrus.Fields{
+				"link":         item.Link,
+				"content_hash": item.ContentHash,
+				"error":        err.Error(),
+			}).Debug("Skipped item snapshot")
+			continue
+		}
+		// Hashes are computed at parse time, so a mismatch means the item was not parsed from a feed
+		if snapshot.Hash != item.ContentHash {
+			continue
+		}
+		keys = append(keys, datastore.NameKey(utils.ItemSnapshotKind, snapshot.Hash, nil))
+		snapshots = append(snapshots, snapshot)
+	}
+	if len(snapshots) == 0 {
+		return 0, nil
+	}
+
+	if _, err := s.store.PutMulti(ctx, keys, snapshots); err != nil {
+		monitoring.RecordItemSnapshots("failed", len(snapshots))
+		return 0, fmt.Errorf("failed to store item snapshots: %v", err)
+	}
+	monitoring.RecordItemSnapshots("stored", len(snapshots))
+	return len(snapshots), nil
+}
+
+// Get returns the snapshot of the content with the given hash; found is false when there is none
+func (s *SnapshotStore) Get(ctx context.Context, hash string) (*utils.ItemSnapshot, bool, error) {
+	if s == nil {
+		return nil, false, nil
+	}
+
+	var snapshot utils.ItemSnapshot
+	err := s.store.Get(ctx, datastore.NameKey(utils.ItemSnapshotKind, hash, nil), &snapshot)
+	if errors.Is(err, datastore.ErrNoSuchEntity) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to load item snapshot: %v", err)
+	}
+	return &snapshot, true, nil
+}
+
+// @Summary Get an item content snapshot
+// @Description Returns the content of stored items exactly as their feeds served it, by the content_hash reported on items, so items can be rendered again without fetching their origin. Identical content from different sources shares one snapshot.
+// @Tags RSS Feed Operations
+// @Produce json
+// @Param hash path string true "Content hash (hex SHA-256)"
+// @Success 200 {object} SnapshotResponse "Content snapshot"
+// @Failure 400 {object} middleware.APIError "Invalid hash"
+// @Failure 404 {object} middleware.APIError "No snapshot with this hash"
+// @Failure 500 {object} middleware.APIError "Internal server error"
+// @Router /snapshots/{hash} [get]
+func (h *Handler) HandleGetSnapshot(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Header.Get("X-Request-ID")
+	if requestID == "" {
+		requestID = utils.GenerateRequestID()
+		w.Header().Set("X-Request-ID", requestID)
+	}
+
+	hash := mux.Vars(r)["hash"]
+	if !snapshotHashPattern.MatchString(hash) {
+		middleware.RespondBadRequest(w, fmt.Errorf("hash must be a lowercase hex SHA-256 digest"), requestID)
+		return
+	}
+
+	snapshot, found, err := h.Snapshots.Get(r.Context(), hash)
+	if err != nil {
+		middleware.RespondInternalError(w, err, requestID)
+		return
+	}
+	if !found {
+		middleware.RespondNotFound(w, fmt.Errorf("no snapshot with hash %s", hash), requestID)
+		return
+	}
+	content, err := snapshot.Text()
+	if err != nil {
+		middleware.RespondInternalError(w, err, requestID)
+		return
+	}
+
+	// Content never changes for a hash, so responses can be cached indefinitely
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(SnapshotResponse{ItemSnapshot: *snapshot, Content: content})
+}
@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/Nexora-Open-Source/rss-feed-backend/monitoring"
+	"github.com/Nexora-Open-Source/rss-feed-backend/types"
+	"github.com/sirupsen/logrus"
+)
+
+// jobStatusKind is the Datastore kind holding job statuses evicted from memory
+const jobStatusKind = "JobStatus"
+
+// Default job status limits, used until SetJobStatusLimits is called
+const (
+	defaultJobStatusRetention = 24 * time.Hour
+	defaultMaxJobStatuses     = 10000
+)
+
+// Limits of the remembered job status misses, which spare Datastore repeated lookups of unknown job IDs
+const (
+	jobMissTTL        = 30 * time.Second
+	maxJobMissEntries = 10000
+)
+
+// archivedJobStatus stores an evicted job status as JSON, since its optional times and nested lists do not map onto entity properties
+type archivedJobStatus struct {
+	JobID      string    `datastore:"job_id"`
+	Status     []byte    `datastore:"status,noindex"`
+	ArchivedAt time.Time `datastore:"archived_at"`
+}
+
+/*
+JobStatusArchive keeps job statuses evicted from the in-memory status table when it
+is at capacity, so they can still be looked up until the retention period ends.
+*/
+type JobStatusArchive struct {
+	store  DatastoreClientInterface
+	logger *logrus.Logger
+}
+
+// NewJobStatusArchive creates an archive of evicted job statuses persisted to store; with a nil store nothing is kept
+func NewJobStatusArchive(store DatastoreClientInterface, logger *logrus.Logger) *JobStatusArchive {
+	return &JobStatusArchive{store: store, logger: logger}
+}
+
+// Save stores evicted job statuses, logging rather than failing on error
+func (a *JobStatusArchive) Save(ctx context.Context, statuses []types.AsyncJobStatus) {
+	if a == nil || a.store == nil || len(statuses) == 0 {
+		return
+	}
+
+	now := time.Now()
+	keys := make([]*datastore.Key, 0, len(statuses))
+	records := make([]*archivedJobStatus, 0, len(statuses))
+	for _, status := range statuses {
+		encoded, err := json.Marshal(status)
+		if err != nil {
+			continue
+		}
+		keys = append(keys, datastore.NameKey(jobStatusKind, status.JobID, nil))
+		records = append(records, &archivedJobStatus{JobID: status.JobID, Status: encoded, ArchivedAt: now})
+	}
+
+	for start := 0; start < len(keys); start += bulkWriteBatch {
+		end := min(start+bulkWriteBatch, len(keys))
+		if _, err := a.store.PutMulti(ctx, keys[start:end], records[start:end]); err != nil {
+			a.logger.WithFields(logrus.Fields{
+				"jobs_count": end - start,
+				"error":      err.Error(),
+			}).Warn("Failed to archive evicted job statuses")
+			monitoring.RecordJobStatusEvictions("archive_failed", end-start)
+			continue
+		}
+		monitoring.RecordJobStatusEvictions("archived", end-start)
+	}
+}
+
+// Lookup returns an archived job status, if the job was created within the retention period.
+// The error is set only when the archive could not be read, so a miss is not known to be final.
+func (a *JobStatusArchive) Lookup(ctx context.Context, jobID string, retention time.Duration) (*types.AsyncJobStatus, bool, error) {
+	if a == nil || a.store == nil {
+		return nil, false, nil
+	}
+
+	var record archivedJobStatus
+	if err := a.store.Get(ctx, datastore.NameKey(jobStatusKind, jobID, nil), &record); err != nil {
+		if errors.Is(err, datastore.ErrNoSuchEntity) {
+			return nil, false, nil
+		}
+		a.logger.WithFields(logrus.Fields{
+			"job_id": jobID,
+			"error":  err.Error(),
+		}).Warn("Failed to look up archived job status")
+		return nil, false, err
+	}
+
+	var status types.AsyncJobStatus
+	if err := json.Unmarshal(record.Status, &status); err != nil {
+		return nil, false, nil
+	}
+	if retention > 0 && time.Since(status.CreatedAt) > retention {
+		return nil, false, nil
+	}
+	return &status, true, nil
+}
+
+// Prune deletes statuses archived before cutoff; those jobs were created earlier still, so are past retention
+func (a *JobStatusArchive) Prune(ctx context.Context, cutoff time.Time) error {
+	if a == nil || a.store == nil {
+		return nil
+	}
+
+	query := datastore.NewQuery(jobStatusKind).Filter("archived_at <", cutoff).KeysOnly()
+	keys, err := a.store.GetAll(ctx, query, nil)
+	if err != nil {
+		return fmt.Errorf("failed to find expired job statuses: %v", err)
+	}
+	for start := 0; start < len(keys); start += bulkWriteBatch {
+		end := min(start+bulkWriteBatch, len(keys))
+		if err := a.store.DeleteMulti(ctx, keys[start:end]); err != nil {
+			return fmt.Errorf("failed to delete expired job statuses: %v", err)
+		}
+	}
+	return nil
+}
+
+// trackJob adds a job status to the status table.
+// When the table is over capacity the least recently used finished jobs are evicted and returned for archiving.
+// Only finished jobs are in the eviction list, so jobs still pending or running are never visited or evicted.
+// Callers must hold statusMutex.
+func (ap *AsyncProcessor) trackJob(status *types.AsyncJobStatus) []types.AsyncJobStatus {
+	ap.jobStatus[status.JobID] = status
+	delete(ap.jobMisses, status.JobID)
+	ap.touchJob(status.JobID)
+
+	var evicted []types.AsyncJobStatus
+	for len(ap.jobStatus) > ap.maxJobStatuses && ap.jobLRU.Len() > 0 {
+		jobID := ap.jobLRU.Back().Value.(string)
+		evicted = append(evicted, *ap.jobStatus[jobID])
+		ap.untrackJob(jobID)
+	}
+	return evicted
+}
+
+// jobFinished reports whether a job status is terminal
+func jobFinished(status *types.AsyncJobStatus) bool {
+	return status.Status == "completed" || status.Status == "failed"
+}
+
+// touchJob marks a finished job status as the most recently used; unfinished jobs stay out of the eviction list.
+// Callers must hold statusMutex.
+func (ap *AsyncProcessor) touchJob(jobID string) {
+	status, exists := ap.jobStatus[jobID]
+	if !exists || !jobFinished(status) {
+		return
+	}
+	if element, exists := ap.jobLRUElements[jobID]; exists {
+		ap.jobLRU.MoveToFront(element)
+		return
+	}
+	ap.jobLRUElements[jobID] = ap.jobLRU.PushFront(jobID)
+}
+
+// rememberJobMiss records that a job was found nowhere, forgetting every miss when the table is full
+func (ap *AsyncProcessor) rememberJobMiss(jobID string) {
+	ap.statusMutex.Lock()
+	defer ap.statusMutex.Unlock()
+
+	if len(ap.jobMisses) >= maxJobMissEntries {
+		ap.jobMisses = make(map[string]time.Time)
+	}
+	ap.jobMisses[jobID] = time.Now().Add(jobMissTTL)
+}
+
+// untrackJob removes a job's status, waiters, and result; callers must hold statusMutex
+func (ap *AsyncProcessor) untrackJob(jobID string) {
+	delete(ap.jobStatus, jobID)
+	if done, exists := ap.jobDone[jobID]; exists {
+		close(done)
+		delete(ap.jobDone, jobID)
+	}
+	delete(ap.jobResults, jobID)
+	if element, exists := ap.jobLRUElements[jobID]; exists {
+		ap.jobLRU.Remove(element)
+		delete(ap.jobLRUElements, jobID)
+	}
+}
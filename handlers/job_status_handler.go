@@ -1,12 +1,16 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/Nexora-Open-Source/rss-feed-backend/middleware"
+	"github.com/Nexora-Open-Source/rss-feed-backend/types"
 	"github.com/Nexora-Open-Source/rss-feed-backend/utils"
+	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
 )
 
@@ -15,14 +19,17 @@ HandleGetJobStatus retrieves the status of an async job.
 
 Query Parameters:
   - job_id: The ID of the job to check.
+  - wait (optional): Long-poll duration (e.g. 25s). The request is held until the job
+    completes or fails, or the wait elapses; it is capped by MaxJobStatusWait.
 
 Example:
 
 	GET /job-status?job_id=job_1234567890_abc123
+	GET /job-status?job_id=job_1234567890_abc123&wait=25s
 
 Response:
-  - 200 OK: Job status information.
-  - 400 Bad Request: Missing job_id parameter.
+  - 200 OK: Job status information, including the job's event timeline.
+  - 400 Bad Request: Missing job_id parameter or invalid wait duration.
   - 404 Not Found: Job not found.
 */
 func (h *Handler) HandleGetJobStatus(w http.ResponseWriter, r *http.Request) {
@@ -39,28 +46,125 @@ func (h *Handler) HandleGetJobStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Parse optional long-poll duration
+	var wait time.Duration
+	if waitStr := r.URL.Query().Get("wait"); waitStr != "" {
+		parsed, err := time.ParseDuration(waitStr)
+		if err != nil || parsed < 0 {
+			middleware.RespondBadRequest(w, fmt.Errorf("invalid wait parameter: must be a non-negative duration such as 25s"), requestID)
+			return
+		}
+		wait = parsed
+		if h.Config.MaxJobStatusWait > 0 && wait > h.Config.MaxJobStatusWait {
+			wait = h.Config.MaxJobStatusWait
+		}
+	}
+
 	// Log the request
-	middleware.Logger.WithFields(logrus.Fields{
-		"request_id": requestID,
-		"job_id":     jobID,
-		"action":     "get_job_status",
+	middleware.Log(r.Context()).WithFields(logrus.Fields{
+		"job_id": jobID,
+		"action": "get_job_status",
+		"wait":   wait.String(),
 	}).Info("Processing job status request")
 
-	// Get job status from async processor
-	jobStatus, exists := h.AsyncProcessor.GetJobStatus(jobID)
+	// Get job status from async processor, holding the connection if a wait was requested
+	var jobStatus *types.AsyncJobStatus
+	var exists bool
+	if wait > 0 {
+		ctx, cancel := context.WithTimeout(r.Context(), wait)
+		defer cancel()
+		jobStatus, exists = h.AsyncProcessor.WaitForJob(ctx, jobID)
+	} else {
+		jobStatus, exists = h.AsyncProcessor.GetJobStatusWithContext(r.Context(), jobID)
+	}
 	if !exists {
 		middleware.RespondNotFound(w, fmt.Errorf("job not found"), requestID)
 		return
 	}
 
 	// Log successful completion
-	middleware.Logger.WithFields(logrus.Fields{
-		"request_id": requestID,
-		"job_id":     jobID,
-		"status":     jobStatus.Status,
+	middleware.Log(r.Context()).WithFields(logrus.Fields{
+		"job_id": jobID,
+		"status": jobStatus.Status,
 	}).Info("Job status retrieved successfully")
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(jobStatus)
 }
+
+/*
+HandleGetJobResult retrieves the items produced by a completed async job.
+
+Path Parameters:
+  - id: The ID of the job.
+
+Example:
+
+	GET /jobs/job_1234567890_abc123/result
+
+Response:
+  - 200 OK: The feed items the job fetched and stored.
+  - 404 Not Found: Job not found or its result has expired.
+  - 409 Conflict: Job is still running or failed.
+*/
+func (h *Handler) HandleGetJobResult(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Header.Get("X-Request-ID")
+	if requestID == "" {
+		requestID = utils.GenerateRequestID()
+		w.Header().Set("X-Request-ID", requestID)
+	}
+
+	jobID := mux.Vars(r)["id"]
+	if jobID == "" {
+		middleware.RespondBadRequest(w, fmt.Errorf("job id is missing"), requestID)
+		return
+	}
+
+	middleware.Log(r.Context()).WithFields(logrus.Fields{
+		"job_id": jobID,
+		"action": "get_job_result",
+	}).Info("Processing job result request")
+
+	jobStatus, exists := h.AsyncProcessor.GetJobStatusWithContext(r.Context(), jobID)
+	if !exists {
+		middleware.RespondNotFound(w, fmt.Errorf("job not found"), requestID)
+		return
+	}
+
+	switch jobStatus.Status {
+	case "completed":
+	case "failed":
+		middleware.RespondConflict(w, fmt.Errorf("job failed: %s", jobStatus.Error), requestID)
+		return
+	default:
+		middleware.RespondConflict(w, fmt.Errorf("job has not completed (status: %s)", jobStatus.Status), requestID)
+		return
+	}
+
+	items, found := h.AsyncProcessor.GetJobResult(jobID)
+	if !found {
+		middleware.RespondNotFound(w, fmt.Errorf("job result has expired"), requestID)
+		return
+	}
+	items = h.muteFilter(r, items)
+
+	middleware.Log(r.Context()).WithFields(logrus.Fields{
+		"job_id":      jobID,
+		"items_count": len(items),
+	}).Info("Job result retrieved successfully")
+
+	response := FetchResponse{
+		Success:    true,
+		Message:    "Job result retrieved successfully",
+		Data:       items,
+		JobID:      jobID,
+		RequestID:  requestID,
+		ItemsCount: len(items),
+		Status:     jobStatus.Status,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/Nexora-Open-Source/rss-feed-backend/types"
+)
+
+// maxJobEvents caps a job's timeline; once full, only terminal events are still recorded
+const maxJobEvents = 100
+
+// recordEvent appends an event to a job's timeline, stamping it with the current time if unset
+func (ap *AsyncProcessor) recordEvent(jobID string, event types.JobEvent) {
+	ap.statusMutex.Lock()
+	defer ap.statusMutex.Unlock()
+
+	if jobStatus, exists := ap.jobStatus[jobID]; exists {
+		appendJobEvent(jobStatus, event)
+	}
+}
+
+// appendJobEvent adds an event to a status's timeline; callers must hold the status lock
+func appendJobEvent(jobStatus *types.AsyncJobStatus, event types.JobEvent) {
+	terminal := event.Type == types.JobEventCompleted || event.Type == types.JobEventFailed
+	if len(jobStatus.Events) >= maxJobEvents && !terminal {
+		return
+	}
+	if event.At.IsZero() {
+		event.At = time.Now()
+	}
+	jobStatus.Events = append(jobStatus.Events, event)
+}
+
+// timelineWriter records each batch a job writes to Datastore on the job's timeline
+type timelineWriter struct {
+	DatastoreClientInterface
+	processor *AsyncProcessor
+	jobID     string
+}
+
+// PutMulti writes the batch and records how many items were saved and how long the write took
+func (w timelineWriter) PutMulti(ctx context.Context, keys []*datastore.Key, src interface{}) ([]*datastore.Key, error) {
+	start := time.Now()
+	saved, err := w.DatastoreClientInterface.PutMulti(ctx, keys, src)
+
+	event := types.JobEvent{Type: types.JobEventSaved, Items: len(keys), DurationMs: time.Since(start).Milliseconds()}
+	var multiErr datastore.MultiError
+	switch {
+	case err == nil:
+	case errors.As(err, &multiErr) && len(multiErr) == len(keys):
+		// Some entities were rejected; the rest of the batch was written
+		failed := 0
+		for _, entityErr := range multiErr {
+			if entityErr != nil {
+				failed++
+			}
+		}
+		event.Items -= failed
+		event.Message = fmt.Sprintf("%d items failed", failed)
+	default:
+		return saved, err
+	}
+	w.processor.recordEvent(w.jobID, event)
+	return saved, err
+}
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Nexora-Open-Source/rss-feed-backend/monitoring"
+)
+
+// JobErrorResourceLimit is the error type of jobs the watchdog stopped for exceeding a budget
+const JobErrorResourceLimit = "resource_limit"
+
+// Job budgets enforced by the watchdog
+const (
+	JobLimitDuration = "duration"
+	JobLimitFeedSize = "feed_size"
+)
+
+// Default job budgets, used until SetJobWatchdog is called
+const (
+	defaultJobMaxDuration  = 2 * time.Minute
+	defaultJobMaxFeedBytes = 10 * 1024 * 1024
+)
+
+// JobLimitError is returned when a job exceeds its wall-clock or feed size budget
+type JobLimitError struct {
+	// Limit is JobLimitDuration or JobLimitFeedSize
+	Limit  string
+	Budget string
+	Err    error
+}
+
+func (e *JobLimitError) Error() string {
+	return fmt.Sprintf("job exceeded its %s budget of %s", e.Limit, e.Budget)
+}
+
+func (e *JobLimitError) Unwrap() error {
+	return e.Err
+}
+
+/*
+JobWatchdog stops async jobs that exceed their budgets and notices feeds that keep doing so.
+
+Each feed job gets a wall-clock budget for fetching and parsing its feed, and each
+feed document a size budget, which also bounds the memory a parse can take. Task
+jobs, such as user data deletion, carry their own deadlines and are not watched.
+
+Go cannot stop a goroutine from outside, so a job over its wall-clock budget has
+its context cancelled and is failed at once, freeing its worker; work that ignores
+the cancellation finishes in the background and its result is discarded.
+
+When one feed's jobs are stopped alertThreshold times in a row the notifier is
+called; a job for the feed that finishes within budget starts the count over.
+*/
+type JobWatchdog struct {
+	mu             sync.Mutex
+	maxDuration    time.Duration
+	maxFeedBytes   int64
+	alertThreshold int
+	strikes        map[string]int
+	notifier       func(feedURL string, strikes int, err *JobLimitError)
+}
+
+// NewJobWatchdog creates a watchdog with the given budgets (0 leaves one unbounded), alerting after alertThreshold stops in a row (0 never alerts)
+func NewJobWatchdog(maxDuration time.Duration, maxFeedBytes int64, alertThreshold int) *JobWatchdog {
+	return &JobWatchdog{
+		maxDuration:    maxDuration,
+		maxFeedBytes:   maxFeedBytes,
+		alertThreshold: alertThreshold,
+		strikes:        make(map[string]int),
+	}
+}
+
+// SetNotifier sets the function called when a feed's jobs keep being stopped
+func (w *JobWatchdog) SetNotifier(notifier func(feedURL string, strikes int, err *JobLimitError)) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.notifier = notifier
+}
+
+// Context returns a context for one job that ends with a *JobLimitError cause once the wall-clock budget is spent
+func (w *JobWatchdog) Context(parent context.Context) (context.Context, context.CancelFunc) {
+	if w == nil || w.maxDuration <= 0 {
+		return context.WithCancel(parent)
+	}
+	return context.WithTimeoutCause(parent, w.maxDuration, &JobLimitError{Limit: JobLimitDuration, Budget: w.maxDuration.String()})
+}
+
+// MaxFeedBytes returns the largest feed document a job may fetch, 0 when unbounded
+func (w *JobWatchdog) MaxFeedBytes() int64 {
+	if w == nil {
+		return 0
+	}
+	return w.maxFeedBytes
+}
+
+// RecordStop counts a job stopped for exceeding a budget, notifying once the feed reaches the alert threshold
+func (w *JobWatchdog) RecordStop(feedURL string, err *JobLimitError) {
+	monitoring.RecordJobWatchdogStop(err.Limit)
+	if w == nil || feedURL == "" {
+		return
+	}
+
+	w.mu.Lock()
+	w.strikes[feedURL]++
+	strikes := w.strikes[feedURL]
+	notifier := w.notifier
+	w.mu.Unlock()
+
+	if notifier != nil && w.alertThreshold > 0 && strikes == w.alertThreshold {
+		notifier(feedURL, strikes, err)
+	}
+}
+
+// RecordSuccess clears a feed's count of jobs stopped in a row
+func (w *JobWatchdog) RecordSuccess(feedURL string) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	delete(w.strikes, feedURL)
+}
+
+// runWithinBudget calls fn, giving up with the context's cause once ctx ends even if fn has not returned
+func runWithinBudget[T any](ctx context.Context, fn func() (T, error)) (T, error) {
+	type outcome struct {
+		value T
+		err   error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		value, err := fn()
+		done <- outcome{value, err}
+	}()
+
+	select {
+	case result := <-done:
+		// Work cut short by the budget reports the budget, not the cancellation it saw
+		if ctx.Err() != nil {
+			var zero T
+			return zero, context.Cause(ctx)
+		}
+		return result.value, result.err
+	case <-ctx.Done():
+		var zero T
+		return zero, context.Cause(ctx)
+	}
+}
+
+// setJobErrorType classifies a failed job's error on its status, so clients can tell budget stops from other failures
+func (ap *AsyncProcessor) setJobErrorType(jobID string, err error) {
+	var limitErr *JobLimitError
+	if !errors.As(err, &limitErr) {
+		return
+	}
+	ap.statusMutex.Lock()
+	defer ap.statusMutex.Unlock()
+
+	if jobStatus, exists := ap.jobStatus[jobID]; exists {
+		jobStatus.ErrorType = JobErrorResourceLimit
+	}
+}
@@ -0,0 +1,365 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/Nexora-Open-Source/rss-feed-backend/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// muteRuleKind is the Datastore kind holding user mute rules
+const muteRuleKind = "MuteRule"
+
+// Mute rule types
+const (
+	MuteTypeKeyword = "keyword"
+	MuteTypeAuthor  = "author"
+	MuteTypeSource  = "source"
+)
+
+// maxMuteRulesPerUser bounds how many mute rules a single user may keep
+const maxMuteRulesPerUser = 200
+
+// ErrInvalidMuteRule is returned when a mute rule fails validation
+var ErrInvalidMuteRule = errors.New("invalid mute rule")
+
+// MuteRule hides items matching a keyword, author, or source from one user
+type MuteRule struct {
+	ID     string `datastore:"id" json:"id"`
+	UserID string `datastore:"user_id" json:"-"`
+	Type   string `datastore:"type,noindex" json:"type"`
+	Value  string `datastore:"value,noindex" json:"value"`
+	// ApplyAtIngest also marks matching items as suppressed for the user when they are stored,
+	// so digests and notifications can skip them
+	ApplyAtIngest bool      `datastore:"apply_at_ingest,noindex" json:"apply_at_ingest"`
+	CreatedAt     time.Time `datastore:"created_at,noindex" json:"created_at"`
+}
+
+// normalize validates the rule and canonicalizes its value for matching
+func (rule *MuteRule) normalize() error {
+	rule.Type = strings.ToLower(strings.TrimSpace(rule.Type))
+	rule.Value = strings.ToLower(strings.TrimSpace(rule.Value))
+	if rule.Value == "" {
+		return fmt.Errorf("value cannot be empty")
+	}
+	if len(rule.Value) > 200 {
+		return fmt.Errorf("value cannot exceed 200 characters")
+	}
+
+	switch rule.Type {
+	case MuteTypeKeyword, MuteTypeAuthor:
+	case MuteTypeSource:
+		// Accept a bare domain or any URL on the source
+		if strings.Contains(rule.Value, "://") {
+			parsed, err := url.Parse(rule.Value)
+			if err != nil || parsed.Hostname() == "" {
+				return fmt.Errorf("source must be a domain or URL")
+			}
+			rule.Value = parsed.Hostname()
+		}
+		rule.Value = strings.TrimPrefix(rule.Value, "www.")
+	default:
+		return fmt.Errorf("type must be one of %q, %q, or %q", MuteTypeKeyword, MuteTypeAuthor, MuteTypeSource)
+	}
+	return nil
+}
+
+// Matches reports whether the rule mutes the item
+func (rule *MuteRule) Matches(item *utils.FeedItem) bool {
+	switch rule.Type {
+	case MuteTypeKeyword:
+		return strings.Contains(strings.ToLower(item.Title), rule.Value) ||
+			strings.Contains(strings.ToLower(item.Description), rule.Value)
+	case MuteTypeAuthor:
+		return strings.ToLower(strings.TrimSpace(item.Author)) == rule.Value
+	case MuteTypeSource:
+		source := utils.ItemSource(item)
+		return source == rule.Value || strings.HasSuffix(source, "."+rule.Value)
+	}
+	return false
+}
+
+/*
+MuteRuleStore keeps each user's mute rules.
+
+Rules are applied as a post-filter on read endpoints; rules marked
+ApplyAtIngest additionally record the user in the SuppressedFor list of
+matching items as they are stored.
+*/
+type MuteRuleStore struct {
+	mu     sync.RWMutex
+	rules  map[string][]MuteRule
+	store  DatastoreClientInterface
+	logger *logrus.Logger
+}
+
+// NewMuteRuleStore creates a mute rule store persisted to Datastore when store is set
+func NewMuteRuleStore(store DatastoreClientInterface, logger *logrus.Logger) *MuteRuleStore {
+	return &MuteRuleStore{
+		rules:  make(map[string][]MuteRule),
+		store:  store,
+		logger: logger,
+	}
+}
+
+// LoadRules loads stored mute rules from Datastore, replacing the rules loaded before
+func (s *MuteRuleStore) LoadRules(ctx context.Context) error {
+	if s == nil || s.store == nil {
+		return nil
+	}
+
+	var rules []MuteRule
+	if _, err := s.store.GetAll(ctx, datastore.NewQuery(muteRuleKind), &rules); err != nil {
+		return fmt.Errorf("failed to load mute rules: %v", err)
+	}
+
+	loaded := make(map[string][]MuteRule)
+	for _, rule := range rules {
+		loaded[rule.UserID] = append(loaded[rule.UserID], rule)
+	}
+	s.mu.Lock()
+	s.rules = loaded
+	s.mu.Unlock()
+
+	s.logger.WithField("rules_count", len(rules)).Info("Mute rules loaded")
+	return nil
+}
+
+// List returns a user's mute rules, oldest first
+func (s *MuteRuleStore) List(userID string) []MuteRule {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rules := append([]MuteRule(nil), s.rules[userID]...)
+	sort.SliceStable(rules, func(i, j int) bool {
+		return rules[i].CreatedAt.Before(rules[j].CreatedAt)
+	})
+	return rules
+}
+
+// Add validates and stores a new rule for the user, returning the stored rule
+func (s *MuteRuleStore) Add(ctx context.Context, userID string, rule MuteRule) (*MuteRule, error) {
+	if s == nil {
+		return nil, fmt.Errorf("mute rules are not available")
+	}
+	if err := rule.normalize(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMuteRule, err)
+	}
+	rule.ID = "mute_" + utils.NewID()
+	rule.UserID = userID
+	rule.CreatedAt = time.Now()
+
+	s.mu.Lock()
+	if len(s.rules[userID]) >= maxMuteRulesPerUser {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("%w: a user may have at most %d mute rules", ErrInvalidMuteRule, maxMuteRulesPerUser)
+	}
+	s.rules[userID] = append(s.rules[userID], rule)
+	s.mu.Unlock()
+
+	if err := s.persist(ctx, rule); err != nil {
+		s.remove(userID, rule.ID)
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// Update replaces the type, value, and ingest setting of a user's rule; it returns false if the rule does not exist
+func (s *MuteRuleStore) Update(ctx context.Context, userID, ruleID string, update MuteRule) (*MuteRule, bool, error) {
+	if s == nil {
+		return nil, false, nil
+	}
+	if err := update.normalize(); err != nil {
+		return nil, true, fmt.Errorf("%w: %v", ErrInvalidMuteRule, err)
+	}
+
+	s.mu.RLock()
+	updated, found := findMuteRule(s.rules[userID], ruleID)
+	s.mu.RUnlock()
+	if !found {
+		return nil, false, nil
+	}
+	updated.Type, updated.Value, updated.ApplyAtIngest = update.Type, update.Value, update.ApplyAtIngest
+
+	// Persist first so a failed write leaves the rule unchanged
+	if err := s.persist(ctx, updated); err != nil {
+		return nil, true, err
+	}
+
+	// Replace rather than modify the slice, which Filter reads without the lock
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rules := make([]MuteRule, 0, len(s.rules[userID]))
+	for _, rule := range s.rules[userID] {
+		if rule.ID == ruleID {
+			rule = updated
+		}
+		rules = append(rules, rule)
+	}
+	s.rules[userID] = rules
+	return &updated, true, nil
+}
+
+// Delete removes a user's rule; it returns false if the rule does not exist
+func (s *MuteRuleStore) Delete(ctx context.Context, userID, ruleID string) (bool, error) {
+	if s == nil || !s.remove(userID, ruleID) {
+		return false, nil
+	}
+
+	if s.store != nil {
+		if err := s.store.DeleteMulti(ctx, []*datastore.Key{datastore.NameKey(muteRuleKind, ruleID, nil)}); err != nil {
+			return true, fmt.Errorf("failed to delete mute rule: %v", err)
+		}
+	}
+	return true, nil
+}
+
+// DeleteUser removes all of a user's rules, including stored rules not loaded into memory, returning how many were deleted
+func (s *MuteRuleStore) DeleteUser(ctx context.Context, userID string) (int, error) {
+	if s == nil {
+		return 0, nil
+	}
+
+	s.mu.Lock()
+	rules := s.rules[userID]
+	delete(s.rules, userID)
+	s.mu.Unlock()
+
+	if s.store == nil {
+		return len(rules), nil
+	}
+	query := datastore.NewQuery(muteRuleKind).Filter("user_id =", userID).KeysOnly()
+	keys, err := s.store.GetAll(ctx, query, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find mute rules: %v", err)
+	}
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		seen[key.Name] = true
+	}
+	for _, rule := range rules {
+		if !seen[rule.ID] {
+			keys = append(keys, datastore.NameKey(muteRuleKind, rule.ID, nil))
+		}
+	}
+
+	for start := 0; start < len(keys); start += bulkWriteBatch {
+		end := min(start+bulkWriteBatch, len(keys))
+		if err := s.store.DeleteMulti(ctx, keys[start:end]); err != nil {
+			return start, fmt.Errorf("failed to delete mute rules: %v", err)
+		}
+	}
+	return len(keys), nil
+}
+
+// Filter returns the items not muted by any of the user's rules
+func (s *MuteRuleStore) Filter(userID string, items []*utils.FeedItem) []*utils.FeedItem {
+	if s == nil || userID == "" {
+		return items
+	}
+
+	s.mu.RLock()
+	rules := s.rules[userID]
+	s.mu.RUnlock()
+	if len(rules) == 0 {
+		return items
+	}
+
+	filtered := make([]*utils.FeedItem, 0, len(items))
+	for _, item := range items {
+		if !anyRuleMatches(rules, item) {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered
+}
+
+// SuppressAtIngest records, on each item, the users whose ingest-time rules mute it
+func (s *MuteRuleStore) SuppressAtIngest(items []*utils.FeedItem) {
+	if s == nil {
+		return
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for userID, rules := range s.rules {
+		var ingestRules []MuteRule
+		for _, rule := range rules {
+			if rule.ApplyAtIngest {
+				ingestRules = append(ingestRules, rule)
+			}
+		}
+		if len(ingestRules) == 0 {
+			continue
+		}
+		for _, item := range items {
+			if anyRuleMatches(ingestRules, item) {
+				item.SuppressedFor = append(item.SuppressedFor, userID)
+			}
+		}
+	}
+}
+
+// remove drops a rule from memory, reporting whether it existed
+func (s *MuteRuleStore) remove(userID, ruleID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rules := s.rules[userID]
+	for i := range rules {
+		if rules[i].ID == ruleID {
+			s.rules[userID] = append(rules[:i:i], rules[i+1:]...)
+			if len(s.rules[userID]) == 0 {
+				delete(s.rules, userID)
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// persist stores a rule in Datastore when a store is configured
+func (s *MuteRuleStore) persist(ctx context.Context, rule MuteRule) error {
+	if s.store == nil {
+		return nil
+	}
+
+	key := datastore.NameKey(muteRuleKind, rule.ID, nil)
+	if _, err := s.store.PutMulti(ctx, []*datastore.Key{key}, []*MuteRule{&rule}); err != nil {
+		return fmt.Errorf("failed to save mute rule: %v", err)
+	}
+	return nil
+}
+
+// findMuteRule returns a copy of the rule with the given ID
+func findMuteRule(rules []MuteRule, ruleID string) (MuteRule, bool) {
+	for _, rule := range rules {
+		if rule.ID == ruleID {
+			return rule, true
+		}
+	}
+	return MuteRule{}, false
+}
+
+// anyRuleMatches reports whether any rule mutes the item
+func anyRuleMatches(rules []MuteRule, item *utils.FeedItem) bool {
+	for i := range rules {
+		if rules[i].Matches(item) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"github.com/Nexora-Open-Source/rss-feed-backend/middleware"
+	"github.com/Nexora-Open-Source/rss-feed-backend/utils"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// UserIDHeader carries the caller's user ID, set by the authenticating gateway in front of the API
+const UserIDHeader = "X-User-ID"
+
+// userIDPattern limits user IDs to characters safe for logging and storage keys
+var userIDPattern = regexp.MustCompile(`^[A-Za-z0-9._@:-]{1,128}$`)
+
+// MuteRuleRequest represents the request body for creating or updating a mute rule
+type MuteRuleRequest struct {
+	Type          string `json:"type" validate:"required"`
+	Value         string `json:"value" validate:"required"`
+	ApplyAtIngest bool   `json:"apply_at_ingest,omitempty"`
+}
+
+// userIDFromRequest returns the caller's user ID, or an empty string when absent or malformed
+func userIDFromRequest(r *http.Request) string {
+	userID := r.Header.Get(UserIDHeader)
+	if !userIDPattern.MatchString(userID) {
+		return ""
+	}
+	return userID
+}
+
+// requireUserID returns the caller's user ID, responding 401 when there is none
+func requireUserID(w http.ResponseWriter, r *http.Request, requestID string) (string, bool) {
+	userID := userIDFromRequest(r)
+	if userID == "" {
+		middleware.RespondUnauthorized(w, fmt.Errorf("a valid %s header is required", UserIDHeader), requestID)
+		return "", false
+	}
+	return userID, true
+}
+
+// muteFilter removes items muted by the caller's rules
+func (h *Handler) muteFilter(r *http.Request, items []*utils.FeedItem) []*utils.FeedItem {
+	return h.MuteRules.Filter(userIDFromRequest(r), items)
+}
+
+// @Summary List mute rules
+// @Description Returns the caller's mute rules. Items matching a rule are hidden from the caller on all read endpoints.
+// @Tags Mute Rules
+// @Produce json
+// @Param X-User-ID header string true "Caller's user ID"
+// @Success 200 {array} MuteRule "Mute rules"
+// @Failure 401 {object} middleware.APIError "Missing user ID"
+// @Router /mute-rules [get]
+func (h *Handler) HandleListMuteRules(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Header.Get("X-Request-ID")
+	if requestID == "" {
+		requestID = utils.GenerateRequestID()
+		w.Header().Set("X-Request-ID", requestID)
+	}
+
+	userID, ok := requireUserID(w, r, requestID)
+	if !ok {
+		return
+	}
+
+	rules := h.MuteRules.List(userID)
+	if rules == nil {
+		rules = []MuteRule{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(rules)
+}
+
+// @Summary Create a mute rule
+// @Description Mutes items by keyword (title or description), author, or source domain for the caller. With apply_at_ingest, matching items are also marked suppressed for the caller when stored.
+// @Tags Mute Rules
+// @Accept json
+// @Produce json
+// @Param X-User-ID header string true "Caller's user ID"
+// @Param request body MuteRuleRequest true "Mute rule"
+// @Success 201 {object} MuteRule "Mute rule created"
+// @Failure 400 {object} middleware.APIError "Bad request or invalid mute rule"
+// @Failure 401 {object} middleware.APIError "Missing user ID"
+// @Failure 500 {object} middleware.APIError "Internal server error"
+// @Router /mute-rules [post]
+func (h *Handler) HandleCreateMuteRule(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Header.Get("X-Request-ID")
+	if requestID == "" {
+		requestID = utils.GenerateRequestID()
+		w.Header().Set("X-Request-ID", requestID)
+	}
+
+	userID, ok := requireUserID(w, r, requestID)
+	if !ok {
+		return
+	}
+
+	var req MuteRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		middleware.RespondBadRequest(w, fmt.Errorf("invalid request body: %v", err), requestID)
+		return
+	}
+
+	rule, err := h.MuteRules.Add(r.Context(), userID, MuteRule{Type: req.Type, Value: req.Value, ApplyAtIngest: req.ApplyAtIngest})
+	if errors.Is(err, ErrInvalidMuteRule) {
+		middleware.RespondValidationError(w, err, requestID)
+		return
+	}
+	if err != nil {
+		middleware.RespondInternalError(w, err, requestID)
+		return
+	}
+
+	middleware.Log(r.Context()).WithFields(logrus.Fields{
+		"user_id": userID,
+		"rule_id": rule.ID,
+		"type":    rule.Type,
+	}).Info("Mute rule created")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(rule)
+}
+
+// @Summary Update a mute rule
+// @Description Replaces the type, value, and ingest setting of one of the caller's mute rules.
+// @Tags Mute Rules
+// @Accept json
+// @Produce json
+// @Param X-User-ID header string true "Caller's user ID"
+// @Param id path string true "Mute rule ID"
+// @Param request body MuteRuleRequest true "Mute rule"
+// @Success 200 {object} MuteRule "Mute rule updated"
+// @Failure 400 {object} middleware.APIError "Bad request or invalid mute rule"
+// @Failure 401 {object} middleware.APIError "Missing user ID"
+// @Failure 404 {object} middleware.APIError "Mute rule not found"
+// @Failure 500 {object} middleware.APIError "Internal server error"
+// @Router /mute-rules/{id} [put]
+func (h *Handler) HandleUpdateMuteRule(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Header.Get("X-Request-ID")
+	if requestID == "" {
+		requestID = utils.GenerateRequestID()
+		w.Header().Set("X-Request-ID", requestID)
+	}
+
+	userID, ok := requireUserID(w, r, requestID)
+	if !ok {
+		return
+	}
+
+	var req MuteRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		middleware.RespondBadRequest(w, fmt.Errorf("invalid request body: %v", err), requestID)
+		return
+	}
+
+	rule, found, err := h.MuteRules.Update(r.Context(), userID, mux.Vars(r)["id"], MuteRule{Type: req.Type, Value: req.Value, ApplyAtIngest: req.ApplyAtIngest})
+	if !found {
+		middleware.RespondNotFound(w, fmt.Errorf("mute rule not found"), requestID)
+		return
+	}
+	if errors.Is(err, ErrInvalidMuteRule) {
+		middleware.RespondValidationError(w, err, requestID)
+		return
+	}
+	if err != nil {
+		middleware.RespondInternalError(w, err, requestID)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(rule)
+}
+
+// @Summary Delete a mute rule
+// @Description Deletes one of the caller's mute rules.
+// @Tags Mute Rules
+// @Param X-User-ID header string true "Caller's user ID"
+// @Param id path string true "Mute rule ID"
+// @Success 204 "Mute rule deleted"
+// @Failure 401 {object} middleware.APIError "Missing user ID"
+// @Failure 404 {object} middleware.APIError "Mute rule not found"
+// @Failure 500 {object} middleware.APIError "Internal server error"
+// @Router /mute-rules/{id} [delete]
+func (h *Handler) HandleDeleteMuteRule(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Header.Get("X-Request-ID")
+	if requestID == "" {
+		requestID = utils.GenerateRequestID()
+		w.Header().Set("X-Request-ID", requestID)
+	}
+
+	userID, ok := requireUserID(w, r, requestID)
+	if !ok {
+		return
+	}
+
+	found, err := h.MuteRules.Delete(r.Context(), userID, mux.Vars(r)["id"])
+	if !found {
+		middleware.RespondNotFound(w, fmt.Errorf("mute rule not found"), requestID)
+		return
+	}
+	if err != nil {
+		middleware.RespondInternalError(w, err, requestID)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
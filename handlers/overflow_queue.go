@@ -0,0 +1,392 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/Nexora-Open-Source/rss-feed-backend/monitoring"
+	"github.com/Nexora-Open-Source/rss-feed-backend/types"
+	"github.com/sirupsen/logrus"
+)
+
+// overflowJobKind is the Datastore kind holding fetch jobs waiting in the overflow queue
+const overflowJobKind = "OverflowJob"
+
+// overflowDrainInterval is how often overflowed jobs are offered back to the job queue
+const overflowDrainInterval = time.Second
+
+// OverflowJob is a fetch or durable task job accepted while the job queue was full
+type OverflowJob struct {
+	JobID     string    `datastore:"job_id" json:"job_id"`
+	URL       string    `datastore:"url,noindex" json:"url"`
+	RequestID string    `datastore:"request_id,noindex" json:"request_id"`
+	TenantID  string    `datastore:"tenant_id,noindex" json:"tenant_id"`
+	CreatedAt time.Time `datastore:"created_at,noindex" json:"created_at"`
+	// Operation and Payload are set for task jobs, whose work is rebuilt by the operation's TaskFactory
+	Operation string `datastore:"operation,noindex" json:"operation,omitempty"`
+	Payload   []byte `datastore:"payload,noindex" json:"payload,omitempty"`
+}
+
+/*
+OverflowQueue holds fetch jobs and durable task jobs, such as bulk feed operations,
+turned away by backpressure until the job queue has room again.
+
+Jobs are persisted when accepted, so jobs still waiting when the server stops
+are picked up after a restart. Before a job goes back into the job queue it is
+claimed: its record is read and deleted in one transaction, so when several
+instances have loaded the same job only the one whose transaction commits runs
+it. A job claimed just before a crash is lost rather than run twice. Only the
+writer keeps an overflow queue; read replicas neither load nor drain one.
+*/
+type OverflowQueue struct {
+	mu      sync.Mutex
+	jobs    []OverflowJob
+	maxJobs int
+	store   DatastoreClientInterface
+	logger  *logrus.Logger
+}
+
+// NewOverflowQueue creates an overflow queue of up to maxJobs jobs persisted to store; 0 disables it and returns nil
+func NewOverflowQueue(store DatastoreClientInterface, maxJobs int, logger *logrus.Logger) *OverflowQueue {
+	if maxJobs <= 0 {
+		return nil
+	}
+	return &OverflowQueue{
+		maxJobs: maxJobs,
+		store:   store,
+		logger:  logger,
+	}
+}
+
+// LoadJobs loads jobs left waiting by a previous run from Datastore
+func (q *OverflowQueue) LoadJobs(ctx context.Context) error {
+	if q == nil || q.store == nil {
+		return nil
+	}
+
+	var jobs []OverflowJob
+	if _, err := q.store.GetAll(ctx, datastore.NewQuery(overflowJobKind), &jobs); err != nil {
+		return fmt.Errorf("failed to load overflow jobs: %v", err)
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].CreatedAt.Before(jobs[j].CreatedAt)
+	})
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.jobs = append(jobs, q.jobs...)
+	monitoring.UpdateOverflowQueueSize(len(q.jobs))
+
+	q.logger.WithField("jobs_count", len(jobs)).Info("Overflow jobs loaded")
+	return nil
+}
+
+// Push accepts a job, persisting it before returning; it fails when the queue is full or the job cannot be stored
+func (q *OverflowQueue) Push(ctx context.Context, job OverflowJob) error {
+	if q == nil {
+		return fmt.Errorf("overflow queue is disabled")
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.jobs) >= q.maxJobs {
+		return fmt.Errorf("overflow queue is full (%d jobs)", q.maxJobs)
+	}
+	if q.store != nil {
+		key := datastore.NameKey(overflowJobKind, job.JobID, nil)
+		if _, err := q.store.PutMulti(ctx, []*datastore.Key{key}, []*OverflowJob{&job}); err != nil {
+			return fmt.Errorf("failed to store overflow job: %v", err)
+		}
+	}
+	q.jobs = append(q.jobs, job)
+	monitoring.UpdateOverflowQueueSize(len(q.jobs))
+	return nil
+}
+
+// Jobs returns the waiting jobs, oldest first
+func (q *OverflowQueue) Jobs() []OverflowJob {
+	if q == nil {
+		return nil
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return append([]OverflowJob(nil), q.jobs...)
+}
+
+// forget drops a job from the jobs waiting in memory
+func (q *OverflowQueue) forget(jobID string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i, job := range q.jobs {
+		if job.JobID == jobID {
+			q.jobs = append(q.jobs[:i], q.jobs[i+1:]...)
+			break
+		}
+	}
+	monitoring.UpdateOverflowQueueSize(len(q.jobs))
+}
+
+/*
+Claim takes a job out of the queue so that it runs on this instance only.
+
+The job's record is read and deleted in a transaction; when another instance has
+already claimed it the record is gone, and Claim drops the job and returns false.
+Stores without transactions delete the record outright. On error the job keeps
+waiting and can be claimed again later.
+*/
+func (q *OverflowQueue) Claim(ctx context.Context, jobID string) (bool, error) {
+	if q == nil {
+		return false, nil
+	}
+	if q.store == nil {
+		q.forget(jobID)
+		return true, nil
+	}
+
+	key := datastore.NameKey(overflowJobKind, jobID, nil)
+	var err error
+	if transactor, ok := q.store.(DatastoreTransactorInterface); ok {
+		_, err = transactor.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+			var job OverflowJob
+			if err := tx.Get(key, &job); err != nil {
+				return err
+			}
+			return tx.Delete(key)
+		})
+	} else {
+		err = q.store.DeleteMulti(ctx, []*datastore.Key{key})
+	}
+	if errors.Is(err, datastore.ErrNoSuchEntity) {
+		q.forget(jobID)
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to claim overflow job: %v", err)
+	}
+	q.forget(jobID)
+	return true, nil
+}
+
+// Release puts back a claimed job that could not be queued after all, ahead of the jobs still waiting
+func (q *OverflowQueue) Release(ctx context.Context, job OverflowJob) error {
+	if q == nil {
+		return fmt.Errorf("overflow queue is disabled")
+	}
+
+	if q.store != nil {
+		key := datastore.NameKey(overflowJobKind, job.JobID, nil)
+		if _, err := q.store.PutMulti(ctx, []*datastore.Key{key}, []*OverflowJob{&job}); err != nil {
+			return fmt.Errorf("failed to store overflow job: %v", err)
+		}
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.jobs = append([]OverflowJob{job}, q.jobs...)
+	monitoring.UpdateOverflowQueueSize(len(q.jobs))
+	return nil
+}
+
+// Remove drops a job that will not run, such as a task that can no longer be rebuilt
+func (q *OverflowQueue) Remove(ctx context.Context, jobID string) {
+	if q == nil {
+		return
+	}
+
+	q.forget(jobID)
+	if q.store == nil {
+		return
+	}
+	if err := q.store.DeleteMulti(ctx, []*datastore.Key{datastore.NameKey(overflowJobKind, jobID, nil)}); err != nil {
+		q.logger.WithFields(logrus.Fields{
+			"job_id": jobID,
+			"error":  err.Error(),
+		}).Warn("Failed to delete dropped overflow job; it will be offered again after a restart")
+	}
+}
+
+// overflowJob parks a job rejected by backpressure in the overflow queue, returning false if it cannot be accepted
+func (ap *AsyncProcessor) overflowJob(job AsyncJob) bool {
+	ap.statusMutex.RLock()
+	overflow := ap.overflow
+	ap.statusMutex.RUnlock()
+	// A task without a payload cannot be rebuilt once its closure is gone
+	if overflow == nil || (job.Task != nil && job.Payload == nil) {
+		return false
+	}
+
+	err := overflow.Push(context.Background(), OverflowJob{
+		JobID:     job.ID,
+		URL:       job.URL,
+		RequestID: job.RequestID,
+		TenantID:  job.TenantID,
+		CreatedAt: job.CreatedAt,
+		Operation: job.Operation,
+		Payload:   job.Payload,
+	})
+	if err != nil {
+		ap.logger.WithFields(logrus.Fields{
+			"job_id":    job.ID,
+			"url":       job.URL,
+			"operation": job.Operation,
+			"error":     err.Error(),
+		}).Warn("Overflow queue did not accept job")
+		return false
+	}
+
+	ap.trackOverflowJob(job)
+	monitoring.RecordAsyncJob("queued_deferred", 0)
+	ap.logger.WithFields(logrus.Fields{
+		"job_id":     job.ID,
+		"url":        job.URL,
+		"operation":  job.Operation,
+		"request_id": job.RequestID,
+	}).Info("Job queue full, job held in overflow queue")
+	return true
+}
+
+// trackOverflowJob records a "queued_deferred" status for an overflowed job that has none
+func (ap *AsyncProcessor) trackOverflowJob(job AsyncJob) {
+	ap.statusMutex.Lock()
+	if _, exists := ap.jobStatus[job.ID]; exists {
+		ap.statusMutex.Unlock()
+		return
+	}
+	evicted := ap.trackJob(&types.AsyncJobStatus{
+		JobID:     job.ID,
+		URL:       job.URL,
+		Operation: job.Operation,
+		Status:    "queued_deferred",
+		CreatedAt: job.CreatedAt,
+		Events: []types.JobEvent{{
+			At:      time.Now(),
+			Type:    types.JobEventDeferred,
+			Message: "job queue full, held in overflow queue",
+		}},
+	})
+	ap.jobDone[job.ID] = make(chan struct{})
+	statusArchive := ap.statusArchive
+	ap.statusMutex.Unlock()
+	statusArchive.Save(context.Background(), evicted)
+}
+
+// drainOverflow moves overflowed jobs back into the job queue, oldest first, while it is below the reject threshold
+func (ap *AsyncProcessor) drainOverflow() {
+	defer ap.wg.Done()
+
+	ticker := time.NewTicker(overflowDrainInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			ap.dispatchOverflow()
+		case <-ap.quit:
+			return
+		}
+	}
+}
+
+// dispatchOverflow queues as many overflowed jobs as the job queue has room for
+func (ap *AsyncProcessor) dispatchOverflow() {
+	ap.statusMutex.RLock()
+	overflow := ap.overflow
+	ap.statusMutex.RUnlock()
+
+	for _, waiting := range overflow.Jobs() {
+		job := AsyncJob{
+			ID:        waiting.JobID,
+			URL:       waiting.URL,
+			RequestID: waiting.RequestID,
+			TenantID:  waiting.TenantID,
+			CreatedAt: waiting.CreatedAt,
+			Operation: waiting.Operation,
+			Payload:   waiting.Payload,
+		}
+		// Jobs loaded after a restart have no status yet
+		ap.trackOverflowJob(job)
+
+		if job.Operation != "" {
+			task, err := ap.buildTask(job.Operation, job.Payload)
+			if err != nil {
+				ap.logger.WithFields(logrus.Fields{
+					"job_id":    job.ID,
+					"operation": job.Operation,
+					"error":     err.Error(),
+				}).Error("Failed to rebuild overflowed task job")
+				ap.updateJobStatus(job.ID, "failed", err.Error(), 0, 0)
+				overflow.Remove(context.Background(), job.ID)
+				continue
+			}
+			job.Task = task
+		}
+
+		if ap.backpressureEnabled && float64(len(ap.jobs)) >= ap.rejectThreshold*float64(ap.queueSize) {
+			continue
+		}
+
+		// Claim the job first, so an instance that loaded it too cannot also run it
+		claimed, err := overflow.Claim(context.Background(), job.ID)
+		if err != nil {
+			ap.logger.WithFields(logrus.Fields{
+				"job_id": job.ID,
+				"error":  err.Error(),
+			}).Warn("Failed to claim overflow job; it will be retried")
+			continue
+		}
+		if !claimed {
+			ap.forgetJob(job.ID)
+			continue
+		}
+		if !ap.requeueOverflowJob(job) {
+			if err := overflow.Release(context.Background(), waiting); err != nil {
+				ap.logger.WithFields(logrus.Fields{
+					"job_id": job.ID,
+					"error":  err.Error(),
+				}).Error("Failed to return claimed overflow job to the queue")
+				ap.updateJobStatus(job.ID, "failed", err.Error(), 0, 0)
+			}
+		}
+	}
+}
+
+// requeueOverflowJob hands an overflowed job to the job queue without blocking, reporting whether it was queued
+func (ap *AsyncProcessor) requeueOverflowJob(job AsyncJob) bool {
+	ap.shutdownMutex.RLock()
+	defer ap.shutdownMutex.RUnlock()
+
+	if ap.shuttingDown {
+		return false
+	}
+
+	// Mark the job pending first so a worker picking it up is not overwritten
+	ap.statusMutex.Lock()
+	jobStatus, exists := ap.jobStatus[job.ID]
+	if exists {
+		jobStatus.Status = "pending"
+	}
+	ap.statusMutex.Unlock()
+
+	select {
+	case ap.jobs <- job:
+		monitoring.UpdateAsyncQueueSize(len(ap.jobs))
+		ap.recordEvent(job.ID, types.JobEvent{Type: types.JobEventQueued, Message: "moved from overflow queue"})
+		return true
+	default:
+		ap.statusMutex.Lock()
+		if exists && jobStatus.Status == "pending" {
+			jobStatus.Status = "queued_deferred"
+		}
+		ap.statusMutex.Unlock()
+		return false
+	}
+}
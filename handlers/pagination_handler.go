@@ -7,9 +7,11 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Nexora-Open-Source/rss-feed-backend/middleware"
@@ -17,12 +19,23 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// Page sizes of item queries, used when none are configured
+const (
+	// defaultItemsPageSize is how many items /items returns when no limit is given
+	defaultItemsPageSize = 100
+	// MaxItemsPageSize is the largest page any item query returns; configured caps may only lower it
+	MaxItemsPageSize = 1000
+)
+
+// TimezoneHeader carries the caller's preferred timezone, an IANA name such as Europe/Berlin
+const TimezoneHeader = "X-Timezone"
+
 // FilterParams represents filtering parameters for feed items
 type FilterParams struct {
 	Source   string `json:"source"`    // Filter by source URL/domain
 	Author   string `json:"author"`    // Filter by author
-	DateFrom string `json:"date_from"` // Filter by date from (RFC3339 format)
-	DateTo   string `json:"date_to"`   // Filter by date to (RFC3339 format)
+	DateFrom string `json:"date_from"` // Filter by date from (RFC3339, or a date or local time; see utils.ParseDateBound)
+	DateTo   string `json:"date_to"`   // Filter by date to (RFC3339, or a date or local time; see utils.ParseDateBound)
 	Keyword  string `json:"keyword"`   // Filter by keyword in title or description
 }
 
@@ -30,21 +43,29 @@ type FilterParams struct {
 type ItemsQueryParams struct {
 	PaginationParams
 	FilterParams
+	// AsOf reads items as they were stored at that time instead of now; zero reads the current items
+	AsOf time.Time `json:"as_of,omitempty"`
 }
 
 // @Summary Get RSS feed items with filtering
-// @Description Retrieves RSS feed items from Google Cloud Datastore with pagination and filtering support.
+// @Description Retrieves RSS feed items from Google Cloud Datastore with pagination and filtering support. When Datastore fails, the last results read for the same query are served with X-Cache: STALE and an X-Data-Staleness header giving their age in seconds.
 // @Tags RSS Feed Operations
 // @Accept json
 // @Produce json
-// @Param limit query int false "Number of items to return (default: 100, max: 1000)"
-// @Param offset query int false "Number of items to skip (default: 0)"
+// @Param limit query int false "Number of items to return (default: 100, max: 1000; both configurable)"
+// @Param offset query int false "Number of items to skip (default: 0); the Link header (RFC 8288) points to the next, previous, first, and last pages, and X-Total-Count gives the total when known"
 // @Param cursor query string false "Pagination cursor for cursor-based pagination"
 // @Param source query string false "Filter by source URL/domain"
 // @Param author query string false "Filter by author"
-// @Param date_from query string false "Filter by date from (RFC3339 format)"
-// @Param date_to query string false "Filter by date to (RFC3339 format)"
+// @Param date_from query string false "Filter by date from: RFC3339, a date (YYYY-MM-DD, from the start of the day), or a local time (YYYY-MM-DDTHH:MM[:SS])"
+// @Param date_to query string false "Filter by date to: RFC3339, a date (YYYY-MM-DD, through the end of the day), or a local time (YYYY-MM-DDTHH:MM[:SS])"
+// @Param since query string false "Only items published since a relative time: a duration such as 30m, 2h, 3d, or 1w, or today or this_week (weeks start on Monday); replaces date_from"
+// @Param tz query string false "IANA timezone for dates and local times without an offset, and for since=today and this_week (defaults to X-Timezone, then DEFAULT_TIMEZONE)"
+// @Param X-Timezone header string false "Caller's preferred IANA timezone"
 // @Param keyword query string false "Filter by keyword in title or description"
+// @Param collapse_duplicates query bool false "Return one item per cluster of cross-source duplicates"
+// @Param folder query string false "Only return items from feeds in this folder or its subfolders, e.g. Tech/Go"
+// @Param as_of query string false "Return items as they were stored at this time (RFC3339, a date, or a local time): items ingested later are left out and edited items are shown as they were; must be within the item revision retention, and reads every item matching the other filters, up to 2000"
 // @Success 200 {object} PaginatedResult "Feed items retrieved successfully"
 // @Failure 400 {object} middleware.APIError "Bad request"
 // @Failure 500 {object} middleware.APIError "Internal server error"
@@ -61,8 +82,9 @@ func (h *Handler) HandleGetFeedItems(w http.ResponseWriter, r *http.Request) {
 	offsetStr := r.URL.Query().Get("offset")
 	cursor := r.URL.Query().Get("cursor")
 
-	limit := 100 // default limit
-	offset := 0  // default offset
+	defaultLimit, maxLimit := h.itemsPageSizes()
+	limit := defaultLimit
+	offset := 0 // default offset
 
 	if limitStr != "" {
 		if parsedLimit, err := strconv.Atoi(limitStr); err == nil {
@@ -71,6 +93,11 @@ func (h *Handler) HandleGetFeedItems(w http.ResponseWriter, r *http.Request) {
 			middleware.RespondBadRequest(w, fmt.Errorf("invalid limit parameter: %v", err), requestID)
 			return
 		}
+		// Oversized pages are refused rather than silently truncated, so clients notice
+		if limit < 1 || limit > maxLimit {
+			middleware.RespondBadRequest(w, fmt.Errorf("invalid limit parameter: must be between 1 and %d", maxLimit), requestID)
+			return
+		}
 	}
 
 	if offsetStr != "" {
@@ -80,6 +107,10 @@ func (h *Handler) HandleGetFeedItems(w http.ResponseWriter, r *http.Request) {
 			middleware.RespondBadRequest(w, fmt.Errorf("invalid offset parameter: %v", err), requestID)
 			return
 		}
+		if offset < 0 {
+			middleware.RespondBadRequest(w, fmt.Errorf("invalid offset parameter: must not be negative"), requestID)
+			return
+		}
 	}
 
 	// Handle cursor-based pagination
@@ -92,6 +123,32 @@ func (h *Handler) HandleGetFeedItems(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	collapseDuplicates := false
+	if collapseStr := r.URL.Query().Get("collapse_duplicates"); collapseStr != "" {
+		parsedCollapse, err := strconv.ParseBool(collapseStr)
+		if err != nil {
+			middleware.RespondBadRequest(w, fmt.Errorf("invalid collapse_duplicates parameter: %v", err), requestID)
+			return
+		}
+		collapseDuplicates = parsedCollapse
+	}
+
+	// Folder membership is resolved against the feed list after fetching, so pages are cached per filter only
+	folder := NormalizeFolderPath(r.URL.Query().Get("folder"))
+	var folderFeeds []FeedSource
+	if folder != "" {
+		if err := ValidateFolderPath(folder); err != nil {
+			middleware.RespondBadRequest(w, fmt.Errorf("invalid folder parameter: %v", err), requestID)
+			return
+		}
+		feeds, _, err := h.loadFeedSources(r.Context())
+		if err != nil {
+			middleware.RespondInternalError(w, err, requestID)
+			return
+		}
+		folderFeeds = feeds
+	}
+
 	// Parse filter parameters
 	filterParams := FilterParams{
 		Source:   r.URL.Query().Get("source"),
@@ -101,17 +158,65 @@ func (h *Handler) HandleGetFeedItems(w http.ResponseWriter, r *http.Request) {
 		Keyword:  r.URL.Query().Get("keyword"),
 	}
 
-	// Validate date parameters
+	// Normalize date parameters to UTC RFC3339 so equivalent filters share a cache entry
+	loc, err := h.filterTimezone(r)
+	if err != nil {
+		middleware.RespondBadRequest(w, err, requestID)
+		return
+	}
+	var dateFrom, dateTo time.Time
 	if filterParams.DateFrom != "" {
-		if _, err := time.Parse(time.RFC3339, filterParams.DateFrom); err != nil {
-			middleware.RespondBadRequest(w, fmt.Errorf("invalid date_from parameter, expected RFC3339 format: %v", err), requestID)
+		dateFrom, err = utils.ParseDateBound(filterParams.DateFrom, loc, false)
+		if err != nil {
+			middleware.RespondBadRequest(w, fmt.Errorf("invalid date_from parameter, %v", err), requestID)
+			return
+		}
+		filterParams.DateFrom = dateFrom.Format(time.RFC3339)
+	}
+
+	// A relative since is a lower bound resolved server-side, in place of date_from
+	if since := r.URL.Query().Get("since"); since != "" {
+		if filterParams.DateFrom != "" {
+			middleware.RespondBadRequest(w, fmt.Errorf("since and date_from cannot both be given"), requestID)
 			return
 		}
+		dateFrom, err = utils.ParseSince(since, time.Now(), loc)
+		if err != nil {
+			middleware.RespondBadRequest(w, fmt.Errorf("invalid since parameter, %v", err), requestID)
+			return
+		}
+		filterParams.DateFrom = dateFrom.Format(time.RFC3339)
 	}
 
 	if filterParams.DateTo != "" {
-		if _, err := time.Parse(time.RFC3339, filterParams.DateTo); err != nil {
-			middleware.RespondBadRequest(w, fmt.Errorf("invalid date_to parameter, expected RFC3339 format: %v", err), requestID)
+		dateTo, err = utils.ParseDateBound(filterParams.DateTo, loc, true)
+		if err != nil {
+			middleware.RespondBadRequest(w, fmt.Errorf("invalid date_to parameter, %v", err), requestID)
+			return
+		}
+		filterParams.DateTo = dateTo.Format(time.RFC3339)
+	}
+
+	if !dateFrom.IsZero() && !dateTo.IsZero() && dateFrom.After(dateTo) {
+		middleware.RespondBadRequest(w, fmt.Errorf("date_from must not be after date_to"), requestID)
+		return
+	}
+
+	// A date alone means the end of that day, so as_of=2024-05-01 shows the items as they stood that evening
+	var asOf time.Time
+	if asOfStr := r.URL.Query().Get("as_of"); asOfStr != "" {
+		asOf, err = utils.ParseDateBound(asOfStr, loc, true)
+		if err != nil {
+			middleware.RespondBadRequest(w, fmt.Errorf("invalid as_of parameter, %v", err), requestID)
+			return
+		}
+		if asOf.After(time.Now()) {
+			middleware.RespondBadRequest(w, fmt.Errorf("invalid as_of parameter: must not be in the future"), requestID)
+			return
+		}
+		// Revisions older than the retention window are pruned, so earlier states cannot be shown faithfully
+		if retention := h.Config.ItemRevisionRetention; retention > 0 && asOf.Before(time.Now().Add(-retention)) {
+			middleware.RespondBadRequest(w, fmt.Errorf("invalid as_of parameter: item history is kept for %s", retention), requestID)
 			return
 		}
 	}
@@ -124,25 +229,28 @@ func (h *Handler) HandleGetFeedItems(w http.ResponseWriter, r *http.Request) {
 			Cursor: cursor,
 		},
 		FilterParams: filterParams,
+		AsOf:         asOf,
 	}
 
 	// Log the request
-	middleware.Logger.WithFields(logrus.Fields{
-		"request_id": requestID,
-		"action":     "get_feed_items",
-		"limit":      limit,
-		"offset":     offset,
-		"cursor":     cursor,
-		"source":     filterParams.Source,
-		"author":     filterParams.Author,
-		"date_from":  filterParams.DateFrom,
-		"date_to":    filterParams.DateTo,
-		"keyword":    filterParams.Keyword,
+	middleware.Log(r.Context()).WithFields(logrus.Fields{
+		"action":    "get_feed_items",
+		"limit":     limit,
+		"offset":    offset,
+		"cursor":    cursor,
+		"source":    filterParams.Source,
+		"author":    filterParams.Author,
+		"date_from": filterParams.DateFrom,
+		"date_to":   filterParams.DateTo,
+		"timezone":  loc.String(),
+		"keyword":   filterParams.Keyword,
+		"collapse":  collapseDuplicates,
+		"folder":    folder,
+		"as_of":     r.URL.Query().Get("as_of"),
 	}).Info("Processing filtered feed items request")
 
 	// Check cache first
-	cacheKey := fmt.Sprintf("items:limit:%d:offset:%d:cursor:%s:source:%s:author:%s:date_from:%s:date_to:%s:keyword:%s",
-		limit, offset, cursor, filterParams.Source, filterParams.Author, filterParams.DateFrom, filterParams.DateTo, filterParams.Keyword)
+	cacheKey := itemsCacheKey(params)
 	cachedResult, found := h.CacheManager.GetStoredItems(cacheKey)
 	if found {
 		// Convert cached items to paginated result
@@ -151,13 +259,21 @@ func (h *Handler) HandleGetFeedItems(w http.ResponseWriter, r *http.Request) {
 			TotalCount: len(cachedResult), // Note: This is simplified
 			HasMore:    len(cachedResult) == limit,
 		}
+		result.Items = h.muteFilter(r, result.Items)
+		if folder != "" {
+			result.Items = folderFilter(folderFeeds, folder, result.Items)
+		}
+		if collapseDuplicates {
+			result.Items, result.DuplicateCounts = utils.CollapseDuplicates(result.Items, h.Config.DuplicateTitleThreshold)
+		}
 
-		middleware.Logger.WithFields(logrus.Fields{
-			"request_id":  requestID,
+		middleware.Log(r.Context()).WithFields(logrus.Fields{
 			"items_count": len(cachedResult),
 			"source":      "cache",
 		}).Info("Feed items retrieved from cache")
 
+		// Cached pages do not keep the total, so only the page links are sent
+		setPaginationHeaders(w, r, offset, limit, result.HasMore, -1)
 		w.Header().Set("Content-Type", "application/json")
 		w.Header().Set("X-Cache", "HIT")
 		w.WriteHeader(http.StatusOK)
@@ -165,40 +281,152 @@ func (h *Handler) HandleGetFeedItems(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Fetch items from datastore with filtering
-	result, err := FetchFeedItemsWithFilter(h.DatastoreClient, params)
-	if err != nil {
-		middleware.Logger.WithFields(logrus.Fields{
-			"request_id": requestID,
-			"error":      err.Error(),
-		}).Error("Failed to fetch feed items")
-		middleware.RespondInternalError(w, err, requestID)
+	// Fetch items from datastore with filtering; the queries stop when the request ends or runs out of time
+	result, err := FetchFeedItemsWithFilter(r.Context(), h.DatastoreClient, params)
+	cacheStatus := "MISS"
+	if errors.Is(err, ErrAsOfScanTooLarge) {
+		middleware.RespondBadRequest(w, err, requestID)
 		return
 	}
+	if err != nil {
+		stale, age, found := h.StaleData.RecallItems(cacheKey)
+		if !found {
+			middleware.Log(r.Context()).WithFields(logrus.Fields{
+				"error": err.Error(),
+			}).Error("Failed to fetch feed items")
+			middleware.RespondInternalError(w, err, requestID)
+			return
+		}
+
+		// Keep the frontend usable during a Datastore incident with the last good page
+		middleware.Log(r.Context()).WithFields(logrus.Fields{
+			"error":     err.Error(),
+			"staleness": age.String(),
+		}).Warn("Failed to fetch feed items, serving stale results")
+		setDataStaleness(w, "items", age)
+		result, cacheStatus = stale, "STALE"
+	} else {
+		h.StaleData.RememberItems(cacheKey, result)
 
-	// Cache the result
-	if err := h.CacheManager.SetStoredItems(cacheKey, result.Items); err != nil {
-		middleware.Logger.WithFields(logrus.Fields{
-			"request_id": requestID,
-			"error":      err.Error(),
-		}).Warn("Failed to cache feed items")
+		// Cache the result
+		if err := h.CacheManager.SetStoredItems(cacheKey, result.Items); err != nil {
+			middleware.Log(r.Context()).WithFields(logrus.Fields{
+				"error": err.Error(),
+			}).Warn("Failed to cache feed items")
+		}
+	}
+
+	// Mute, filter by folder, and collapse after caching so the cached page is shared by all callers
+	result.Items = h.muteFilter(r, result.Items)
+	if folder != "" {
+		result.Items = folderFilter(folderFeeds, folder, result.Items)
+	}
+	if collapseDuplicates {
+		result.Items, result.DuplicateCounts = utils.CollapseDuplicates(result.Items, h.Config.DuplicateTitleThreshold)
 	}
 
 	// Log successful completion
-	middleware.Logger.WithFields(logrus.Fields{
-		"request_id":  requestID,
+	middleware.Log(r.Context()).WithFields(logrus.Fields{
 		"items_count": len(result.Items),
 		"total_count": result.TotalCount,
 		"has_more":    result.HasMore,
 		"source":      "datastore",
 	}).Info("Feed items retrieved successfully")
 
+	setPaginationHeaders(w, r, offset, limit, result.HasMore, result.TotalCount)
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("X-Cache", "MISS")
+	w.Header().Set("X-Cache", cacheStatus)
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(result)
 }
 
+// itemsPageSizes returns the default and largest /items page sizes, falling back to the built-in ones when unset
+func (h *Handler) itemsPageSizes() (int, int) {
+	defaultLimit, maxLimit := h.Config.DefaultPageSize, h.Config.MaxPageSize
+	if maxLimit <= 0 || maxLimit > MaxItemsPageSize {
+		maxLimit = MaxItemsPageSize
+	}
+	if defaultLimit <= 0 {
+		defaultLimit = defaultItemsPageSize
+	}
+	if defaultLimit > maxLimit {
+		defaultLimit = maxLimit
+	}
+	return defaultLimit, maxLimit
+}
+
+/*
+setPaginationHeaders describes an offset-paginated response in headers, so generic HTTP clients
+and crawlers can page through it without reading the response body.
+
+The Link header (RFC 8288) points to the next page when there are more items, and to the
+previous and first pages after the first one. When totalCount is known, X-Total-Count carries
+it and the Link header also points to the last page; pass a negative totalCount when it is not.
+*/
+func setPaginationHeaders(w http.ResponseWriter, r *http.Request, offset, limit int, hasMore bool, totalCount int) {
+	var links []string
+	if hasMore {
+		links = append(links, fmt.Sprintf(`<%s>; rel="next"`, pageURL(r, offset+limit, limit)))
+	}
+	if offset > 0 {
+		links = append(links, fmt.Sprintf(`<%s>; rel="prev"`, pageURL(r, max(offset-limit, 0), limit)))
+		links = append(links, fmt.Sprintf(`<%s>; rel="first"`, pageURL(r, 0, limit)))
+	}
+	if totalCount >= 0 {
+		w.Header().Set("X-Total-Count", strconv.Itoa(totalCount))
+		// Pages step by limit from the requested offset, so the last page is the last step before the total
+		if totalCount > offset {
+			lastOffset := offset + (totalCount-offset-1)/limit*limit
+			links = append(links, fmt.Sprintf(`<%s>; rel="last"`, pageURL(r, lastOffset, limit)))
+		}
+	}
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+}
+
+// pageURL returns the request's URL moved to another page; a cursor is replaced by the page's offset
+func pageURL(r *http.Request, offset, limit int) string {
+	query := r.URL.Query()
+	query.Del("cursor")
+	query.Set("limit", strconv.Itoa(limit))
+	query.Set("offset", strconv.Itoa(offset))
+	return r.URL.Path + "?" + query.Encode()
+}
+
+// itemsCacheKey returns the cache key of an items page; date filters must already be normalized to UTC.
+// as_of pages are cached for the same TTL as current ones, since retention cleanup can still remove items from a past state.
+func itemsCacheKey(params ItemsQueryParams) string {
+	key := fmt.Sprintf("items:limit:%d:offset:%d:cursor:%s:source:%s:author:%s:date_from:%s:date_to:%s:keyword:%s",
+		params.Limit, params.Offset, params.Cursor, params.Source, params.Author, params.DateFrom, params.DateTo, params.Keyword)
+	if !params.AsOf.IsZero() {
+		key += ":as_of:" + params.AsOf.UTC().Format(time.RFC3339Nano)
+	}
+	return key
+}
+
+// filterTimezone resolves the timezone of the request's date filters: the tz parameter, then the X-Timezone header, then the configured default
+func (h *Handler) filterTimezone(r *http.Request) (*time.Location, error) {
+	if name := r.URL.Query().Get("tz"); name != "" {
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tz parameter: %v", err)
+		}
+		return loc, nil
+	}
+	if name := r.Header.Get(TimezoneHeader); name != "" {
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s header: %v", TimezoneHeader, err)
+		}
+		return loc, nil
+	}
+	if h.Config.DefaultTimezone != nil {
+		return h.Config.DefaultTimezone, nil
+	}
+	return time.UTC, nil
+}
+
 /*
 HandleGetFeedItemsLegacy retrieves all RSS feed items (legacy endpoint for backward compatibility).
 
@@ -218,25 +446,23 @@ func (h *Handler) HandleGetFeedItemsLegacy(w http.ResponseWriter, r *http.Reques
 	}
 
 	// Log the request
-	middleware.Logger.WithFields(logrus.Fields{
-		"request_id": requestID,
-		"action":     "get_feed_items_legacy",
+	middleware.Log(r.Context()).WithFields(logrus.Fields{
+		"action": "get_feed_items_legacy",
 	}).Info("Processing legacy feed items request")
 
 	// Fetch items using legacy function
 	items, err := FetchFeedItemsLegacy(h.DatastoreClient)
 	if err != nil {
-		middleware.Logger.WithFields(logrus.Fields{
-			"request_id": requestID,
-			"error":      err.Error(),
+		middleware.Log(r.Context()).WithFields(logrus.Fields{
+			"error": err.Error(),
 		}).Error("Failed to fetch legacy feed items")
 		middleware.RespondInternalError(w, err, requestID)
 		return
 	}
+	items = h.muteFilter(r, items)
 
 	// Log successful completion
-	middleware.Logger.WithFields(logrus.Fields{
-		"request_id":  requestID,
+	middleware.Log(r.Context()).WithFields(logrus.Fields{
 		"items_count": len(items),
 	}).Info("Legacy feed items retrieved successfully")
 
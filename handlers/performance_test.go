@@ -6,14 +6,21 @@ This test file covers:
 - Configurable batch sizes based on feed size
 - Async processor backpressure mechanisms
 - Performance configuration validation
+- Ingest pipeline benchmarks: duplicate clustering, batch saves, cache reads, and /items encoding
 */
 package handlers
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
+	"cloud.google.com/go/datastore"
 	"github.com/Nexora-Open-Source/rss-feed-backend/cache"
+	"github.com/Nexora-Open-Source/rss-feed-backend/middleware"
 	"github.com/Nexora-Open-Source/rss-feed-backend/utils"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
@@ -278,3 +285,122 @@ func BenchmarkCacheManagerTTL(b *testing.B) {
 		cacheManager.SetFeedItems("http://test.com", items)
 	}
 }
+
+// benchmarkItems returns n distinct items spread over a handful of sources, like a large ingest batch
+func benchmarkItems(n int) []*utils.FeedItem {
+	items := make([]*utils.FeedItem, n)
+	published := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := range items {
+		items[i] = &utils.FeedItem{
+			Title:       fmt.Sprintf("Item %d: city council approves budget for transit project %d", i, i%50),
+			Link:        fmt.Sprintf("https://source%d.example.com/articles/%d", i%8, i),
+			Description: "The council voted to fund the next phase of the project after a lengthy public hearing.",
+			Author:      fmt.Sprintf("Reporter %d", i%20),
+			PubDate:     published.Add(-time.Duration(i) * time.Minute).Format(time.RFC3339),
+		}
+	}
+	return items
+}
+
+// benchStore is a Datastore fake holding nothing, so benchmarks measure the code calling it rather than a mock
+type benchStore struct{}
+
+func (benchStore) Get(ctx context.Context, key *datastore.Key, dst interface{}) error {
+	return datastore.ErrNoSuchEntity
+}
+
+func (benchStore) GetAll(ctx context.Context, q *datastore.Query, dst interface{}) ([]*datastore.Key, error) {
+	return nil, nil
+}
+
+func (benchStore) PutMulti(ctx context.Context, keys []*datastore.Key, src interface{}) ([]*datastore.Key, error) {
+	return keys, nil
+}
+
+func (benchStore) DeleteMulti(ctx context.Context, keys []*datastore.Key) error {
+	return nil
+}
+
+// benchCache is a cache fake that answers every stored items lookup with the same page
+type benchCache struct {
+	items []*utils.FeedItem
+}
+
+func (c benchCache) GetStoredItems(key string) ([]*utils.FeedItem, bool) { return c.items, true }
+
+func (c benchCache) SetStoredItems(key string, items []*utils.FeedItem) error { return nil }
+
+func (c benchCache) GetFeedItems(key string) ([]*utils.FeedItem, bool) { return nil, false }
+
+func (c benchCache) SetFeedItems(key string, items []*utils.FeedItem) error { return nil }
+
+func (c benchCache) InvalidateFeed(url string) error { return nil }
+
+// BenchmarkItemClustererAssign benchmarks duplicate detection for a 1k item batch against the default window
+func BenchmarkItemClustererAssign(b *testing.B) {
+	items := benchmarkItems(1000)
+	defaults := DefaultHandlerConfig()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		clusterer := NewItemClusterer(nil, defaults.DuplicateWindowSize, defaults.DuplicateTitleThreshold)
+		clusterer.Assign(items)
+	}
+}
+
+// BenchmarkSaveToDatastore benchmarks deduplicating and batch saving 1k items to a fake Datastore
+func BenchmarkSaveToDatastore(b *testing.B) {
+	items := benchmarkItems(1000)
+	store := benchStore{}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := SaveToDatastoreWithReport(context.Background(), store, items); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkCacheManagerGetFeedItems benchmarks cache hits for a cached feed
+func BenchmarkCacheManagerGetFeedItems(b *testing.B) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	cacheManager := cache.NewCacheManager(cache.NewInMemoryCache(15*time.Minute), logger, 15*time.Minute, 30*time.Minute, 5*time.Minute, 60*time.Minute)
+	require.NoError(b, cacheManager.SetFeedItems("http://test.com", benchmarkItems(50)))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, found := cacheManager.GetFeedItems("http://test.com"); !found {
+			b.Fatal("expected a cache hit")
+		}
+	}
+}
+
+// BenchmarkHandleGetFeedItems benchmarks serving and encoding a cached page of 100 items from /items
+func BenchmarkHandleGetFeedItems(b *testing.B) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	middleware.Logger = logger
+
+	handler := &Handler{
+		DatastoreClient: benchStore{},
+		CacheManager:    benchCache{items: benchmarkItems(100)},
+		Logger:          logger,
+		Config:          DefaultHandlerConfig(),
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		req := httptest.NewRequest("GET", "/items?limit=100", nil)
+		w := httptest.NewRecorder()
+		handler.HandleGetFeedItems(w, req)
+		if w.Code != http.StatusOK {
+			b.Fatalf("unexpected status %d", w.Code)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/Nexora-Open-Source/rss-feed-backend/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// feedPollKind is the Datastore kind holding per-feed poll statistics
+const feedPollKind = "FeedPoll"
+
+// FeedPollState records how often polls of a feed have found new items
+type FeedPollState struct {
+	FeedURL    string          `datastore:"feed_url" json:"feed_url"`
+	Stats      utils.PollStats `datastore:"stats" json:"stats"`
+	LastPollAt time.Time       `datastore:"last_poll_at,noindex" json:"last_poll_at"`
+	LatestItem string          `datastore:"latest_item,noindex" json:"-"`
+	// ItemCount is how many items the last successful poll returned
+	ItemCount int `datastore:"item_count,noindex" json:"item_count"`
+	// NextInterval is the interval drawn for the next poll
+	NextInterval time.Duration `datastore:"next_interval,noindex" json:"next_interval"`
+	// RetryAt is when a host that throttled the last poll allows the feed to be polled again
+	RetryAt time.Time `datastore:"retry_at,noindex" json:"retry_at,omitempty"`
+}
+
+/*
+PollScheduler adapts how often each feed is polled to how often polls find new items.
+
+Fetched feeds are cached until they are due to be polled again, so the interval
+drawn here becomes the feed's cache TTL once enough polls have been observed.
+Until then the cache falls back to its publication-gap estimate.
+*/
+type PollScheduler struct {
+	mu     sync.Mutex
+	feeds  map[string]*FeedPollState
+	policy utils.PollPolicy
+	store  DatastoreClientInterface
+	logger *logrus.Logger
+	rng    *rand.Rand
+	now    func() time.Time
+}
+
+// NewPollScheduler creates a scheduler that learns poll intervals within the policy's bounds
+func NewPollScheduler(store DatastoreClientInterface, policy utils.PollPolicy, logger *logrus.Logger) *PollScheduler {
+	return &PollScheduler{
+		feeds:  make(map[string]*FeedPollState),
+		policy: policy,
+		store:  store,
+		logger: logger,
+		rng:    rand.New(rand.NewSource(time.Now().UnixNano())),
+		now:    time.Now,
+	}
+}
+
+// LoadState loads stored poll statistics from Datastore
+func (s *PollScheduler) LoadState(ctx context.Context) error {
+	if s == nil || s.store == nil {
+		return nil
+	}
+
+	var records []FeedPollState
+	if _, err := s.store.GetAll(ctx, datastore.NewQuery(feedPollKind), &records); err != nil {
+		return fmt.Errorf("failed to load feed poll state: %v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range records {
+		s.feeds[records[i].FeedURL] = &records[i]
+	}
+	return nil
+}
+
+// RecordPoll records a successful fetch of the feed and draws the interval until its next poll
+func (s *PollScheduler) RecordPoll(ctx context.Context, feedURL string, items []*utils.FeedItem) {
+	if s == nil {
+		return
+	}
+
+	now := s.now()
+	_, latestItem := newestItem(items)
+
+	s.mu.Lock()
+	record, exists := s.feeds[feedURL]
+	if !exists {
+		record = &FeedPollState{FeedURL: feedURL}
+		s.feeds[feedURL] = record
+	}
+	// The first poll has nothing to compare against
+	if exists && !record.LastPollAt.IsZero() {
+		yielded := latestItem != "" && latestItem != record.LatestItem
+		record.Stats.Observe(now.Sub(record.LastPollAt), yielded, s.policy.Decay)
+	}
+	if latestItem != "" {
+		record.LatestItem = latestItem
+	}
+	record.LastPollAt = now
+	record.ItemCount = len(items)
+	record.NextInterval = s.policy.SampleInterval(record.Stats, s.rng)
+	snapshot := *record
+	s.mu.Unlock()
+
+	s.persist(ctx, snapshot)
+}
+
+// LearnedInterval returns the feed's learned poll interval and the probability a poll finds new items;
+// the interval is 0 while too few polls have been observed
+func (s *PollScheduler) LearnedInterval(feedURL string) (time.Duration, float64) {
+	if s == nil {
+		return 0, 0
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, exists := s.feeds[feedURL]
+	if !exists {
+		return 0, 0
+	}
+	interval := s.policy.LearnedInterval(record.Stats)
+	if interval == 0 {
+		return 0, 0
+	}
+	return interval, record.Stats.YieldProbability()
+}
+
+// ItemCount returns how many items the feed's last successful poll returned, if it has been polled
+func (s *PollScheduler) ItemCount(feedURL string) (int, bool) {
+	if s == nil {
+		return 0, false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, exists := s.feeds[feedURL]
+	if !exists || record.LastPollAt.IsZero() {
+		return 0, false
+	}
+	return record.ItemCount, true
+}
+
+// NextInterval returns how long to wait before polling the feed again, if it has been learned.
+// The wait is extended to cover any Retry-After the feed's host asked for.
+func (s *PollScheduler) NextInterval(feedURL string) (time.Duration, bool) {
+	if s == nil {
+		return 0, false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, exists := s.feeds[feedURL]
+	if !exists || record.NextInterval <= 0 {
+		return 0, false
+	}
+	if wait := record.RetryAt.Sub(s.now()); wait > record.NextInterval {
+		return wait, true
+	}
+	return record.NextInterval, true
+}
+
+// RecordRetryAfter records that the feed's host asked not to be polled again for wait
+func (s *PollScheduler) RecordRetryAfter(ctx context.Context, feedURL string, wait time.Duration) {
+	if s == nil || wait <= 0 {
+		return
+	}
+
+	s.mu.Lock()
+	record, exists := s.feeds[feedURL]
+	if !exists {
+		record = &FeedPollState{FeedURL: feedURL}
+		s.feeds[feedURL] = record
+	}
+	record.RetryAt = s.now().Add(wait)
+	snapshot := *record
+	s.mu.Unlock()
+
+	s.persist(ctx, snapshot)
+}
+
+// RetryAt returns when the feed may be polled again if its host is still asking clients to back off
+func (s *PollScheduler) RetryAt(feedURL string) (time.Time, bool) {
+	if s == nil {
+		return time.Time{}, false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, exists := s.feeds[feedURL]
+	if !exists || !record.RetryAt.After(s.now()) {
+		return time.Time{}, false
+	}
+	return record.RetryAt, true
+}
+
+// Backoffs returns when each feed whose host is still asking clients to back off may be polled again, by feed URL
+func (s *PollScheduler) Backoffs() map[string]time.Time {
+	backoffs := make(map[string]time.Time)
+	if s == nil {
+		return backoffs
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for feedURL, record := range s.feeds {
+		if record.RetryAt.After(now) {
+			backoffs[feedURL] = record.RetryAt
+		}
+	}
+	return backoffs
+}
+
+// persist stores a feed's poll state, logging rather than failing the fetch on error
+func (s *PollScheduler) persist(ctx context.Context, record FeedPollState) {
+	if s.store == nil {
+		return
+	}
+
+	key := datastore.NameKey(feedPollKind, record.FeedURL, nil)
+	if _, err := s.store.PutMulti(ctx, []*datastore.Key{key}, []*FeedPollState{&record}); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"url":   record.FeedURL,
+			"error": err.Error(),
+		}).Warn("Failed to save feed poll state")
+	}
+}
@@ -51,6 +51,8 @@ type Handler struct {
 	CacheManager    *cache.CacheManager
 	Logger          *logrus.Logger
 	AsyncProcessor  AsyncProcessorInterface
+	// BlockedCIDRs lists additional networks feed URLs may not target
+	BlockedCIDRs []*net.IPNet
 }
 
 // NewHandler creates a new RSS handler
@@ -245,8 +247,13 @@ func (h *Handler) validateAndSanitizeURL(inputURL string) (string, error) {
 		return "", fmt.Errorf("URL must have a valid host")
 	}
 
+	// Normalize to punycode, lowercase host, and canonical path before security checks
+	if err := utils.NormalizeParsedURL(parsedURL); err != nil {
+		return "", err
+	}
+
 	// Enhanced security checks
-	host := strings.ToLower(parsedURL.Host)
+	host := parsedURL.Host
 
 	// Block localhost and private IP ranges
 	if h.isPrivateOrLocalhost(host) {
@@ -273,30 +280,17 @@ func (h *Handler) validateAndSanitizeURL(inputURL string) (string, error) {
 	return parsedURL.String(), nil
 }
 
-// isPrivateOrLocalhost checks if the host is a private IP or localhost
+// isPrivateOrLocalhost checks if the host is a private IP, localhost, or metadata endpoint
 func (h *Handler) isPrivateOrLocalhost(host string) bool {
-	// Remove port if present
-	hostOnly := host
-	if strings.Contains(host, ":") {
-		hostOnly = strings.Split(host, ":")[0]
-	}
-
-	// Check for localhost variations
-	localhostPatterns := []string{
-		"localhost", "127.0.0.1", "::1", "0.0.0.0",
-		"0:0:0:0:0:0:0:1", "0:0:0:0:0:0:0:0",
-	}
-	for _, pattern := range localhostPatterns {
-		if hostOnly == pattern {
-			return true
-		}
+	// Check localhost, metadata endpoints, and private/reserved IPv4 and IPv6 ranges
+	if utils.IsBlockedHost(host, h.BlockedCIDRs) {
+		return true
 	}
 
-	// Try to parse as IP address
-	ip := net.ParseIP(hostOnly)
-	if ip != nil {
-		// Check if it's a private IP
-		return ip.IsPrivate() || ip.IsLoopback() || ip.IsUnspecified()
+	// Remove port if present
+	hostOnly := host
+	if splitHost, _, err := net.SplitHostPort(host); err == nil {
+		hostOnly = splitHost
 	}
 
 	// Check for private domain patterns
@@ -16,12 +16,15 @@ Usage:
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
 	"net/url"
 	"os"
-	"strings"
+	"strconv"
 
 	"github.com/Nexora-Open-Source/rss-feed-backend/middleware"
 	"github.com/Nexora-Open-Source/rss-feed-backend/utils"
@@ -42,7 +45,7 @@ func validateEnvironmentVariables() error {
 }
 
 // validateAndSanitizeURL validates and sanitizes the input URL
-func validateAndSanitizeURL(inputURL string) (string, error) {
+func (h *Handler) validateAndSanitizeURL(inputURL string) (string, error) {
 	// Basic URL validation
 	if inputURL == "" {
 		return "", fmt.Errorf("URL cannot be empty")
@@ -69,10 +72,14 @@ func validateAndSanitizeURL(inputURL string) (string, error) {
 		return "", fmt.Errorf("URL must have a valid host")
 	}
 
-	// Prevent localhost and private IP addresses (basic security)
-	host := strings.ToLower(parsedURL.Host)
-	if strings.Contains(host, "localhost") || strings.Contains(host, "127.0.0.1") {
-		return "", fmt.Errorf("localhost URLs are not allowed")
+	// Normalize to punycode, lowercase host, and canonical path before any checks
+	if err := utils.NormalizeParsedURL(parsedURL); err != nil {
+		return "", err
+	}
+
+	// Prevent localhost, private/reserved ranges, and cloud metadata endpoints (SSRF)
+	if utils.IsBlockedHost(parsedURL.Host, h.Config.BlockedCIDRs) {
+		return "", fmt.Errorf("access to private networks and localhost is not allowed")
 	}
 
 	// Return the sanitized URL
@@ -84,6 +91,7 @@ type FetchRequest struct {
 	URL          string `json:"url" validate:"required"`
 	Async        bool   `json:"async,omitempty"`
 	ForceRefresh bool   `json:"force_refresh,omitempty"`
+	AutoAsync    bool   `json:"auto_async,omitempty"`
 }
 
 // FetchResponse represents the response for fetch operations
@@ -97,6 +105,10 @@ type FetchResponse struct {
 	Source     string      `json:"source,omitempty"`
 	Cache      string      `json:"cache,omitempty"`
 	Status     string      `json:"status,omitempty"`
+	// CanonicalURL is set when the requested feed URL has permanently moved
+	CanonicalURL string `json:"canonical_url,omitempty"`
+	// Ingest reports how many items were saved and lists any that could not be stored
+	Ingest *IngestReport `json:"ingest,omitempty"`
 }
 
 // @title RSS Feed Backend API
@@ -114,16 +126,24 @@ type FetchResponse struct {
 // @host localhost:8080
 // @BasePath /
 
+// @securityDefinitions.apikey AdminToken
+// @in header
+// @name Authorization
+// @description Admin endpoints require "Bearer " followed by the ADMIN_TOKEN value
+
 // @Summary Fetch and store RSS feed
 // @Description Fetches an RSS feed from a URL and stores the parsed items in Google Cloud Datastore. Supports both synchronous and asynchronous processing.
 // @Tags RSS Feed Operations
 // @Accept json
 // @Produce json
 // @Param request body FetchRequest true "RSS feed fetch request"
+// @Param X-Tenant-ID header string false "Tenant whose ingest budget the writes draw on (defaults to X-User-ID)"
 // @Success 200 {object} FetchResponse "Feed items fetched and stored successfully"
 // @Success 202 {object} FetchResponse "Job submitted for async processing"
 // @Failure 400 {object} middleware.APIError "Bad request"
+// @Failure 429 {object} middleware.APIError "Tenant ingest queue is full"
 // @Failure 500 {object} middleware.APIError "Internal server error"
+// @Failure 503 {object} middleware.APIError "Async job queue is full; retry after the Retry-After header, with the queue load in X-Queue-Depth and X-Queue-Capacity"
 // @Router /fetch-store [post]
 func (h *Handler) HandleFetchAndStore(w http.ResponseWriter, r *http.Request) {
 	requestID := r.Header.Get("X-Request-ID")
@@ -150,42 +170,28 @@ func (h *Handler) HandleFetchAndStore(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Validate and sanitize the URL
-	sanitizedURL, err := validateAndSanitizeURL(req.URL)
+	sanitizedURL, err := h.validateAndSanitizeURL(req.URL)
 	if err != nil {
 		middleware.RespondValidationError(w, err, requestID)
 		return
 	}
 
-	if req.Async {
-		// Submit job for async processing
-		jobID, err := h.AsyncProcessor.SubmitJob(sanitizedURL, requestID)
-		if err != nil {
-			middleware.Logger.WithFields(logrus.Fields{
-				"request_id": requestID,
-				"url":        sanitizedURL,
-				"error":      err.Error(),
-			}).Error("Failed to submit async job")
-			middleware.RespondInternalError(w, err, requestID)
-			return
-		}
-
-		response := FetchResponse{
-			Success:   true,
-			Message:   "Job submitted for async processing",
-			JobID:     jobID,
-			RequestID: requestID,
-			Status:    "submitted",
-		}
+	// Follow confirmed permanent redirects so old URLs share the canonical cache and storage keys
+	requestedURL := sanitizedURL
+	sanitizedURL = h.Redirects.Resolve(sanitizedURL)
+	var canonicalURL string
+	if sanitizedURL != requestedURL {
+		canonicalURL = sanitizedURL
+	}
 
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusAccepted)
-		json.NewEncoder(w).Encode(response)
+	tenant := TenantFromRequest(r)
+	if req.Async {
+		h.submitAsyncJob(w, r.Context(), sanitizedURL, requestID, tenant, "Job submitted for async processing")
 		return
 	}
 
 	// Log the request
-	middleware.Logger.WithFields(logrus.Fields{
-		"request_id":    requestID,
+	middleware.Log(r.Context()).WithFields(logrus.Fields{
 		"url":           sanitizedURL,
 		"action":        "fetch_and_store",
 		"force_refresh": req.ForceRefresh,
@@ -195,21 +201,21 @@ func (h *Handler) HandleFetchAndStore(w http.ResponseWriter, r *http.Request) {
 	if !req.ForceRefresh {
 		cachedItems, found := h.CacheManager.GetFeedItems(sanitizedURL)
 		if found {
-			middleware.Logger.WithFields(logrus.Fields{
-				"request_id":  requestID,
+			middleware.Log(r.Context()).WithFields(logrus.Fields{
 				"url":         sanitizedURL,
 				"items_count": len(cachedItems),
 				"source":      "cache",
 			}).Info("RSS feed retrieved from cache")
 
 			response := FetchResponse{
-				Success:    true,
-				Message:    "RSS feed retrieved successfully",
-				Data:       cachedItems,
-				RequestID:  requestID,
-				ItemsCount: len(cachedItems),
-				Source:     "cache",
-				Cache:      "HIT",
+				Success:      true,
+				Message:      "RSS feed retrieved successfully",
+				Data:         cachedItems,
+				RequestID:    requestID,
+				ItemsCount:   len(cachedItems),
+				Source:       "cache",
+				Cache:        "HIT",
+				CanonicalURL: canonicalURL,
 			}
 
 			w.Header().Set("Content-Type", "application/json")
@@ -220,55 +226,144 @@ func (h *Handler) HandleFetchAndStore(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Don't fetch from a host that asked clients to back off; a job fetches once it allows
+	if _, throttled := h.Polls.RetryAt(sanitizedURL); throttled {
+		h.submitAsyncJob(w, r.Context(), sanitizedURL, requestID, tenant, throttledJobMessage)
+		return
+	}
+
+	// Bound the sync fetch-store by the client connection and the configured cap
+	ctx := r.Context()
+	if h.Config.SyncFetchTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.Config.SyncFetchTimeout)
+		defer cancel()
+	}
+
+	// With auto_async, give the sync path a shorter budget before handing off to a job
+	workCtx := ctx
+	if req.AutoAsync && h.Config.AutoAsyncThreshold > 0 {
+		var cancel context.CancelFunc
+		workCtx, cancel = context.WithTimeout(ctx, h.Config.AutoAsyncThreshold)
+		defer cancel()
+	}
+
 	// Parse the RSS feed
-	feedItems, err := utils.FetchRSSFeed(sanitizedURL)
+	fetchResult, err := h.fetchFeed(workCtx, sanitizedURL)
 	if err != nil {
-		middleware.Logger.WithFields(logrus.Fields{
-			"request_id": requestID,
-			"url":        sanitizedURL,
-			"error":      err.Error(),
+		h.FeedHealth.RecordFailure(ctx, sanitizedURL, err)
+		var throttledErr *utils.ThrottledError
+		if errors.As(err, &throttledErr) {
+			h.Polls.RecordRetryAfter(ctx, sanitizedURL, h.Config.RetryPolicy.Wait(throttledErr.RetryAfter))
+			h.submitAsyncJob(w, r.Context(), sanitizedURL, requestID, tenant, throttledJobMessage)
+			return
+		}
+		if h.fallbackToAsync(w, workCtx, ctx, sanitizedURL, requestID, tenant) {
+			return
+		}
+		if h.respondSyncContextError(w, ctx, sanitizedURL, requestID) {
+			return
+		}
+		middleware.Log(r.Context()).WithFields(logrus.Fields{
+			"url":   sanitizedURL,
+			"error": err.Error(),
 		}).Error("Failed to fetch RSS feed")
+		h.ErrorWebhooks.RecordFetchFailure(sanitizedURL, err)
 		middleware.RespondExternalAPIError(w, err, requestID)
 		return
 	}
 
+	feedItems := fetchResult.Items
+	h.FeedHealth.RecordSuccess(ctx, sanitizedURL, feedItems)
+	h.Polls.RecordPoll(ctx, sanitizedURL, feedItems)
+
+	// Track permanent redirects; once confirmed the feed is keyed by its new URL
+	migratedURL, err := h.Redirects.Observe(ctx, sanitizedURL, fetchResult)
+	if err != nil {
+		middleware.Log(r.Context()).WithFields(logrus.Fields{
+			"url":   sanitizedURL,
+			"error": err.Error(),
+		}).Warn("Failed to persist feed URL migration")
+	}
+	if migratedURL != sanitizedURL {
+		sanitizedURL, canonicalURL = migratedURL, migratedURL
+	}
+
+	// Group copies of articles already seen from other sources
+	h.Clusters.Assign(feedItems)
+	h.MuteRules.SuppressAtIngest(feedItems)
+
 	// Save the feed items to Datastore
-	if err := SaveToDatastore(h.DatastoreClient, feedItems); err != nil {
-		middleware.Logger.WithFields(logrus.Fields{
-			"request_id":  requestID,
+	report, err := SaveToDatastoreWithReport(workCtx, ingestClient(h.DatastoreClient, h.IngestThrottle, tenant), feedItems)
+	if err != nil {
+		if errors.Is(err, ErrIngestThrottled) {
+			middleware.Log(r.Context()).WithFields(logrus.Fields{
+				"url":    sanitizedURL,
+				"tenant": tenant,
+			}).Warn("Ingest throttled for tenant")
+			middleware.RespondRateLimited(w, err, requestID)
+			return
+		}
+		if h.fallbackToAsync(w, workCtx, ctx, sanitizedURL, requestID, tenant) {
+			return
+		}
+		if h.respondSyncContextError(w, ctx, sanitizedURL, requestID) {
+			return
+		}
+		middleware.Log(r.Context()).WithFields(logrus.Fields{
 			"url":         sanitizedURL,
 			"items_count": len(feedItems),
 			"error":       err.Error(),
 		}).Error("Failed to save to Datastore")
+		h.ErrorWebhooks.RecordStoreFailure(sanitizedURL, err)
 		middleware.RespondInternalError(w, err, requestID)
 		return
 	}
 
+	h.ErrorWebhooks.RecordSuccess(sanitizedURL)
+	h.IngestCounters.Apply(ctx, sanitizedURL, report.Batches)
+	if _, err := h.Snapshots.Save(ctx, report.Items); err != nil {
+		middleware.Log(r.Context()).WithFields(logrus.Fields{
+			"url":   sanitizedURL,
+			"error": err.Error(),
+		}).Warn("Failed to store item snapshots")
+	}
+
+	// Skip caching and responding if the client went away after the save
+	if h.respondSyncContextError(w, ctx, sanitizedURL, requestID) {
+		return
+	}
+
 	// Cache the results
 	if err := h.CacheManager.SetFeedItems(sanitizedURL, feedItems); err != nil {
-		middleware.Logger.WithFields(logrus.Fields{
-			"request_id": requestID,
-			"url":        sanitizedURL,
-			"error":      err.Error(),
+		middleware.Log(r.Context()).WithFields(logrus.Fields{
+			"url":   sanitizedURL,
+			"error": err.Error(),
 		}).Warn("Failed to cache RSS feed")
 	}
 
 	// Log successful completion
-	middleware.Logger.WithFields(logrus.Fields{
-		"request_id":  requestID,
+	middleware.Log(r.Context()).WithFields(logrus.Fields{
 		"url":         sanitizedURL,
 		"items_count": len(feedItems),
 		"source":      "live",
 	}).Info("RSS feed processed successfully")
 
+	message := "RSS feed processed and stored successfully"
+	if len(report.Failed) > 0 {
+		message = fmt.Sprintf("RSS feed processed; %d items could not be stored", len(report.Failed))
+	}
+
 	response := FetchResponse{
-		Success:    true,
-		Message:    "RSS feed processed and stored successfully",
-		Data:       feedItems,
-		RequestID:  requestID,
-		ItemsCount: len(feedItems),
-		Source:     "live",
-		Cache:      "MISS",
+		Success:      true,
+		Message:      message,
+		Data:         feedItems,
+		RequestID:    requestID,
+		ItemsCount:   len(feedItems),
+		Source:       "live",
+		Cache:        "MISS",
+		CanonicalURL: canonicalURL,
+		Ingest:       &report,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -276,3 +371,95 @@ func (h *Handler) HandleFetchAndStore(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(response)
 }
+
+// throttledJobMessage explains a sync fetch-store handed to a job because the feed host is throttling
+const throttledJobMessage = "Feed host asked to retry later; the fetch will run as an async job once it allows"
+
+// submitAsyncJob queues the URL for async processing and responds with 202 and the job ID
+func (h *Handler) submitAsyncJob(w http.ResponseWriter, ctx context.Context, feedURL, requestID, tenant, message string) {
+	jobID, err := h.AsyncProcessor.SubmitTenantJobWithContext(ctx, feedURL, requestID, tenant)
+	if err != nil {
+		if respondQueueFull(w, err, requestID) {
+			return
+		}
+		middleware.Logger.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"url":        feedURL,
+			"error":      err.Error(),
+		}).Error("Failed to submit async job")
+		middleware.RespondInternalError(w, err, requestID)
+		return
+	}
+
+	response := FetchResponse{
+		Success:   true,
+		Message:   message,
+		JobID:     jobID,
+		RequestID: requestID,
+		Status:    "submitted",
+	}
+	// A full job queue may have parked the job in the overflow queue instead
+	if status, exists := h.AsyncProcessor.GetJobStatus(jobID); exists && status.Status == "queued_deferred" {
+		response.Message = "Job queue is full; job accepted into the overflow queue and will run when there is room"
+		response.Status = "queued_deferred"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(response)
+}
+
+// respondQueueFull answers a job rejected by backpressure with 503, a Retry-After estimate, and the queue load.
+// It returns false for other errors.
+func respondQueueFull(w http.ResponseWriter, err error, requestID string) bool {
+	var queueFull *QueueFullError
+	if !errors.As(err, &queueFull) {
+		return false
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(queueFull.RetryAfter.Seconds()))))
+	w.Header().Set("X-Queue-Depth", strconv.Itoa(queueFull.Depth))
+	w.Header().Set("X-Queue-Capacity", strconv.Itoa(queueFull.Capacity))
+	middleware.RespondServiceUnavailable(w, err, requestID)
+	return true
+}
+
+// fallbackToAsync converts a sync fetch-store that ran past the auto_async threshold into an async job.
+// It returns false when the threshold was not the cause of the failure.
+func (h *Handler) fallbackToAsync(w http.ResponseWriter, workCtx, ctx context.Context, feedURL, requestID, tenant string) bool {
+	if workCtx == ctx || workCtx.Err() == nil || ctx.Err() != nil {
+		return false
+	}
+
+	middleware.Log(ctx).WithFields(logrus.Fields{
+		"url":       feedURL,
+		"threshold": h.Config.AutoAsyncThreshold.String(),
+	}).Info("Sync fetch-store exceeded auto_async threshold, converting to async job")
+
+	h.submitAsyncJob(w, ctx, feedURL, requestID, tenant, "Sync processing exceeded threshold, continuing as async job")
+	return true
+}
+
+// respondSyncContextError handles a cancelled or expired sync fetch context.
+// It returns false when the context is still live and the caller should handle the error itself.
+func (h *Handler) respondSyncContextError(w http.ResponseWriter, ctx context.Context, feedURL, requestID string) bool {
+	err := ctx.Err()
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		middleware.Log(ctx).WithFields(logrus.Fields{
+			"url":     feedURL,
+			"timeout": h.Config.SyncFetchTimeout.String(),
+		}).Warn("Sync fetch-store exceeded deadline")
+		middleware.RespondGatewayTimeout(w, fmt.Errorf("sync fetch-store exceeded %s", h.Config.SyncFetchTimeout), requestID)
+		return true
+	}
+
+	// The client disconnected; there is nobody left to respond to
+	middleware.Log(ctx).WithFields(logrus.Fields{
+		"url": feedURL,
+	}).Info("Client disconnected, sync fetch-store cancelled")
+	return true
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Nexora-Open-Source/rss-feed-backend/middleware"
+	"github.com/Nexora-Open-Source/rss-feed-backend/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// Limits of GET /dev/simulated-feed
+const (
+	defaultSimulatedItems = 20
+	maxSimulatedItems     = MaxItemsPageSize
+	maxSimulatedLag       = 30 * time.Second
+)
+
+// simulatedFeedEpoch is the publication time of the newest simulated item, fixed so responses never change
+var simulatedFeedEpoch = time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+// Word lists simulated items are built from
+var (
+	simulatedSources  = []string{"daily-planet", "tech-wire", "open-source-weekly", "city-herald", "science-digest"}
+	simulatedAuthors  = []string{"Alex Rivera", "Sam Okafor", "Priya Natarajan", "Jordan Lee", "Mina Cho", ""}
+	simulatedSubjects = []string{"Go release", "city council", "Mars rover", "open data portal", "transit strike", "chip shortage", "heat wave", "election night"}
+	simulatedVerbs    = []string{"announces", "delays", "wins approval for", "faces criticism over", "reveals plans for", "reports record numbers in"}
+	simulatedTopics   = []string{"new features", "budget cuts", "a surprise finding", "the next phase", "community feedback", "security fixes"}
+	simulatedFillers  = []string{
+		"Officials said more details would follow later this week.",
+		"The change takes effect immediately for most users.",
+		"Analysts expect the decision to shape the coming quarter.",
+		"Readers have already shared hundreds of comments.",
+		"A full timeline is available on the project's website.",
+	}
+)
+
+// @Summary Get a simulated feed
+// @Description Served only with SIMULATED_FEED_ENABLED in development environments. Returns deterministic synthetic feed items shaped like GET /items, with optional artificial latency, so frontends can be built without external feeds or stored data. The same parameters always return the same items.
+// @Tags Development
+// @Produce json
+// @Param items query int false "Number of items to return (default: 20, max: 1000)"
+// @Param lag query string false "Delay before responding, as a Go duration such as 2s (max: 30s)"
+// @Param seed query int false "Varies the generated items; each seed gives a different deterministic feed (default: 1)"
+// @Success 200 {object} PaginatedResult "Simulated feed items"
+// @Failure 400 {object} middleware.APIError "Bad request"
+// @Router /dev/simulated-feed [get]
+func (h *Handler) HandleSimulatedFeed(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Header.Get("X-Request-ID")
+	if requestID == "" {
+		requestID = utils.GenerateRequestID()
+		w.Header().Set("X-Request-ID", requestID)
+	}
+
+	count := defaultSimulatedItems
+	if itemsStr := r.URL.Query().Get("items"); itemsStr != "" {
+		parsedCount, err := strconv.Atoi(itemsStr)
+		if err != nil || parsedCount < 0 || parsedCount > maxSimulatedItems {
+			middleware.RespondBadRequest(w, fmt.Errorf("invalid items parameter: must be between 0 and %d", maxSimulatedItems), requestID)
+			return
+		}
+		count = parsedCount
+	}
+
+	var lag time.Duration
+	if lagStr := r.URL.Query().Get("lag"); lagStr != "" {
+		parsedLag, err := time.ParseDuration(lagStr)
+		if err != nil || parsedLag < 0 || parsedLag > maxSimulatedLag {
+			middleware.RespondBadRequest(w, fmt.Errorf("invalid lag parameter: must be a duration such as 2s, at most %s", maxSimulatedLag), requestID)
+			return
+		}
+		lag = parsedLag
+	}
+
+	seed := int64(1)
+	if seedStr := r.URL.Query().Get("seed"); seedStr != "" {
+		parsedSeed, err := strconv.ParseInt(seedStr, 10, 64)
+		if err != nil {
+			middleware.RespondBadRequest(w, fmt.Errorf("invalid seed parameter: must be an integer"), requestID)
+			return
+		}
+		seed = parsedSeed
+	}
+
+	middleware.Log(r.Context()).WithFields(logrus.Fields{
+		"action": "get_simulated_feed",
+		"items":  count,
+		"lag":    lag.String(),
+		"seed":   seed,
+	}).Info("Processing simulated feed request")
+
+	// Simulate a slow backend, giving up if the caller does
+	if lag > 0 {
+		timer := time.NewTimer(lag)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	items := simulatedFeedItems(count, seed)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Simulated", "true")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(PaginatedResult{
+		Items:      items,
+		TotalCount: len(items),
+	})
+}
+
+// simulatedFeedItems generates count items, newest first, that depend only on count and seed
+func simulatedFeedItems(count int, seed int64) []*utils.FeedItem {
+	random := rand.New(rand.NewSource(seed))
+	items := make([]*utils.FeedItem, 0, count)
+	publishedAt := simulatedFeedEpoch
+	for i := 0; i < count; i++ {
+		source := simulatedSources[random.Intn(len(simulatedSources))]
+		subject := simulatedSubjects[random.Intn(len(simulatedSubjects))]
+		title := fmt.Sprintf("%s %s %s", strings.ToUpper(subject[:1])+subject[1:],
+			simulatedVerbs[random.Intn(len(simulatedVerbs))], simulatedTopics[random.Intn(len(simulatedTopics))])
+
+		sentences := make([]string, 1+random.Intn(3))
+		for j := range sentences {
+			sentences[j] = simulatedFillers[random.Intn(len(simulatedFillers))]
+		}
+
+		items = append(items, &utils.FeedItem{
+			Title:       title,
+			Link:        fmt.Sprintf("https://%s.example.com/%d/articles/%d", source, seed, i+1),
+			Description: strings.Join(sentences, " "),
+			Author:      simulatedAuthors[random.Intn(len(simulatedAuthors))],
+			PubDate:     publishedAt.Format(time.RFC3339),
+			PublishedAt: publishedAt,
+		})
+		// Space items irregularly, as real feeds publish
+		publishedAt = publishedAt.Add(-time.Duration(5+random.Intn(115)) * time.Minute)
+	}
+	return items
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Nexora-Open-Source/rss-feed-backend/monitoring"
+	"github.com/Nexora-Open-Source/rss-feed-backend/utils"
+)
+
+// DataStalenessHeader reports, in seconds, how old the data in a response served from the stale fallback is
+const DataStalenessHeader = "X-Data-Staleness"
+
+// staleResult is a page of items as last read from Datastore
+type staleResult struct {
+	result    PaginatedResult
+	fetchedAt time.Time
+}
+
+/*
+StaleFallback keeps the most recent Datastore results of read endpoints so they can
+still be served while Datastore is failing.
+
+Each successful /items query is remembered by its cache key, along with the
+subscribed feed sources behind /feeds. When a later query fails, the remembered
+result is served with an X-Data-Staleness header giving its age in seconds,
+instead of an error. Results older than the maximum age are not served; the
+oldest results are forgotten once the entry limit is reached.
+*/
+type StaleFallback struct {
+	mu         sync.Mutex
+	maxAge     time.Duration
+	maxEntries int
+	results    map[string]staleResult
+	feeds      []FeedSource
+	feedsAt    time.Time
+	now        func() time.Time
+}
+
+// NewStaleFallback serves results up to maxAge old, remembering at most maxEntries item queries;
+// a maxAge of 0 disables the fallback and returns nil
+func NewStaleFallback(maxAge time.Duration, maxEntries int) *StaleFallback {
+	if maxAge <= 0 {
+		return nil
+	}
+	return &StaleFallback{
+		maxAge:     maxAge,
+		maxEntries: maxEntries,
+		results:    make(map[string]staleResult),
+		now:        time.Now,
+	}
+}
+
+// RememberItems keeps a successful result of the items query with the given cache key
+func (s *StaleFallback) RememberItems(key string, result *PaginatedResult) {
+	if s == nil || result == nil || s.maxEntries <= 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.results[key]; !exists && len(s.results) >= s.maxEntries {
+		s.evictOldest()
+	}
+	// Copy the items so filtering the served page cannot change what is remembered
+	remembered := *result
+	remembered.Items = append([]*utils.FeedItem(nil), result.Items...)
+	s.results[key] = staleResult{result: remembered, fetchedAt: s.now()}
+}
+
+// evictOldest forgets the least recently fetched items query
+func (s *StaleFallback) evictOldest() {
+	oldestKey := ""
+	var oldest time.Time
+	for key, entry := range s.results {
+		if oldestKey == "" || entry.fetchedAt.Before(oldest) {
+			oldestKey, oldest = key, entry.fetchedAt
+		}
+	}
+	delete(s.results, oldestKey)
+}
+
+// RecallItems returns the remembered result of an items query and its age; found is false when
+// there is none within the maximum age
+func (s *StaleFallback) RecallItems(key string) (*PaginatedResult, time.Duration, bool) {
+	if s == nil {
+		return nil, 0, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, exists := s.results[key]
+	if !exists {
+		return nil, 0, false
+	}
+	age := s.now().Sub(entry.fetchedAt)
+	if age > s.maxAge {
+		delete(s.results, key)
+		return nil, 0, false
+	}
+	result := entry.result
+	result.Items = append([]*utils.FeedItem(nil), entry.result.Items...)
+	return &result, age, true
+}
+
+// RememberFeeds keeps the subscribed feed sources last read from Datastore
+func (s *StaleFallback) RememberFeeds(feeds []FeedSource) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.feeds = append([]FeedSource(nil), feeds...)
+	s.feedsAt = s.now()
+}
+
+// RecallFeeds returns the remembered subscribed feed sources and their age; found is false when
+// there are none within the maximum age
+func (s *StaleFallback) RecallFeeds() ([]FeedSource, time.Duration, bool) {
+	if s == nil {
+		return nil, 0, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.feedsAt.IsZero() {
+		return nil, 0, false
+	}
+	age := s.now().Sub(s.feedsAt)
+	if age > s.maxAge {
+		return nil, 0, false
+	}
+	return append([]FeedSource(nil), s.feeds...), age, true
+}
+
+// setDataStaleness marks a response as served from the stale fallback and counts it by endpoint
+func setDataStaleness(w http.ResponseWriter, endpoint string, age time.Duration) {
+	w.Header().Set(DataStalenessHeader, strconv.Itoa(int(age/time.Second)))
+	monitoring.RecordStaleResponse(endpoint)
+}
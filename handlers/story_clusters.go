@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/Nexora-Open-Source/rss-feed-backend/middleware"
+	"github.com/Nexora-Open-Source/rss-feed-backend/utils"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// defaultStoryWindow is used when no story window is configured
+	defaultStoryWindow = 48 * time.Hour
+	// maxStoryWindow bounds the window parameter accepted by GET /clusters
+	maxStoryWindow = 7 * 24 * time.Hour
+	// maxRecentItems bounds how many recent items are grouped or ranked per request
+	maxRecentItems = 1000
+	// defaultStoryLimit and maxStoryLimit bound how many stories are returned
+	defaultStoryLimit = 20
+	maxStoryLimit     = 100
+)
+
+// StoryClustersResponse is the response body for GET /clusters
+type StoryClustersResponse struct {
+	Clusters     []*utils.StoryGroup `json:"clusters"`
+	TotalCount   int                 `json:"total_count"`
+	ItemsScanned int                 `json:"items_scanned"`
+	Window       string              `json:"window"`
+}
+
+// @Summary Get story clusters
+// @Description Groups recent items from different sources that cover the same story, by title similarity within a time window.
+// @Tags RSS Feed Operations
+// @Produce json
+// @Param window query string false "How far back to look and how far apart related items may be, as a Go duration (default: 48h, max: 168h)"
+// @Param limit query int false "Number of stories to return (default: 20, max: 100)"
+// @Param min_sources query int false "Only return stories covered by at least this many sources (default: 1)"
+// @Success 200 {object} StoryClustersResponse "Story clusters"
+// @Failure 400 {object} middleware.APIError "Bad request"
+// @Failure 500 {object} middleware.APIError "Internal server error"
+// @Router /clusters [get]
+func (h *Handler) HandleGetStoryClusters(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Header.Get("X-Request-ID")
+	if requestID == "" {
+		requestID = utils.GenerateRequestID()
+		w.Header().Set("X-Request-ID", requestID)
+	}
+
+	window := h.Config.StoryWindow
+	if window <= 0 {
+		window = defaultStoryWindow
+	}
+	if windowStr := r.URL.Query().Get("window"); windowStr != "" {
+		parsedWindow, err := time.ParseDuration(windowStr)
+		if err != nil || parsedWindow <= 0 {
+			middleware.RespondBadRequest(w, fmt.Errorf("invalid window parameter: must be a positive duration such as 24h"), requestID)
+			return
+		}
+		window = parsedWindow
+	}
+	if window > maxStoryWindow {
+		window = maxStoryWindow
+	}
+
+	limit := defaultStoryLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsedLimit, err := strconv.Atoi(limitStr)
+		if err != nil || parsedLimit <= 0 {
+			middleware.RespondBadRequest(w, fmt.Errorf("invalid limit parameter: must be a positive integer"), requestID)
+			return
+		}
+		limit = parsedLimit
+	}
+	if limit > maxStoryLimit {
+		limit = maxStoryLimit
+	}
+
+	minSources := 1
+	if minSourcesStr := r.URL.Query().Get("min_sources"); minSourcesStr != "" {
+		parsedMinSources, err := strconv.Atoi(minSourcesStr)
+		if err != nil || parsedMinSources <= 0 {
+			middleware.RespondBadRequest(w, fmt.Errorf("invalid min_sources parameter: must be a positive integer"), requestID)
+			return
+		}
+		minSources = parsedMinSources
+	}
+
+	// Log the request
+	middleware.Log(r.Context()).WithFields(logrus.Fields{
+		"action":      "get_story_clusters",
+		"window":      window.String(),
+		"limit":       limit,
+		"min_sources": minSources,
+	}).Info("Processing story clusters request")
+
+	items, cacheStatus, err := h.recentItems(r.Context(), window)
+	if err != nil {
+		middleware.Log(r.Context()).WithFields(logrus.Fields{
+			"error": err.Error(),
+		}).Error("Failed to fetch items for story clusters")
+		middleware.RespondInternalError(w, err, requestID)
+		return
+	}
+	items = h.muteFilter(r, items)
+
+	var clusters []*utils.StoryGroup
+	for _, group := range utils.GroupStories(items, h.Config.StoryTitleThreshold, window) {
+		if group.SourceCount >= minSources {
+			clusters = append(clusters, group)
+		}
+	}
+	response := StoryClustersResponse{
+		Clusters:     clusters,
+		TotalCount:   len(clusters),
+		ItemsScanned: len(items),
+		Window:       window.String(),
+	}
+	if len(response.Clusters) > limit {
+		response.Clusters = response.Clusters[:limit]
+	}
+	if response.Clusters == nil {
+		response.Clusters = []*utils.StoryGroup{}
+	}
+
+	// Log successful completion
+	middleware.Log(r.Context()).WithFields(logrus.Fields{
+		"clusters_count": len(response.Clusters),
+		"total_count":    response.TotalCount,
+		"items_scanned":  response.ItemsScanned,
+	}).Info("Story clusters retrieved successfully")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Cache", cacheStatus)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// recentItems returns up to maxRecentItems items published within window, from the cache when possible
+func (h *Handler) recentItems(ctx context.Context, window time.Duration) ([]*utils.FeedItem, string, error) {
+	cacheKey := fmt.Sprintf("recent:window:%s", window)
+	if items, found := h.CacheManager.GetStoredItems(cacheKey); found {
+		return items, "HIT", nil
+	}
+
+	since := time.Now().Add(-window)
+	query := datastore.NewQuery("FeedItem").
+		Filter("pub_date >=", since.Format(time.RFC3339)).
+		Order("-pub_date").
+		Limit(maxRecentItems)
+
+	var items []*utils.FeedItem
+	if _, err := h.DatastoreClient.GetAll(ctx, query, &items); err != nil {
+		return nil, "", err
+	}
+
+	if err := h.CacheManager.SetStoredItems(cacheKey, items); err != nil {
+		middleware.Logger.WithFields(logrus.Fields{
+			"cache_key": cacheKey,
+			"error":     err.Error(),
+		}).Warn("Failed to cache recent items")
+	}
+	return items, "MISS", nil
+}
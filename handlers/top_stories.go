@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Nexora-Open-Source/rss-feed-backend/middleware"
+	"github.com/Nexora-Open-Source/rss-feed-backend/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// defaultTopWindow is how far back GET /items/top looks when no window is given
+const defaultTopWindow = 24 * time.Hour
+
+// TopItem is an item in the top stories ranking along with the story it belongs to
+type TopItem struct {
+	utils.ScoredItem
+	StoryID   string `json:"story_id"`
+	StorySize int    `json:"story_size"`
+}
+
+// TopItemsResponse is the response body for GET /items/top
+type TopItemsResponse struct {
+	Items        []TopItem `json:"items"`
+	ItemsScanned int       `json:"items_scanned"`
+	Window       string    `json:"window"`
+}
+
+// @Summary Get top stories
+// @Description Ranks recent items by recency, source weight, story coverage, and keyword boosts, returning the best-scoring item of each story.
+// @Tags RSS Feed Operations
+// @Produce json
+// @Param window query string false "How far back to look, as a Go duration (default: 24h, max: 168h)"
+// @Param limit query int false "Number of items to return (default: 20, max: 100)"
+// @Success 200 {object} TopItemsResponse "Top stories"
+// @Failure 400 {object} middleware.APIError "Bad request"
+// @Failure 500 {object} middleware.APIError "Internal server error"
+// @Router /items/top [get]
+func (h *Handler) HandleGetTopItems(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Header.Get("X-Request-ID")
+	if requestID == "" {
+		requestID = utils.GenerateRequestID()
+		w.Header().Set("X-Request-ID", requestID)
+	}
+
+	window := defaultTopWindow
+	if windowStr := r.URL.Query().Get("window"); windowStr != "" {
+		parsedWindow, err := time.ParseDuration(windowStr)
+		if err != nil || parsedWindow <= 0 {
+			middleware.RespondBadRequest(w, fmt.Errorf("invalid window parameter: must be a positive duration such as 24h"), requestID)
+			return
+		}
+		window = parsedWindow
+	}
+	if window > maxStoryWindow {
+		window = maxStoryWindow
+	}
+
+	limit := defaultStoryLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsedLimit, err := strconv.Atoi(limitStr)
+		if err != nil || parsedLimit <= 0 {
+			middleware.RespondBadRequest(w, fmt.Errorf("invalid limit parameter: must be a positive integer"), requestID)
+			return
+		}
+		limit = parsedLimit
+	}
+	if limit > maxStoryLimit {
+		limit = maxStoryLimit
+	}
+
+	// Log the request
+	middleware.Log(r.Context()).WithFields(logrus.Fields{
+		"action": "get_top_items",
+		"window": window.String(),
+		"limit":  limit,
+	}).Info("Processing top items request")
+
+	items, cacheStatus, err := h.recentItems(r.Context(), window)
+	if err != nil {
+		middleware.Log(r.Context()).WithFields(logrus.Fields{
+			"error": err.Error(),
+		}).Error("Failed to fetch items for top stories")
+		middleware.RespondInternalError(w, err, requestID)
+		return
+	}
+	items = h.muteFilter(r, items)
+
+	response := TopItemsResponse{
+		Items:        rankTopItems(items, h.scorer(), h.Config.StoryTitleThreshold, window, time.Now()),
+		ItemsScanned: len(items),
+		Window:       window.String(),
+	}
+	if len(response.Items) > limit {
+		response.Items = response.Items[:limit]
+	}
+
+	// Log successful completion
+	middleware.Log(r.Context()).WithFields(logrus.Fields{
+		"items_count":   len(response.Items),
+		"items_scanned": response.ItemsScanned,
+	}).Info("Top items retrieved successfully")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Cache", cacheStatus)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// scorer returns the configured item scorer, falling back to default weights
+func (h *Handler) scorer() utils.ItemScorer {
+	if h.Scorer != nil {
+		return h.Scorer
+	}
+	return utils.NewWeightedScorer(utils.DefaultRankingWeights(), nil, nil)
+}
+
+// rankTopItems scores items with their story sizes and keeps the best-scoring item of each story
+func rankTopItems(items []*utils.FeedItem, scorer utils.ItemScorer, storyThreshold float64, window time.Duration, now time.Time) []TopItem {
+	stories := make(map[*utils.FeedItem]*utils.StoryGroup, len(items))
+	for _, story := range utils.GroupStories(items, storyThreshold, window) {
+		for _, item := range story.Items {
+			stories[item] = story
+		}
+	}
+
+	ranked := utils.RankItems(items, scorer, func(item *utils.FeedItem) utils.RankingSignals {
+		signals := utils.RankingSignals{Now: now, ClusterSize: 1}
+		if story, exists := stories[item]; exists {
+			signals.ClusterSize = story.ItemCount
+		}
+		return signals
+	})
+
+	top := make([]TopItem, 0, len(ranked))
+	seen := make(map[string]bool)
+	for _, scored := range ranked {
+		topItem := TopItem{ScoredItem: scored, StoryID: utils.ClusterIDFor(scored.Item), StorySize: 1}
+		if story, exists := stories[scored.Item]; exists {
+			topItem.StoryID, topItem.StorySize = story.ID, story.ItemCount
+		}
+		if seen[topItem.StoryID] {
+			continue
+		}
+		seen[topItem.StoryID] = true
+		top = append(top, topItem)
+	}
+	return top
+}
@@ -0,0 +1,284 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Nexora-Open-Source/rss-feed-backend/middleware"
+	"github.com/Nexora-Open-Source/rss-feed-backend/utils"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// AnalyticsConsentHeader carries the caller's analytics consent, set by the gateway; only "granted" opts in
+const AnalyticsConsentHeader = "X-Analytics-Consent"
+
+// EndpointUsage counts consenting requests to one endpoint
+type EndpointUsage struct {
+	Endpoint string `json:"endpoint"`
+	Requests int64  `json:"requests"`
+	Errors   int64  `json:"errors"`
+}
+
+// TenantUsage counts one tenant's consenting requests by feature
+type TenantUsage struct {
+	Tenant   string           `json:"tenant"`
+	Requests int64            `json:"requests"`
+	Features map[string]int64 `json:"features"`
+}
+
+// UsageAnalyticsReport is the response body for GET /admin/usage-analytics
+type UsageAnalyticsReport struct {
+	Enabled bool `json:"enabled"`
+	// From and To are the first and last UTC dates covered
+	From      string          `json:"from"`
+	To        string          `json:"to"`
+	Endpoints []EndpointUsage `json:"endpoints"`
+	Tenants   []TenantUsage   `json:"tenants"`
+}
+
+// analyticsDay holds one UTC day of aggregated usage
+type analyticsDay struct {
+	endpoints map[string]*EndpointUsage
+	tenants   map[string]map[string]int64
+}
+
+/*
+UsageAnalytics aggregates API usage from callers who opted in.
+
+Only requests carrying AnalyticsConsentHeader "granted", and no Sec-GPC opt-out,
+are counted. Nothing about an individual request is kept: each is folded into
+daily per-endpoint counts and, when it names a tenant with X-Tenant-ID, daily
+per-tenant feature counts. Requests attributed to a user ID are never broken out
+by tenant. Days older than the retention window are discarded.
+*/
+type UsageAnalytics struct {
+	mu            sync.Mutex
+	days          map[string]*analyticsDay
+	retentionDays int
+	now           func() time.Time
+}
+
+// NewUsageAnalytics keeps aggregated usage for retentionDays days; 0 disables analytics and returns nil
+func NewUsageAnalytics(retentionDays int) *UsageAnalytics {
+	if retentionDays <= 0 {
+		return nil
+	}
+	return &UsageAnalytics{
+		days:          make(map[string]*analyticsDay),
+		retentionDays: retentionDays,
+		now:           time.Now,
+	}
+}
+
+// hasAnalyticsConsent reports whether the caller opted in to usage analytics
+func hasAnalyticsConsent(r *http.Request) bool {
+	// Global Privacy Control overrides any consent the gateway forwarded
+	if r.Header.Get("Sec-GPC") == "1" {
+		return false
+	}
+	return r.Header.Get(AnalyticsConsentHeader) == "granted"
+}
+
+// Middleware counts each served request from a consenting caller
+func (a *UsageAnalytics) Middleware(next http.Handler) http.Handler {
+	if a == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !hasAnalyticsConsent(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		endpoint := r.Method + " unmatched"
+		if route := mux.CurrentRoute(r); route != nil {
+			if template, err := route.GetPathTemplate(); err == nil {
+				endpoint = r.Method + " " + template
+			}
+		}
+		tenant := ""
+		if header := r.Header.Get(TenantIDHeader); userIDPattern.MatchString(header) {
+			tenant = header
+		}
+		a.Record(endpoint, tenant, recorder.status)
+	})
+}
+
+// Record counts one request to endpoint with the given response status; an empty tenant is not broken out
+func (a *UsageAnalytics) Record(endpoint, tenant string, status int) {
+	if a == nil {
+		return
+	}
+
+	day := a.now().UTC().Format(time.DateOnly)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	bucket, exists := a.days[day]
+	if !exists {
+		bucket = &analyticsDay{
+			endpoints: make(map[string]*EndpointUsage),
+			tenants:   make(map[string]map[string]int64),
+		}
+		a.days[day] = bucket
+		a.prune()
+	}
+
+	usage, exists := bucket.endpoints[endpoint]
+	if !exists {
+		usage = &EndpointUsage{Endpoint: endpoint}
+		bucket.endpoints[endpoint] = usage
+	}
+	usage.Requests++
+	if status >= http.StatusBadRequest {
+		usage.Errors++
+	}
+
+	if tenant != "" {
+		features, exists := bucket.tenants[tenant]
+		if !exists {
+			features = make(map[string]int64)
+			bucket.tenants[tenant] = features
+		}
+		features[endpoint]++
+	}
+}
+
+// Report sums the last days days of usage, most used endpoints and tenants first; days is capped at the retention window
+func (a *UsageAnalytics) Report(days int) UsageAnalyticsReport {
+	report := UsageAnalyticsReport{Endpoints: []EndpointUsage{}, Tenants: []TenantUsage{}}
+	if a == nil {
+		return report
+	}
+	if days <= 0 || days > a.retentionDays {
+		days = a.retentionDays
+	}
+
+	today := a.now().UTC()
+	report.Enabled = true
+	report.From = today.AddDate(0, 0, 1-days).Format(time.DateOnly)
+	report.To = today.Format(time.DateOnly)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	endpoints := make(map[string]*EndpointUsage)
+	tenants := make(map[string]*TenantUsage)
+	for day, bucket := range a.days {
+		if day < report.From {
+			continue
+		}
+		for endpoint, usage := range bucket.endpoints {
+			total, exists := endpoints[endpoint]
+			if !exists {
+				total = &EndpointUsage{Endpoint: endpoint}
+				endpoints[endpoint] = total
+			}
+			total.Requests += usage.Requests
+			total.Errors += usage.Errors
+		}
+		for tenant, features := range bucket.tenants {
+			total, exists := tenants[tenant]
+			if !exists {
+				total = &TenantUsage{Tenant: tenant, Features: make(map[string]int64)}
+				tenants[tenant] = total
+			}
+			for feature, count := range features {
+				total.Features[feature] += count
+				total.Requests += count
+			}
+		}
+	}
+
+	for _, usage := range endpoints {
+		report.Endpoints = append(report.Endpoints, *usage)
+	}
+	sort.Slice(report.Endpoints, func(i, j int) bool {
+		if report.Endpoints[i].Requests != report.Endpoints[j].Requests {
+			return report.Endpoints[i].Requests > report.Endpoints[j].Requests
+		}
+		return report.Endpoints[i].Endpoint < report.Endpoints[j].Endpoint
+	})
+	for _, usage := range tenants {
+		report.Tenants = append(report.Tenants, *usage)
+	}
+	sort.Slice(report.Tenants, func(i, j int) bool {
+		if report.Tenants[i].Requests != report.Tenants[j].Requests {
+			return report.Tenants[i].Requests > report.Tenants[j].Requests
+		}
+		return report.Tenants[i].Tenant < report.Tenants[j].Tenant
+	})
+	return report
+}
+
+// prune drops days outside the retention window; callers must hold the lock
+func (a *UsageAnalytics) prune() {
+	oldest := a.now().UTC().AddDate(0, 0, 1-a.retentionDays).Format(time.DateOnly)
+	for day := range a.days {
+		if day < oldest {
+			delete(a.days, day)
+		}
+	}
+}
+
+// statusRecorder captures the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (rw *statusRecorder) WriteHeader(code int) {
+	rw.status = code
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+// @Summary Get usage analytics
+// @Description Returns aggregated API usage from callers who opted in to analytics: request and error counts per endpoint, and per-feature request counts for each tenant named by X-Tenant-ID. No individual requests are stored. Enabled is false when analytics are turned off. Requires the admin token.
+// @Tags Admin
+// @Produce json
+// @Security AdminToken
+// @Param days query int false "Number of most recent days to include (defaults to the whole retention window)"
+// @Success 200 {object} UsageAnalyticsReport "Usage analytics"
+// @Failure 400 {object} middleware.APIError "Invalid days"
+// @Failure 401 {object} middleware.APIError "Missing or invalid admin token"
+// @Router /admin/usage-analytics [get]
+func (h *Handler) HandleGetUsageAnalytics(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Header.Get("X-Request-ID")
+	if requestID == "" {
+		requestID = utils.GenerateRequestID()
+		w.Header().Set("X-Request-ID", requestID)
+	}
+
+	days := 0
+	if value := r.URL.Query().Get("days"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			middleware.RespondBadRequest(w, fmt.Errorf("days must be a positive integer"), requestID)
+			return
+		}
+		days = parsed
+	}
+
+	report := h.UsageAnalytics.Report(days)
+
+	middleware.Log(r.Context()).WithFields(logrus.Fields{
+		"enabled":   report.Enabled,
+		"endpoints": len(report.Endpoints),
+		"tenants":   len(report.Tenants),
+	}).Debug("Usage analytics retrieved")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(report)
+}
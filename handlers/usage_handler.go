@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Nexora-Open-Source/rss-feed-backend/middleware"
+	"github.com/Nexora-Open-Source/rss-feed-backend/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// UsageResponse is the response body for GET /usage
+type UsageResponse struct {
+	Tenant string `json:"tenant"`
+	// IngestThrottled is false when ingest throttling is disabled, in which case Ingest only names the tenant
+	IngestThrottled bool        `json:"ingest_throttled"`
+	Ingest          IngestUsage `json:"ingest"`
+}
+
+// @Summary Get tenant usage
+// @Description Returns the caller's tenant's Datastore ingest budget and usage: its current write rate, entities written and queued, and how often writes were throttled or rejected.
+// @Tags Usage
+// @Produce json
+// @Param X-Tenant-ID header string false "Tenant to report on (defaults to X-User-ID)"
+// @Success 200 {object} UsageResponse "Tenant usage"
+// @Router /usage [get]
+func (h *Handler) HandleGetUsage(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Header.Get("X-Request-ID")
+	if requestID == "" {
+		requestID = utils.GenerateRequestID()
+		w.Header().Set("X-Request-ID", requestID)
+	}
+
+	tenant := TenantFromRequest(r)
+	response := UsageResponse{
+		Tenant:          tenant,
+		IngestThrottled: h.IngestThrottle != nil,
+		Ingest:          h.IngestThrottle.Usage(tenant),
+	}
+
+	middleware.Log(r.Context()).WithFields(logrus.Fields{
+		"entities_written": response.Ingest.EntitiesWritten,
+		"entities_queued":  response.Ingest.EntitiesQueued,
+	}).Info("Usage retrieved")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/Nexora-Open-Source/rss-feed-backend/middleware"
+	"github.com/Nexora-Open-Source/rss-feed-backend/types"
+	"github.com/Nexora-Open-Source/rss-feed-backend/utils"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// Categories of per-user data reported by a user data deletion job
+const (
+	UserDataMuteRules       = "mute_rules"
+	UserDataSuppressedItems = "suppressed_items"
+	UserDataIngestUsage     = "ingest_usage"
+)
+
+// UserDataDeletionResponse is the response body for DELETE /users/{id}/data
+type UserDataDeletionResponse struct {
+	Success   bool   `json:"success"`
+	Message   string `json:"message"`
+	JobID     string `json:"job_id"`
+	RequestID string `json:"request_id"`
+	Status    string `json:"status"`
+	// Deadline is when the deletion must have finished; the job fails if it has not
+	Deadline time.Time `json:"deadline"`
+}
+
+// @Summary Delete a user's data
+// @Description Deletes the user's mute rules, removes the user from the suppression lists of stored items, and forgets the user's ingest usage. The deletion runs as an async job that must finish within the configured deadline; poll /job-status for the completion report, which lists each category of data with the number of records changed. Requires the admin token, since the X-User-ID header alone cannot prove who is asking.
+// @Tags Users
+// @Produce json
+// @Security AdminToken
+// @Param id path string true "User ID"
+// @Success 202 {object} UserDataDeletionResponse "Deletion submitted"
+// @Failure 400 {object} middleware.APIError "Missing user ID"
+// @Failure 401 {object} middleware.APIError "Missing or invalid admin token"
+// @Failure 500 {object} middleware.APIError "Internal server error"
+// @Failure 503 {object} middleware.APIError "Async job queue is full; retry after the Retry-After header"
+// @Router /users/{id}/data [delete]
+func (h *Handler) HandleDeleteUserData(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Header.Get("X-Request-ID")
+	if requestID == "" {
+		requestID = utils.GenerateRequestID()
+		w.Header().Set("X-Request-ID", requestID)
+	}
+
+	// The route requires the admin token, so the path names the user rather than the caller's own header
+	userID := mux.Vars(r)["id"]
+	if userID == "" {
+		middleware.RespondBadRequest(w, fmt.Errorf("user ID is required"), requestID)
+		return
+	}
+
+	// The deadline runs from the request, so time spent queued counts against it
+	deadline := time.Now().Add(h.Config.UserDataDeadline)
+	jobID, err := h.AsyncProcessor.SubmitTask("delete_user_data", requestID, h.deleteUserDataTask(userID, deadline))
+	if err != nil {
+		if respondQueueFull(w, err, requestID) {
+			return
+		}
+		middleware.Log(r.Context()).WithError(err).Error("Failed to submit user data deletion")
+		middleware.RespondInternalError(w, err, requestID)
+		return
+	}
+
+	middleware.Log(r.Context()).WithFields(logrus.Fields{
+		"job_id":   jobID,
+		"user_id":  userID,
+		"deadline": deadline.Format(time.RFC3339),
+	}).Info("User data deletion submitted")
+
+	response := UserDataDeletionResponse{
+		Success:   true,
+		Message:   "User data deletion submitted; poll /job-status for the completion report",
+		JobID:     jobID,
+		RequestID: requestID,
+		Status:    "submitted",
+		Deadline:  deadline.UTC(),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(response)
+}
+
+// deleteUserDataTask returns the job task deleting or anonymizing a user's data.
+// Each category is reported separately; the job fails if any category could not be finished by the deadline.
+func (h *Handler) deleteUserDataTask(userID string, deadline time.Time) JobTask {
+	return func(ctx context.Context) ([]types.TargetResult, error) {
+		ctx, cancel := context.WithDeadline(ctx, deadline)
+		defer cancel()
+
+		steps := []struct {
+			target string
+			run    func(context.Context) (int, error)
+		}{
+			{UserDataMuteRules, func(ctx context.Context) (int, error) {
+				return h.MuteRules.DeleteUser(ctx, userID)
+			}},
+			{UserDataSuppressedItems, func(ctx context.Context) (int, error) {
+				return anonymizeSuppressedItems(ctx, h.DatastoreClient, userID)
+			}},
+			{UserDataIngestUsage, func(context.Context) (int, error) {
+				if h.IngestThrottle.Forget(userID) {
+					return 1, nil
+				}
+				return 0, nil
+			}},
+		}
+
+		results := make([]types.TargetResult, 0, len(steps))
+		var failed []string
+		for _, step := range steps {
+			// Once the deadline has passed, remaining categories are reported unfinished rather than attempted
+			count, err := 0, ctx.Err()
+			if err == nil {
+				count, err = step.run(ctx)
+			}
+			result := types.TargetResult{Target: step.target, Success: err == nil, Count: count}
+			if err != nil {
+				if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+					err = fmt.Errorf("deadline passed before the deletion finished: %v", err)
+				}
+				result.Error = err.Error()
+				failed = append(failed, step.target)
+			}
+			results = append(results, result)
+		}
+
+		if len(failed) > 0 {
+			return results, fmt.Errorf("user data deletion incomplete for %v", failed)
+		}
+		return results, nil
+	}
+}
+
+// anonymizeSuppressedItems removes a user from the suppression lists of stored items, returning how many items changed
+func anonymizeSuppressedItems(ctx context.Context, client DatastoreClientInterface, userID string) (int, error) {
+	if client == nil {
+		return 0, nil
+	}
+
+	var items []*utils.FeedItem
+	keys, err := client.GetAll(ctx, datastore.NewQuery("FeedItem").Filter("suppressed_for =", userID), &items)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find suppressed items: %v", err)
+	}
+	for _, item := range items {
+		remaining := item.SuppressedFor[:0]
+		for _, suppressed := range item.SuppressedFor {
+			if suppressed != userID {
+				remaining = append(remaining, suppressed)
+			}
+		}
+		item.SuppressedFor = remaining
+	}
+
+	for start := 0; start < len(keys); start += bulkWriteBatch {
+		end := min(start+bulkWriteBatch, len(keys))
+		if _, err := client.PutMulti(ctx, keys[start:end], items[start:end]); err != nil {
+			return start, fmt.Errorf("failed to update suppressed items: %v", err)
+		}
+	}
+	return len(keys), nil
+}
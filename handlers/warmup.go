@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Nexora-Open-Source/rss-feed-backend/middleware"
+	"github.com/sirupsen/logrus"
+)
+
+// WarmUpReport summarizes a startup warm-up
+type WarmUpReport struct {
+	// Feeds is how many feed sources were listed, and WarmedFeeds how many had their items preloaded
+	Feeds       int `json:"feeds"`
+	WarmedFeeds int `json:"warmed_feeds"`
+	// Pages is how many item pages were cached, and FailedPages how many could not be read
+	Pages       int   `json:"pages"`
+	FailedPages int   `json:"failed_pages"`
+	DurationMs  int64 `json:"duration_ms"`
+	// TimedOut reports whether the warm-up ran out of time before preloading everything
+	TimedOut bool `json:"timed_out"`
+}
+
+/*
+WarmUp preloads hot data after a deploy so the first requests do not all take the
+cold path.
+
+The feed list is read from storage, then the default /items page and, for the
+feeds with the most items ingested, the /items page filtered by the feed's site
+are read from Datastore and cached. Predefined feeds come first among feeds
+with equal counts, so a fresh deployment warms the default feed list.
+The server reports not ready until the warm-up finishes, so load balancers only
+send traffic to warm instances. The warm-up is bounded by a timeout; failures
+leave the cold path to fill the cache as usual.
+*/
+type WarmUp struct {
+	mu       sync.Mutex
+	maxFeeds int
+	done     bool
+	report   WarmUpReport
+}
+
+// NewWarmUp creates a warm-up preloading the items of up to maxFeeds feeds
+func NewWarmUp(maxFeeds int) *WarmUp {
+	return &WarmUp{maxFeeds: maxFeeds}
+}
+
+// Done reports whether the warm-up has finished; without a warm-up there is nothing to wait for
+func (wu *WarmUp) Done() bool {
+	if wu == nil {
+		return true
+	}
+	wu.mu.Lock()
+	defer wu.mu.Unlock()
+
+	return wu.done
+}
+
+// Report returns the summary of the finished warm-up
+func (wu *WarmUp) Report() WarmUpReport {
+	if wu == nil {
+		return WarmUpReport{}
+	}
+	wu.mu.Lock()
+	defer wu.mu.Unlock()
+
+	return wu.report
+}
+
+// RunWarmUp preloads hot data within ctx and marks the warm-up done, even when it fails or times out
+func (h *Handler) RunWarmUp(ctx context.Context) WarmUpReport {
+	if h.WarmUp == nil {
+		return WarmUpReport{}
+	}
+	start := time.Now()
+	var report WarmUpReport
+	defer func() {
+		report.DurationMs = time.Since(start).Milliseconds()
+		report.TimedOut = ctx.Err() != nil
+
+		h.WarmUp.mu.Lock()
+		h.WarmUp.done = true
+		h.WarmUp.report = report
+		h.WarmUp.mu.Unlock()
+
+		h.Logger.WithFields(logrus.Fields{
+			"feeds":        report.Feeds,
+			"warmed_feeds": report.WarmedFeeds,
+			"pages":        report.Pages,
+			"failed_pages": report.FailedPages,
+			"duration_ms":  report.DurationMs,
+			"timed_out":    report.TimedOut,
+		}).Info("Startup warm-up finished")
+	}()
+
+	// Listing feeds reads subscribed sources from storage and remembers them for the stale fallback
+	feeds, _, err := h.loadFeedSources(ctx)
+	if err != nil {
+		h.Logger.WithError(err).Warn("Warm-up failed to load feed sources")
+	}
+	report.Feeds = len(feeds)
+
+	// Pages are cached under the same keys as /items requests without a limit
+	defaultLimit, _ := h.itemsPageSizes()
+	params := ItemsQueryParams{PaginationParams: PaginationParams{Limit: defaultLimit}}
+	if h.warmItemsPage(ctx, params) {
+		report.Pages++
+	} else {
+		report.FailedPages++
+	}
+
+	for _, source := range warmUpSources(feeds, h.WarmUp.maxFeeds) {
+		if ctx.Err() != nil {
+			break
+		}
+		params.Source = source
+		if h.warmItemsPage(ctx, params) {
+			report.Pages++
+			report.WarmedFeeds++
+		} else {
+			report.FailedPages++
+		}
+	}
+	return report
+}
+
+// warmItemsPage reads an items page from Datastore into the cache, reporting whether it succeeded
+func (h *Handler) warmItemsPage(ctx context.Context, params ItemsQueryParams) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	cacheKey := itemsCacheKey(params)
+	result, err := FetchFeedItemsWithFilter(ctx, h.DatastoreClient, params)
+	if err != nil {
+		middleware.Log(ctx).WithFields(logrus.Fields{
+			"source": params.Source,
+			"error":  err.Error(),
+		}).Warn("Warm-up failed to load items page")
+		return false
+	}
+	h.StaleData.RememberItems(cacheKey, result)
+	if err := h.CacheManager.SetStoredItems(cacheKey, result.Items); err != nil {
+		middleware.Log(ctx).WithFields(logrus.Fields{
+			"source": params.Source,
+			"error":  err.Error(),
+		}).Warn("Warm-up failed to cache items page")
+		return false
+	}
+	return true
+}
+
+// warmUpSources returns the site filters of the feeds with the most items ingested, up to maxFeeds distinct sites
+func warmUpSources(feeds []FeedSource, maxFeeds int) []string {
+	ranked := append([]FeedSource(nil), feeds...)
+	// loadFeedSources lists predefined feeds first, so a stable sort keeps them ahead of equally busy subscriptions
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].ItemsIngested > ranked[j].ItemsIngested
+	})
+
+	seen := make(map[string]bool)
+	var sources []string
+	for _, feed := range ranked {
+		if len(sources) >= maxFeeds {
+			break
+		}
+		if feed.Disabled {
+			continue
+		}
+		parsed, err := url.Parse(feed.URL)
+		if err != nil || parsed.Host == "" {
+			continue
+		}
+		site := parsed.Scheme + "://" + parsed.Host
+		if !seen[site] {
+			seen[site] = true
+			sources = append(sources, site)
+		}
+	}
+	return sources
+}
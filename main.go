@@ -21,6 +21,7 @@ Endpoints:
 package main
 
 import (
+	"context"
 	"crypto/sha256"
 	"fmt"
 	"log"
@@ -28,13 +29,18 @@ import (
 	"strings"
 	"sync"
 	"time"
+	// Embed the timezone database so tz filters work on images without one
+	_ "time/tzdata"
 
+	"github.com/Nexora-Open-Source/rss-feed-backend/admin"
 	"github.com/Nexora-Open-Source/rss-feed-backend/config"
 	_ "github.com/Nexora-Open-Source/rss-feed-backend/docs"
+	"github.com/Nexora-Open-Source/rss-feed-backend/handlers"
 	"github.com/Nexora-Open-Source/rss-feed-backend/middleware"
 	"github.com/Nexora-Open-Source/rss-feed-backend/monitoring"
 	"github.com/Nexora-Open-Source/rss-feed-backend/utils"
 	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
 	httpSwagger "github.com/swaggo/http-swagger/v2"
 	"golang.org/x/time/rate"
 )
@@ -111,14 +117,174 @@ func main() {
 
 	// Initialize structured logger
 	middleware.InitLogger()
+	if err := middleware.ConfigureLogger(appConfig.Config.LogOptions()); err != nil {
+		log.Fatalf("Failed to configure application log: %v", err)
+	}
 	middleware.Logger.Info("Starting RSS Feed Backend Server")
 
+	// Tune the garbage collector before ingest starts
+	runtimeOptions := appConfig.Config.RuntimeOptions()
+	if err := monitoring.ConfigureRuntime(runtimeOptions); err != nil {
+		log.Fatalf("Failed to configure garbage collector: %v", err)
+	}
+	middleware.Logger.WithFields(logrus.Fields{
+		"gc_percent":   runtimeOptions.GCPercent,
+		"memory_limit": runtimeOptions.MemoryLimit,
+		"heap_ballast": runtimeOptions.BallastBytes,
+	}).Info("Garbage collector configured")
+	if utils.FaultInjectionEnabled() {
+		faults := appConfig.Config.FaultInjectionConfig
+		middleware.Logger.WithFields(logrus.Fields{
+			"error_rates":   faults.ErrorRates,
+			"latency_rates": faults.LatencyRates,
+			"latency":       faults.Latency.String(),
+		}).Warn("Fault injection enabled; dependency calls will be delayed and failed on purpose")
+	}
+
+	// The access log is written separately from the application log so pipelines can route them differently
+	var accessLogger *middleware.AccessLogger
+	if appConfig.Config.AccessLogOutput != middleware.LogOutputOff {
+		accessLogOutput, err := middleware.OpenLogOutput(appConfig.Config.AccessLogOutput)
+		if err != nil {
+			log.Fatalf("Failed to open access log: %v", err)
+		}
+		accessLogger, err = middleware.NewAccessLogger(accessLogOutput, middleware.AccessLogFormat(appConfig.Config.AccessLogFormat))
+		if err != nil {
+			log.Fatalf("Failed to create access log: %v", err)
+		}
+	}
+
 	// Initialize handler with dependencies using DI container
 	handler, err := appConfig.Services.Container.GetHandler()
 	if err != nil {
 		log.Fatalf("Failed to initialize handler: %v", err)
 	}
 
+	// Load confirmed feed URL aliases so moved feeds resolve from the first request
+	if err := handler.Redirects.LoadAliases(context.Background()); err != nil {
+		middleware.Logger.WithError(err).Warn("Failed to load feed URL aliases")
+	}
+
+	// Seed duplicate detection with recently stored items so syndicated copies join existing clusters;
+	// read-only replicas never ingest, so they skip it
+	if !appConfig.Config.ReadOnly {
+		if err := handler.Clusters.LoadRecent(context.Background()); err != nil {
+			middleware.Logger.WithError(err).Warn("Failed to load recent items for duplicate detection")
+		}
+	}
+
+	// Register feed error webhooks so failing feeds notify their owners from the first ingest
+	if err := handler.LoadFeedErrorWebhooks(context.Background()); err != nil {
+		middleware.Logger.WithError(err).Warn("Failed to load feed error webhooks")
+	}
+
+	// Load user mute rules so read endpoints filter from the first request
+	if err := handler.MuteRules.LoadRules(context.Background()); err != nil {
+		middleware.Logger.WithError(err).Warn("Failed to load mute rules")
+	}
+
+	// Restore learned poll intervals so feeds keep their schedule across restarts
+	if err := handler.Polls.LoadState(context.Background()); err != nil {
+		middleware.Logger.WithError(err).Warn("Failed to load feed poll state")
+	}
+
+	// Restore ingest counters with their applied batch tokens so replayed batches are not counted twice
+	if err := handler.IngestCounters.LoadCounts(context.Background()); err != nil {
+		middleware.Logger.WithError(err).Warn("Failed to load ingest counters")
+	}
+
+	// Restore jobs left in the overflow queue; they are queued again as the job queue has room.
+	// Read-only replicas keep no overflow queue, so the writer alone runs them
+	if !appConfig.Config.ReadOnly {
+		if err := handler.Overflow.LoadJobs(context.Background()); err != nil {
+			middleware.Logger.WithError(err).Warn("Failed to load overflow jobs")
+		}
+	}
+
+	// Prune item revisions past their retention window every hour; replicas leave writes to the writer
+	if !appConfig.Config.ReadOnly {
+		go func() {
+			ticker := time.NewTicker(time.Hour)
+			defer ticker.Stop()
+			for range ticker.C {
+				cutoff := time.Now().Add(-appConfig.Config.PerformanceConfig.ItemRevisionRetention)
+				if _, err := handlers.PruneItemRevisions(context.Background(), handler.DatastoreClient, cutoff); err != nil {
+					middleware.Logger.WithError(err).Warn("Failed to prune item revisions")
+				}
+			}
+		}()
+	}
+
+	// Restore feed liveness state and optionally alert when feeds go stale
+	if err := handler.FeedHealth.LoadHealth(context.Background()); err != nil {
+		middleware.Logger.WithError(err).Warn("Failed to load feed health")
+	}
+	if appConfig.Config.PerformanceConfig.DeadFeedAlerts {
+		handler.FeedHealth.SetStaleNotifier(func(health handlers.FeedHealth) {
+			alertManager.TriggerManualAlert(
+				monitoring.AlertTypeFeedStale,
+				monitoring.SeverityLow,
+				"Feed possibly dead",
+				fmt.Sprintf("Feed %s is stale (%s); last new item at %s", health.FeedURL, health.Reason, health.LastNewItemAt.Format(time.RFC3339)),
+				map[string]string{"feed_url": health.FeedURL, "reason": health.Reason},
+			)
+		})
+	}
+
+	// Mutations reach the writer alone, so replicas reload what it changes to keep filters and counts current
+	if appConfig.Config.ReadOnly {
+		go func() {
+			ticker := time.NewTicker(appConfig.Config.ReplicaRefreshInterval)
+			defer ticker.Stop()
+			for range ticker.C {
+				handler.RefreshReplicaState(context.Background())
+			}
+		}()
+	}
+
+	// Alert when the watchdog keeps stopping one feed's jobs
+	handler.Watchdog.SetNotifier(func(feedURL string, strikes int, err *handlers.JobLimitError) {
+		alertManager.TriggerManualAlert(
+			monitoring.AlertTypeJobOverBudget,
+			monitoring.SeverityMedium,
+			"Feed jobs keep exceeding their budget",
+			fmt.Sprintf("The last %d jobs for feed %s were stopped: %v", strikes, feedURL, err),
+			map[string]string{"feed_url": feedURL, "limit": err.Limit},
+		)
+	})
+
+	// Ingest the server's own canary feed on a schedule, alerting when a run fails
+	handler.Canary.SetFailureNotifier(func(result handlers.CanaryResult) {
+		alertManager.TriggerManualAlert(
+			monitoring.AlertTypeCanaryFailure,
+			monitoring.SeverityHigh,
+			"Ingest canary failed",
+			fmt.Sprintf("Canary failed at the %s stage after %dms: %s", result.Stage, result.LatencyMs, result.Error),
+			map[string]string{"stage": result.Stage},
+		)
+	})
+	if handler.Canary != nil {
+		go func() {
+			ticker := time.NewTicker(appConfig.Config.PerformanceConfig.CanaryInterval)
+			defer ticker.Stop()
+			for range ticker.C {
+				handler.Canary.Run(context.Background())
+			}
+		}()
+	}
+
+	// Active alerts are listed in the admin overview
+	handler.Alerts = alertManager
+
+	// Diagnostics report the latest 50 errors from request and background logs alike, and the configuration without secrets
+	recentErrors := middleware.NewRecentErrors(50)
+	middleware.Logger.AddHook(recentErrors)
+	if handler.Logger != middleware.Logger {
+		handler.Logger.AddHook(recentErrors)
+	}
+	handler.RecentErrors = recentErrors
+	handler.DiagnosticsConfig = appConfig.Config.Redacted()
+
 	// Initialize rate limiter with configuration
 	limiter := NewRateLimiter(rate.Limit(appConfig.Config.RateLimitRequestsPerMinute/60.0), appConfig.Config.RateLimitBurst)
 
@@ -128,9 +294,16 @@ func main() {
 		defer ticker.Stop()
 		for range ticker.C {
 			limiter.Cleanup()
+			handler.ClientBans.Cleanup()
 		}
 	}()
 
+	// Each route gets its own request budget; the configuration was validated at startup
+	routeTimeouts, err := appConfig.Config.RouteTimeoutBudgets()
+	if err != nil {
+		log.Fatalf("Failed to parse route timeouts: %v", err)
+	}
+
 	// Initialize the router
 	router := mux.NewRouter()
 
@@ -141,28 +314,101 @@ func main() {
 	router.HandleFunc("/health", handler.HandleHealthCheck).Methods("GET")
 	router.HandleFunc("/health/live", handler.HandleLivenessCheck).Methods("GET")
 	router.HandleFunc("/health/ready", handler.HandleReadinessCheck).Methods("GET")
+	router.HandleFunc(handlers.CanaryPath, handler.HandleCanaryFeed).Methods("GET")
 
 	// Setup Swagger documentation
 	router.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
 
+	// Route middleware: correlation fields are attached first so every log entry for the request carries them,
+	// metrics wrap rate limiting so rejected requests are still measured, and banned clients are refused before either;
+	// route timeouts bound the handler alone and are measured as 504s
+	routeChain := middleware.NewChain().
+		Use(middleware.StageLogging, "correlation", middleware.CorrelationMiddleware(handlers.TenantFromRequest)).
+		Use(middleware.StageAccessControl, "client_bans", handler.ClientBans.Middleware).
+		Use(middleware.StageMetrics, "metrics", middleware.FromFunc(MonitoringMiddleware)).
+		Use(middleware.StageMetrics, "usage_analytics", handler.UsageAnalytics.Middleware).
+		Use(middleware.StageRateLimit, "rate_limit", middleware.FromFunc(func(next http.HandlerFunc) http.HandlerFunc {
+			return RateLimitMiddleware(limiter, next)
+		})).
+		Use(middleware.StageTimeout, "timeout", middleware.RouteTimeoutMiddleware(routeTimeouts, appConfig.Config.RouteTimeoutDefault))
+
+	// Admin routes additionally require the admin token; failed attempts count toward the auth failure ban rule
+	adminChain := routeChain.Clone().
+		Use(middleware.StageAuth, "admin_auth", middleware.AdminAuthMiddleware(appConfig.Config.SecurityConfig.AdminToken))
+
 	// Setup API routes with rate limiting and monitoring middleware
-	router.HandleFunc("/fetch-store", MonitoringMiddleware(RateLimitMiddleware(limiter, handler.HandleFetchAndStore))).Methods("POST")
-	router.HandleFunc("/feeds", MonitoringMiddleware(RateLimitMiddleware(limiter, handler.HandleGetFeeds))).Methods("GET")
-	router.HandleFunc("/items", MonitoringMiddleware(RateLimitMiddleware(limiter, handler.HandleGetFeedItems))).Methods("GET")
-	router.HandleFunc("/items/legacy", MonitoringMiddleware(RateLimitMiddleware(limiter, handler.HandleGetFeedItemsLegacy))).Methods("GET")
-	router.HandleFunc("/job-status", MonitoringMiddleware(RateLimitMiddleware(limiter, handler.HandleGetJobStatus))).Methods("GET")
+	registerAPIRoutes(router, handler, routeChain, adminChain)
 
-	// Apply logging middleware
-	withLogging := middleware.LoggingMiddleware(router)
+	// Synthetic data for frontend development; opt-in, and refused at startup outside development environments
+	if appConfig.Config.SimulatedFeedEnabled {
+		router.HandleFunc("/dev/simulated-feed", routeChain.ThenFunc(handler.HandleSimulatedFeed)).Methods("GET")
+		middleware.Logger.Info("Simulated feed enabled at /dev/simulated-feed")
+	}
+
+	// Optional admin UI; it polls the overview every few seconds, so the overview is not rate limited.
+	// The UI's static files are public, but the overview requires the admin token like every other admin route
+	if appConfig.Config.AdminUIEnabled {
+		router.HandleFunc("/admin/overview", adminChain.ThenFunc(handler.HandleGetAdminOverview, middleware.Skip("rate_limit"))).Methods("GET")
+		admin.SetupUI(router)
+		middleware.Logger.Info("Admin UI enabled at " + admin.UIPath)
+	}
+
+	// Server-wide middleware, applied in canonical stage order
+	serverChain := middleware.NewChain().
+		Use(middleware.StageCORS, "cors", func(next http.Handler) http.Handler {
+			return CORSMiddleware(next, appConfig.Config)
+		}).
+		Use(middleware.StageLogging, "access_log", middleware.AccessLogMiddleware(accessLogger)).
+		Use(middleware.StageLogging, "logging", middleware.LoggingMiddleware)
+
+	// Replicas serve reads only; mutations belong to the single writer
+	if appConfig.Config.ReadOnly {
+		middleware.Logger.Info("Read-only mode enabled: mutations will be rejected with 503")
+		serverChain.Use(middleware.StageAccessControl, "read_only", ReadOnlyMiddleware)
+	}
 
-	// Attach the CORS middleware with enhanced configuration
-	withCORS := CORSMiddleware(withLogging, appConfig.Config)
+	// Preload hot data in the background; readiness is withheld until it finishes or times out
+	if appConfig.Config.PerformanceConfig.WarmUpEnabled {
+		handler.WarmUp = handlers.NewWarmUp(appConfig.Config.PerformanceConfig.WarmUpMaxFeeds)
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), appConfig.Config.PerformanceConfig.WarmUpTimeout)
+			defer cancel()
+			handler.RunWarmUp(ctx)
+		}()
+	}
 
 	// Start the server
 	fmt.Println("Server is running on https://localhost:8080")
 	fmt.Println("Metrics available at http://localhost:8080/metrics")
 	middleware.Logger.Info("Server starting on :8080")
-	log.Fatal(http.ListenAndServe(":8080", withCORS))
+	log.Fatal(http.ListenAndServe(":8080", serverChain.Then(router)))
+}
+
+// registerAPIRoutes registers the API routes; admin routes and bulk changes go through adminChain
+func registerAPIRoutes(router *mux.Router, handler *handlers.Handler, routeChain, adminChain *middleware.Chain) {
+	router.HandleFunc("/fetch-store", routeChain.ThenFunc(handler.HandleFetchAndStore)).Methods("POST")
+	router.HandleFunc("/feeds", routeChain.ThenFunc(handler.HandleGetFeeds)).Methods("GET")
+	router.HandleFunc("/feeds", routeChain.ThenFunc(handler.HandleAddFeed)).Methods("POST")
+	router.HandleFunc("/feeds/bulk", adminChain.ThenFunc(handler.HandleBulkFeeds)).Methods("POST")
+	router.HandleFunc("/folders", routeChain.ThenFunc(handler.HandleGetFolders)).Methods("GET")
+	router.HandleFunc("/items", routeChain.ThenFunc(handler.HandleGetFeedItems)).Methods("GET")
+	router.HandleFunc("/clusters", routeChain.ThenFunc(handler.HandleGetStoryClusters)).Methods("GET")
+	router.HandleFunc("/items/top", routeChain.ThenFunc(handler.HandleGetTopItems)).Methods("GET")
+	router.HandleFunc("/items/count", routeChain.ThenFunc(handler.HandleGetItemCount)).Methods("GET")
+	router.HandleFunc("/items/legacy", routeChain.ThenFunc(handler.HandleGetFeedItemsLegacy)).Methods("GET")
+	router.HandleFunc("/snapshots/{hash}", routeChain.ThenFunc(handler.HandleGetSnapshot)).Methods("GET")
+	router.HandleFunc("/mute-rules", routeChain.ThenFunc(handler.HandleListMuteRules)).Methods("GET")
+	router.HandleFunc("/mute-rules", routeChain.ThenFunc(handler.HandleCreateMuteRule)).Methods("POST")
+	router.HandleFunc("/mute-rules/{id}", routeChain.ThenFunc(handler.HandleUpdateMuteRule)).Methods("PUT")
+	router.HandleFunc("/mute-rules/{id}", routeChain.ThenFunc(handler.HandleDeleteMuteRule)).Methods("DELETE")
+	router.HandleFunc("/users/{id}/data", adminChain.ThenFunc(handler.HandleDeleteUserData)).Methods("DELETE")
+	router.HandleFunc("/usage", routeChain.ThenFunc(handler.HandleGetUsage)).Methods("GET")
+	router.HandleFunc("/admin/diagnostics", adminChain.ThenFunc(handler.HandleGetDiagnostics)).Methods("GET")
+	router.HandleFunc("/admin/usage-analytics", adminChain.ThenFunc(handler.HandleGetUsageAnalytics)).Methods("GET")
+	router.HandleFunc("/admin/bans", adminChain.ThenFunc(handler.HandleListClientBans)).Methods("GET")
+	router.HandleFunc("/admin/bans/{id}", adminChain.ThenFunc(handler.HandleLiftClientBan)).Methods("DELETE")
+	router.HandleFunc("/job-status", routeChain.ThenFunc(handler.HandleGetJobStatus)).Methods("GET")
+	router.HandleFunc("/jobs/{id}/result", routeChain.ThenFunc(handler.HandleGetJobResult)).Methods("GET")
 }
 
 // MonitoringMiddleware adds metrics and tracing to HTTP handlers
@@ -286,6 +532,23 @@ func RateLimitMiddleware(limiter *RateLimiter, next http.HandlerFunc) http.Handl
 	}
 }
 
+// ReadOnlyMiddleware rejects requests that could modify state with 503, for read-only replicas
+func ReadOnlyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		requestID := r.Header.Get("X-Request-ID")
+		if requestID == "" {
+			requestID = utils.GenerateRequestID()
+		}
+		middleware.RespondServiceUnavailable(w, fmt.Errorf("this replica is read-only; send %s %s to the writer", r.Method, r.URL.Path), requestID)
+	})
+}
+
 // getAllowedOrigins returns the appropriate allowed origins based on environment
 func getAllowedOrigins(corsConfig config.CORSConfig) []string {
 	switch strings.ToLower(corsConfig.Environment) {
@@ -361,7 +624,7 @@ func CORSMiddleware(next http.Handler, appConfig *config.Config) http.Handler {
 		if len(corsConfig.AllowedHeaders) > 0 {
 			w.Header().Set("Access-Control-Allow-Headers", strings.Join(corsConfig.AllowedHeaders, ", "))
 		} else {
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With, X-Request-ID")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With, X-Request-ID, X-User-ID, X-Tenant-ID")
 		}
 
 		// Set exposed headers
@@ -0,0 +1,217 @@
+/*
+Package middleware provides an HTTP access log kept separate from the application log.
+
+The access log records one line per request in Apache combined or JSON format and
+writes to its own destination, so log pipelines can route request traffic and
+application events differently.
+
+Usage:
+
+	out, _ := OpenLogOutput("stdout")
+	accessLogger, _ := NewAccessLogger(out, AccessLogJSON)
+	handler := AccessLogMiddleware(accessLogger)(router)
+*/
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// AccessLogFormat selects how access log lines are written
+type AccessLogFormat string
+
+const (
+	// AccessLogCombined writes the Apache combined log format
+	AccessLogCombined AccessLogFormat = "combined"
+	// AccessLogJSON writes one JSON object per line
+	AccessLogJSON AccessLogFormat = "json"
+)
+
+// LogOutputOff disables a log when given as its output
+const LogOutputOff = "off"
+
+// combinedTimeFormat is the timestamp layout of the Apache combined log format
+const combinedTimeFormat = "02/Jan/2006:15:04:05 -0700"
+
+// AccessLogEntry is one request in the access log
+type AccessLogEntry struct {
+	Time       time.Time `json:"time"`
+	RemoteAddr string    `json:"remote_addr"`
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	Query      string    `json:"query,omitempty"`
+	Protocol   string    `json:"protocol"`
+	Status     int       `json:"status"`
+	Bytes      int64     `json:"bytes"`
+	DurationMs int64     `json:"duration_ms"`
+	Referer    string    `json:"referer,omitempty"`
+	UserAgent  string    `json:"user_agent,omitempty"`
+	RequestID  string    `json:"request_id,omitempty"`
+}
+
+// AccessLogger writes access log entries to a single destination
+type AccessLogger struct {
+	mu     sync.Mutex
+	out    io.Writer
+	format AccessLogFormat
+}
+
+// NewAccessLogger creates an access logger writing the given format to out
+func NewAccessLogger(out io.Writer, format AccessLogFormat) (*AccessLogger, error) {
+	if err := ValidateAccessLogFormat(string(format)); err != nil {
+		return nil, err
+	}
+	return &AccessLogger{out: out, format: format}, nil
+}
+
+// ValidateAccessLogFormat checks that format names a supported access log format
+func ValidateAccessLogFormat(format string) error {
+	switch AccessLogFormat(format) {
+	case AccessLogCombined, AccessLogJSON:
+		return nil
+	}
+	return fmt.Errorf("unknown access log format %q: must be \"combined\" or \"json\"", format)
+}
+
+// Log writes one entry; write errors are reported to the application log
+func (l *AccessLogger) Log(entry AccessLogEntry) {
+	var line []byte
+	if l.format == AccessLogJSON {
+		encoded, err := json.Marshal(entry)
+		if err != nil {
+			return
+		}
+		line = append(encoded, '\n')
+	} else {
+		line = []byte(formatCombined(entry))
+	}
+
+	l.mu.Lock()
+	_, err := l.out.Write(line)
+	l.mu.Unlock()
+
+	if err != nil && Logger != nil {
+		Logger.WithError(err).Warn("Failed to write access log")
+	}
+}
+
+// formatCombined renders an entry in the Apache combined log format
+func formatCombined(entry AccessLogEntry) string {
+	request := entry.Method + " " + entry.Path
+	if entry.Query != "" {
+		request += "?" + entry.Query
+	}
+	request += " " + entry.Protocol
+
+	size := "-"
+	if entry.Bytes > 0 {
+		size = strconv.FormatInt(entry.Bytes, 10)
+	}
+
+	return fmt.Sprintf("%s - - [%s] %s %d %s %s %s\n",
+		orDash(entry.RemoteAddr),
+		entry.Time.Format(combinedTimeFormat),
+		strconv.Quote(request),
+		entry.Status,
+		size,
+		strconv.Quote(orDash(entry.Referer)),
+		strconv.Quote(orDash(entry.UserAgent)),
+	)
+}
+
+// orDash returns "-" for empty log fields, as the combined format expects
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// accessLogWriter records the status and size of a response
+type accessLogWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (w *accessLogWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *accessLogWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// AccessLogMiddleware writes one access log line per request; a nil logger disables it
+func AccessLogMiddleware(logger *AccessLogger) Middleware {
+	return func(next http.Handler) http.Handler {
+		if logger == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rw := &accessLogWriter{ResponseWriter: w}
+
+			next.ServeHTTP(rw, r)
+
+			if rw.status == 0 {
+				rw.status = http.StatusOK
+			}
+			// Handlers set X-Request-ID on the response when they generate one
+			requestID := r.Header.Get("X-Request-ID")
+			if requestID == "" {
+				requestID = rw.Header().Get("X-Request-ID")
+			}
+			remoteAddr := r.RemoteAddr
+			if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+				remoteAddr = host
+			}
+
+			logger.Log(AccessLogEntry{
+				Time:       start,
+				RemoteAddr: remoteAddr,
+				Method:     r.Method,
+				Path:       r.URL.Path,
+				Query:      r.URL.RawQuery,
+				Protocol:   r.Proto,
+				Status:     rw.status,
+				Bytes:      rw.bytes,
+				DurationMs: time.Since(start).Milliseconds(),
+				Referer:    r.Referer(),
+				UserAgent:  r.UserAgent(),
+				RequestID:  requestID,
+			})
+		})
+	}
+}
+
+// OpenLogOutput opens a log destination: "stdout", "stderr", or a file path appended to
+func OpenLogOutput(destination string) (io.Writer, error) {
+	switch destination {
+	case "", "stdout":
+		return os.Stdout, nil
+	case "stderr":
+		return os.Stderr, nil
+	}
+	file, err := os.OpenFile(destination, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file %s: %v", destination, err)
+	}
+	return file, nil
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccessLogMiddleware(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-ID", "req-123")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("created"))
+	})
+
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest("POST", "/feeds?url=x", nil)
+		req.RemoteAddr = "203.0.113.7:51234"
+		req.Header.Set("User-Agent", "test-agent")
+		return req
+	}
+
+	t.Run("combined format", func(t *testing.T) {
+		var out bytes.Buffer
+		logger, err := NewAccessLogger(&out, AccessLogCombined)
+		assert.NoError(t, err)
+
+		AccessLogMiddleware(logger)(handler).ServeHTTP(httptest.NewRecorder(), newRequest())
+
+		line := out.String()
+		assert.True(t, strings.HasPrefix(line, "203.0.113.7 - - ["), line)
+		assert.Contains(t, line, `] "POST /feeds?url=x HTTP/1.1" 201 7 "-" "test-agent"`)
+		assert.Equal(t, 1, strings.Count(line, "\n"))
+	})
+
+	t.Run("json format", func(t *testing.T) {
+		var out bytes.Buffer
+		logger, err := NewAccessLogger(&out, AccessLogJSON)
+		assert.NoError(t, err)
+
+		AccessLogMiddleware(logger)(handler).ServeHTTP(httptest.NewRecorder(), newRequest())
+
+		var entry AccessLogEntry
+		assert.NoError(t, json.Unmarshal(out.Bytes(), &entry))
+		assert.Equal(t, "203.0.113.7", entry.RemoteAddr)
+		assert.Equal(t, "/feeds", entry.Path)
+		assert.Equal(t, 201, entry.Status)
+		assert.Equal(t, int64(7), entry.Bytes)
+		assert.Equal(t, "req-123", entry.RequestID)
+	})
+
+	t.Run("disabled and invalid", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		AccessLogMiddleware(nil)(handler).ServeHTTP(recorder, newRequest())
+		assert.Equal(t, http.StatusCreated, recorder.Code)
+
+		_, err := NewAccessLogger(&bytes.Buffer{}, "common")
+		assert.Error(t, err)
+	})
+}
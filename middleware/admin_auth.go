@@ -0,0 +1,48 @@
+/*
+Package middleware provides bearer token authentication for admin endpoints.
+
+Admin endpoints expose operational state and can change it, such as lifting
+client bans, so they are only served to callers presenting the configured admin
+token. Without a token the admin API is disabled rather than left open.
+*/
+package middleware
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// AdminAuthMiddleware only serves requests carrying token as a bearer token; an empty token refuses every request
+func AdminAuthMiddleware(token string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestID := r.Header.Get("X-Request-ID")
+			if requestID == "" {
+				requestID = generateRequestID()
+			}
+
+			if token == "" {
+				RespondForbidden(w, fmt.Errorf("admin API is disabled; set ADMIN_TOKEN to enable it"), requestID)
+				return
+			}
+
+			presented, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !found || subtle.ConstantTimeCompare([]byte(presented), []byte(token)) != 1 {
+				Logger.WithFields(logrus.Fields{
+					"request_id": requestID,
+					"path":       r.URL.Path,
+				}).Warn("Rejected admin request without a valid token")
+
+				w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+				RespondUnauthorized(w, fmt.Errorf("a valid admin token is required"), requestID)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
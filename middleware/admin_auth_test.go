@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdminAuthMiddleware(t *testing.T) {
+	Logger = logrus.New()
+	Logger.SetOutput(io.Discard)
+	defer InitLogger()
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	request := func(token, authorization string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/admin/bans", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		w := httptest.NewRecorder()
+		AdminAuthMiddleware(token)(ok).ServeHTTP(w, req)
+		return w
+	}
+
+	const token = "s3cr3t-admin-token-of-some-length"
+	assert.Equal(t, http.StatusOK, request(token, "Bearer "+token).Code)
+
+	w := request(token, "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, `Bearer realm="admin"`, w.Header().Get("WWW-Authenticate"))
+	assert.Equal(t, http.StatusUnauthorized, request(token, "Bearer wrong").Code)
+	assert.Equal(t, http.StatusUnauthorized, request(token, token).Code)
+
+	// Without a configured token the admin API is disabled
+	assert.Equal(t, http.StatusForbidden, request("", "Bearer ").Code)
+}
@@ -0,0 +1,172 @@
+/*
+Package middleware provides a chain builder that applies HTTP middleware in a canonical order.
+
+Middleware is registered with the stage it belongs to, and the chain always
+applies stages outermost first in the order below, whatever order Use was called
+in. Middleware within the same stage keeps its registration order. Routes can
+opt out of individual middleware by name.
+
+Usage:
+
+	chain := NewChain().
+		Use(StageRateLimit, "rate_limit", rateLimit).
+		Use(StageMetrics, "metrics", metrics)
+	router.HandleFunc("/items", chain.ThenFunc(handler.HandleGetFeedItems))
+	router.HandleFunc("/internal", chain.ThenFunc(handler.HandleInternal, Skip("rate_limit")))
+*/
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// Middleware wraps an HTTP handler
+type Middleware func(http.Handler) http.Handler
+
+// Stage orders middleware in a chain; lower stages wrap higher ones
+type Stage int
+
+// Canonical middleware stages, outermost first
+const (
+	// StageRecovery catches panics from everything inside it
+	StageRecovery Stage = iota
+	// StageCORS answers preflight requests before any other work
+	StageCORS
+	// StageLogging records every request that reaches the service, including rejected ones
+	StageLogging
+	// StageAccessControl rejects requests the instance does not serve, such as mutations on read-only replicas
+	StageAccessControl
+	// StageMetrics measures requests that are served
+	StageMetrics
+	// StageAuth identifies the caller
+	StageAuth
+	// StageRateLimit limits identified callers
+	StageRateLimit
+	// StageTimeout bounds how long the handler may run
+	StageTimeout
+	// StageCompression encodes the handler's response
+	StageCompression
+)
+
+// stageNames names stages in chain descriptions
+var stageNames = map[Stage]string{
+	StageRecovery:      "recovery",
+	StageCORS:          "cors",
+	StageLogging:       "logging",
+	StageAccessControl: "access_control",
+	StageMetrics:       "metrics",
+	StageAuth:          "auth",
+	StageRateLimit:     "rate_limit",
+	StageTimeout:       "timeout",
+	StageCompression:   "compression",
+}
+
+// String returns the stage name
+func (s Stage) String() string {
+	if name, exists := stageNames[s]; exists {
+		return name
+	}
+	return fmt.Sprintf("stage(%d)", int(s))
+}
+
+// chainEntry is a registered middleware
+type chainEntry struct {
+	stage      Stage
+	name       string
+	middleware Middleware
+}
+
+// Chain builds handlers wrapped in middleware applied in stage order
+type Chain struct {
+	entries []chainEntry
+}
+
+// NewChain creates an empty middleware chain
+func NewChain() *Chain {
+	return &Chain{}
+}
+
+// Use registers middleware at a stage under a unique name; it panics on a duplicate name
+func (c *Chain) Use(stage Stage, name string, middleware Middleware) *Chain {
+	for _, entry := range c.entries {
+		if entry.name == name {
+			panic(fmt.Sprintf("middleware %q registered twice", name))
+		}
+	}
+
+	c.entries = append(c.entries, chainEntry{stage: stage, name: name, middleware: middleware})
+	sort.SliceStable(c.entries, func(i, j int) bool {
+		return c.entries[i].stage < c.entries[j].stage
+	})
+	return c
+}
+
+// Clone returns a copy of the chain, so middleware added to the copy does not reach routes built from the original
+func (c *Chain) Clone() *Chain {
+	return &Chain{entries: append([]chainEntry(nil), c.entries...)}
+}
+
+// RouteOption adjusts the chain for a single route
+type RouteOption func(skipped map[string]bool)
+
+// Skip opts a route out of the named middleware
+func Skip(names ...string) RouteOption {
+	return func(skipped map[string]bool) {
+		for _, name := range names {
+			skipped[name] = true
+		}
+	}
+}
+
+// Then wraps h in the chain's middleware, outermost stage first; it panics when skipping unregistered middleware
+func (c *Chain) Then(h http.Handler, options ...RouteOption) http.Handler {
+	skipped := make(map[string]bool)
+	for _, option := range options {
+		option(skipped)
+	}
+	for name := range skipped {
+		if !c.has(name) {
+			panic(fmt.Sprintf("cannot skip unregistered middleware %q", name))
+		}
+	}
+
+	for i := len(c.entries) - 1; i >= 0; i-- {
+		if !skipped[c.entries[i].name] {
+			h = c.entries[i].middleware(h)
+		}
+	}
+	return h
+}
+
+// ThenFunc wraps a handler function in the chain's middleware
+func (c *Chain) ThenFunc(h http.HandlerFunc, options ...RouteOption) http.HandlerFunc {
+	return c.Then(h, options...).ServeHTTP
+}
+
+// Names returns the registered middleware names in the order requests pass through them
+func (c *Chain) Names() []string {
+	names := make([]string, 0, len(c.entries))
+	for _, entry := range c.entries {
+		names = append(names, entry.name)
+	}
+	return names
+}
+
+// has reports whether middleware is registered under name
+func (c *Chain) has(name string) bool {
+	for _, entry := range c.entries {
+		if entry.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// FromFunc adapts middleware written against http.HandlerFunc
+func FromFunc(middleware func(http.HandlerFunc) http.HandlerFunc) Middleware {
+	return func(next http.Handler) http.Handler {
+		return middleware(next.ServeHTTP)
+	}
+}
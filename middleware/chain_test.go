@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingMiddleware appends its name to calls when a request passes through it
+func recordingMiddleware(name string, calls *[]string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*calls = append(*calls, name)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func TestChainOrdersByStage(t *testing.T) {
+	var calls []string
+	chain := NewChain().
+		Use(StageRateLimit, "rate_limit", recordingMiddleware("rate_limit", &calls)).
+		Use(StageLogging, "logging", recordingMiddleware("logging", &calls)).
+		Use(StageMetrics, "metrics", recordingMiddleware("metrics", &calls)).
+		Use(StageCORS, "cors", recordingMiddleware("cors", &calls)).
+		Use(StageMetrics, "tracing", recordingMiddleware("tracing", &calls))
+
+	handler := chain.ThenFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, "handler")
+	})
+	handler(httptest.NewRecorder(), httptest.NewRequest("GET", "/items", nil))
+
+	// Stages apply outermost first regardless of registration order; a stage keeps registration order
+	expected := []string{"cors", "logging", "metrics", "tracing", "rate_limit"}
+	assert.Equal(t, expected, chain.Names())
+	assert.Equal(t, append(expected, "handler"), calls)
+}
+
+func TestChainSkip(t *testing.T) {
+	var calls []string
+	chain := NewChain().
+		Use(StageMetrics, "metrics", recordingMiddleware("metrics", &calls)).
+		Use(StageRateLimit, "rate_limit", recordingMiddleware("rate_limit", &calls))
+
+	handler := chain.Then(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, "handler")
+	}), Skip("rate_limit"))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/health", nil))
+	assert.Equal(t, []string{"metrics", "handler"}, calls)
+
+	// Skipping only affects the route it was given for
+	calls = nil
+	chain.ThenFunc(func(w http.ResponseWriter, r *http.Request) {})(httptest.NewRecorder(), httptest.NewRequest("GET", "/items", nil))
+	assert.Equal(t, []string{"metrics", "rate_limit"}, calls)
+}
+
+func TestChainClone(t *testing.T) {
+	noop := func(next http.Handler) http.Handler { return next }
+	chain := NewChain().
+		Use(StageLogging, "logging", noop).
+		Use(StageRateLimit, "rate_limit", noop)
+
+	// Middleware added to a clone takes its stage there and leaves the original alone
+	admin := chain.Clone().Use(StageAuth, "admin_auth", noop)
+	assert.Equal(t, []string{"logging", "admin_auth", "rate_limit"}, admin.Names())
+	assert.Equal(t, []string{"logging", "rate_limit"}, chain.Names())
+}
+
+func TestChainRejectsMisconfiguration(t *testing.T) {
+	noop := func(next http.Handler) http.Handler { return next }
+	chain := NewChain().Use(StageLogging, "logging", noop)
+
+	assert.Panics(t, func() { chain.Use(StageMetrics, "logging", noop) })
+	assert.Panics(t, func() { chain.Then(http.NotFoundHandler(), Skip("loging")) })
+	assert.Equal(t, "logging", StageLogging.String())
+}
@@ -0,0 +1,47 @@
+/*
+Package middleware resolves the address of the client behind a request.
+
+X-Forwarded-For is written by whoever sends the request, so only the hops
+appended by proxies the operator trusts are believed. The header is read from
+the right: while the address a hop came from is a trusted proxy, the address
+that proxy recorded replaces it, and the first untrusted address is the client.
+*/
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ClientIP returns the address of the client behind r, following X-Forwarded-For only through trustedProxies
+func ClientIP(r *http.Request, trustedProxies []*net.IPNet) string {
+	ip := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+
+	hops := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(hops) - 1; i >= 0 && isTrustedProxy(ip, trustedProxies); i-- {
+		hop := strings.TrimSpace(hops[i])
+		if net.ParseIP(hop) == nil {
+			break
+		}
+		ip = hop
+	}
+	return ip
+}
+
+// isTrustedProxy reports whether ip belongs to one of the trusted proxy networks
+func isTrustedProxy(ip string, trustedProxies []*net.IPNet) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, network := range trustedProxies {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
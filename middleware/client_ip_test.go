@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientIP(t *testing.T) {
+	_, proxies, _ := net.ParseCIDR("10.0.0.0/8")
+	trusted := []*net.IPNet{proxies}
+
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor string
+		trusted      []*net.IPNet
+		expectedAddr string
+	}{
+		{"direct connection", "198.51.100.7:4000", "", trusted, "198.51.100.7"},
+		{"forwarded header from an untrusted peer is ignored", "198.51.100.7:4000", "203.0.113.9", trusted, "198.51.100.7"},
+		{"no trusted proxies ignores the header", "10.0.0.2:4000", "203.0.113.9", nil, "10.0.0.2"},
+		{"trusted proxy", "10.0.0.2:4000", "203.0.113.9", trusted, "203.0.113.9"},
+		{"spoofed hops left of the client are ignored", "10.0.0.2:4000", "192.0.2.1, 203.0.113.9", trusted, "203.0.113.9"},
+		{"chain of trusted proxies", "10.0.0.2:4000", "203.0.113.9, 10.1.1.1", trusted, "203.0.113.9"},
+		{"malformed hop stops at the proxy", "10.0.0.2:4000", "not-an-ip", trusted, "10.0.0.2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			}
+			assert.Equal(t, tt.expectedAddr, ClientIP(req, tt.trusted))
+		})
+	}
+}
@@ -20,11 +20,13 @@ const (
 	ErrCodeUnauthorized       ErrorCode = "UNAUTHORIZED"
 	ErrCodeForbidden          ErrorCode = "FORBIDDEN"
 	ErrCodeNotFound           ErrorCode = "NOT_FOUND"
+	ErrCodeConflict           ErrorCode = "CONFLICT"
 	ErrCodeRateLimited        ErrorCode = "RATE_LIMITED"
 	ErrCodeInternalError      ErrorCode = "INTERNAL_ERROR"
 	ErrCodeServiceUnavailable ErrorCode = "SERVICE_UNAVAILABLE"
 	ErrCodeValidation         ErrorCode = "VALIDATION_ERROR"
 	ErrCodeExternalAPI        ErrorCode = "EXTERNAL_API_ERROR"
+	ErrCodeTimeout            ErrorCode = "TIMEOUT"
 )
 
 // APIError represents a structured error response
@@ -73,6 +75,8 @@ func getErrorMessage(code ErrorCode) string {
 		return "You don't have permission to access this resource"
 	case ErrCodeNotFound:
 		return "The requested resource was not found"
+	case ErrCodeConflict:
+		return "The resource is not in a state that allows this request"
 	case ErrCodeRateLimited:
 		return "Rate limit exceeded. Please try again later"
 	case ErrCodeInternalError:
@@ -83,6 +87,8 @@ func getErrorMessage(code ErrorCode) string {
 		return "Request validation failed"
 	case ErrCodeExternalAPI:
 		return "Failed to communicate with external service"
+	case ErrCodeTimeout:
+		return "The request did not complete within the allowed time"
 	default:
 		return "An unknown error occurred"
 	}
@@ -110,6 +116,10 @@ func RespondNotFound(w http.ResponseWriter, err error, requestID string) {
 	ErrorHandler(w, err, ErrCodeNotFound, http.StatusNotFound, requestID)
 }
 
+func RespondConflict(w http.ResponseWriter, err error, requestID string) {
+	ErrorHandler(w, err, ErrCodeConflict, http.StatusConflict, requestID)
+}
+
 func RespondRateLimited(w http.ResponseWriter, err error, requestID string) {
 	ErrorHandler(w, err, ErrCodeRateLimited, http.StatusTooManyRequests, requestID)
 }
@@ -129,3 +139,7 @@ func RespondValidationError(w http.ResponseWriter, err error, requestID string)
 func RespondExternalAPIError(w http.ResponseWriter, err error, requestID string) {
 	ErrorHandler(w, err, ErrCodeExternalAPI, http.StatusBadGateway, requestID)
 }
+
+func RespondGatewayTimeout(w http.ResponseWriter, err error, requestID string) {
+	ErrorHandler(w, err, ErrCodeTimeout, http.StatusGatewayTimeout, requestID)
+}
@@ -0,0 +1,287 @@
+/*
+Package middleware provides a selectable application log backend.
+
+Logrus remains the default backend. With the slog backend, entries are encoded by
+log/slog's handlers, which allocate far less per entry; existing logrus call sites
+keep working because a hook forwards their entries to the slog handler. Hot paths
+log through Slog, which writes to whichever backend is configured, and
+LogrusHandler lets slog calls flow into a logrus logger's formatter and hooks.
+
+Usage:
+
+	err := ConfigureLogger(LogOptions{Backend: LogBackendSlog, Level: "info", Format: "json", Output: "stderr"})
+	Slog.LogAttrs(ctx, slog.LevelInfo, "Feed fetched", slog.String("url", feedURL))
+*/
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Supported application log backends
+const (
+	LogBackendLogrus = "logrus"
+	LogBackendSlog   = "slog"
+)
+
+// Slog is the structured logger for hot paths; it writes through the configured backend
+var Slog *slog.Logger
+
+// LogOptions configures the application log
+type LogOptions struct {
+	// Backend is "logrus" or "slog"
+	Backend string
+	Level   string
+	// Format is "json" or "text"
+	Format string
+	// Output is "stdout", "stderr", a file path, or "off"
+	Output string
+}
+
+// Validate checks that the options name a supported backend, level, and format
+func (o LogOptions) Validate() error {
+	if o.Backend != LogBackendLogrus && o.Backend != LogBackendSlog {
+		return fmt.Errorf("unknown log backend %q: must be \"logrus\" or \"slog\"", o.Backend)
+	}
+	if _, err := logrus.ParseLevel(o.Level); err != nil {
+		return fmt.Errorf("invalid log level: %v", err)
+	}
+	if o.Format != "json" && o.Format != "text" {
+		return fmt.Errorf("unknown log format %q: must be \"json\" or \"text\"", o.Format)
+	}
+	return nil
+}
+
+// ConfigureLogger sets up the application log; Logger and Slog both write through the chosen backend
+func ConfigureLogger(options LogOptions) error {
+	if err := options.Validate(); err != nil {
+		return err
+	}
+	if Logger == nil {
+		InitLogger()
+	}
+	level, _ := logrus.ParseLevel(options.Level)
+
+	out := io.Discard
+	if options.Output != LogOutputOff {
+		opened, err := OpenLogOutput(options.Output)
+		if err != nil {
+			return err
+		}
+		out = opened
+	}
+
+	hooks := make(logrus.LevelHooks)
+	hooks.Add(ContextHook{})
+
+	if options.Backend == LogBackendSlog {
+		handlerOptions := &slog.HandlerOptions{Level: slogLevel(level)}
+		var handler slog.Handler = slog.NewJSONHandler(out, handlerOptions)
+		if options.Format == "text" {
+			handler = slog.NewTextHandler(out, handlerOptions)
+		}
+		handler = contextHandler{Handler: handler}
+
+		// Logrus entries skip its formatter and are encoded by the slog handler instead
+		hooks.Add(SlogHook{handler: handler})
+		Logger.ReplaceHooks(hooks)
+		Logger.SetFormatter(discardFormatter{})
+		Logger.SetOutput(io.Discard)
+		Logger.SetLevel(level)
+		Slog = slog.New(handler)
+		return nil
+	}
+
+	if options.Format == "text" {
+		Logger.SetFormatter(&logrus.TextFormatter{TimestampFormat: time.RFC3339, FullTimestamp: true})
+	} else {
+		Logger.SetFormatter(&logrus.JSONFormatter{TimestampFormat: time.RFC3339})
+	}
+	Logger.ReplaceHooks(hooks)
+	Logger.SetOutput(out)
+	Logger.SetLevel(level)
+	Slog = slog.New(NewLogrusHandler(Logger))
+	return nil
+}
+
+// LogrusHandler is a slog.Handler that writes records to a logrus logger,
+// so they pass through its level, formatter, and hooks
+type LogrusHandler struct {
+	logger *logrus.Logger
+	attrs  []slog.Attr
+	group  string
+}
+
+// NewLogrusHandler creates a slog handler writing to logger
+func NewLogrusHandler(logger *logrus.Logger) *LogrusHandler {
+	return &LogrusHandler{logger: logger}
+}
+
+// Enabled implements slog.Handler
+func (h *LogrusHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.logger.IsLevelEnabled(logrusLevel(level))
+}
+
+// Handle implements slog.Handler
+func (h *LogrusHandler) Handle(ctx context.Context, record slog.Record) error {
+	fields := make(logrus.Fields, len(h.attrs)+record.NumAttrs())
+	for _, attr := range h.attrs {
+		addLogrusField(fields, "", attr)
+	}
+	record.Attrs(func(attr slog.Attr) bool {
+		addLogrusField(fields, h.group, attr)
+		return true
+	})
+
+	h.logger.WithContext(ctx).WithTime(record.Time).WithFields(fields).Log(logrusLevel(record.Level), record.Message)
+	return nil
+}
+
+// WithAttrs implements slog.Handler
+func (h *LogrusHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = make([]slog.Attr, 0, len(h.attrs)+len(attrs))
+	clone.attrs = append(clone.attrs, h.attrs...)
+	for _, attr := range attrs {
+		if h.group != "" {
+			attr.Key = h.group + attr.Key
+		}
+		clone.attrs = append(clone.attrs, attr)
+	}
+	return &clone
+}
+
+// WithGroup implements slog.Handler
+func (h *LogrusHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.group = h.group + name + "."
+	return &clone
+}
+
+// addLogrusField adds an attribute to fields, flattening groups into dotted keys
+func addLogrusField(fields logrus.Fields, prefix string, attr slog.Attr) {
+	value := attr.Value.Resolve()
+	if value.Kind() == slog.KindGroup {
+		for _, member := range value.Group() {
+			addLogrusField(fields, prefix+attr.Key+".", member)
+		}
+		return
+	}
+	if attr.Key != "" {
+		fields[prefix+attr.Key] = value.Any()
+	}
+}
+
+// SlogHook forwards logrus entries to a slog handler
+type SlogHook struct {
+	handler slog.Handler
+}
+
+// Levels implements logrus.Hook
+func (SlogHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire implements logrus.Hook
+func (h SlogHook) Fire(entry *logrus.Entry) error {
+	ctx := entry.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	level := slogLevel(entry.Level)
+	if !h.handler.Enabled(ctx, level) {
+		return nil
+	}
+
+	// Sort fields so output is stable across entries
+	keys := make([]string, 0, len(entry.Data))
+	for key := range entry.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	record := slog.NewRecord(entry.Time, level, entry.Message, 0)
+	for _, key := range keys {
+		record.AddAttrs(slog.Any(key, entry.Data[key]))
+	}
+	return h.handler.Handle(ctx, record)
+}
+
+// discardFormatter skips logrus formatting when entries are encoded by slog
+type discardFormatter struct{}
+
+// Format implements logrus.Formatter
+func (discardFormatter) Format(*logrus.Entry) ([]byte, error) {
+	return nil, nil
+}
+
+// contextHandler adds the correlation fields from a record's context, as ContextHook does for logrus
+type contextHandler struct {
+	slog.Handler
+}
+
+// Handle implements slog.Handler
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if ctx != nil {
+		present := make(map[string]bool, record.NumAttrs())
+		record.Attrs(func(attr slog.Attr) bool {
+			present[attr.Key] = true
+			return true
+		})
+
+		entry := &logrus.Entry{Context: ctx, Data: make(logrus.Fields, 4)}
+		ContextHook{}.Fire(entry)
+		for _, key := range []string{"request_id", "trace_id", "tenant", "route"} {
+			if value, exists := entry.Data[key]; exists && !present[key] {
+				record.AddAttrs(slog.Any(key, value))
+			}
+		}
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+// WithAttrs implements slog.Handler
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup implements slog.Handler
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithGroup(name)}
+}
+
+// logrusLevel maps a slog level to the nearest logrus level
+func logrusLevel(level slog.Level) logrus.Level {
+	switch {
+	case level < slog.LevelInfo:
+		return logrus.DebugLevel
+	case level < slog.LevelWarn:
+		return logrus.InfoLevel
+	case level < slog.LevelError:
+		return logrus.WarnLevel
+	}
+	return logrus.ErrorLevel
+}
+
+// slogLevel maps a logrus level to the nearest slog level
+func slogLevel(level logrus.Level) slog.Level {
+	switch level {
+	case logrus.TraceLevel, logrus.DebugLevel:
+		return slog.LevelDebug
+	case logrus.InfoLevel:
+		return slog.LevelInfo
+	case logrus.WarnLevel:
+		return slog.LevelWarn
+	}
+	return slog.LevelError
+}
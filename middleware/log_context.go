@@ -0,0 +1,117 @@
+/*
+Package middleware provides request-correlated logging.
+
+CorrelationMiddleware stores a request's ID, tenant, and route in its context, and
+ContextHook copies them, together with the active trace ID, into every log entry
+created from that context. Handlers log through Log(ctx) instead of passing the
+same fields to every WithFields call.
+
+Usage:
+
+	middleware.Log(r.Context()).WithField("url", feedURL).Info("Feed fetched")
+*/
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Nexora-Open-Source/rss-feed-backend/utils"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// LogFields are the correlation fields attached to every log entry made within a request
+type LogFields struct {
+	RequestID string
+	Tenant    string
+	Route     string
+}
+
+// logFieldsKey is the context key holding a request's LogFields
+type logFieldsKey struct{}
+
+// WithLogFields returns a context whose log entries carry fields
+func WithLogFields(ctx context.Context, fields LogFields) context.Context {
+	return context.WithValue(ctx, logFieldsKey{}, fields)
+}
+
+// LogFieldsFromContext returns the correlation fields stored in ctx
+func LogFieldsFromContext(ctx context.Context) (LogFields, bool) {
+	if ctx == nil {
+		return LogFields{}, false
+	}
+	fields, ok := ctx.Value(logFieldsKey{}).(LogFields)
+	return fields, ok
+}
+
+// Log returns a log entry that picks up the correlation fields stored in ctx
+func Log(ctx context.Context) *logrus.Entry {
+	return Logger.WithContext(ctx)
+}
+
+// ContextHook adds request_id, trace_id, tenant, and route from an entry's context to the entry.
+// Fields set explicitly on the entry take precedence.
+type ContextHook struct{}
+
+// Levels implements logrus.Hook
+func (ContextHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire implements logrus.Hook
+func (ContextHook) Fire(entry *logrus.Entry) error {
+	if entry.Context == nil {
+		return nil
+	}
+
+	if fields, ok := LogFieldsFromContext(entry.Context); ok {
+		setLogField(entry, "request_id", fields.RequestID)
+		setLogField(entry, "tenant", fields.Tenant)
+		setLogField(entry, "route", fields.Route)
+	}
+	if spanContext := trace.SpanContextFromContext(entry.Context); spanContext.HasTraceID() {
+		setLogField(entry, "trace_id", spanContext.TraceID().String())
+	}
+	return nil
+}
+
+// setLogField sets a non-empty field unless the entry already has it
+func setLogField(entry *logrus.Entry, key, value string) {
+	if value == "" {
+		return
+	}
+	if _, exists := entry.Data[key]; !exists {
+		entry.Data[key] = value
+	}
+}
+
+// CorrelationMiddleware stores the request's correlation fields in its context. Requests without an
+// X-Request-ID are given one, which handlers see in the request headers and clients in the response.
+// tenantOf attributes the request to a tenant and may be nil.
+func CorrelationMiddleware(tenantOf func(*http.Request) string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestID := r.Header.Get("X-Request-ID")
+			if requestID == "" {
+				requestID = utils.GenerateRequestID()
+				r.Header.Set("X-Request-ID", requestID)
+				w.Header().Set("X-Request-ID", requestID)
+			}
+
+			fields := LogFields{RequestID: requestID, Route: r.URL.Path}
+			// Prefer the route template so entries for /mute-rules/{id} group together
+			if route := mux.CurrentRoute(r); route != nil {
+				if template, err := route.GetPathTemplate(); err == nil {
+					fields.Route = template
+				}
+			}
+			if tenantOf != nil {
+				fields.Tenant = tenantOf(r)
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithLogFields(r.Context(), fields)))
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestCorrelatedLogging(t *testing.T) {
+	var out bytes.Buffer
+	Logger = logrus.New()
+	Logger.SetFormatter(&logrus.JSONFormatter{})
+	Logger.SetOutput(&out)
+	Logger.AddHook(ContextHook{})
+	defer InitLogger()
+
+	traceID := trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36}
+	router := mux.NewRouter()
+	router.Handle("/mute-rules/{id}", CorrelationMiddleware(func(r *http.Request) string {
+		return r.Header.Get("X-Tenant-ID")
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := trace.ContextWithSpanContext(r.Context(), trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID}))
+		Log(ctx).WithField("rule_id", "mute_1").Info("Mute rule updated")
+		Log(r.Context()).WithField("tenant", "explicit").Info("Explicit fields win")
+	})))
+
+	req := httptest.NewRequest("PUT", "/mute-rules/mute_1", nil)
+	req.Header.Set("X-Tenant-ID", "acme")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	lines := bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n"))
+	assert.Equal(t, 2, len(lines))
+
+	var first, second map[string]interface{}
+	assert.NoError(t, json.Unmarshal(lines[0], &first))
+	assert.NoError(t, json.Unmarshal(lines[1], &second))
+
+	// A missing request ID is generated and shared with the handler and the client
+	requestID := recorder.Header().Get("X-Request-ID")
+	assert.NotEmpty(t, requestID)
+	assert.Equal(t, requestID, first["request_id"])
+	assert.Equal(t, "acme", first["tenant"])
+	assert.Equal(t, "/mute-rules/{id}", first["route"])
+	assert.Equal(t, traceID.String(), first["trace_id"])
+	assert.Equal(t, "mute_1", first["rule_id"])
+
+	assert.Equal(t, "explicit", second["tenant"])
+	assert.Equal(t, requestID, second["request_id"])
+	assert.NotContains(t, second, "trace_id")
+}
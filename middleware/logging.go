@@ -6,9 +6,11 @@ package middleware
 import (
 	"bytes"
 	"io"
+	"log/slog"
 	"net/http"
 	"time"
 
+	"github.com/Nexora-Open-Source/rss-feed-backend/utils"
 	"github.com/sirupsen/logrus"
 )
 
@@ -39,9 +41,12 @@ func InitLogger() {
 		TimestampFormat: time.RFC3339,
 	})
 	Logger.SetLevel(logrus.InfoLevel)
+	Logger.AddHook(ContextHook{})
+	Slog = slog.New(NewLogrusHandler(Logger))
 }
 
-// LoggingMiddleware logs HTTP requests and responses
+// LoggingMiddleware logs failed HTTP requests with their bodies to the application log;
+// successful requests are logged at debug level, since the access log records every request
 func LoggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
@@ -66,51 +71,52 @@ func LoggingMiddleware(next http.Handler) http.Handler {
 		// Calculate duration
 		duration := time.Since(start)
 
-		// Log request and response
-		fields := logrus.Fields{
-			"method":      r.Method,
-			"path":        r.URL.Path,
-			"query":       r.URL.RawQuery,
-			"remote_addr": r.RemoteAddr,
-			"user_agent":  r.UserAgent(),
-			"status":      rw.status,
-			"duration_ms": duration.Milliseconds(),
-			"request_id":  generateRequestID(),
+		// Log with appropriate level based on status; skip building the entry when the level is disabled
+		level, message := slog.LevelDebug, "Request completed successfully"
+		switch {
+		case rw.status >= 500:
+			level, message = slog.LevelError, "Request completed with server error"
+		case rw.status >= 400:
+			level, message = slog.LevelWarn, "Request completed with client error"
+		}
+		if !Slog.Enabled(r.Context(), level) {
+			return
+		}
+
+		// Route middleware assigns missing request IDs in the shared request headers
+		requestID := r.Header.Get("X-Request-ID")
+		if requestID == "" {
+			requestID = generateRequestID()
 		}
 
+		// Log request and response
+		attrs := make([]slog.Attr, 0, 10)
+		attrs = append(attrs,
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.String("query", r.URL.RawQuery),
+			slog.String("remote_addr", r.RemoteAddr),
+			slog.String("user_agent", r.UserAgent()),
+			slog.Int("status", rw.status),
+			slog.Int64("duration_ms", duration.Milliseconds()),
+			slog.String("request_id", requestID),
+		)
+
 		// Add request body if present (limit size for security)
 		if len(bodyBytes) > 0 && len(bodyBytes) < 1024 {
-			fields["request_body"] = string(bodyBytes)
+			attrs = append(attrs, slog.String("request_body", string(bodyBytes)))
 		}
 
 		// Add response body for errors (limit size)
 		if rw.status >= 400 && rw.body.Len() > 0 && rw.body.Len() < 1024 {
-			fields["response_body"] = rw.body.String()
+			attrs = append(attrs, slog.String("response_body", rw.body.String()))
 		}
 
-		// Log with appropriate level based on status
-		switch {
-		case rw.status >= 500:
-			Logger.WithFields(fields).Error("Request completed with server error")
-		case rw.status >= 400:
-			Logger.WithFields(fields).Warn("Request completed with client error")
-		default:
-			Logger.WithFields(fields).Info("Request completed successfully")
-		}
+		Slog.LogAttrs(r.Context(), level, message, attrs...)
 	})
 }
 
 // generateRequestID generates a unique request ID
 func generateRequestID() string {
-	return time.Now().Format("20060102150405") + "-" + randomString(8)
-}
-
-// randomString generates a random string of specified length
-func randomString(length int) string {
-	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	b := make([]byte, length)
-	for i := range b {
-		b[i] = charset[time.Now().UnixNano()%int64(len(charset))]
-	}
-	return string(b)
+	return utils.GenerateRequestID()
 }
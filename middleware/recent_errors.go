@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RecentError is an error-level log entry kept for diagnostics
+type RecentError struct {
+	Time    time.Time              `json:"time"`
+	Level   string                 `json:"level"`
+	Message string                 `json:"message"`
+	Fields  map[string]interface{} `json:"fields,omitempty"`
+}
+
+/*
+RecentErrors is a logrus hook that keeps the latest error-level log entries, so an
+operator can see what went wrong recently without searching the log.
+
+Only the newest entries are kept, up to the configured size. Field values that are
+not plain JSON values, such as errors, are kept as their string form.
+*/
+type RecentErrors struct {
+	mu      sync.Mutex
+	entries []RecentError
+	next    int
+	size    int
+}
+
+// NewRecentErrors creates a hook keeping the latest size error entries
+func NewRecentErrors(size int) *RecentErrors {
+	return &RecentErrors{size: size}
+}
+
+// Levels implements logrus.Hook
+func (r *RecentErrors) Levels() []logrus.Level {
+	return []logrus.Level{logrus.PanicLevel, logrus.FatalLevel, logrus.ErrorLevel}
+}
+
+// Fire implements logrus.Hook
+func (r *RecentErrors) Fire(entry *logrus.Entry) error {
+	if r == nil || r.size <= 0 {
+		return nil
+	}
+
+	recent := RecentError{
+		Time:    entry.Time.UTC(),
+		Level:   entry.Level.String(),
+		Message: entry.Message,
+	}
+	if len(entry.Data) > 0 {
+		recent.Fields = make(map[string]interface{}, len(entry.Data))
+		for key, value := range entry.Data {
+			switch value.(type) {
+			case string, bool, int, int64, float64:
+				recent.Fields[key] = value
+			default:
+				recent.Fields[key] = fmt.Sprint(value)
+			}
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.entries) < r.size {
+		r.entries = append(r.entries, recent)
+		return nil
+	}
+	r.entries[r.next] = recent
+	r.next = (r.next + 1) % r.size
+	return nil
+}
+
+// Entries returns the kept entries, newest first
+func (r *RecentErrors) Entries() []RecentError {
+	if r == nil {
+		return []RecentError{}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries := make([]RecentError, 0, len(r.entries))
+	// Once full, next is the oldest entry; walk backwards from the one before it
+	for i := 0; i < len(r.entries); i++ {
+		index := (r.next - 1 - i + 2*len(r.entries)) % len(r.entries)
+		entries = append(entries, r.entries[index])
+	}
+	return entries
+}
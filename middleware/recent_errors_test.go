@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecentErrors(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	recent := NewRecentErrors(2)
+	logger.AddHook(recent)
+
+	logger.Warn("not an error")
+	logger.WithError(errors.New("connection refused")).WithField("url", "https://example.com/feed.xml").Error("first")
+	logger.Error("second")
+	logger.WithField("attempt", 3).Error("third")
+
+	// Only errors are kept, newest first, up to the size
+	entries := recent.Entries()
+	require.Len(t, entries, 2)
+	assert.Equal(t, "third", entries[0].Message)
+	assert.Equal(t, "error", entries[0].Level)
+	assert.Equal(t, 3, entries[0].Fields["attempt"])
+	assert.Equal(t, "second", entries[1].Message)
+
+	// Values that are not plain JSON values are kept as strings
+	recent = NewRecentErrors(5)
+	logger.ReplaceHooks(make(logrus.LevelHooks))
+	logger.AddHook(recent)
+	logger.WithError(errors.New("connection refused")).Error("fetch failed")
+	assert.Equal(t, "connection refused", recent.Entries()[0].Fields["error"])
+
+	var disabled *RecentErrors
+	assert.Empty(t, disabled.Entries())
+}
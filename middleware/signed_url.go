@@ -0,0 +1,34 @@
+/*
+Package middleware provides signed URL verification for temporary public downloads.
+*/
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/Nexora-Open-Source/rss-feed-backend/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// SignedURLMiddleware only serves requests whose URL carries a valid, unexpired signature
+func SignedURLMiddleware(signer *utils.URLSigner, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := signer.Verify(r.URL); err != nil {
+			requestID := r.Header.Get("X-Request-ID")
+			if requestID == "" {
+				requestID = generateRequestID()
+			}
+
+			Logger.WithFields(logrus.Fields{
+				"request_id": requestID,
+				"path":       r.URL.Path,
+				"error":      err.Error(),
+			}).Warn("Rejected request with invalid signed URL")
+
+			RespondForbidden(w, err, requestID)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+}
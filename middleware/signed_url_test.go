@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/Nexora-Open-Source/rss-feed-backend/utils"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignedURLMiddleware(t *testing.T) {
+	Logger = logrus.New()
+	Logger.SetOutput(io.Discard)
+	defer InitLogger()
+
+	signer := utils.NewURLSigner([]byte("0123456789abcdef0123456789abcdef"))
+	download := SignedURLMiddleware(signer, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("export"))
+	})
+	request := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		download(w, httptest.NewRequest("GET", target, nil))
+		return w
+	}
+
+	signed, err := signer.Sign("/exports/items.json?format=json", time.Minute)
+	require.NoError(t, err)
+	w := request(signed)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "export", w.Body.String())
+
+	// Missing and altered signatures are refused
+	assert.Equal(t, http.StatusForbidden, request("/exports/items.json?format=json").Code)
+	tampered, err := url.Parse(signed)
+	require.NoError(t, err)
+	query := tampered.Query()
+	query.Set("format", "csv")
+	tampered.RawQuery = query.Encode()
+	assert.Equal(t, http.StatusForbidden, request(tampered.String()).Code)
+	tampered, err = url.Parse(signed)
+	require.NoError(t, err)
+	tampered.Path = "/exports/users.json"
+	assert.Equal(t, http.StatusForbidden, request(tampered.String()).Code)
+
+	// Without a signer nothing is served
+	w = httptest.NewRecorder()
+	SignedURLMiddleware(nil, download)(w, httptest.NewRequest("GET", signed, nil))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// Expiry is checked to the second, so a one-second URL is refused within two
+	expiring, err := signer.Sign("/exports/items.json", time.Second)
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return request(expiring).Code == http.StatusForbidden
+	}, 3*time.Second, 50*time.Millisecond)
+}
@@ -0,0 +1,192 @@
+/*
+Package middleware provides per-route request timeouts.
+
+Each route can be given its own budget, such as a few seconds for reads and longer
+for synchronous ingest. A request over its budget has its context cancelled and
+receives a structured 504; whatever the handler writes afterwards is discarded.
+*/
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// ParseRouteTimeouts parses "route=duration" entries, where route is a path template such as /items or /jobs/{id}/result
+func ParseRouteTimeouts(entries []string) (map[string]time.Duration, error) {
+	budgets := make(map[string]time.Duration)
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		route, value, found := strings.Cut(entry, "=")
+		route = strings.TrimSpace(route)
+		if !found || !strings.HasPrefix(route, "/") {
+			return nil, fmt.Errorf("invalid route timeout %q: expected /route=duration", entry)
+		}
+		budget, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid route timeout %q: %v", entry, err)
+		}
+		if budget < 0 {
+			return nil, fmt.Errorf("invalid route timeout %q: must not be negative", entry)
+		}
+		budgets[route] = budget
+	}
+	return budgets, nil
+}
+
+// RouteTimeoutMiddleware gives each request the budget of its route, or fallback for unlisted routes; a budget of 0 is unbounded
+func RouteTimeoutMiddleware(budgets map[string]time.Duration, fallback time.Duration) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := r.URL.Path
+			if current := mux.CurrentRoute(r); current != nil {
+				if template, err := current.GetPathTemplate(); err == nil {
+					route = template
+				}
+			}
+			budget, exists := budgets[route]
+			if !exists {
+				budget = fallback
+			}
+			if budget <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			serveWithinBudget(w, r, next, route, budget)
+		})
+	}
+}
+
+// serveWithinBudget runs next with a context ending after budget, buffering its response so a 504 can replace it
+func serveWithinBudget(w http.ResponseWriter, r *http.Request, next http.Handler, route string, budget time.Duration) {
+	ctx, cancel := context.WithTimeout(r.Context(), budget)
+	defer cancel()
+	r = r.WithContext(ctx)
+
+	buffered := &timeoutWriter{header: make(http.Header)}
+	done := make(chan struct{})
+	panicked := make(chan interface{}, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				panicked <- p
+			}
+		}()
+		next.ServeHTTP(buffered, r)
+		buffered.finish()
+		close(done)
+	}()
+
+	select {
+	case p := <-panicked:
+		// Re-raise on the serving goroutine so recovery middleware sees it
+		panic(p)
+	case <-done:
+		buffered.flushTo(w)
+	case <-ctx.Done():
+		if !buffered.expire() {
+			// The handler finished just as the budget ran out
+			<-done
+			buffered.flushTo(w)
+			return
+		}
+		if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			// The client went away; there is nobody left to respond to
+			return
+		}
+
+		requestID := r.Header.Get("X-Request-ID")
+		if requestID == "" {
+			requestID = generateRequestID()
+		}
+		Log(ctx).WithFields(logrus.Fields{
+			"route":  route,
+			"budget": budget.String(),
+		}).Warn("Request exceeded its route timeout")
+		RespondGatewayTimeout(w, fmt.Errorf("%s did not complete within %s", route, budget), requestID)
+	}
+}
+
+// timeoutWriter buffers a handler's response until it finishes within its budget
+type timeoutWriter struct {
+	mu       sync.Mutex
+	header   http.Header
+	body     bytes.Buffer
+	status   int
+	finished bool
+	expired  bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.expired || tw.status != 0 {
+		return
+	}
+	tw.status = code
+}
+
+func (tw *timeoutWriter) Write(data []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.expired {
+		return 0, http.ErrHandlerTimeout
+	}
+	if tw.status == 0 {
+		tw.status = http.StatusOK
+	}
+	return tw.body.Write(data)
+}
+
+// finish marks the handler as done, so the response is kept even if the budget runs out now
+func (tw *timeoutWriter) finish() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	tw.finished = true
+}
+
+// expire discards the response, reporting false when the handler had already finished
+func (tw *timeoutWriter) expire() bool {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.finished {
+		return false
+	}
+	tw.expired = true
+	return true
+}
+
+// flushTo writes the buffered response to w once the handler has finished
+func (tw *timeoutWriter) flushTo(w http.ResponseWriter) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	for name, values := range tw.header {
+		w.Header()[name] = values
+	}
+	if tw.status == 0 {
+		tw.status = http.StatusOK
+	}
+	w.WriteHeader(tw.status)
+	w.Write(tw.body.Bytes())
+}
@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRouteTimeouts(t *testing.T) {
+	budgets, err := ParseRouteTimeouts([]string{"/items=5s", " /jobs/{id}/result = 1m ", ""})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]time.Duration{"/items": 5 * time.Second, "/jobs/{id}/result": time.Minute}, budgets)
+
+	for _, entries := range [][]string{{"/items"}, {"items=5s"}, {"/items=soon"}, {"/items=-1s"}} {
+		_, err := ParseRouteTimeouts(entries)
+		assert.Error(t, err, entries)
+	}
+}
+
+func TestRouteTimeoutMiddleware(t *testing.T) {
+	Logger = logrus.New()
+	Logger.SetOutput(io.Discard)
+	defer InitLogger()
+
+	cancelled := make(chan struct{})
+	router := mux.NewRouter()
+	timeout := RouteTimeoutMiddleware(map[string]time.Duration{"/slow/{id}": 20 * time.Millisecond, "/unbounded": 0}, time.Second)
+	router.Handle("/slow/{id}", timeout(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		close(cancelled)
+		w.Write([]byte("too late"))
+	})))
+	router.Handle("/fast", timeout(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Cache", "HIT")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("done"))
+	})))
+	router.Handle("/unbounded", timeout(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, hasDeadline := r.Context().Deadline()
+		assert.False(t, hasDeadline)
+	})))
+
+	// A request over its route's budget gets a structured 504, and the handler sees its context cancelled
+	req := httptest.NewRequest("GET", "/slow/1", nil)
+	req.Header.Set("X-Request-ID", "req_slow")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+	var apiErr APIError
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &apiErr))
+	assert.Equal(t, ErrCodeTimeout, apiErr.Error)
+	assert.Equal(t, "req_slow", apiErr.RequestID)
+	assert.Contains(t, apiErr.Details, "/slow/{id}")
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("handler context was not cancelled")
+	}
+
+	// Responses within budget pass through unchanged
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/fast", nil))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "HIT", w.Header().Get("X-Cache"))
+	assert.Equal(t, "done", w.Body.String())
+
+	// A budget of 0 leaves the route unbounded, even with a fallback
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/unbounded", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -31,6 +31,9 @@ const (
 	AlertTypeCacheFailure   AlertType = "cache_failure"
 	AlertTypeWorkerDown     AlertType = "worker_down"
 	AlertTypeHighErrorRate  AlertType = "high_error_rate"
+	AlertTypeFeedStale      AlertType = "feed_stale"
+	AlertTypeJobOverBudget  AlertType = "job_over_budget"
+	AlertTypeCanaryFailure  AlertType = "canary_failure"
 )
 
 // Alert represents an alert
@@ -2,6 +2,8 @@
 package monitoring
 
 import (
+	"strconv"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 )
@@ -59,6 +61,108 @@ var (
 		},
 	)
 
+	asyncStoreQueueSize = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "rss_async_store_queue_size",
+			Help: "Current number of fetched feeds waiting for a store worker",
+		},
+	)
+
+	overflowQueueSize = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "rss_overflow_queue_size",
+			Help: "Current number of fetch jobs held in the persistent overflow queue",
+		},
+	)
+
+	asyncStageDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "rss_async_stage_duration_seconds",
+			Help:    "Duration of the fetch and store stages of async jobs",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"stage", "status"},
+	)
+
+	backpressureDelay = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "rss_async_backpressure_delay_seconds",
+			Help:    "Delay applied to async job submissions as the queue approaches its reject threshold",
+			Buckets: prometheus.DefBuckets,
+		},
+	)
+
+	jobStatusEvictions = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "rss_job_status_evictions_total",
+			Help: "Total number of finished job statuses evicted from memory at capacity, by archive result",
+		},
+		[]string{"result"},
+	)
+
+	jobWatchdogStops = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "rss_job_watchdog_stops_total",
+			Help: "Total number of async jobs stopped by the watchdog for exceeding a budget",
+		},
+		[]string{"limit"},
+	)
+
+	canaryRuns = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "rss_canary_runs_total",
+			Help: "Total number of canary runs, by result: success or the stage that failed",
+		},
+		[]string{"result"},
+	)
+
+	canaryLatency = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "rss_canary_latency_seconds",
+			Help: "Duration of the latest canary run through the ingest pipeline",
+		},
+	)
+
+	injectedFaults = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "rss_injected_faults_total",
+			Help: "Total number of faults injected for resilience testing, by target and fault (latency or error)",
+		},
+		[]string{"target", "fault"},
+	)
+
+	itemSnapshots = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "rss_item_snapshots_total",
+			Help: "Total number of item content snapshots, by result: stored, skipped (too large), or failed",
+		},
+		[]string{"result"},
+	)
+
+	clientBans = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "rss_client_bans_total",
+			Help: "Total number of clients temporarily banned, by the rule they broke",
+		},
+		[]string{"rule"},
+	)
+
+	staleResponses = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "rss_stale_responses_total",
+			Help: "Total number of responses served from the stale fallback because Datastore failed, by endpoint",
+		},
+		[]string{"endpoint"},
+	)
+
+	feedErrorWebhooks = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "rss_feed_error_webhooks_total",
+			Help: "Total number of per-feed error webhook calls, by result: sent or failed",
+		},
+		[]string{"result"},
+	)
+
 	// Cache metrics
 	cacheHits = promauto.NewCounterVec(
 		prometheus.CounterOpts{
@@ -85,6 +189,30 @@ var (
 		[]string{"operation", "status"},
 	)
 
+	ingestItemsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "rss_ingest_items_total",
+			Help: "Total number of feed items offered for storage, by outcome (saved, duplicate, failed)",
+		},
+		[]string{"status"},
+	)
+
+	ingestCounterBatchesTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "rss_ingest_counter_batches_total",
+			Help: "Total number of stored batches offered to the per-source counters, by result (applied, replayed)",
+		},
+		[]string{"result"},
+	)
+
+	schemaMigrationsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "rss_schema_migrations_total",
+			Help: "Total number of stored entities upgraded on read from an older schema version",
+		},
+		[]string{"kind", "from_version"},
+	)
+
 	datastoreOperationDuration = promauto.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Name:    "rss_datastore_operation_duration_seconds",
@@ -119,6 +247,13 @@ var (
 			Help: "Number of active async workers",
 		},
 	)
+
+	activeStoreWorkers = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "rss_active_store_workers",
+			Help: "Number of active async store workers",
+		},
+	)
 )
 
 // RecordFeedFetch records metrics for RSS feed fetching
@@ -141,6 +276,69 @@ func UpdateAsyncQueueSize(size int) {
 	asyncQueueSize.Set(float64(size))
 }
 
+// UpdateAsyncStoreQueueSize updates the async store queue size gauge
+func UpdateAsyncStoreQueueSize(size int) {
+	asyncStoreQueueSize.Set(float64(size))
+}
+
+// UpdateOverflowQueueSize updates the overflow queue size gauge
+func UpdateOverflowQueueSize(size int) {
+	overflowQueueSize.Set(float64(size))
+}
+
+// RecordBackpressureDelay records how long a job submission was held back by backpressure
+func RecordBackpressureDelay(seconds float64) {
+	backpressureDelay.Observe(seconds)
+}
+
+// RecordJobStatusEvictions records count job statuses evicted from memory; result is "archived" or "archive_failed"
+func RecordJobStatusEvictions(result string, count int) {
+	jobStatusEvictions.WithLabelValues(result).Add(float64(count))
+}
+
+// RecordJobWatchdogStop records a job stopped by the watchdog; limit is "duration" or "feed_size"
+func RecordJobWatchdogStop(limit string) {
+	jobWatchdogStops.WithLabelValues(limit).Inc()
+}
+
+// RecordCanaryRun records a canary run; result is "success" or the stage that failed
+func RecordCanaryRun(result string, seconds float64) {
+	canaryRuns.WithLabelValues(result).Inc()
+	canaryLatency.Set(seconds)
+}
+
+// RecordInjectedFault records a fault injected into calls to a dependency
+func RecordInjectedFault(target, fault string) {
+	injectedFaults.WithLabelValues(target, fault).Inc()
+}
+
+// RecordItemSnapshots counts item content snapshots with the given result
+func RecordItemSnapshots(result string, count int) {
+	if count > 0 {
+		itemSnapshots.WithLabelValues(result).Add(float64(count))
+	}
+}
+
+// RecordClientBan records a client banned for breaking a ban rule
+func RecordClientBan(rule string) {
+	clientBans.WithLabelValues(rule).Inc()
+}
+
+// RecordStaleResponse records a response served from the stale fallback
+func RecordStaleResponse(endpoint string) {
+	staleResponses.WithLabelValues(endpoint).Inc()
+}
+
+// RecordFeedErrorWebhook records a call to a feed's error webhook with the given result
+func RecordFeedErrorWebhook(result string) {
+	feedErrorWebhooks.WithLabelValues(result).Inc()
+}
+
+// RecordAsyncStage records how long one stage ("fetch" or "store") of an async job took
+func RecordAsyncStage(stage, status string, duration float64) {
+	asyncStageDuration.WithLabelValues(stage, status).Observe(duration)
+}
+
 // RecordCacheHit records a cache hit
 func RecordCacheHit(operation string) {
 	cacheHits.WithLabelValues(operation).Inc()
@@ -157,6 +355,25 @@ func RecordDatastoreOperation(operation, status string, duration float64) {
 	datastoreOperationDuration.WithLabelValues(operation, status).Observe(duration)
 }
 
+// RecordIngestItems counts feed items offered for storage with the given outcome
+func RecordIngestItems(status string, count int) {
+	if count > 0 {
+		ingestItemsTotal.WithLabelValues(status).Add(float64(count))
+	}
+}
+
+// RecordIngestCounterBatches counts batches offered to the per-source counters with the given result
+func RecordIngestCounterBatches(result string, count int) {
+	if count > 0 {
+		ingestCounterBatchesTotal.WithLabelValues(result).Add(float64(count))
+	}
+}
+
+// RecordSchemaMigration counts an entity of the given kind upgraded on read from an older schema version
+func RecordSchemaMigration(kind string, fromVersion int) {
+	schemaMigrationsTotal.WithLabelValues(kind, strconv.Itoa(fromVersion)).Inc()
+}
+
 // RecordHTTPRequest records HTTP request metrics
 func RecordHTTPRequest(method, endpoint, status string, duration float64) {
 	httpRequestsTotal.WithLabelValues(method, endpoint, status).Inc()
@@ -167,3 +384,8 @@ func RecordHTTPRequest(method, endpoint, status string, duration float64) {
 func UpdateActiveWorkers(count int) {
 	activeWorkers.Set(float64(count))
 }
+
+// UpdateActiveStoreWorkers updates the active store workers gauge
+func UpdateActiveStoreWorkers(count int) {
+	activeStoreWorkers.Set(float64(count))
+}
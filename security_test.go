@@ -3,11 +3,14 @@ package main
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/Nexora-Open-Source/rss-feed-backend/config"
+	"github.com/Nexora-Open-Source/rss-feed-backend/handlers"
 	"github.com/Nexora-Open-Source/rss-feed-backend/middleware"
+	"github.com/gorilla/mux"
 	"golang.org/x/time/rate"
 )
 
@@ -69,6 +72,63 @@ func TestEnhancedRateLimiting(t *testing.T) {
 	}
 }
 
+// TestReadOnlyMiddleware tests that read-only replicas serve reads and reject mutations
+func TestReadOnlyMiddleware(t *testing.T) {
+	handler := ReadOnlyMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for _, method := range []string{http.MethodGet, http.MethodHead, http.MethodOptions} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(method, "/items", nil))
+		if w.Code != http.StatusOK {
+			t.Errorf("%s should be served by a read-only replica, got %d", method, w.Code)
+		}
+	}
+
+	for _, method := range []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(method, "/fetch-store", nil))
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("%s should be rejected with 503 by a read-only replica, got %d", method, w.Code)
+		}
+	}
+}
+
+// TestAdminRoutesRequireToken tests that admin and bulk routes refuse requests without the admin token
+func TestAdminRoutesRequireToken(t *testing.T) {
+	routeChain := middleware.NewChain()
+	adminChain := routeChain.Clone().
+		Use(middleware.StageAuth, "admin_auth", middleware.AdminAuthMiddleware("secret"))
+	router := mux.NewRouter()
+	registerAPIRoutes(router, &handlers.Handler{}, routeChain, adminChain)
+
+	routes := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{http.MethodPost, "/feeds/bulk", `{"action":"delete","filter":{"disabled":false}}`},
+		{http.MethodDelete, "/users/alice/data", ""},
+		{http.MethodGet, "/admin/bans", ""},
+		{http.MethodDelete, "/admin/bans/client", ""},
+		{http.MethodGet, "/admin/diagnostics", ""},
+	}
+	for _, route := range routes {
+		for _, authorization := range []string{"", "Bearer wrong"} {
+			req := httptest.NewRequest(route.method, route.path, strings.NewReader(route.body))
+			if authorization != "" {
+				req.Header.Set("Authorization", authorization)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != http.StatusUnauthorized {
+				t.Errorf("%s %s with authorization %q should be refused with 401, got %d", route.method, route.path, authorization, w.Code)
+			}
+		}
+	}
+}
+
 // TestURLValidation tests the enhanced URL validation
 func TestURLValidation(t *testing.T) {
 	// This would require setting up the full handler with dependencies
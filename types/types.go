@@ -9,11 +9,54 @@ import (
 type AsyncJobStatus struct {
 	JobID       string     `json:"job_id"`
 	URL         string     `json:"url"`
-	Status      string     `json:"status"` // pending, processing, completed, failed
+	Status      string     `json:"status"` // pending, queued_deferred, processing, deferred, completed, failed
 	CreatedAt   time.Time  `json:"created_at"`
 	StartedAt   *time.Time `json:"started_at,omitempty"`
 	CompletedAt *time.Time `json:"completed_at,omitempty"`
 	Error       string     `json:"error,omitempty"`
+	ErrorType   string     `json:"error_type,omitempty"` // resource_limit when the job watchdog stopped the job
 	ItemsCount  int        `json:"items_count,omitempty"`
 	DurationMs  int64      `json:"duration_ms,omitempty"`
+	// Operation names the task run by jobs that do not fetch a feed, such as "bulk_disable"
+	Operation string `json:"operation,omitempty"`
+	// RetryAt is when a deferred job will be queued again, after its feed host throttled it
+	RetryAt   *time.Time `json:"retry_at,omitempty"`
+	Deferrals int        `json:"deferrals,omitempty"`
+	// Results lists per-target outcomes for task jobs
+	Results []TargetResult `json:"results,omitempty"`
+	// Events is the job's timeline, oldest first
+	Events []JobEvent `json:"events,omitempty"`
+}
+
+// Job event types, in the order a feed job usually records them
+const (
+	JobEventQueued    = "queued"
+	JobEventStarted   = "started"
+	JobEventCacheHit  = "cache_hit"
+	JobEventFetched   = "fetched"
+	JobEventParsed    = "parsed"
+	JobEventSaved     = "saved_batch"
+	JobEventCached    = "cached"
+	JobEventDeferred  = "deferred"
+	JobEventCompleted = "completed"
+	JobEventFailed    = "failed"
+)
+
+// JobEvent is one step in an async job's timeline; the measurement fields are set when relevant to the step
+type JobEvent struct {
+	At         time.Time `json:"at"`
+	Type       string    `json:"type"`
+	Message    string    `json:"message,omitempty"`
+	DurationMs int64     `json:"duration_ms,omitempty"`
+	Bytes      int64     `json:"bytes,omitempty"`
+	Items      int       `json:"items,omitempty"`
+}
+
+// TargetResult is the outcome of a job's work on one target, such as one feed of a bulk operation
+type TargetResult struct {
+	Target  string `json:"target"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+	// Count is how many records the work changed, for targets that stand for a set of records
+	Count int `json:"count,omitempty"`
 }
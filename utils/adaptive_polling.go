@@ -0,0 +1,170 @@
+/*
+Package utils provides adaptive poll interval estimation for feeds.
+
+Key Functions:
+  - PollStats.Observe: Records whether a poll found new items and how long it had been since the previous poll.
+  - PollPolicy.LearnedInterval: Returns the interval expected to find new items at the target rate.
+  - PollPolicy.SampleInterval: Draws an interval by Thompson sampling, so feeds keep exploring shorter and longer intervals.
+
+Each poll is treated as a trial whose outcome is whether the feed had new items.
+The probability of success is tracked as a Beta posterior with older polls
+decayed, and new items are assumed to arrive at a steady rate. The interval is
+then chosen so the chance of a poll finding new items equals the target yield:
+feeds that usually have something new are polled more often, and feeds that
+usually do not are polled less often.
+
+Usage:
+
+	policy := DefaultPollPolicy()
+	stats.Observe(time.Since(lastPoll), foundNewItems, policy.Decay)
+	next := policy.SampleInterval(stats, rng)
+*/
+package utils
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// PollStats holds decayed counts of poll outcomes for a feed
+type PollStats struct {
+	// Yields and Misses are decayed counts of polls that did and did not find new items
+	Yields float64 `datastore:"yields,noindex" json:"yields"`
+	Misses float64 `datastore:"misses,noindex" json:"misses"`
+	// IntervalSeconds is the decayed sum of time since the previous poll, over Yields+Misses polls
+	IntervalSeconds float64 `datastore:"interval_seconds,noindex" json:"interval_seconds"`
+	// Observations is the undecayed number of polls observed
+	Observations int `datastore:"observations,noindex" json:"observations"`
+}
+
+// Observe records a poll made interval after the previous one; decay discounts earlier polls
+func (s *PollStats) Observe(interval time.Duration, yielded bool, decay float64) {
+	if interval <= 0 {
+		return
+	}
+	s.Yields *= decay
+	s.Misses *= decay
+	s.IntervalSeconds *= decay
+	if yielded {
+		s.Yields++
+	} else {
+		s.Misses++
+	}
+	s.IntervalSeconds += interval.Seconds()
+	s.Observations++
+}
+
+// YieldProbability returns the posterior mean probability that a poll finds new items
+func (s PollStats) YieldProbability() float64 {
+	return (s.Yields + 1) / (s.Yields + s.Misses + 2)
+}
+
+// MeanInterval returns the decayed average time between the observed polls
+func (s PollStats) MeanInterval() time.Duration {
+	polls := s.Yields + s.Misses
+	if polls <= 0 {
+		return 0
+	}
+	return time.Duration(s.IntervalSeconds / polls * float64(time.Second))
+}
+
+// PollPolicy sets the bounds and target for adaptive poll intervals
+type PollPolicy struct {
+	MinInterval time.Duration `json:"min_interval"`
+	MaxInterval time.Duration `json:"max_interval"`
+	// TargetYield is the desired probability that a poll finds new items
+	TargetYield float64 `json:"target_yield"`
+	// Decay discounts older polls each time a poll is observed (1 never forgets)
+	Decay float64 `json:"decay"`
+	// MinObservations is how many polls are needed before a learned interval is used
+	MinObservations int `json:"min_observations"`
+}
+
+// DefaultPollPolicy returns the policy used when none is configured
+func DefaultPollPolicy() PollPolicy {
+	return PollPolicy{
+		MinInterval:     5 * time.Minute,
+		MaxInterval:     24 * time.Hour,
+		TargetYield:     0.5,
+		Decay:           0.9,
+		MinObservations: 3,
+	}
+}
+
+// LearnedInterval returns the interval at the posterior mean yield, or 0 while too few polls have been observed
+func (p PollPolicy) LearnedInterval(stats PollStats) time.Duration {
+	if stats.Observations < p.MinObservations || stats.MeanInterval() <= 0 {
+		return 0
+	}
+	return p.intervalFor(stats.MeanInterval(), stats.YieldProbability())
+}
+
+// SampleInterval returns an interval at a yield drawn from the posterior, or 0 while too few polls have been observed
+func (p PollPolicy) SampleInterval(stats PollStats, rng *rand.Rand) time.Duration {
+	if stats.Observations < p.MinObservations || stats.MeanInterval() <= 0 {
+		return 0
+	}
+	return p.intervalFor(stats.MeanInterval(), sampleBeta(rng, stats.Yields+1, stats.Misses+1))
+}
+
+/*
+intervalFor scales the observed interval to reach the target yield.
+
+With new items arriving at rate r, a poll after interval t finds something with
+probability 1 - e^(-rt). Solving for r from the observed yield and interval, then
+for t at the target yield, gives t' = t * ln(1-target) / ln(1-yield).
+*/
+func (p PollPolicy) intervalFor(observed time.Duration, yield float64) time.Duration {
+	var interval time.Duration
+	switch {
+	case yield >= 1:
+		interval = p.MinInterval
+	case yield <= 0:
+		interval = p.MaxInterval
+	default:
+		scale := math.Log1p(-p.TargetYield) / math.Log1p(-yield)
+		interval = time.Duration(math.Min(float64(observed)*scale, float64(p.MaxInterval)))
+	}
+
+	if interval < p.MinInterval {
+		interval = p.MinInterval
+	}
+	if p.MaxInterval > 0 && interval > p.MaxInterval {
+		interval = p.MaxInterval
+	}
+	return interval.Round(time.Second)
+}
+
+// sampleBeta draws from a Beta(a, b) distribution
+func sampleBeta(rng *rand.Rand, a, b float64) float64 {
+	x := sampleGamma(rng, a)
+	y := sampleGamma(rng, b)
+	if x+y == 0 {
+		return 0.5
+	}
+	return x / (x + y)
+}
+
+// sampleGamma draws from a Gamma(shape, 1) distribution using the Marsaglia-Tsang method
+func sampleGamma(rng *rand.Rand, shape float64) float64 {
+	if shape < 1 {
+		// Boost the shape above 1 and scale the result back down
+		return sampleGamma(rng, shape+1) * math.Pow(rng.Float64(), 1/shape)
+	}
+
+	d := shape - 1.0/3
+	c := 1 / math.Sqrt(9*d)
+	for {
+		x := rng.NormFloat64()
+		v := 1 + c*x
+		if v <= 0 {
+			continue
+		}
+		v = v * v * v
+		u := rng.Float64()
+		if math.Log(u) < 0.5*x*x+d-d*v+d*math.Log(v) {
+			return d * v
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// localDateTimeFormats lists the date filter layouts without a UTC offset, which are read in the caller's timezone
+var localDateTimeFormats = []string{
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+}
+
+// ParseDateBound parses a date filter bound given as RFC3339, as a local date and time, or as a date alone.
+// Values without a UTC offset are read in loc (UTC when nil). A date alone covers the whole day, so it
+// means the start of the day as a lower bound and the last second of the day when end is set.
+// The result is in UTC.
+func ParseDateBound(value string, loc *time.Location, end bool) (time.Time, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	if bound, err := time.Parse(time.RFC3339, value); err == nil {
+		return bound.UTC(), nil
+	}
+	if day, err := time.ParseInLocation(time.DateOnly, value, loc); err == nil {
+		if end {
+			// Step to the next midnight by calendar so days lengthened or shortened by DST end correctly
+			day = time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, loc).Add(-time.Second)
+		}
+		return day.UTC(), nil
+	}
+	for _, format := range localDateTimeFormats {
+		if bound, err := time.ParseInLocation(format, value, loc); err == nil {
+			return bound.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("expected RFC3339, YYYY-MM-DD, or YYYY-MM-DDTHH:MM[:SS]")
+}
+
+// relativeUnits maps the unit suffixes accepted by ParseSince to their length
+var relativeUnits = map[byte]time.Duration{
+	'm': time.Minute,
+	'h': time.Hour,
+	'd': 24 * time.Hour,
+	'w': 7 * 24 * time.Hour,
+}
+
+// ParseSince resolves a relative lower bound such as "30m", "2h", "3d", or "1w" before now, or the start of
+// "today" or "this_week" (weeks start on Monday) in loc (UTC when nil). Durations are truncated to the minute
+// so repeated queries share a bound. The result is in UTC.
+func ParseSince(value string, now time.Time, loc *time.Location) (time.Time, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	local := now.In(loc)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	switch value {
+	case "today":
+		return midnight.UTC(), nil
+	case "this_week":
+		daysSinceMonday := (int(local.Weekday()) + 6) % 7
+		return time.Date(local.Year(), local.Month(), local.Day()-daysSinceMonday, 0, 0, 0, 0, loc).UTC(), nil
+	}
+
+	if len(value) < 2 {
+		return time.Time{}, fmt.Errorf("expected a duration such as 2h or 3d, today, or this_week")
+	}
+	unit, ok := relativeUnits[value[len(value)-1]]
+	count, err := strconv.Atoi(value[:len(value)-1])
+	if !ok || err != nil || count <= 0 || time.Duration(count) > math.MaxInt64/unit {
+		return time.Time{}, fmt.Errorf("expected a duration such as 2h or 3d, today, or this_week")
+	}
+	return now.Add(-time.Duration(count) * unit).UTC().Truncate(time.Minute), nil
+}
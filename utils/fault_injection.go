@@ -0,0 +1,140 @@
+/*
+Package utils provides fault injection for resilience testing.
+
+Key Functions:
+  - NewFaultInjector: Creates an injector from per-target error and latency rates.
+  - SetFaultInjector: Installs the injector consulted by InjectFault; nil turns injection off.
+  - InjectFault: Delays or fails one call into a dependency at the configured rates.
+
+Faults can be injected into cache operations, Datastore calls, and feed fetches, so
+retries, circuit breakers, and fallbacks can be exercised in staging without
+breaking the dependencies themselves. Injection is off unless an injector is
+installed, and configuration refuses to install one outside development and staging.
+
+Usage:
+
+	SetFaultInjector(NewFaultInjector(map[string]float64{FaultTargetDatastore: 0.1}, nil, 0))
+	if err := InjectFault(ctx, FaultTargetDatastore); err != nil {
+		return err
+	}
+*/
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/Nexora-Open-Source/rss-feed-backend/monitoring"
+)
+
+// Fault targets, the dependencies faults can be injected into
+const (
+	FaultTargetCache     = "cache"
+	FaultTargetDatastore = "datastore"
+	FaultTargetFetch     = "fetch"
+)
+
+// FaultTargets lists every fault target
+var FaultTargets = []string{FaultTargetCache, FaultTargetDatastore, FaultTargetFetch}
+
+// ErrInjectedFault is returned by calls failed by fault injection
+var ErrInjectedFault = errors.New("injected fault")
+
+// FaultInjector delays and fails calls into dependencies at configured rates
+type FaultInjector struct {
+	mu           sync.Mutex
+	rng          *rand.Rand
+	errorRates   map[string]float64
+	latencyRates map[string]float64
+	latency      time.Duration
+}
+
+// NewFaultInjector creates an injector failing calls to each target at its error rate
+// and delaying them by latency at its latency rate; rates are fractions of calls from 0 to 1
+func NewFaultInjector(errorRates, latencyRates map[string]float64, latency time.Duration) *FaultInjector {
+	return &FaultInjector{
+		rng:          rand.New(rand.NewSource(time.Now().UnixNano())),
+		errorRates:   errorRates,
+		latencyRates: latencyRates,
+		latency:      latency,
+	}
+}
+
+// ValidateFaultRates checks that rates name known targets and are fractions from 0 to 1
+func ValidateFaultRates(rates map[string]float64) error {
+	for target, rate := range rates {
+		known := false
+		for _, candidate := range FaultTargets {
+			known = known || candidate == target
+		}
+		if !known {
+			return fmt.Errorf("unknown fault target %q: expected one of %v", target, FaultTargets)
+		}
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("fault rate for %s must be between 0 and 1", target)
+		}
+	}
+	return nil
+}
+
+// Inject delays and fails a call into target at the configured rates; a nil injector injects nothing
+func (f *FaultInjector) Inject(ctx context.Context, target string) error {
+	if f == nil {
+		return nil
+	}
+
+	f.mu.Lock()
+	delay := f.latency > 0 && f.rng.Float64() < f.latencyRates[target]
+	fail := f.rng.Float64() < f.errorRates[target]
+	f.mu.Unlock()
+
+	if delay {
+		monitoring.RecordInjectedFault(target, "latency")
+		timer := time.NewTimer(f.latency)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+	if fail {
+		monitoring.RecordInjectedFault(target, "error")
+		return fmt.Errorf("%w: %s", ErrInjectedFault, target)
+	}
+	return nil
+}
+
+var (
+	faultInjectorMu sync.RWMutex
+	faultInjector   *FaultInjector
+)
+
+// SetFaultInjector installs the injector consulted by InjectFault; nil turns injection off
+func SetFaultInjector(injector *FaultInjector) {
+	faultInjectorMu.Lock()
+	defer faultInjectorMu.Unlock()
+
+	faultInjector = injector
+}
+
+// FaultInjectionEnabled reports whether an injector is installed
+func FaultInjectionEnabled() bool {
+	faultInjectorMu.RLock()
+	defer faultInjectorMu.RUnlock()
+
+	return faultInjector != nil
+}
+
+// InjectFault delays and fails a call into target using the installed injector, if any
+func InjectFault(ctx context.Context, target string) error {
+	faultInjectorMu.RLock()
+	injector := faultInjector
+	faultInjectorMu.RUnlock()
+
+	return injector.Inject(ctx, target)
+}
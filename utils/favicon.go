@@ -0,0 +1,225 @@
+/*
+Package utils provides favicon and site icon resolution for feed sources.
+
+Key Functions:
+  - NewFaviconResolver: Creates a resolver with its own HTTP client, cache TTL, and size limit.
+  - Resolve: Finds and verifies the icon for a feed's site.
+  - IconURL: Returns a cached icon URL, resolving it in the background on a miss.
+
+Icons are looked up on the feed's site home page (apple-touch-icon first, then
+icon links), falling back to /favicon.ico. A candidate is only accepted if it
+is served as an image within the size limit. Results, including misses, are
+cached per site.
+
+Usage:
+
+	resolver := NewFaviconResolver(NewSafeHTTPClient(10*time.Second, nil), 24*time.Hour, 100*1024)
+	iconURL := resolver.IconURL("https://example.com/feed.xml")
+*/
+package utils
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// maxIconPageBytes bounds how much of a site's home page is scanned for icon links
+const maxIconPageBytes = 512 * 1024
+
+// iconRelPriority orders link rel values from most to least preferred
+var iconRelPriority = []string{"apple-touch-icon", "apple-touch-icon-precomposed", "icon", "shortcut icon"}
+
+// faviconEntry is a cached icon lookup result; an empty IconURL records a miss
+type faviconEntry struct {
+	iconURL   string
+	expiresAt time.Time
+}
+
+// FaviconResolver resolves and caches site icons for feed sources
+type FaviconResolver struct {
+	client   *http.Client
+	ttl      time.Duration
+	maxBytes int64
+
+	mu       sync.RWMutex
+	entries  map[string]faviconEntry
+	inFlight map[string]bool
+}
+
+// NewFaviconResolver creates a new favicon resolver
+func NewFaviconResolver(client *http.Client, ttl time.Duration, maxBytes int64) *FaviconResolver {
+	return &FaviconResolver{
+		client:   client,
+		ttl:      ttl,
+		maxBytes: maxBytes,
+		entries:  make(map[string]faviconEntry),
+		inFlight: make(map[string]bool),
+	}
+}
+
+// IconURL returns the cached icon URL for the feed's site. On a cache miss it
+// starts a background resolution and returns an empty string.
+func (fr *FaviconResolver) IconURL(feedURL string) string {
+	if fr == nil {
+		return ""
+	}
+
+	site, err := siteRoot(feedURL)
+	if err != nil {
+		return ""
+	}
+
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+
+	if entry, exists := fr.entries[site]; exists && time.Now().Before(entry.expiresAt) {
+		return entry.iconURL
+	}
+	if !fr.inFlight[site] {
+		fr.inFlight[site] = true
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			fr.Resolve(ctx, feedURL)
+		}()
+	}
+	return ""
+}
+
+// Resolve finds the icon for the feed's site, caches the result, and returns it
+func (fr *FaviconResolver) Resolve(ctx context.Context, feedURL string) (string, error) {
+	site, err := siteRoot(feedURL)
+	if err != nil {
+		return "", err
+	}
+
+	iconURL := ""
+	for _, candidate := range fr.iconCandidates(ctx, site) {
+		if fr.verifyIcon(ctx, candidate) {
+			iconURL = candidate
+			break
+		}
+	}
+
+	fr.mu.Lock()
+	fr.entries[site] = faviconEntry{iconURL: iconURL, expiresAt: time.Now().Add(fr.ttl)}
+	delete(fr.inFlight, site)
+	fr.mu.Unlock()
+
+	return iconURL, nil
+}
+
+// iconCandidates returns icon URLs declared on the site's home page in priority order, then /favicon.ico
+func (fr *FaviconResolver) iconCandidates(ctx context.Context, site string) []string {
+	fallback := site + "/favicon.ico"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, site+"/", nil)
+	if err != nil {
+		return []string{fallback}
+	}
+	resp, err := fr.client.Do(req)
+	if err != nil {
+		return []string{fallback}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || !strings.Contains(resp.Header.Get("Content-Type"), "html") {
+		return []string{fallback}
+	}
+
+	links := parseIconLinks(io.LimitReader(resp.Body, maxIconPageBytes), resp.Request.URL)
+
+	var candidates []string
+	for _, rel := range iconRelPriority {
+		candidates = append(candidates, links[rel]...)
+	}
+	return append(candidates, fallback)
+}
+
+// verifyIcon checks that the URL serves an image no larger than the size limit
+func (fr *FaviconResolver) verifyIcon(ctx context.Context, iconURL string) bool {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, iconURL, nil)
+	if err != nil {
+		return false
+	}
+	resp, err := fr.client.Do(req)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || resp.ContentLength > fr.maxBytes {
+		return false
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if !strings.HasPrefix(contentType, "image/") && !strings.HasPrefix(contentType, "application/octet-stream") {
+		return false
+	}
+
+	// Servers may omit Content-Length, so enforce the limit on the body itself
+	n, err := io.Copy(io.Discard, io.LimitReader(resp.Body, fr.maxBytes+1))
+	return err == nil && n > 0 && n <= fr.maxBytes
+}
+
+// parseIconLinks collects absolute http(s) icon hrefs from <link> tags, grouped by rel
+func parseIconLinks(r io.Reader, base *url.URL) map[string][]string {
+	links := make(map[string][]string)
+	tokenizer := html.NewTokenizer(r)
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return links
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := tokenizer.TagName()
+			if string(name) == "body" {
+				return links
+			}
+			if string(name) != "link" || !hasAttr {
+				continue
+			}
+
+			var rel, href string
+			for {
+				key, val, more := tokenizer.TagAttr()
+				switch string(key) {
+				case "rel":
+					rel = strings.ToLower(strings.TrimSpace(string(val)))
+				case "href":
+					href = strings.TrimSpace(string(val))
+				}
+				if !more {
+					break
+				}
+			}
+			if rel == "" || href == "" {
+				continue
+			}
+
+			ref, err := base.Parse(href)
+			if err != nil || (ref.Scheme != "http" && ref.Scheme != "https") {
+				continue
+			}
+			links[rel] = append(links[rel], ref.String())
+		}
+	}
+}
+
+// siteRoot returns the scheme and host of a feed URL
+func siteRoot(feedURL string) (string, error) {
+	u, err := url.Parse(feedURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL format: %v", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("URL must be absolute http or https")
+	}
+	return u.Scheme + "://" + u.Host, nil
+}
@@ -4,14 +4,12 @@ Package utils provides helper functions for the RSS feed backend.
 package utils
 
 import (
-	"crypto/rand"
 	"encoding/base64"
-	"time"
 )
 
-// GenerateRequestID generates a unique request ID
+// GenerateRequestID generates a unique, time-sortable request ID
 func GenerateRequestID() string {
-	return time.Now().Format("20060102150405") + "-" + RandomString(8)
+	return NewID()
 }
 
 // RandomString generates a random string of specified length
@@ -22,16 +20,7 @@ func RandomString(length int) string {
 
 	// Generate random bytes
 	bytes := make([]byte, length)
-	_, err := rand.Read(bytes)
-	if err != nil {
-		// Fallback to time-based generation if crypto/rand fails
-		const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-		b := make([]byte, length)
-		for i := range b {
-			b[i] = charset[time.Now().UnixNano()%int64(len(charset))]
-		}
-		return string(b)
-	}
+	fillRandom(bytes)
 
 	// Encode to base64 and trim to desired length
 	return base64.URLEncoding.EncodeToString(bytes)[:length]
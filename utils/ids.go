@@ -0,0 +1,208 @@
+/*
+Package utils provides pluggable, time-sortable ID generation.
+
+Key Functions:
+  - NewID: Returns a new ID from the configured generator.
+  - SetIDGenerator: Replaces the generator used by NewID.
+  - NewULIDGenerator: Creates a generator of monotonic ULIDs (the default).
+  - NewUUIDv7Generator: Creates a generator of RFC 9562 version 7 UUIDs.
+
+Both formats begin with a millisecond timestamp, so IDs sort in creation order
+as strings. Request IDs, job IDs, and other generated identifiers all come from
+NewID.
+
+Feed items are deliberately not given generated IDs. They are keyed by link, so
+an item fetched again, from any feed or instance, lands on the entity already
+stored; a fresh ID per fetch would defeat that deduplication.
+
+Usage:
+
+	SetIDGenerator(NewUUIDv7Generator())
+	id := NewID()
+*/
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	mathrand "math/rand"
+	"sync"
+	"time"
+)
+
+// IDGenerator creates unique, time-sortable identifiers
+type IDGenerator interface {
+	NewID() string
+}
+
+var (
+	idGeneratorMu sync.RWMutex
+	idGenerator   IDGenerator = NewULIDGenerator()
+)
+
+// SetIDGenerator replaces the generator used by NewID
+func SetIDGenerator(generator IDGenerator) {
+	idGeneratorMu.Lock()
+	defer idGeneratorMu.Unlock()
+
+	idGenerator = generator
+}
+
+// NewID returns a new ID from the configured generator
+func NewID() string {
+	idGeneratorMu.RLock()
+	generator := idGenerator
+	idGeneratorMu.RUnlock()
+
+	return generator.NewID()
+}
+
+// NewIDGenerator returns the generator for a format name: "ulid" or "uuidv7"
+func NewIDGenerator(format string) (IDGenerator, error) {
+	switch format {
+	case "ulid":
+		return NewULIDGenerator(), nil
+	case "uuidv7":
+		return NewUUIDv7Generator(), nil
+	}
+	return nil, fmt.Errorf("unknown ID format %q: must be \"ulid\" or \"uuidv7\"", format)
+}
+
+// crockford is the Crockford base32 alphabet used by ULIDs
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+/*
+ULIDGenerator creates 26-character ULIDs: a 48-bit millisecond timestamp followed
+by 80 random bits. IDs created within the same millisecond increment the random
+part instead of drawing new bits, so they still sort in creation order.
+*/
+type ULIDGenerator struct {
+	mu      sync.Mutex
+	lastMs  uint64
+	entropy [10]byte
+	now     func() time.Time
+}
+
+// NewULIDGenerator creates a monotonic ULID generator
+func NewULIDGenerator() *ULIDGenerator {
+	return &ULIDGenerator{now: time.Now}
+}
+
+// NewID implements IDGenerator
+func (g *ULIDGenerator) NewID() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	switch ms := uint64(g.now().UnixMilli()); {
+	case ms > g.lastMs:
+		g.lastMs = ms
+		fillRandom(g.entropy[:])
+	case !incrementBytes(g.entropy[:]):
+		// The random part overflowed; borrow the next millisecond
+		g.lastMs++
+		fillRandom(g.entropy[:])
+	}
+	ms := g.lastMs
+
+	var id [16]byte
+	id[0], id[1], id[2] = byte(ms>>40), byte(ms>>32), byte(ms>>24)
+	id[3], id[4], id[5] = byte(ms>>16), byte(ms>>8), byte(ms)
+	copy(id[6:], g.entropy[:])
+	return encodeCrockford(id)
+}
+
+// UUIDv7Generator creates RFC 9562 version 7 UUIDs, which begin with a millisecond timestamp
+type UUIDv7Generator struct {
+	mu     sync.Mutex
+	lastMs uint64
+	seq    uint16
+	now    func() time.Time
+}
+
+// NewUUIDv7Generator creates a UUIDv7 generator
+func NewUUIDv7Generator() *UUIDv7Generator {
+	return &UUIDv7Generator{now: time.Now}
+}
+
+// NewID implements IDGenerator
+func (g *UUIDv7Generator) NewID() string {
+	var id [16]byte
+	fillRandom(id[6:])
+
+	g.mu.Lock()
+	ms := uint64(g.now().UnixMilli())
+	if ms > g.lastMs {
+		// Start each millisecond's 12-bit counter at a random value in its lower half
+		g.lastMs = ms
+		g.seq = binary.BigEndian.Uint16(id[6:8]) & 0x07ff
+	} else {
+		// Keep IDs within a millisecond ordered, borrowing the next millisecond on overflow
+		g.seq++
+		if g.seq > 0x0fff {
+			g.lastMs++
+			g.seq = 0
+		}
+		ms = g.lastMs
+	}
+	seq := g.seq
+	g.mu.Unlock()
+
+	id[0], id[1], id[2] = byte(ms>>40), byte(ms>>32), byte(ms>>24)
+	id[3], id[4], id[5] = byte(ms>>16), byte(ms>>8), byte(ms)
+	id[6] = 0x70 | byte(seq>>8) // version 7
+	id[7] = byte(seq)
+	id[8] = 0x80 | id[8]&0x3f // RFC 9562 variant
+
+	var out [36]byte
+	hex.Encode(out[0:8], id[0:4])
+	out[8] = '-'
+	hex.Encode(out[9:13], id[4:6])
+	out[13] = '-'
+	hex.Encode(out[14:18], id[6:8])
+	out[18] = '-'
+	hex.Encode(out[19:23], id[8:10])
+	out[23] = '-'
+	hex.Encode(out[24:], id[10:])
+	return string(out[:])
+}
+
+// encodeCrockford encodes 128 bits as 26 Crockford base32 characters, most significant first
+func encodeCrockford(id [16]byte) string {
+	hi := binary.BigEndian.Uint64(id[0:8])
+	lo := binary.BigEndian.Uint64(id[8:16])
+
+	var out [26]byte
+	for i := 25; i >= 0; i-- {
+		out[i] = crockford[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:])
+}
+
+// incrementBytes adds one to a big-endian number, reporting false when it overflows
+func incrementBytes(b []byte) bool {
+	for i := len(b) - 1; i >= 0; i-- {
+		b[i]++
+		if b[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// fillRandom fills b from crypto/rand, falling back to a seeded math/rand source if it fails
+func fillRandom(b []byte) {
+	if _, err := rand.Read(b); err != nil {
+		fallbackRandMu.Lock()
+		fallbackRand.Read(b)
+		fallbackRandMu.Unlock()
+	}
+}
+
+var (
+	fallbackRandMu sync.Mutex
+	fallbackRand   = mathrand.New(mathrand.NewSource(time.Now().UnixNano()))
+)
@@ -0,0 +1,188 @@
+/*
+Package utils provides cross-source duplicate detection for feed items.
+
+Key Functions:
+  - CanonicalItemURL: Returns an item link without tracking parameters or cosmetic differences.
+  - TitleTokens: Reduces a title to the sorted set of words used for comparison.
+  - TitleSimilarity: Scores how alike two titles are.
+  - ClusterIDFor: Derives the cluster ID of an item that starts a new duplicate cluster.
+  - CollapseDuplicates: Keeps one item per duplicate cluster.
+
+Syndicated articles are republished by many feeds under different links and
+with lightly edited titles, so two items are treated as the same story when
+their canonical URLs match or their titles are sufficiently similar.
+
+Usage:
+
+	collapsed, counts := CollapseDuplicates(items, 0.8)
+*/
+package utils
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// minTitleTokens is the fewest words a title needs before it is compared by similarity
+const minTitleTokens = 3
+
+// trackingParams lists query parameters that identify the referrer rather than the article
+var trackingParams = map[string]bool{
+	"fbclid":  true,
+	"gclid":   true,
+	"mc_cid":  true,
+	"mc_eid":  true,
+	"cmpid":   true,
+	"ref":     true,
+	"ref_src": true,
+	"smid":    true,
+}
+
+// CanonicalItemURL returns the form of an item link used to match syndicated copies.
+// Tracking parameters, fragments, a leading "www.", the scheme, and trailing slashes are ignored.
+func CanonicalItemURL(link string) string {
+	normalized, err := NormalizeURL(link)
+	if err != nil {
+		return strings.TrimSpace(link)
+	}
+	u, err := url.Parse(normalized)
+	if err != nil {
+		return normalized
+	}
+
+	u.Scheme = "https"
+	u.Fragment = ""
+	u.RawFragment = ""
+	u.Host = strings.TrimPrefix(u.Host, "www.")
+
+	query := u.Query()
+	for param := range query {
+		if strings.HasPrefix(param, "utm_") || trackingParams[param] {
+			query.Del(param)
+		}
+	}
+	u.RawQuery = query.Encode()
+
+	if len(u.Path) > 1 {
+		u.Path = strings.TrimRight(u.Path, "/")
+		u.RawPath = ""
+	}
+	return u.String()
+}
+
+// TitleTokens returns the distinct lowercase words of a title, sorted
+func TitleTokens(title string) []string {
+	words := strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	sort.Strings(words)
+
+	tokens := words[:0]
+	for i, word := range words {
+		if i == 0 || word != words[i-1] {
+			tokens = append(tokens, word)
+		}
+	}
+	return tokens
+}
+
+// TokenSimilarity returns the Jaccard similarity of two sorted token sets from TitleTokens.
+// Titles too short to compare reliably score 0.
+func TokenSimilarity(a, b []string) float64 {
+	if len(a) < minTitleTokens || len(b) < minTitleTokens {
+		return 0
+	}
+
+	shared := 0
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] == b[j]:
+			shared++
+			i++
+			j++
+		case a[i] < b[j]:
+			i++
+		default:
+			j++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
+
+// TitleSimilarity scores how alike two titles are, from 0 (unrelated) to 1 (same words)
+func TitleSimilarity(a, b string) float64 {
+	return TokenSimilarity(TitleTokens(a), TitleTokens(b))
+}
+
+// ClusterIDFor derives a stable cluster ID from an item's canonical URL
+func ClusterIDFor(item *FeedItem) string {
+	sum := sha256.Sum256([]byte(CanonicalItemURL(item.Link)))
+	return fmt.Sprintf("%x", sum[:8])
+}
+
+// IsSameStory reports whether two items from possibly different sources carry the same article.
+// A threshold of 0 disables title matching, leaving only canonical URL and cluster ID matches.
+func (f *FeedItem) IsSameStory(other *FeedItem, threshold float64) bool {
+	if f.ClusterID != "" && f.ClusterID == other.ClusterID {
+		return true
+	}
+	if CanonicalItemURL(f.Link) == CanonicalItemURL(other.Link) {
+		return true
+	}
+	return threshold > 0 && TitleSimilarity(f.Title, other.Title) >= threshold
+}
+
+/*
+CollapseDuplicates keeps the first item of each duplicate cluster, preserving order.
+
+Items are grouped by their stored cluster ID; items stored before clustering was
+introduced are matched against the kept items by canonical URL and title
+similarity. The input items are not modified: kept items without a cluster ID are
+returned as copies with one assigned.
+
+Returns:
+  - The collapsed items.
+  - The number of items in each cluster that had duplicates, keyed by cluster ID.
+*/
+func CollapseDuplicates(items []*FeedItem, threshold float64) ([]*FeedItem, map[string]int) {
+	collapsed := make([]*FeedItem, 0, len(items))
+	sizes := make(map[string]int)
+
+	for _, item := range items {
+		clusterID := item.ClusterID
+		if clusterID == "" {
+			for _, kept := range collapsed {
+				if item.IsSameStory(kept, threshold) {
+					clusterID = kept.ClusterID
+					break
+				}
+			}
+		}
+		if clusterID == "" {
+			clusterID = ClusterIDFor(item)
+		}
+
+		sizes[clusterID]++
+		if sizes[clusterID] > 1 {
+			continue
+		}
+		if item.ClusterID != clusterID {
+			copied := *item
+			copied.ClusterID = clusterID
+			item = &copied
+		}
+		collapsed = append(collapsed, item)
+	}
+
+	duplicateCounts := make(map[string]int)
+	for clusterID, size := range sizes {
+		if size > 1 {
+			duplicateCounts[clusterID] = size
+		}
+	}
+	return collapsed, duplicateCounts
+}
@@ -0,0 +1,45 @@
+package utils
+
+import "unicode/utf8"
+
+// Size limits, in bytes, on the text fields of stored feed items. They keep every item
+// far below Datastore's 1 MiB entity limit, so one oversized item cannot fail a batch.
+const (
+	MaxTitleBytes       = 500
+	MaxDescriptionBytes = 2000
+	MaxAuthorBytes      = 100
+	// MaxLinkBytes is Datastore's limit on key names; items are keyed by link, so longer links cannot be stored
+	MaxLinkBytes = 1500
+)
+
+// TruncationMarker ends a field that was cut to fit its size limit
+const TruncationMarker = " […]"
+
+// TruncateText shortens s to at most maxBytes, ending it with TruncationMarker and never splitting a UTF-8 sequence.
+// It reports whether s was shortened.
+func TruncateText(s string, maxBytes int) (string, bool) {
+	if len(s) <= maxBytes {
+		return s, false
+	}
+	cut := maxBytes - len(TruncationMarker)
+	if cut <= 0 {
+		return "", true
+	}
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + TruncationMarker, true
+}
+
+// EnforceSizeLimits truncates text fields longer than their limits, marking the item Truncated if any were cut.
+// The link is left alone because it is the item's key; check it against MaxLinkBytes before storing.
+func (f *FeedItem) EnforceSizeLimits() bool {
+	var title, description, author bool
+	f.Title, title = TruncateText(f.Title, MaxTitleBytes)
+	f.Description, description = TruncateText(f.Description, MaxDescriptionBytes)
+	f.Author, author = TruncateText(f.Author, MaxAuthorBytes)
+	if title || description || author {
+		f.Truncated = true
+	}
+	return f.Truncated
+}
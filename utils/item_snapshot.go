@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// ItemSnapshotKind is the Datastore kind holding item content snapshots, keyed by content hash
+const ItemSnapshotKind = "ItemSnapshot"
+
+// MaxSnapshotBytes is the largest compressed snapshot stored, leaving room under Datastore's 1 MiB entity limit
+const MaxSnapshotBytes = 900 * 1024
+
+// ErrSnapshotTooLarge is returned for content that does not fit in a snapshot even when compressed
+var ErrSnapshotTooLarge = errors.New("snapshot too large")
+
+// ContentDigest returns the hex SHA-256 digest that addresses content, or "" for blank content
+func ContentDigest(content string) string {
+	if strings.TrimSpace(content) == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+/*
+ItemSnapshot is a compressed copy of an item's content exactly as its feed served it.
+
+Snapshots are addressed by ContentDigest, so identical content published by any
+number of sources is stored once, and an item's content can be rendered again
+later without fetching its origin.
+*/
+type ItemSnapshot struct {
+	Hash string `datastore:"hash" json:"hash"`
+	// Content is the gzip-compressed content
+	Content   []byte    `datastore:"content,noindex" json:"-"`
+	Size      int       `datastore:"size,noindex" json:"size"`
+	CreatedAt time.Time `datastore:"created_at,noindex" json:"created_at"`
+}
+
+// NewItemSnapshot compresses content into a snapshot; content over MaxSnapshotBytes compressed returns ErrSnapshotTooLarge
+func NewItemSnapshot(content string, createdAt time.Time) (*ItemSnapshot, error) {
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	if _, err := writer.Write([]byte(content)); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	if compressed.Len() > MaxSnapshotBytes {
+		return nil, fmt.Errorf("%w: %d bytes compressed", ErrSnapshotTooLarge, compressed.Len())
+	}
+	return &ItemSnapshot{
+		Hash:      ContentDigest(content),
+		Content:   compressed.Bytes(),
+		Size:      len(content),
+		CreatedAt: createdAt,
+	}, nil
+}
+
+// Text decompresses the snapshot, checking the content still matches its hash
+func (s *ItemSnapshot) Text() (string, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(s.Content))
+	if err != nil {
+		return "", fmt.Errorf("failed to read snapshot %s: %v", s.Hash, err)
+	}
+	defer reader.Close()
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return "", fmt.Errorf("failed to read snapshot %s: %v", s.Hash, err)
+	}
+	if ContentDigest(string(content)) != s.Hash {
+		return "", fmt.Errorf("snapshot %s does not match its hash", s.Hash)
+	}
+	return string(content), nil
+}
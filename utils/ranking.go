@@ -0,0 +1,166 @@
+/*
+Package utils provides item scoring for top stories rankings.
+
+Key Functions:
+  - NewWeightedScorer: Creates the default scorer combining recency, source, cluster size, and keyword signals.
+  - RankItems: Scores items with any ItemScorer and returns them best first.
+  - ParseWeights: Parses "key=weight" lists used for source weights and keyword boosts.
+
+The scoring algorithm is pluggable: anything implementing ItemScorer can be
+passed to RankItems in place of the weighted scorer.
+
+Usage:
+
+	scorer := NewWeightedScorer(DefaultRankingWeights(), map[string]float64{"bbc.co.uk": 1.5}, nil)
+	ranked := RankItems(items, scorer, signals)
+*/
+package utils
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RankingSignals holds what is known about an item beyond its own fields when it is scored
+type RankingSignals struct {
+	// Now is the time recency is measured from
+	Now time.Time
+	// ClusterSize is the number of items covering the same story, including the item itself
+	ClusterSize int
+}
+
+// ItemScorer scores an item for ranking; higher scores rank first
+type ItemScorer interface {
+	Score(item *FeedItem, signals RankingSignals) float64
+}
+
+// RankingWeights sets how much each signal contributes to an item's score
+type RankingWeights struct {
+	Recency     float64 `json:"recency"`
+	Source      float64 `json:"source"`
+	ClusterSize float64 `json:"cluster_size"`
+	Keyword     float64 `json:"keyword"`
+	// RecencyHalfLife is the age at which an item's recency score halves
+	RecencyHalfLife time.Duration `json:"recency_half_life"`
+}
+
+// DefaultRankingWeights returns the weights used when none are configured
+func DefaultRankingWeights() RankingWeights {
+	return RankingWeights{
+		Recency:         1.0,
+		Source:          1.0,
+		ClusterSize:     1.0,
+		Keyword:         1.0,
+		RecencyHalfLife: 6 * time.Hour,
+	}
+}
+
+// WeightedScorer scores items as a weighted sum of recency, source weight, cluster size, and keyword boosts
+type WeightedScorer struct {
+	weights       RankingWeights
+	sourceWeights map[string]float64
+	keywordBoosts map[string]float64
+}
+
+// NewWeightedScorer creates a scorer; sources are matched by domain, and sources without a weight count as 1
+func NewWeightedScorer(weights RankingWeights, sourceWeights, keywordBoosts map[string]float64) *WeightedScorer {
+	scorer := &WeightedScorer{
+		weights:       weights,
+		sourceWeights: make(map[string]float64, len(sourceWeights)),
+		keywordBoosts: make(map[string]float64, len(keywordBoosts)),
+	}
+	for source, weight := range sourceWeights {
+		scorer.sourceWeights[strings.TrimPrefix(strings.ToLower(source), "www.")] = weight
+	}
+	for keyword, boost := range keywordBoosts {
+		scorer.keywordBoosts[strings.ToLower(keyword)] = boost
+	}
+	return scorer
+}
+
+// Score implements ItemScorer
+func (s *WeightedScorer) Score(item *FeedItem, signals RankingSignals) float64 {
+	score := s.weights.Source * s.sourceWeight(ItemSource(item))
+
+	if pubTime, err := ParsePubDate(item.PubDate); err == nil && !pubTime.IsZero() && s.weights.RecencyHalfLife > 0 {
+		age := signals.Now.Sub(pubTime)
+		if age < 0 {
+			age = 0
+		}
+		score += s.weights.Recency * math.Pow(0.5, float64(age)/float64(s.weights.RecencyHalfLife))
+	}
+
+	// Wider coverage counts, with diminishing returns
+	if signals.ClusterSize > 1 {
+		score += s.weights.ClusterSize * math.Log2(float64(signals.ClusterSize))
+	}
+
+	if len(s.keywordBoosts) > 0 {
+		text := strings.ToLower(item.Title + " " + item.Description)
+		for keyword, boost := range s.keywordBoosts {
+			if strings.Contains(text, keyword) {
+				score += s.weights.Keyword * boost
+			}
+		}
+	}
+	return score
+}
+
+// sourceWeight returns the weight of the most specific configured domain the source belongs to
+func (s *WeightedScorer) sourceWeight(source string) float64 {
+	for domain := source; domain != ""; {
+		if weight, exists := s.sourceWeights[domain]; exists {
+			return weight
+		}
+		dot := strings.IndexByte(domain, '.')
+		if dot < 0 {
+			break
+		}
+		domain = domain[dot+1:]
+	}
+	return 1
+}
+
+// ScoredItem is an item with the score it was ranked by
+type ScoredItem struct {
+	Item  *FeedItem `json:"item"`
+	Score float64   `json:"score"`
+}
+
+// RankItems scores items and returns them highest score first; signalsFor supplies each item's signals
+func RankItems(items []*FeedItem, scorer ItemScorer, signalsFor func(*FeedItem) RankingSignals) []ScoredItem {
+	ranked := make([]ScoredItem, 0, len(items))
+	for _, item := range items {
+		ranked = append(ranked, ScoredItem{Item: item, Score: scorer.Score(item, signalsFor(item))})
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].Score > ranked[j].Score
+	})
+	return ranked
+}
+
+// ParseWeights parses "key=weight" entries, ignoring empty entries
+func ParseWeights(entries []string) (map[string]float64, error) {
+	weights := make(map[string]float64)
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		key, value, found := strings.Cut(entry, "=")
+		key = strings.TrimSpace(key)
+		if !found || key == "" {
+			return nil, fmt.Errorf("invalid weight %q: expected key=weight", entry)
+		}
+		weight, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid weight %q: %v", entry, err)
+		}
+		weights[key] = weight
+	}
+	return weights, nil
+}
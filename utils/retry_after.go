@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrFeedThrottled is returned when the feed server responds 429 Too Many Requests or 503 Service Unavailable
+var ErrFeedThrottled = errors.New("feed host is throttling requests")
+
+// ThrottledError describes a throttled fetch; use errors.As to read how long the host asked clients to wait
+type ThrottledError struct {
+	StatusCode int
+	// RetryAfter is the wait requested by the Retry-After header, or 0 when the header was missing or invalid
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *ThrottledError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("%v (status %d, retry after %s): %v", ErrFeedThrottled, e.StatusCode, e.RetryAfter, e.Err)
+	}
+	return fmt.Sprintf("%v (status %d): %v", ErrFeedThrottled, e.StatusCode, e.Err)
+}
+
+// Unwrap lets errors.Is match both ErrFeedThrottled and the underlying HTTP error
+func (e *ThrottledError) Unwrap() []error {
+	return []error{ErrFeedThrottled, e.Err}
+}
+
+// RetryPolicy bounds how long fetches of a throttled feed are held back
+type RetryPolicy struct {
+	// DefaultWait applies when the host sent no usable Retry-After
+	DefaultWait time.Duration `json:"default_wait"`
+	// MaxWait caps the Retry-After a host may request
+	MaxWait time.Duration `json:"max_wait"`
+	// MaxDeferrals is how many times a job is rescheduled before it fails
+	MaxDeferrals int `json:"max_deferrals"`
+}
+
+// DefaultRetryPolicy returns the policy used when none is configured
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		DefaultWait:  time.Minute,
+		MaxWait:      time.Hour,
+		MaxDeferrals: 3,
+	}
+}
+
+// Wait returns how long to hold back after a host requested the given wait (0 if it sent none)
+func (p RetryPolicy) Wait(requested time.Duration) time.Duration {
+	wait := requested
+	if wait <= 0 {
+		wait = p.DefaultWait
+	}
+	if p.MaxWait > 0 && wait > p.MaxWait {
+		wait = p.MaxWait
+	}
+	return wait
+}
+
+// IsThrottleStatus reports whether an HTTP status asks clients to back off
+func IsThrottleStatus(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode == http.StatusServiceUnavailable
+}
+
+// ParseRetryAfter parses a Retry-After header given either as delay seconds or as an HTTP date.
+// Dates in the past yield a zero wait.
+func ParseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	at, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	if wait := at.Sub(now); wait > 0 {
+		return wait, true
+	}
+	return 0, true
+}
@@ -3,6 +3,9 @@ Package utils provides utility functions for RSS feed parsing.
 
 Key Functions:
   - FetchRSSFeed: Parses an RSS feed from a URL and returns a slice of feed items.
+  - FetchRSSFeedWithContext: Same as FetchRSSFeed, but aborts when the context is cancelled.
+  - FetchRSSFeedResult: Fetches a feed with its metadata and reports where it was served from after redirects.
+  - EstimateUpdateInterval: Estimates how often a feed publishes from its items' dates.
 
 Dependencies:
   - Uses the `gofeed` library for RSS parsing.
@@ -17,9 +20,15 @@ Usage:
 package utils
 
 import (
+	"bytes"
+	"context"
 	"crypto/md5"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"net/url"
+	"sort"
 	"strings"
 	"time"
 
@@ -33,6 +42,25 @@ type FeedItem struct {
 	Description string `datastore:"description,noindex"`
 	Author      string `datastore:"author,noindex"`
 	PubDate     string `datastore:"pub_date,noindex"`
+	// ClusterID groups copies of the same article published by different sources
+	ClusterID string `datastore:"cluster_id"`
+	// SuppressedFor lists users whose ingest-time mute rules matched the item
+	SuppressedFor []string `datastore:"suppressed_for" json:"-"`
+	// Truncated reports whether a text field was cut to fit its size limit
+	Truncated bool `datastore:"truncated,noindex" json:"truncated,omitempty"`
+	// PublishedAt is PubDate parsed to a time; it is zero when PubDate could not be parsed
+	PublishedAt time.Time `datastore:"published_at" json:"-"`
+	// SchemaVersion is the layout the item was stored with; see FeedItemSchemaVersion
+	SchemaVersion int `datastore:"schema_version" json:"-"`
+	// ContentHash is the ContentDigest of the item's content as its feed served it, before sanitizing;
+	// it is empty for items without content and for items stored before hashes were recorded
+	ContentHash string `datastore:"content_hash" json:"content_hash,omitempty"`
+	// RawContent is the content ContentHash was computed from; it is kept in snapshots, never with the item
+	RawContent string `datastore:"-" json:"-"`
+	// IngestedAt is when the item was first stored; it is zero for items stored before ingest times were recorded
+	IngestedAt time.Time `datastore:"ingested_at,noindex" json:"-"`
+	// RevisedAt is when this version of the item was stored; the versions it replaced are kept as revisions
+	RevisedAt time.Time `datastore:"revised_at,noindex" json:"-"`
 }
 
 // Validate validates the FeedItem fields
@@ -42,25 +70,27 @@ func (f *FeedItem) Validate() error {
 	// Validate Title
 	if strings.TrimSpace(f.Title) == "" {
 		errors = append(errors, "title cannot be empty")
-	} else if len(f.Title) > 500 {
-		errors = append(errors, "title cannot exceed 500 characters")
+	} else if len(f.Title) > MaxTitleBytes {
+		errors = append(errors, fmt.Sprintf("title cannot exceed %d characters", MaxTitleBytes))
 	}
 
 	// Validate Link
 	if strings.TrimSpace(f.Link) == "" {
 		errors = append(errors, "link cannot be empty")
+	} else if len(f.Link) > MaxLinkBytes {
+		errors = append(errors, fmt.Sprintf("link cannot exceed %d characters", MaxLinkBytes))
 	} else if _, err := url.ParseRequestURI(f.Link); err != nil {
 		errors = append(errors, "link must be a valid URL")
 	}
 
 	// Validate Description
-	if len(f.Description) > 2000 {
-		errors = append(errors, "description cannot exceed 2000 characters")
+	if len(f.Description) > MaxDescriptionBytes {
+		errors = append(errors, fmt.Sprintf("description cannot exceed %d characters", MaxDescriptionBytes))
 	}
 
 	// Validate Author
-	if len(f.Author) > 100 {
-		errors = append(errors, "author cannot exceed 100 characters")
+	if len(f.Author) > MaxAuthorBytes {
+		errors = append(errors, fmt.Sprintf("author cannot exceed %d characters", MaxAuthorBytes))
 	}
 
 	// Validate PubDate
@@ -83,6 +113,12 @@ func (f *FeedItem) GenerateContentHash() string {
 	return fmt.Sprintf("%x", md5.Sum([]byte(content)))
 }
 
+// ContentChanged reports whether other, a stored copy of this item, was stored with different content;
+// it is false when either item has no content hash
+func (f *FeedItem) ContentChanged(other *FeedItem) bool {
+	return f.ContentHash != "" && other.ContentHash != "" && f.ContentHash != other.ContentHash
+}
+
 // IsDuplicate checks if this item is likely a duplicate of another
 func (f *FeedItem) IsDuplicate(other *FeedItem) bool {
 	// Exact link match
@@ -106,6 +142,13 @@ func (f *FeedItem) Sanitize() {
 	f.Description = strings.TrimSpace(f.Description)
 	f.Author = strings.TrimSpace(f.Author)
 	f.PubDate = strings.TrimSpace(f.PubDate)
+
+	// Normalize the link so equivalent URLs map to the same storage key
+	if f.Link != "" {
+		if normalized, err := NormalizeURL(f.Link); err == nil {
+			f.Link = normalized
+		}
+	}
 }
 
 /*
@@ -132,25 +175,220 @@ FeedItem Structure:
   - PubDate:     The publication date of the RSS feed item.
 */
 func FetchRSSFeed(url string) ([]*FeedItem, error) {
+	return FetchRSSFeedWithContext(context.Background(), url)
+}
+
+// FetchRSSFeedWithContext fetches and parses an RSS feed, aborting the request when ctx is done
+func FetchRSSFeedWithContext(ctx context.Context, url string) ([]*FeedItem, error) {
+	result, err := FetchRSSFeedResult(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	return result.Items, nil
+}
+
+// FetchResult holds the items and metadata of a fetched feed and where the feed was served from
+type FetchResult struct {
+	Items []*FeedItem
+	// Title, Description, and Language are the feed's channel-level metadata
+	Title       string
+	Description string
+	Language    string
+	// FinalURL is the normalized URL the feed was served from after following redirects
+	FinalURL string
+	// PermanentRedirect reports whether the feed moved and every redirect hop was permanent (301 or 308)
+	PermanentRedirect bool
+	// Bytes is the size of the feed document; FetchDuration and ParseDuration time its download and parsing
+	Bytes         int64
+	FetchDuration time.Duration
+	ParseDuration time.Duration
+}
+
+// maxFeedRedirects matches the net/http default redirect limit
+const maxFeedRedirects = 10
+
+var (
+	// ErrFeedGone is returned when the feed server responds 410 Gone
+	ErrFeedGone = errors.New("feed is gone")
+	// ErrFeedParked is returned when the feed redirects to a domain parking page
+	ErrFeedParked = errors.New("feed domain is parked")
+	// ErrFeedTooLarge is returned when the feed document exceeds the size limit
+	ErrFeedTooLarge = errors.New("feed document is too large")
+	// ErrFeedParse is returned when the feed document was downloaded but is not a valid feed
+	ErrFeedParse = errors.New("feed could not be parsed")
+)
+
+// parkingHosts lists domain parking services feeds are redirected to once a domain lapses
+var parkingHosts = []string{
+	"sedoparking.com",
+	"parkingcrew.net",
+	"bodis.com",
+	"above.com",
+	"dan.com",
+	"afternic.com",
+	"hugedomains.com",
+	"parklogic.com",
+	"domainmarket.com",
+}
+
+// isParkingHost reports whether the URL is served by a known domain parking service
+func isParkingHost(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, parking := range parkingHosts {
+		if host == parking || strings.HasSuffix(host, "."+parking) {
+			return true
+		}
+	}
+	return false
+}
+
+// FetchRSSFeedResult fetches and parses an RSS feed, recording any redirects followed along the way
+func FetchRSSFeedResult(ctx context.Context, feedURL string) (*FetchResult, error) {
+	return FetchRSSFeedResultWithLimit(ctx, feedURL, 0)
+}
+
+// FetchRSSFeedResultWithLimit is FetchRSSFeedResult refusing feed documents over maxBytes (0 is unbounded),
+// which also bounds the memory parsing the feed can take
+func FetchRSSFeedResultWithLimit(ctx context.Context, feedURL string, maxBytes int64) (*FetchResult, error) {
+	return FetchRSSFeedResultWithClient(ctx, defaultFeedClient, feedURL, maxBytes)
+}
+
+// defaultFeedClient fetches feeds for callers that bring no client; it refuses private and reserved
+// addresses on every connection, so neither the feed URL nor a redirect can reach internal services
+var defaultFeedClient = NewSafeHTTPClient(0, nil)
+
+// FetchRSSFeedResultWithClient is FetchRSSFeedResultWithLimit fetching through client. The client's own
+// redirect policy still applies to every hop; redirects it allows are recorded in the result.
+func FetchRSSFeedResultWithClient(ctx context.Context, client *http.Client, feedURL string, maxBytes int64) (*FetchResult, error) {
+	if err := InjectFault(ctx, FaultTargetFetch); err != nil {
+		return nil, err
+	}
+
+	result := &FetchResult{FinalURL: feedURL}
+	redirected, permanent := false, true
+
 	parser := gofeed.NewParser()
-	feed, err := parser.ParseURL(url)
+	tracked := *client
+	tracked.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if client.CheckRedirect != nil {
+			if err := client.CheckRedirect(req, via); err != nil {
+				return err
+			}
+		}
+		if len(via) >= maxFeedRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxFeedRedirects)
+		}
+		redirected = true
+		if req.Response == nil ||
+			(req.Response.StatusCode != http.StatusMovedPermanently && req.Response.StatusCode != http.StatusPermanentRedirect) {
+			permanent = false
+		}
+		result.FinalURL = req.URL.String()
+		return nil
+	}
+
+	// A lapsed domain's parking page fails to parse, so any failure after redirecting there means the feed is parked
+	fail := func(err error) (*FetchResult, error) {
+		if redirected && isParkingHost(result.FinalURL) {
+			return nil, fmt.Errorf("%w: redirected to %s", ErrFeedParked, result.FinalURL)
+		}
+		return nil, err
+	}
+
+	// Download the whole document before parsing so the two can be timed separately
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, feedURL, nil)
 	if err != nil {
 		return nil, err
 	}
+	req.Header.Set("User-Agent", parser.UserAgent)
+	start := time.Now()
+	resp, err := tracked.Do(req)
+	if err != nil {
+		return fail(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		httpErr := gofeed.HTTPError{StatusCode: resp.StatusCode, Status: resp.Status}
+		if resp.StatusCode == http.StatusGone {
+			return nil, fmt.Errorf("%w: %v", ErrFeedGone, httpErr)
+		}
+		if IsThrottleStatus(resp.StatusCode) {
+			retryAfter, _ := ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+			return nil, &ThrottledError{StatusCode: resp.StatusCode, RetryAfter: retryAfter, Err: httpErr}
+		}
+		return fail(httpErr)
+	}
+
+	reader := io.Reader(resp.Body)
+	if maxBytes > 0 {
+		reader = io.LimitReader(resp.Body, maxBytes+1)
+	}
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		return fail(err)
+	}
+	if maxBytes > 0 && int64(len(body)) > maxBytes {
+		return nil, fmt.Errorf("%w: more than %d bytes", ErrFeedTooLarge, maxBytes)
+	}
+	result.Bytes = int64(len(body))
+	result.FetchDuration = time.Since(start)
+
+	parseStart := time.Now()
+	if err := parseFeedDocument(parser, bytes.NewReader(body), result); err != nil {
+		return fail(fmt.Errorf("%w: %v", ErrFeedParse, err))
+	}
+	result.ParseDuration = time.Since(parseStart)
+
+	if redirected {
+		if normalized, err := NormalizeURL(result.FinalURL); err == nil {
+			result.FinalURL = normalized
+		}
+		result.PermanentRedirect = permanent && result.FinalURL != feedURL
+	}
+	return result, nil
+}
+
+// parseFeedDocument parses a downloaded feed document into result's metadata and items
+func parseFeedDocument(parser *gofeed.Parser, document io.Reader, result *FetchResult) error {
+	feed, err := parser.Parse(document)
+	if err != nil {
+		return err
+	}
+	result.Items = convertFeedItems(feed)
+	result.Title = strings.TrimSpace(feed.Title)
+	result.Description = strings.TrimSpace(feed.Description)
+	result.Language = strings.TrimSpace(feed.Language)
+	return nil
+}
+
+// convertFeedItems converts parsed gofeed entries into sanitized, validated feed items
+func convertFeedItems(feed *gofeed.Feed) []*FeedItem {
 
 	var items []*FeedItem
 	for _, entry := range feed.Items {
-		pubDate, _ := time.Parse(time.RFC1123Z, entry.Published)
 		item := &FeedItem{
 			Title:       entry.Title,
 			Link:        entry.Link,
 			Description: entry.Description,
 			Author:      handleAuthor(entry),
-			PubDate:     pubDate.Format(time.RFC3339),
+			PubDate:     entryPubDate(entry).Format(time.RFC3339),
+		}
+
+		// Hash the fullest content the entry carries, exactly as served, for change detection and snapshots
+		item.RawContent = entry.Content
+		if strings.TrimSpace(item.RawContent) == "" {
+			item.RawContent = entry.Description
 		}
+		item.ContentHash = ContentDigest(item.RawContent)
 
-		// Sanitize the item
+		// Sanitize the item, cutting oversized fields instead of dropping the whole item
 		item.Sanitize()
+		item.EnforceSizeLimits()
 
 		// Validate the item
 		if err := item.Validate(); err != nil {
@@ -160,7 +398,75 @@ func FetchRSSFeed(url string) ([]*FeedItem, error) {
 
 		items = append(items, item)
 	}
-	return items, nil
+	return items
+}
+
+// entryPubDate returns when a feed entry was published, falling back to when it was last updated.
+// gofeed parses RSS, Atom, and JSON Feed dates in their many layouts; entries without a usable date get the zero time.
+func entryPubDate(entry *gofeed.Item) time.Time {
+	if entry.PublishedParsed != nil {
+		return *entry.PublishedParsed
+	}
+	if entry.UpdatedParsed != nil {
+		return *entry.UpdatedParsed
+	}
+	if pubTime, err := ParsePubDate(entry.Published); err == nil {
+		return pubTime
+	}
+	return time.Time{}
+}
+
+// pubDateFormats lists the publication date layouts accepted by ParsePubDate, in order
+var pubDateFormats = []string{
+	time.RFC3339,
+	time.RFC1123Z,
+	time.RFC1123,
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05Z",
+}
+
+// ParsePubDate parses a feed item publication date in any of the common layouts
+func ParsePubDate(value string) (time.Time, error) {
+	for _, format := range pubDateFormats {
+		if pubTime, err := time.Parse(format, value); err == nil {
+			return pubTime, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized publication date %q", value)
+}
+
+// EstimateUpdateInterval returns the average time between consecutive item publications,
+// ignoring gaps over a week, or 0 when there are too few dated items to tell
+func EstimateUpdateInterval(items []*FeedItem) time.Duration {
+	var pubTimes []time.Time
+	for _, item := range items {
+		if pubTime, err := ParsePubDate(item.PubDate); err == nil {
+			pubTimes = append(pubTimes, pubTime)
+		}
+	}
+	if len(pubTimes) < 2 {
+		return 0
+	}
+
+	// Sort by publication time (newest first)
+	sort.Slice(pubTimes, func(i, j int) bool {
+		return pubTimes[i].After(pubTimes[j])
+	})
+
+	var totalDuration time.Duration
+	count := 0
+	for i := 1; i < len(pubTimes); i++ {
+		diff := pubTimes[i-1].Sub(pubTimes[i])
+		if diff > 0 && diff < 7*24*time.Hour { // Ignore unrealistic gaps
+			totalDuration += diff
+			count++
+		}
+	}
+
+	if count == 0 {
+		return 0
+	}
+	return totalDuration / time.Duration(count)
 }
 
 func handleAuthor(entry *gofeed.Item) string {
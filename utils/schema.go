@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"cloud.google.com/go/datastore"
+	"github.com/Nexora-Open-Source/rss-feed-backend/monitoring"
+)
+
+/*
+Stored entities carry a schema_version property so their layout can change without
+rewriting every entity at once. Entities are upgraded lazily: one loaded at an older
+version is migrated in memory as it is read, and stored at the current version the
+next time it is written. Entities written before versioning load as version 0.
+
+To change a layout, append a migration that upgrades an entity from the previous
+version; the current version is the number of migrations.
+*/
+
+// feedItemMigrations upgrade a FeedItem loaded at version i to version i+1
+var feedItemMigrations = []func(*FeedItem){
+	// 1: PublishedAt holds the publication date parsed from PubDate, so items can be ordered by time
+	func(f *FeedItem) {
+		if pubTime, err := ParsePubDate(f.PubDate); err == nil {
+			f.PublishedAt = pubTime.UTC()
+		}
+	},
+}
+
+// FeedItemSchemaVersion is the schema version at which feed items are stored
+var FeedItemSchemaVersion = len(feedItemMigrations)
+
+// Upgrade migrates the item to FeedItemSchemaVersion, returning the version it started at
+func (f *FeedItem) Upgrade() int {
+	from := f.SchemaVersion
+	for f.SchemaVersion < len(feedItemMigrations) {
+		feedItemMigrations[f.SchemaVersion](f)
+		f.SchemaVersion++
+	}
+	return from
+}
+
+// Load implements datastore.PropertyLoadSaver, upgrading items stored at an older schema version
+func (f *FeedItem) Load(properties []datastore.Property) error {
+	if err := datastore.LoadStruct(f, properties); err != nil {
+		return err
+	}
+	if from := f.Upgrade(); from != f.SchemaVersion {
+		monitoring.RecordSchemaMigration("FeedItem", from)
+	}
+	return nil
+}
+
+// Save implements datastore.PropertyLoadSaver, storing the item at the current schema version
+func (f *FeedItem) Save() ([]datastore.Property, error) {
+	// Upgrade a copy so saving never modifies an item other goroutines may be reading
+	upgraded := *f
+	upgraded.Upgrade()
+	return datastore.SaveStruct(&upgraded)
+}
@@ -0,0 +1,146 @@
+/*
+Package utils provides story clustering for feed items.
+
+Key Functions:
+  - GroupStories: Groups items from different sources that cover the same story.
+  - ItemSource: Returns the site an item was published by.
+
+Stories are looser than duplicates: two items belong to the same story when
+their titles share enough words and they were published within a time window of
+each other, even if the articles themselves differ.
+
+Usage:
+
+	groups := GroupStories(items, 0.5, 48*time.Hour)
+*/
+package utils
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// StoryGroup is a set of items from one or more sources covering the same story
+type StoryGroup struct {
+	ID             string      `json:"id"`
+	Representative *FeedItem   `json:"representative"`
+	Items          []*FeedItem `json:"items"`
+	ItemCount      int         `json:"item_count"`
+	SourceCount    int         `json:"source_count"`
+	Sources        []string    `json:"sources"`
+	FirstPublished time.Time   `json:"first_published"`
+	LastPublished  time.Time   `json:"last_published"`
+}
+
+// storyMember is an item placed in a story along with its comparison data
+type storyMember struct {
+	item   *FeedItem
+	at     time.Time
+	tokens []string
+}
+
+// ItemSource returns the host an item was published on, without a leading "www."
+func ItemSource(item *FeedItem) string {
+	u, err := url.Parse(item.Link)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
+
+/*
+GroupStories groups items covering the same story.
+
+An item joins a story when it shares a cluster ID with one of the story's items,
+or when its title is at least threshold similar to a story item published no more
+than window earlier. Items without a publication date are ignored.
+
+Each story's representative is its earliest item, and its items are listed newest
+first. Stories are ordered by source count, then by most recent publication.
+*/
+func GroupStories(items []*FeedItem, threshold float64, window time.Duration) []*StoryGroup {
+	var dated []storyMember
+	for _, item := range items {
+		at, err := ParsePubDate(item.PubDate)
+		if err != nil || at.IsZero() {
+			continue
+		}
+		dated = append(dated, storyMember{item: item, at: at, tokens: TitleTokens(item.Title)})
+	}
+	sort.SliceStable(dated, func(i, j int) bool {
+		return dated[i].at.Before(dated[j].at)
+	})
+
+	var stories [][]storyMember
+	for _, candidate := range dated {
+		placed := false
+		for i, story := range stories {
+			if belongsToStory(candidate, story, threshold, window) {
+				stories[i] = append(story, candidate)
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			stories = append(stories, []storyMember{candidate})
+		}
+	}
+
+	groups := make([]*StoryGroup, 0, len(stories))
+	for _, story := range stories {
+		groups = append(groups, newStoryGroup(story))
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].SourceCount != groups[j].SourceCount {
+			return groups[i].SourceCount > groups[j].SourceCount
+		}
+		return groups[i].LastPublished.After(groups[j].LastPublished)
+	})
+	return groups
+}
+
+// belongsToStory reports whether candidate, published no earlier than any member, matches the story
+func belongsToStory(candidate storyMember, story []storyMember, threshold float64, window time.Duration) bool {
+	for _, member := range story {
+		if candidate.item.ClusterID != "" && candidate.item.ClusterID == member.item.ClusterID {
+			return true
+		}
+		if window > 0 && candidate.at.Sub(member.at) > window {
+			continue
+		}
+		if threshold > 0 && TokenSimilarity(candidate.tokens, member.tokens) >= threshold {
+			return true
+		}
+	}
+	return false
+}
+
+// newStoryGroup summarizes a story whose members are ordered oldest first
+func newStoryGroup(story []storyMember) *StoryGroup {
+	representative := story[0].item
+	group := &StoryGroup{
+		ID:             representative.ClusterID,
+		Representative: representative,
+		Items:          make([]*FeedItem, 0, len(story)),
+		ItemCount:      len(story),
+		FirstPublished: story[0].at,
+		LastPublished:  story[len(story)-1].at,
+	}
+	if group.ID == "" {
+		group.ID = ClusterIDFor(representative)
+	}
+
+	sources := make(map[string]bool)
+	for i := len(story) - 1; i >= 0; i-- {
+		group.Items = append(group.Items, story[i].item)
+		if source := ItemSource(story[i].item); source != "" && !sources[source] {
+			sources[source] = true
+			group.Sources = append(group.Sources, source)
+		}
+	}
+	sort.Strings(group.Sources)
+	group.SourceCount = len(group.Sources)
+	return group
+}
@@ -0,0 +1,201 @@
+/*
+Package utils provides URL normalization for the RSS feed backend.
+
+Key Functions:
+  - NormalizeURL: Returns the canonical form of a URL string.
+  - NormalizeParsedURL: Canonicalizes an already parsed URL in place.
+
+Normalization converts internationalized hosts to punycode, lowercases the
+scheme and host, drops default ports, removes dot segments from the path, and
+rewrites percent-encoding to its canonical form so that equivalent URLs share
+the same validation result, cache key, and storage key.
+
+Usage:
+
+	normalized, err := NormalizeURL("HTTPS://Bücher.Example:443/a/./b/%7euser")
+	// normalized == "https://xn--bcher-kva.example/a/b/~user"
+*/
+package utils
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// hostProfile maps internationalized hosts for lookup while tolerating
+// underscores, which appear in real-world feed hostnames
+var hostProfile = idna.New(
+	idna.MapForLookup(),
+	idna.BidiRule(),
+	idna.StrictDomainName(false),
+)
+
+// defaultPorts maps schemes to the port that may be omitted from the host
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+// NormalizeURL parses and canonicalizes a URL string
+func NormalizeURL(rawURL string) (string, error) {
+	parsedURL, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return "", fmt.Errorf("invalid URL format: %v", err)
+	}
+	if err := NormalizeParsedURL(parsedURL); err != nil {
+		return "", err
+	}
+	return parsedURL.String(), nil
+}
+
+// NormalizeParsedURL canonicalizes the scheme, host, path, and query of a parsed URL in place
+func NormalizeParsedURL(u *url.URL) error {
+	u.Scheme = strings.ToLower(u.Scheme)
+
+	if u.Host != "" {
+		host, err := normalizeHost(u.Host, u.Scheme)
+		if err != nil {
+			return err
+		}
+		u.Host = host
+	}
+
+	// Canonicalize the path using its escaped form so encoded delimiters survive
+	escapedPath := removeDotSegments(normalizePercentEncoding(u.EscapedPath()))
+	if escapedPath == "" && u.Host != "" {
+		escapedPath = "/"
+	}
+	path, err := url.PathUnescape(escapedPath)
+	if err != nil {
+		return fmt.Errorf("invalid URL path: %v", err)
+	}
+	u.Path = path
+	u.RawPath = escapedPath
+	if u.EscapedPath() != escapedPath {
+		// RawPath is only honoured when it is a valid encoding of Path
+		u.RawPath = ""
+	}
+
+	u.RawQuery = normalizePercentEncoding(u.RawQuery)
+
+	return nil
+}
+
+// normalizeHost lowercases the host, converts it to punycode, and drops the default port
+func normalizeHost(hostport, scheme string) (string, error) {
+	host, port := hostport, ""
+	if h, p, err := net.SplitHostPort(hostport); err == nil {
+		host, port = h, p
+	}
+	host = strings.TrimSuffix(strings.TrimSuffix(strings.TrimPrefix(host, "["), "]"), ".")
+
+	if ip := net.ParseIP(stripZone(host)); ip == nil {
+		asciiHost, err := hostProfile.ToASCII(host)
+		if err != nil {
+			return "", fmt.Errorf("invalid internationalized host: %v", err)
+		}
+		host = asciiHost
+	}
+	host = strings.ToLower(host)
+
+	if port == defaultPorts[scheme] {
+		port = ""
+	}
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port != "" {
+		host += ":" + port
+	}
+	return host, nil
+}
+
+// stripZone removes an IPv6 zone identifier (fe80::1%eth0) from a host
+func stripZone(host string) string {
+	if i := strings.LastIndex(host, "%"); i != -1 {
+		return host[:i]
+	}
+	return host
+}
+
+// removeDotSegments resolves "." and ".." segments as described in RFC 3986 section 5.2.4
+func removeDotSegments(path string) string {
+	if !strings.Contains(path, ".") {
+		return path
+	}
+
+	segments := strings.Split(path, "/")
+	output := make([]string, 0, len(segments))
+	for i, segment := range segments {
+		last := i == len(segments)-1
+		switch segment {
+		case ".":
+			if last {
+				output = append(output, "")
+			}
+		case "..":
+			if len(output) > 1 {
+				output = output[:len(output)-1]
+			}
+			if last {
+				output = append(output, "")
+			}
+		default:
+			output = append(output, segment)
+		}
+	}
+	return strings.Join(output, "/")
+}
+
+// normalizePercentEncoding uppercases percent-encoded octets and decodes
+// those that represent unreserved characters (RFC 3986 section 6.2.2)
+func normalizePercentEncoding(s string) string {
+	if !strings.Contains(s, "%") {
+		return s
+	}
+
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] == '%' && i+2 < len(s) && isHex(s[i+1]) && isHex(s[i+2]) {
+			octet := unhex(s[i+1])<<4 | unhex(s[i+2])
+			if isUnreserved(octet) {
+				b.WriteByte(octet)
+			} else {
+				b.WriteByte('%')
+				b.WriteString(strings.ToUpper(s[i+1 : i+3]))
+			}
+			i += 2
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// isUnreserved reports whether c is an RFC 3986 unreserved character
+func isUnreserved(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+// isHex reports whether c is a hexadecimal digit
+func isHex(c byte) bool {
+	return ('0' <= c && c <= '9') || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F')
+}
+
+// unhex converts a hexadecimal digit to its value
+func unhex(c byte) byte {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	default:
+		return c - 'A' + 10
+	}
+}
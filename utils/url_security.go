@@ -0,0 +1,158 @@
+/*
+Package utils provides URL security helpers for the RSS feed backend.
+
+Key Functions:
+  - IsBlockedHost: Reports whether a host targets a private, reserved, or metadata address.
+  - ParseCIDRs: Parses a list of CIDR strings into networks for additional blocking.
+  - ValidateFetchURL: Checks that a URL is HTTP(S) and does not target a blocked host.
+
+Usage:
+
+	extra, err := ParseCIDRs([]string{"203.0.113.0/24"})
+	if IsBlockedHost(parsedURL.Host, extra) {
+	    return fmt.Errorf("access to private networks and localhost is not allowed")
+	}
+*/
+package utils
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// blockedHostnames lists hostnames that always resolve to internal services
+var blockedHostnames = []string{
+	"localhost",
+	"metadata",
+	"metadata.google.internal",
+	"instance-data",
+}
+
+// defaultBlockedCIDRs lists reserved ranges not covered by the net.IP helpers
+var defaultBlockedCIDRs = mustParseCIDRs([]string{
+	"0.0.0.0/8",      // "This" network
+	"100.64.0.0/10",  // Carrier-grade NAT
+	"169.254.0.0/16", // IPv4 link-local, including 169.254.169.254 metadata
+	"192.0.0.0/24",   // IETF protocol assignments
+	"198.18.0.0/15",  // Benchmarking
+	"fc00::/7",       // IPv6 unique local addresses, including fd00:ec2::254 metadata
+	"fe80::/10",      // IPv6 link-local
+	"64:ff9b::/96",   // NAT64 well-known prefix
+	"2001:db8::/32",  // IPv6 documentation
+	"100::/64",       // IPv6 discard-only
+	"::/128",         // IPv6 unspecified
+	"::1/128",        // IPv6 loopback
+	"240.0.0.0/4",    // Reserved, including broadcast
+})
+
+// ParseCIDRs parses a list of CIDR strings, ignoring empty entries
+func ParseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+	for _, cidr := range cidrs {
+		cidr = strings.TrimSpace(cidr)
+		if cidr == "" {
+			continue
+		}
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q: %v", cidr, err)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// mustParseCIDRs parses built-in CIDR lists and panics on programmer error
+func mustParseCIDRs(cidrs []string) []*net.IPNet {
+	networks, err := ParseCIDRs(cidrs)
+	if err != nil {
+		panic(err)
+	}
+	return networks
+}
+
+// IsBlockedIP reports whether the IP is loopback, private, link-local, reserved,
+// or contained in one of the additional blocked networks
+func IsBlockedIP(ip net.IP, additional []*net.IPNet) bool {
+	if ip == nil {
+		return false
+	}
+
+	// Unmap IPv4-mapped IPv6 addresses (::ffff:10.0.0.1) so IPv4 rules apply
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4
+	}
+
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return true
+	}
+
+	for _, network := range defaultBlockedCIDRs {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	for _, network := range additional {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsBlockedHost reports whether a URL host (optionally with port) targets
+// localhost, a metadata endpoint, or a blocked IP range
+func IsBlockedHost(host string, additional []*net.IPNet) bool {
+	hostOnly := strings.ToLower(strings.TrimSpace(host))
+
+	// Remove port if present; SplitHostPort also strips IPv6 brackets
+	if h, _, err := net.SplitHostPort(hostOnly); err == nil {
+		hostOnly = h
+	}
+	hostOnly = strings.TrimSuffix(strings.TrimPrefix(hostOnly, "["), "]")
+	hostOnly = strings.TrimSuffix(hostOnly, ".")
+
+	// Drop IPv6 zone identifiers (fe80::1%eth0)
+	if i := strings.LastIndex(hostOnly, "%"); i != -1 {
+		hostOnly = hostOnly[:i]
+	}
+
+	if hostOnly == "" {
+		return true
+	}
+
+	for _, name := range blockedHostnames {
+		if hostOnly == name || strings.HasSuffix(hostOnly, "."+name) {
+			return true
+		}
+	}
+
+	if ip := net.ParseIP(hostOnly); ip != nil {
+		return IsBlockedIP(ip, additional)
+	}
+
+	return false
+}
+
+// ValidateFetchURL checks that a URL may be fetched: it must be an absolute HTTP(S) URL whose host is not blocked
+func ValidateFetchURL(rawURL string, additional []*net.IPNet) error {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid URL format: %v", err)
+	}
+	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
+		return fmt.Errorf("only HTTP and HTTPS URLs are allowed")
+	}
+	if parsedURL.Host == "" {
+		return fmt.Errorf("URL must have a valid host")
+	}
+	if IsBlockedHost(parsedURL.Host, additional) {
+		return fmt.Errorf("access to private networks and localhost is not allowed")
+	}
+	return nil
+}
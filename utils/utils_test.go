@@ -1,10 +1,26 @@
 package utils
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"math"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
+	"cloud.google.com/go/datastore"
 	"github.com/mmcdole/gofeed"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGenerateRequestID(t *testing.T) {
@@ -16,9 +32,47 @@ func TestGenerateRequestID(t *testing.T) {
 	assert.NotEmpty(t, id2)
 	assert.NotEqual(t, id1, id2)
 
-	// Test that IDs are expected length (14 timestamp + 1 dash + 8 random = 23)
-	assert.Equal(t, 23, len(id1))
-	assert.Equal(t, 23, len(id2))
+	// Request IDs are 26-character ULIDs that sort in creation order
+	assert.Equal(t, 26, len(id1))
+	assert.Equal(t, 26, len(id2))
+	assert.Less(t, id1, id2)
+}
+
+func TestULIDGenerator(t *testing.T) {
+	generator := NewULIDGenerator()
+	now := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+	generator.now = func() time.Time { return now }
+
+	// IDs within one millisecond, and after the clock steps back, still increase
+	previous := generator.NewID()
+	for i := 0; i < 1000; i++ {
+		if i == 500 {
+			now = now.Add(-time.Second)
+		}
+		id := generator.NewID()
+		assert.Less(t, previous, id)
+		previous = id
+	}
+
+	// The first 10 characters encode the timestamp
+	later := NewULIDGenerator()
+	later.now = func() time.Time { return now.Add(time.Hour) }
+	assert.Less(t, previous[:10], later.NewID()[:10])
+	assert.Regexp(t, `^[0-9A-HJKMNP-TV-Z]{26}$`, previous)
+}
+
+func TestUUIDv7Generator(t *testing.T) {
+	generator := NewUUIDv7Generator()
+	previous := ""
+	for i := 0; i < 1000; i++ {
+		id := generator.NewID()
+		assert.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, id)
+		assert.Less(t, previous, id)
+		previous = id
+	}
+
+	_, err := NewIDGenerator("uuidv4")
+	assert.Error(t, err)
 }
 
 func TestRandomString(t *testing.T) {
@@ -111,6 +165,455 @@ func TestFetchRSSFeedInvalidURL(t *testing.T) {
 	}
 }
 
+func TestFetchRSSFeedResultRedirects(t *testing.T) {
+	const feed = `<?xml version="1.0"?><rss version="2.0"><channel><title>Test</title>
+<item><title>Item 1</title><link>https://example.com/1</link></item></channel></rss>`
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/feed.xml", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(feed))
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/feed.xml", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/moved-twice", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/moved", http.StatusPermanentRedirect)
+	})
+	mux.HandleFunc("/temporary", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/moved", http.StatusFound)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tests := []struct {
+		path      string
+		permanent bool
+	}{
+		{"/feed.xml", false},
+		{"/moved", true},
+		{"/moved-twice", true},
+		{"/temporary", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			result, err := FetchRSSFeedResultWithClient(context.Background(), server.Client(), server.URL+tt.path, 0)
+			assert.NoError(t, err)
+			assert.Len(t, result.Items, 1)
+			assert.Equal(t, server.URL+"/feed.xml", result.FinalURL)
+			assert.Equal(t, tt.permanent, result.PermanentRedirect)
+			assert.Equal(t, int64(len(feed)), result.Bytes)
+		})
+	}
+}
+
+func TestFeedItemSchemaUpgrade(t *testing.T) {
+	// An item stored before versioning has neither schema_version nor published_at
+	legacy := []datastore.Property{
+		{Name: "title", Value: "Legacy", NoIndex: true},
+		{Name: "link", Value: "https://example.com/legacy"},
+		{Name: "pub_date", Value: "Mon, 02 Jan 2006 15:04:05 +0100", NoIndex: true},
+	}
+
+	var item FeedItem
+	assert.NoError(t, item.Load(legacy))
+	assert.Equal(t, FeedItemSchemaVersion, item.SchemaVersion)
+	assert.Equal(t, time.Date(2006, 1, 2, 14, 4, 5, 0, time.UTC), item.PublishedAt)
+
+	// Saving stamps the current version without modifying the item
+	fresh := &FeedItem{Title: "Fresh", Link: "https://example.com/fresh", PubDate: "2024-01-10T06:00:00Z"}
+	properties, err := fresh.Save()
+	assert.NoError(t, err)
+	assert.Zero(t, fresh.SchemaVersion)
+	saved := make(map[string]interface{})
+	for _, property := range properties {
+		saved[property.Name] = property.Value
+	}
+	assert.Equal(t, int64(FeedItemSchemaVersion), saved["schema_version"])
+	assert.Equal(t, time.Date(2024, 1, 10, 6, 0, 0, 0, time.UTC), saved["published_at"])
+}
+
+func TestEnforceSizeLimits(t *testing.T) {
+	// A multi-byte rune straddling the cut point is dropped whole
+	truncated, cut := TruncateText(strings.Repeat("é", 10), 10)
+	assert.True(t, cut)
+	assert.Equal(t, "éé"+TruncationMarker, truncated)
+	assert.LessOrEqual(t, len(truncated), 10)
+
+	item := &FeedItem{
+		Title:       "Short title",
+		Link:        "https://example.com/1",
+		Description: strings.Repeat("word ", 200000),
+	}
+	assert.True(t, item.EnforceSizeLimits())
+	assert.Equal(t, "Short title", item.Title)
+	assert.Len(t, item.Description, MaxDescriptionBytes)
+	assert.True(t, strings.HasSuffix(item.Description, TruncationMarker))
+	assert.NoError(t, item.Validate())
+
+	// Oversized items from a feed are kept with their fields cut rather than dropped
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<?xml version="1.0"?><rss version="2.0"><channel><title>Test</title>
+<item><title>Huge</title><link>https://example.com/huge</link><description>` + strings.Repeat("x", 1<<20) + `</description></item></channel></rss>`))
+	}))
+	defer server.Close()
+
+	result, err := FetchRSSFeedResultWithClient(context.Background(), server.Client(), server.URL, 0)
+	assert.NoError(t, err)
+	if assert.Len(t, result.Items, 1) {
+		assert.True(t, result.Items[0].Truncated)
+		assert.Len(t, result.Items[0].Description, MaxDescriptionBytes)
+	}
+}
+
+func TestFaviconResolverResolve(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\nicon")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<html><head>
+<link rel="icon" href="/small.png">
+<link rel="apple-touch-icon" href="/too-large.png">
+</head><body></body></html>`))
+	})
+	mux.HandleFunc("/small.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(png)
+	})
+	mux.HandleFunc("/too-large.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(make([]byte, 1024))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	resolver := NewFaviconResolver(server.Client(), time.Hour, 512)
+
+	// The preferred apple-touch-icon exceeds the size limit, so the icon link is used
+	iconURL, err := resolver.Resolve(context.Background(), server.URL+"/feed.xml")
+	assert.NoError(t, err)
+	assert.Equal(t, server.URL+"/small.png", iconURL)
+	assert.Equal(t, iconURL, resolver.IconURL(server.URL+"/other-feed.xml"))
+}
+
+func TestFaviconResolverFallsBackToFaviconICO(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+	mux.HandleFunc("/favicon.ico", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/x-icon")
+		w.Write([]byte{0, 0, 1, 0})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	resolver := NewFaviconResolver(server.Client(), time.Hour, 1024)
+	iconURL, err := resolver.Resolve(context.Background(), server.URL+"/feed.xml")
+	assert.NoError(t, err)
+	assert.Equal(t, server.URL+"/favicon.ico", iconURL)
+}
+
+func TestSafeHTTPClientBlocksPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	// httptest listens on loopback, which the safe client must refuse
+	_, err := NewSafeHTTPClient(time.Second, nil).Get(server.URL)
+	assert.ErrorIs(t, err, ErrBlockedAddress)
+
+	// Feeds are fetched through the safe client unless the caller brings its own
+	_, err = FetchRSSFeedResult(context.Background(), server.URL+"/feed.xml")
+	assert.ErrorIs(t, err, ErrBlockedAddress)
+
+	resolver := NewFaviconResolver(NewSafeHTTPClient(time.Second, nil), time.Hour, 1024)
+	iconURL, err := resolver.Resolve(context.Background(), server.URL+"/feed.xml")
+	assert.NoError(t, err)
+	assert.Empty(t, iconURL)
+}
+
+func TestFetchRSSFeedResultDeadFeeds(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGone)
+	}))
+	defer server.Close()
+
+	_, err := FetchRSSFeedResultWithClient(context.Background(), server.Client(), server.URL+"/feed.xml", 0)
+	assert.ErrorIs(t, err, ErrFeedGone)
+
+	assert.True(t, isParkingHost("https://www.sedoparking.com/landing?domain=example.com"))
+	assert.False(t, isParkingHost("https://example.com/feed.xml"))
+}
+
+func TestFetchRSSFeedResultWithLimit(t *testing.T) {
+	feed := `<?xml version="1.0"?><rss version="2.0"><channel><title>Big</title>` +
+		`<item><title>Post</title><link>https://example.com/post</link><description>` + strings.Repeat("x", 2048) + `</description></item>` +
+		`</channel></rss>`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(feed))
+	}))
+	defer server.Close()
+
+	_, err := FetchRSSFeedResultWithClient(context.Background(), server.Client(), server.URL+"/feed.xml", 1024)
+	assert.ErrorIs(t, err, ErrFeedTooLarge)
+
+	result, err := FetchRSSFeedResultWithClient(context.Background(), server.Client(), server.URL+"/feed.xml", int64(len(feed)))
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(len(feed)), result.Bytes)
+	}
+}
+
+func TestFetchRSSFeedResultThrottled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	_, err := FetchRSSFeedResultWithClient(context.Background(), server.Client(), server.URL+"/feed.xml", 0)
+	assert.ErrorIs(t, err, ErrFeedThrottled)
+	var throttled *ThrottledError
+	if assert.ErrorAs(t, err, &throttled) {
+		assert.Equal(t, http.StatusTooManyRequests, throttled.StatusCode)
+		assert.Equal(t, 2*time.Minute, throttled.RetryAfter)
+	}
+
+	now := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+	wait, ok := ParseRetryAfter("Wed, 10 Jan 2024 12:05:00 GMT", now)
+	assert.True(t, ok)
+	assert.Equal(t, 5*time.Minute, wait)
+	_, ok = ParseRetryAfter("soon", now)
+	assert.False(t, ok)
+
+	policy := RetryPolicy{DefaultWait: time.Minute, MaxWait: time.Hour}
+	assert.Equal(t, time.Minute, policy.Wait(0))
+	assert.Equal(t, time.Hour, policy.Wait(48*time.Hour))
+}
+
+func TestIsBlockedHost(t *testing.T) {
+	extra, err := ParseCIDRs([]string{"203.0.113.0/24", "2001:db9::/32"})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		host    string
+		blocked bool
+	}{
+		{"public hostname", "example.com", false},
+		{"public hostname with port", "example.com:8443", false},
+		{"public IPv4", "93.184.216.34", false},
+		{"public IPv6", "[2606:2800:220:1:248:1893:25c8:1946]", false},
+		{"localhost", "localhost", true},
+		{"localhost with port", "localhost:8080", true},
+		{"localhost trailing dot", "localhost.", true},
+		{"loopback IPv4", "127.0.0.1", true},
+		{"loopback IPv4 range", "127.1.2.3", true},
+		{"loopback IPv6", "[::1]", true},
+		{"loopback IPv6 with port", "[::1]:8080", true},
+		{"unspecified IPv4", "0.0.0.0", true},
+		{"unspecified IPv6", "[::]", true},
+		{"private 10/8", "10.0.0.1", true},
+		{"private 172.16/12", "172.16.5.4", true},
+		{"private 192.168/16", "192.168.1.1", true},
+		{"carrier-grade NAT", "100.64.0.1", true},
+		{"metadata IPv4", "169.254.169.254", true},
+		{"metadata IPv4 with port", "169.254.169.254:80", true},
+		{"metadata hostname", "metadata.google.internal", true},
+		{"metadata hostname uppercase", "METADATA.GOOGLE.INTERNAL", true},
+		{"IPv6 unique local", "[fc00::1]", true},
+		{"IPv6 unique local fd", "[fd12:3456:789a::1]", true},
+		{"IPv6 metadata", "[fd00:ec2::254]", true},
+		{"IPv6 link-local", "[fe80::1]", true},
+		{"IPv6 link-local with zone", "[fe80::1%25eth0]", true},
+		{"IPv4-mapped loopback", "[::ffff:127.0.0.1]", true},
+		{"IPv4-mapped private", "[::ffff:10.0.0.1]", true},
+		{"IPv4-mapped metadata", "[::ffff:169.254.169.254]", true},
+		{"IPv4-mapped public", "[::ffff:93.184.216.34]", false},
+		{"multicast", "224.0.0.1", true},
+		{"broadcast", "255.255.255.255", true},
+		{"additional IPv4 CIDR", "203.0.113.10", true},
+		{"additional IPv6 CIDR", "[2001:db9::1]", true},
+		{"empty host", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.blocked, IsBlockedHost(tt.host, extra))
+		})
+	}
+}
+
+func TestParseCIDRs(t *testing.T) {
+	networks, err := ParseCIDRs([]string{" 10.1.0.0/16 ", "", "fd00::/8"})
+	assert.NoError(t, err)
+	assert.Len(t, networks, 2)
+
+	_, err = ParseCIDRs([]string{"not-a-cidr"})
+	assert.Error(t, err)
+}
+
+func TestNormalizeURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"already canonical", "https://example.com/feed.xml", "https://example.com/feed.xml"},
+		{"uppercase scheme and host", "HTTPS://Example.COM/Feed.xml", "https://example.com/Feed.xml"},
+		{"unicode host to punycode", "https://bücher.example/rss", "https://xn--bcher-kva.example/rss"},
+		{"punycode host unchanged", "https://xn--bcher-kva.example/rss", "https://xn--bcher-kva.example/rss"},
+		{"fullwidth host", "https://ｅｘａｍｐｌｅ.com/rss", "https://example.com/rss"},
+		{"default https port", "https://example.com:443/rss", "https://example.com/rss"},
+		{"default http port", "http://example.com:80/rss", "http://example.com/rss"},
+		{"non-default port kept", "https://example.com:8443/rss", "https://example.com:8443/rss"},
+		{"trailing dot in host", "https://example.com./rss", "https://example.com/rss"},
+		{"empty path", "https://example.com", "https://example.com/"},
+		{"dot segments", "https://example.com/a/./b/../c/feed", "https://example.com/a/c/feed"},
+		{"lowercase percent-encoding", "https://example.com/a%2fb", "https://example.com/a%2Fb"},
+		{"unreserved percent-encoding", "https://example.com/%7euser/%46eed", "https://example.com/~user/Feed"},
+		{"encoded dot segment", "https://example.com/a/%2E%2E/feed", "https://example.com/feed"},
+		{"query percent-encoding", "https://example.com/rss?q=a%2fb&x=%7e", "https://example.com/rss?q=a%2Fb&x=~"},
+		{"unicode path", "https://example.com/ü", "https://example.com/%C3%BC"},
+		{"IPv6 host", "http://[2001:DB8::1]:80/rss", "http://[2001:db8::1]/rss"},
+		{"unicode digits resolve to IP", "http://①②⑦.0.0.1/rss", "http://127.0.0.1/rss"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NormalizeURL(tt.input)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestNormalizeURLEquivalentFormsMatch(t *testing.T) {
+	forms := []string{
+		"https://Bücher.Example/Feed/%7Euser",
+		"HTTPS://xn--bcher-kva.example:443/Feed/~user",
+		"https://BÜCHER.example/Feed/./%7euser",
+	}
+
+	expected, err := NormalizeURL(forms[0])
+	assert.NoError(t, err)
+	for _, form := range forms[1:] {
+		normalized, err := NormalizeURL(form)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, normalized, form)
+	}
+}
+
+func TestFeedItemSanitizeNormalizesLink(t *testing.T) {
+	item := &FeedItem{Link: "  HTTPS://Example.com:443/a/../post%7e1  "}
+	item.Sanitize()
+	assert.Equal(t, "https://example.com/post~1", item.Link)
+}
+
+func TestURLSignerRoundTrip(t *testing.T) {
+	signer := NewURLSigner([]byte("0123456789abcdef0123456789abcdef"))
+
+	signed, err := signer.Sign("/exports/items.json?format=json", 10*time.Minute)
+	assert.NoError(t, err)
+
+	u, err := url.Parse(signed)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, u.Query().Get(SignedURLSignatureParam))
+	assert.NotEmpty(t, u.Query().Get(SignedURLExpiresParam))
+	assert.NoError(t, signer.Verify(u))
+}
+
+func TestURLSignerRejectsInvalidURLs(t *testing.T) {
+	signer := NewURLSigner([]byte("0123456789abcdef0123456789abcdef"))
+	signed, err := signer.Sign("/exports/items.json?format=json", 10*time.Minute)
+	assert.NoError(t, err)
+
+	tamper := func(edit func(u *url.URL)) *url.URL {
+		u, _ := url.Parse(signed)
+		edit(u)
+		return u
+	}
+
+	tests := []struct {
+		name     string
+		url      *url.URL
+		expected error
+	}{
+		{"missing signature", tamper(func(u *url.URL) { u.RawQuery = "format=json" }), ErrSignatureMissing},
+		{"modified path", tamper(func(u *url.URL) { u.Path = "/exports/other.json" }), ErrSignatureInvalid},
+		{"modified query", tamper(func(u *url.URL) {
+			q := u.Query()
+			q.Set("format", "csv")
+			u.RawQuery = q.Encode()
+		}), ErrSignatureInvalid},
+		{"extended expiry", tamper(func(u *url.URL) {
+			q := u.Query()
+			q.Set(SignedURLExpiresParam, "9999999999")
+			u.RawQuery = q.Encode()
+		}), ErrSignatureInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, signer.Verify(tt.url))
+		})
+	}
+
+	t.Run("different secret", func(t *testing.T) {
+		other := NewURLSigner([]byte("fedcba9876543210fedcba9876543210"))
+		u, _ := url.Parse(signed)
+		assert.Equal(t, ErrSignatureInvalid, other.Verify(u))
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		u, _ := url.Parse(signed)
+		signer.now = func() time.Time { return time.Now().Add(time.Hour) }
+		defer func() { signer.now = time.Now }()
+		assert.Equal(t, ErrSignatureExpired, signer.Verify(u))
+	})
+}
+
+func TestURLSignerRequiresSecret(t *testing.T) {
+	_, err := NewURLSigner(nil).Sign("/exports/items.json", time.Minute)
+	assert.Error(t, err)
+}
+
+func TestItemSnapshot(t *testing.T) {
+	assert.Empty(t, ContentDigest("  \n"))
+
+	content := "<p>Café opens <b>downtown</b></p>"
+	snapshot, err := NewItemSnapshot(content, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, ContentDigest(content), snapshot.Hash)
+	assert.Equal(t, len(content), snapshot.Size)
+
+	text, err := snapshot.Text()
+	require.NoError(t, err)
+	assert.Equal(t, content, text)
+
+	// Content that no longer matches its address is refused
+	tampered, err := NewItemSnapshot("<p>Something else</p>", time.Now())
+	require.NoError(t, err)
+	tampered.Hash = snapshot.Hash
+	_, err = tampered.Text()
+	assert.Error(t, err)
+
+	// Parsed items are hashed from their fullest content as served, before sanitizing
+	doc := `<?xml version="1.0"?><rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/"><channel><title>T</title>
+<item><title>Full</title><link>https://example.com/full</link><description>Summary</description><content:encoded><![CDATA[<p>Full text</p>]]></content:encoded></item>
+<item><title>Summary only</title><link>https://example.com/summary</link><description><![CDATA[<p>Summary</p>]]></description></item>
+</channel></rss>`
+	var result FetchResult
+	require.NoError(t, parseFeedDocument(gofeed.NewParser(), strings.NewReader(doc), &result))
+	require.Len(t, result.Items, 2)
+	assert.Equal(t, ContentDigest("<p>Full text</p>"), result.Items[0].ContentHash)
+	assert.Equal(t, ContentDigest("<p>Summary</p>"), result.Items[1].ContentHash)
+	assert.True(t, result.Items[0].ContentChanged(&FeedItem{ContentHash: ContentDigest("<p>Earlier text</p>")}))
+	assert.False(t, result.Items[0].ContentChanged(&FeedItem{}))
+}
+
 // Benchmark tests
 func BenchmarkGenerateRequestID(b *testing.B) {
 	for i := 0; i < b.N; i++ {
@@ -123,3 +626,338 @@ func BenchmarkRandomString(b *testing.B) {
 		RandomString(10)
 	}
 }
+
+func BenchmarkParseFeed1kItems(b *testing.B) {
+	var doc strings.Builder
+	doc.WriteString(`<?xml version="1.0" encoding="UTF-8"?><rss version="2.0"><channel><title>Benchmark</title><link>https://example.com</link><description>Benchmark feed</description>`)
+	for i := 0; i < 1000; i++ {
+		doc.WriteString("<item><title>Item " + strconv.Itoa(i) + "</title><link>https://example.com/items/" + strconv.Itoa(i) +
+			"</link><description><![CDATA[<p>Body of item " + strconv.Itoa(i) + ", with <b>markup</b> to strip.</p>]]></description>" +
+			"<author>writer@example.com (Writer)</author><pubDate>Mon, 02 Jan 2006 15:04:05 -0700</pubDate></item>")
+	}
+	doc.WriteString("</channel></rss>")
+	data := []byte(doc.String())
+
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var result FetchResult
+		if err := parseFeedDocument(gofeed.NewParser(), bytes.NewReader(data), &result); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestCanonicalItemURL(t *testing.T) {
+	expected := CanonicalItemURL("https://example.com/news/story")
+	equivalent := []string{
+		"http://www.example.com/news/story/",
+		"https://Example.com/news/story?utm_source=feed&utm_medium=rss",
+		"https://example.com/news/story#comments",
+		"https://example.com/news/story?fbclid=abc",
+	}
+	for _, link := range equivalent {
+		assert.Equal(t, expected, CanonicalItemURL(link), link)
+	}
+
+	assert.NotEqual(t, expected, CanonicalItemURL("https://example.com/news/story?id=2"))
+}
+
+func TestTitleSimilarity(t *testing.T) {
+	assert.Equal(t, 1.0, TitleSimilarity("Markets Rally as Rates Fall", "markets rally, as rates fall!"))
+	assert.GreaterOrEqual(t, TitleSimilarity(
+		"Central bank cuts interest rates for the first time in four years",
+		"Central bank cuts interest rates for first time in four years - Reuters",
+	), 0.8)
+	assert.Less(t, TitleSimilarity("Central bank cuts interest rates", "Local team wins championship final"), 0.2)
+
+	// Very short titles are never considered similar
+	assert.Equal(t, 0.0, TitleSimilarity("Update", "Update"))
+}
+
+func TestCollapseDuplicates(t *testing.T) {
+	items := []*FeedItem{
+		{Title: "Central bank cuts interest rates for the first time in four years", Link: "https://news.example.com/rates"},
+		{Title: "Central bank cuts interest rates for first time in four years", Link: "https://wire.example.org/a/123"},
+		{Title: "Local team wins championship final", Link: "https://sports.example.com/final"},
+		{Title: "Local team wins", Link: "https://www.sports.example.com/final?utm_source=rss"},
+	}
+
+	collapsed, counts := CollapseDuplicates(items, 0.8)
+	assert.Len(t, collapsed, 2)
+	assert.Equal(t, "https://news.example.com/rates", collapsed[0].Link)
+	assert.Equal(t, "https://sports.example.com/final", collapsed[1].Link)
+	assert.Equal(t, 2, counts[collapsed[0].ClusterID])
+	assert.Equal(t, 2, counts[collapsed[1].ClusterID])
+
+	// Inputs are left untouched
+	assert.Empty(t, items[0].ClusterID)
+
+	// Without title matching only the canonical URL groups items
+	collapsed, _ = CollapseDuplicates(items, 0)
+	assert.Len(t, collapsed, 3)
+}
+
+func TestGroupStories(t *testing.T) {
+	items := []*FeedItem{
+		{Title: "Storm forces thousands to evacuate coastal towns", Link: "https://a.example.com/storm", PubDate: "2024-03-01T08:00:00Z"},
+		{Title: "Thousands evacuate coastal towns as storm approaches", Link: "https://www.b.example.org/news/1", PubDate: "2024-03-01T10:00:00Z"},
+		{Title: "Coastal towns evacuate as storm forces thousands out", Link: "https://a.example.com/storm-update", PubDate: "2024-03-01T12:00:00Z"},
+		{Title: "New library opens downtown this weekend", Link: "https://c.example.net/library", PubDate: "2024-03-01T09:00:00Z"},
+		{Title: "Storm forces thousands to evacuate coastal towns", Link: "https://d.example.com/old", PubDate: "2024-02-01T08:00:00Z"},
+		{Title: "Undated story about storm forces thousands", Link: "https://e.example.com/x"},
+	}
+
+	groups := GroupStories(items, 0.5, 48*time.Hour)
+	assert.Len(t, groups, 3)
+
+	story := groups[0]
+	assert.Equal(t, 3, story.ItemCount)
+	assert.Equal(t, 2, story.SourceCount)
+	assert.Equal(t, []string{"a.example.com", "b.example.org"}, story.Sources)
+	assert.Equal(t, "https://a.example.com/storm", story.Representative.Link)
+	assert.Equal(t, "https://a.example.com/storm-update", story.Items[0].Link)
+	assert.NotEmpty(t, story.ID)
+
+	// Coverage a month apart is a separate story
+	assert.Equal(t, 1, groups[1].ItemCount)
+	assert.Equal(t, 1, groups[2].ItemCount)
+}
+
+func TestWeightedScorer(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	weights := RankingWeights{Recency: 1, Source: 1, ClusterSize: 1, Keyword: 1, RecencyHalfLife: 6 * time.Hour}
+	scorer := NewWeightedScorer(weights, map[string]float64{"trusted.example.com": 2}, map[string]float64{"Election": 3})
+
+	fresh := &FeedItem{Title: "Fresh", Link: "https://other.example.com/1", PubDate: "2024-03-01T12:00:00Z"}
+	old := &FeedItem{Title: "Old", Link: "https://other.example.com/2", PubDate: "2024-03-01T06:00:00Z"}
+	assert.InDelta(t, 2.0, scorer.Score(fresh, RankingSignals{Now: now, ClusterSize: 1}), 1e-9)
+	assert.InDelta(t, 1.5, scorer.Score(old, RankingSignals{Now: now, ClusterSize: 1}), 1e-9)
+
+	// Subdomains inherit the source weight, wider coverage and keywords add to the score
+	weighted := &FeedItem{Title: "Election results", Link: "https://www.news.trusted.example.com/3", PubDate: "2024-03-01T12:00:00Z"}
+	assert.InDelta(t, 1+2+2+3, scorer.Score(weighted, RankingSignals{Now: now, ClusterSize: 4}), 1e-9)
+
+	ranked := RankItems([]*FeedItem{old, weighted, fresh}, scorer, func(*FeedItem) RankingSignals {
+		return RankingSignals{Now: now, ClusterSize: 1}
+	})
+	assert.Equal(t, []*FeedItem{weighted, fresh, old}, []*FeedItem{ranked[0].Item, ranked[1].Item, ranked[2].Item})
+}
+
+func TestParseWeights(t *testing.T) {
+	weights, err := ParseWeights([]string{"bbc.co.uk=1.5", " example.com = 0.5 ", ""})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]float64{"bbc.co.uk": 1.5, "example.com": 0.5}, weights)
+
+	_, err = ParseWeights([]string{"bbc.co.uk"})
+	assert.Error(t, err)
+	_, err = ParseWeights([]string{"bbc.co.uk=high"})
+	assert.Error(t, err)
+}
+
+func TestPollPolicyBounds(t *testing.T) {
+	policy := PollPolicy{MinInterval: 5 * time.Minute, MaxInterval: 24 * time.Hour, TargetYield: 0.5, Decay: 0.9, MinObservations: 3}
+
+	var stats PollStats
+	stats.Observe(time.Hour, true, policy.Decay)
+	stats.Observe(time.Hour, true, policy.Decay)
+	assert.Zero(t, policy.LearnedInterval(stats), "too few polls to learn from")
+
+	// Polls that always find new items shorten the interval, down to the minimum
+	for i := 0; i < 50; i++ {
+		stats.Observe(time.Hour, true, policy.Decay)
+	}
+	assert.Less(t, policy.LearnedInterval(stats), time.Hour)
+	assert.GreaterOrEqual(t, policy.LearnedInterval(stats), policy.MinInterval)
+
+	// Polls that never find new items lengthen it, up to the maximum
+	stats = PollStats{}
+	for i := 0; i < 50; i++ {
+		stats.Observe(time.Hour, false, policy.Decay)
+	}
+	assert.Greater(t, policy.LearnedInterval(stats), time.Hour)
+	assert.LessOrEqual(t, policy.LearnedInterval(stats), policy.MaxInterval)
+
+	// A yield at the target keeps the interval
+	stats = PollStats{Yields: 10, Misses: 10, IntervalSeconds: 20 * 3600, Observations: 20}
+	assert.Equal(t, time.Hour, policy.LearnedInterval(stats))
+}
+
+func TestPollPolicyConverges(t *testing.T) {
+	policy := DefaultPollPolicy()
+	rng := rand.New(rand.NewSource(1))
+
+	// Simulate a feed publishing on average once an hour, first polled every 8 hours
+	rate := 1 / time.Hour.Hours()
+	stats := PollStats{}
+	interval := 8 * time.Hour
+	for i := 0; i < 300; i++ {
+		yielded := rng.Float64() < 1-math.Exp(-rate*interval.Hours())
+		stats.Observe(interval, yielded, policy.Decay)
+		if next := policy.SampleInterval(stats, rng); next > 0 {
+			interval = next
+		}
+	}
+
+	// The interval at which half of polls find new items is ln 2 hours, about 42 minutes
+	learned := policy.LearnedInterval(stats)
+	assert.Greater(t, learned, 15*time.Minute)
+	assert.Less(t, learned, 2*time.Hour)
+}
+
+func TestParseDateBound(t *testing.T) {
+	berlin := time.FixedZone("CET", 3600)
+
+	// Offsets in the value win over the caller's timezone
+	bound, err := ParseDateBound("2024-03-01T12:00:00+02:00", berlin, false)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC), bound)
+
+	// A date alone covers the whole local day
+	bound, err = ParseDateBound("2024-03-01", berlin, false)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 2, 29, 23, 0, 0, 0, time.UTC), bound)
+	bound, err = ParseDateBound("2024-03-01", berlin, true)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 1, 22, 59, 59, 0, time.UTC), bound)
+
+	// Local times are read in the caller's timezone, and in UTC when none is given
+	bound, err = ParseDateBound("2024-03-01T08:30", berlin, true)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 1, 7, 30, 0, 0, time.UTC), bound)
+	bound, err = ParseDateBound("2024-03-01 08:30:00", nil, false)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 1, 8, 30, 0, 0, time.UTC), bound)
+
+	_, err = ParseDateBound("03/01/2024", berlin, false)
+	assert.Error(t, err)
+}
+
+func TestParseSince(t *testing.T) {
+	// Thursday 2024-03-07 01:30:45 UTC is still Wednesday evening in New York (UTC-5)
+	now := time.Date(2024, 3, 7, 1, 30, 45, 0, time.UTC)
+	newYork := time.FixedZone("EST", -5*3600)
+
+	since, err := ParseSince("2h", now, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 6, 23, 30, 0, 0, time.UTC), since)
+	since, err = ParseSince("3d", now, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 4, 1, 30, 0, 0, time.UTC), since)
+
+	since, err = ParseSince("today", now, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 7, 0, 0, 0, 0, time.UTC), since)
+	since, err = ParseSince("today", now, newYork)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 6, 5, 0, 0, 0, time.UTC), since)
+	since, err = ParseSince("this_week", now, newYork)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 4, 5, 0, 0, 0, time.UTC), since)
+
+	for _, value := range []string{"", "h", "0d", "-2h", "2y", "yesterday", "99999999999999w"} {
+		_, err = ParseSince(value, now, nil)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestFaultInjection(t *testing.T) {
+	injector := NewFaultInjector(
+		map[string]float64{FaultTargetDatastore: 1},
+		map[string]float64{FaultTargetFetch: 1},
+		time.Hour,
+	)
+	assert.ErrorIs(t, injector.Inject(context.Background(), FaultTargetDatastore), ErrInjectedFault)
+	assert.NoError(t, injector.Inject(context.Background(), FaultTargetCache))
+
+	// Injected latency gives way to the caller's deadline
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, injector.Inject(ctx, FaultTargetFetch), context.DeadlineExceeded)
+
+	// Fetches fail before any request is made
+	SetFaultInjector(NewFaultInjector(map[string]float64{FaultTargetFetch: 1}, nil, 0))
+	defer SetFaultInjector(nil)
+	_, err := FetchRSSFeedResult(context.Background(), "http://127.0.0.1:1/feed")
+	assert.ErrorIs(t, err, ErrInjectedFault)
+
+	assert.Error(t, ValidateFaultRates(map[string]float64{"queue": 0.5}))
+	assert.Error(t, ValidateFaultRates(map[string]float64{FaultTargetCache: 1.5}))
+	assert.NoError(t, ValidateFaultRates(map[string]float64{FaultTargetCache: 0.5}))
+}
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata/feeds from the current parser output")
+
+// goldenFeed is the parsed form of a corpus feed recorded in its golden file
+type goldenFeed struct {
+	Title       string       `json:"title"`
+	Description string       `json:"description"`
+	Language    string       `json:"language"`
+	Items       []goldenItem `json:"items"`
+}
+
+type goldenItem struct {
+	Title       string `json:"title"`
+	Link        string `json:"link"`
+	Description string `json:"description"`
+	Author      string `json:"author"`
+	PubDate     string `json:"pub_date"`
+	Truncated   bool   `json:"truncated,omitempty"`
+}
+
+// TestFeedCorpus parses each feed in testdata/feeds and compares the result with its golden file.
+// After an intended parsing change, regenerate the golden files with: go test ./utils -run TestFeedCorpus -update
+func TestFeedCorpus(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "feeds", "*.xml"))
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no corpus feeds found: %v", err)
+	}
+
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			document, err := os.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer document.Close()
+
+			result := &FetchResult{}
+			if err := parseFeedDocument(gofeed.NewParser(), document, result); err != nil {
+				t.Fatalf("failed to parse %s: %v", path, err)
+			}
+			parsed := goldenFeed{Title: result.Title, Description: result.Description, Language: result.Language}
+			for _, item := range result.Items {
+				parsed.Items = append(parsed.Items, goldenItem{
+					Title:       item.Title,
+					Link:        item.Link,
+					Description: item.Description,
+					Author:      item.Author,
+					PubDate:     item.PubDate,
+					Truncated:   item.Truncated,
+				})
+			}
+
+			var got bytes.Buffer
+			encoder := json.NewEncoder(&got)
+			encoder.SetEscapeHTML(false)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(parsed); err != nil {
+				t.Fatal(err)
+			}
+
+			goldenPath := strings.TrimSuffix(path, ".xml") + ".golden.json"
+			if *updateGolden {
+				if err := os.WriteFile(goldenPath, got.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(goldenPath)
+			if err != nil {
+				t.Fatalf("missing golden file; run with -update to create it: %v", err)
+			}
+			assert.Equal(t, string(want), got.String())
+		})
+	}
+}